@@ -19,10 +19,13 @@ package abi
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 )
 
 // The ABI holds information about a contract's context and available
@@ -186,3 +189,64 @@ func (abi *ABI) EventByID(topic common.Hash) (*Event, error) {
 	}
 	return nil, fmt.Errorf("no event with id: %#x", topic.Hex())
 }
+
+var (
+	// revertSelector is a special function selector for revert reason unpacking.
+	revertSelector = crypto.Keccak256([]byte("Error(string)"))[:4]
+
+	// panicSelector is a special function selector for panic reason unpacking.
+	panicSelector = crypto.Keccak256([]byte("Panic(uint256)"))[:4]
+
+	// panicReasons map is for readable panic codes, see
+	// https://docs.soliditylang.org/en/latest/control-structures.html#panic-via-assert-and-error-via-require
+	panicReasons = map[uint64]string{
+		0x00: "generic panic",
+		0x01: "assert(false)",
+		0x11: "arithmetic underflow or overflow",
+		0x12: "division or modulo by zero",
+		0x21: "enum overflow",
+		0x22: "invalid encoded storage byte array accessed",
+		0x31: "out-of-bounds array access; popping on an empty array",
+		0x32: "out-of-bounds access of an array or bytesN",
+		0x41: "out of memory",
+		0x51: "uninitialized function",
+	}
+
+	// errInvalidRevert is returned when the revert data is neither an
+	// Error(string) nor a Panic(uint256) payload.
+	errInvalidRevert = errors.New("invalid revert data")
+)
+
+// UnpackRevert resolves the abi-encoded revert reason. According to the solidity
+// spec https://solidity.readthedocs.io/en/latest/control-structures.html#revert,
+// the provided revert reason is abi-encoded as if it were a call to a function
+// `Error(string)` or `Panic(uint256)`. Custom errors cannot be decoded without
+// their ABI and are reported as errInvalidRevert.
+func UnpackRevert(data []byte) (string, error) {
+	if len(data) < 4 {
+		return "", errInvalidRevert
+	}
+	switch {
+	case bytes.Equal(data[:4], revertSelector):
+		typ, _ := NewType("string", "", nil)
+		var reason string
+		if err := (Arguments{{Type: typ}}).Unpack(&reason, data[4:]); err != nil {
+			return "", err
+		}
+		return reason, nil
+
+	case bytes.Equal(data[:4], panicSelector):
+		typ, _ := NewType("uint256", "", nil)
+		var code *big.Int
+		if err := (Arguments{{Type: typ}}).Unpack(&code, data[4:]); err != nil {
+			return "", err
+		}
+		if code.IsUint64() {
+			if reason, ok := panicReasons[code.Uint64()]; ok {
+				return reason, nil
+			}
+		}
+		return fmt.Sprintf("unknown panic code: %#x", code), nil
+	}
+	return "", errInvalidRevert
+}
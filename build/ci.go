@@ -79,6 +79,7 @@ var (
 		executablePath("geth"),
 		executablePath("puppeth"),
 		executablePath("rlpdump"),
+		executablePath("rpcreplay"),
 		executablePath("wnode"),
 		executablePath("clef"),
 	}
@@ -124,6 +124,14 @@ var (
 		Usage: "External EVM configuration (default = built-in interpreter)",
 		Value: "",
 	}
+	OvmStateDumpFlag = cli.StringFlag{
+		Name:  "ovm.statedump",
+		Usage: "Path or URL of the OVM state dump, enables execution through the OVM",
+	}
+	OvmSummaryFlag = cli.BoolFlag{
+		Name:  "ovm.summary",
+		Usage: "print a summary of the OVM-level calls after execution",
+	}
 )
 
 func init() {
@@ -150,12 +158,15 @@ func init() {
 		DisableMemoryFlag,
 		DisableStackFlag,
 		EVMInterpreterFlag,
+		OvmStateDumpFlag,
+		OvmSummaryFlag,
 	}
 	app.Commands = []cli.Command{
 		compileCommand,
 		disasmCommand,
 		runCommand,
 		stateTestCommand,
+		transitionCommand,
 	}
 	cli.CommandHelpTemplate = utils.OriginCommandHelpTemplate
 }
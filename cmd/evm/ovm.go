@@ -0,0 +1,271 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rollup/dump"
+)
+
+// ovmEnv is the execution environment of the OVM, consisting of the genesis
+// state built from a state dump and the chain configuration that references
+// the system contracts in that dump.
+type ovmEnv struct {
+	config  *params.ChainConfig
+	statedb *state.StateDB
+	genesis *core.Genesis
+}
+
+// newOvmEnv loads the state dump at the given path and builds the genesis
+// state from it. The optional genesis supplies additional accounts and the
+// header fields; the system contract configuration is taken from the dump.
+func newOvmEnv(dumpPath string, genesis *core.Genesis) (*ovmEnv, error) {
+	stateDump, err := dump.Load(dumpPath)
+	if err != nil {
+		return nil, err
+	}
+	// Execution of OVM transactions is switched on globally in the vm package
+	vm.UsingOVM = true
+
+	if genesis == nil {
+		genesis = new(core.Genesis)
+	}
+	if genesis.Config == nil {
+		config := *params.AllCliqueProtocolChanges
+		genesis.Config = &config
+	}
+	if genesis.ChainID == nil {
+		genesis.ChainID = genesis.Config.ChainID
+	}
+	if genesis.GasLimit == 0 {
+		genesis.GasLimit = params.GenesisGasLimit
+	}
+	hash := stateDump.ComputeHash()
+	genesis.Config.StateDump = stateDump
+	genesis.Config.StateDumpHash = &hash
+
+	db := rawdb.NewMemoryDatabase()
+	block := genesis.ToBlock(db)
+	statedb, err := state.New(block.Root(), state.NewDatabase(db), nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ovmEnv{config: genesis.Config, statedb: statedb, genesis: genesis}, nil
+}
+
+// header returns the header of the block the transaction at the given offset
+// from the genesis is executed in. Like on the L2 chain, every transaction is
+// placed in a block of its own that carries the L1 timestamp of the
+// transaction.
+func (env *ovmEnv) header(offset uint64, tx *types.Transaction) *types.Header {
+	timestamp := env.genesis.Timestamp
+	if meta := tx.GetMeta(); meta != nil && meta.L1Timestamp != 0 {
+		timestamp = meta.L1Timestamp
+	}
+	return &types.Header{
+		Number:     new(big.Int).SetUint64(env.genesis.Number + offset),
+		Time:       timestamp,
+		GasLimit:   env.genesis.GasLimit,
+		Difficulty: big.NewInt(1),
+		Coinbase:   env.genesis.Coinbase,
+	}
+}
+
+// executionManager returns the address of the OVM_ExecutionManager.
+func (env *ovmEnv) executionManager() common.Address {
+	return env.config.StateDump.Accounts["OVM_ExecutionManager"].Address
+}
+
+// stateManager returns the address of the OVM_StateManager.
+func (env *ovmEnv) stateManager() common.Address {
+	return env.config.StateDump.Accounts["OVM_StateManager"].Address
+}
+
+// call executes the transaction through the same path as the sequencer: it is
+// converted into an OVM message, wrapped into a call to the execution manager
+// and applied to the state.
+func (env *ovmEnv) call(tx *types.Transaction, header *types.Header, cfg vm.Config) ([]byte, uint64, bool, error) {
+	decompressor := env.config.StateDump.Accounts["OVM_SequencerEntrypoint"]
+	msg, err := core.AsOvmMessage(tx, types.MakeSigner(env.config, header.Number), decompressor.Address, header.GasLimit)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	context := core.NewEVMContext(msg, header, ovmChainContext{}, &header.Coinbase)
+	context.BlockNumber = msg.L1BlockNumber()
+
+	vmenv := vm.NewEVM(context, env.statedb, env.config, cfg)
+	return core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(header.GasLimit))
+}
+
+// newOvmCallTx creates an L1 to L2 transaction from the given L1 sender to the
+// target contract, which is the simplest way to invoke a contract in the OVM
+// as it needs neither a signature nor the sequencer entrypoint.
+func newOvmCallTx(sender, target common.Address, input []byte, gas uint64, l1BlockNumber *big.Int, l1Timestamp uint64) *types.Transaction {
+	var index uint64
+	tx := types.NewTransaction(0, target, new(big.Int), gas, new(big.Int), input)
+	tx.SetTransactionMeta(types.NewTransactionMeta(
+		l1BlockNumber,
+		l1Timestamp,
+		&sender,
+		types.SighashEIP155,
+		types.QueueOriginL1ToL2,
+		&index,
+		&index,
+		input,
+	))
+	return tx
+}
+
+// ovmChainContext is a chain context without any headers besides the current
+// one, which is enough to execute transactions on top of the genesis state.
+type ovmChainContext struct{}
+
+func (ovmChainContext) Engine() consensus.Engine                    { return nil }
+func (ovmChainContext) GetHeader(common.Hash, uint64) *types.Header { return nil }
+
+// ovmCall is a single call made by the execution manager on behalf of a
+// contract, which corresponds to one of the ovmCALL, ovmSTATICCALL,
+// ovmDELEGATECALL, ovmCREATE or ovmCREATE2 operations of the contract.
+type ovmCall struct {
+	Depth  int
+	Op     vm.OpCode
+	Target common.Address
+	Gas    uint64
+}
+
+// ovmCallSummary is a tracer that collects the OVM-level calls of a
+// transaction, skipping the internal bookkeeping of the execution manager.
+type ovmCallSummary struct {
+	executionManager common.Address
+	stateManager     common.Address
+
+	calls   []ovmCall
+	output  []byte
+	gasUsed uint64
+	err     error
+}
+
+func newOvmCallSummary(env *ovmEnv) *ovmCallSummary {
+	return &ovmCallSummary{
+		executionManager: env.executionManager(),
+		stateManager:     env.stateManager(),
+	}
+}
+
+func (s *ovmCallSummary) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	return nil
+}
+
+func (s *ovmCallSummary) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if contract.Address() != s.executionManager {
+		return nil
+	}
+	switch op {
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		target := common.BigToAddress(stack.Back(1))
+		if target == s.stateManager {
+			return nil
+		}
+		s.calls = append(s.calls, ovmCall{Depth: depth, Op: op, Target: target, Gas: stack.Back(0).Uint64()})
+	case vm.CREATE, vm.CREATE2:
+		s.calls = append(s.calls, ovmCall{Depth: depth, Op: op, Gas: gas})
+	}
+	return nil
+}
+
+func (s *ovmCallSummary) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	return nil
+}
+
+func (s *ovmCallSummary) CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) error {
+	s.output, s.gasUsed, s.err = output, gasUsed, err
+	return nil
+}
+
+// Write prints the collected calls, indented by their depth below the
+// execution manager.
+func (s *ovmCallSummary) Write(w io.Writer) {
+	fmt.Fprintln(w, "#### OVM CALLS ####")
+	base := 0
+	if len(s.calls) > 0 {
+		base = s.calls[0].Depth
+	}
+	for _, call := range s.calls {
+		indent := strings.Repeat("  ", (call.Depth-base)/2)
+		if call.Op == vm.CREATE || call.Op == vm.CREATE2 {
+			fmt.Fprintf(w, "%s%v gas=%d\n", indent, call.Op, call.Gas)
+			continue
+		}
+		fmt.Fprintf(w, "%s%v %s gas=%d\n", indent, call.Op, call.Target.Hex(), call.Gas)
+	}
+	fmt.Fprintf(w, "gas used: %d\noutput: 0x%x\n", s.gasUsed, s.output)
+	if s.err != nil {
+		fmt.Fprintf(w, "error: %v\n", s.err)
+	}
+}
+
+// multiTracer forwards all events to each of its tracers.
+type multiTracer []vm.Tracer
+
+func (t multiTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	for _, tracer := range t {
+		if err := tracer.CaptureStart(from, to, create, input, gas, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t multiTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	for _, tracer := range t {
+		if err := tracer.CaptureState(env, pc, op, gas, cost, memory, stack, contract, depth, err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t multiTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	for _, tracer := range t {
+		if err := tracer.CaptureFault(env, pc, op, gas, cost, memory, stack, contract, depth, err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t multiTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	for _, tracer := range t {
+		if err := tracer.CaptureEnd(output, gasUsed, d, err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
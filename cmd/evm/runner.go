@@ -19,7 +19,9 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"math/big"
 	"os"
@@ -97,10 +99,29 @@ func timedExec(bench bool, execFunc func() ([]byte, uint64, error)) ([]byte, uin
 	return output, gasLeft, execTime, err
 }
 
+// readInput returns the call data given by the --input or --inputfile flag.
+func readInput(ctx *cli.Context) []byte {
+	var hexInput []byte
+	if inputFileFlag := ctx.GlobalString(InputFileFlag.Name); inputFileFlag != "" {
+		var err error
+		if hexInput, err = ioutil.ReadFile(inputFileFlag); err != nil {
+			fmt.Printf("could not load input from file: %v\n", err)
+			os.Exit(1)
+		}
+	} else {
+		hexInput = []byte(ctx.GlobalString(InputFlag.Name))
+	}
+	return common.FromHex(string(bytes.TrimSpace(hexInput)))
+}
+
 func runCmd(ctx *cli.Context) error {
 	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(false)))
 	glogger.Verbosity(log.Lvl(ctx.GlobalInt(VerbosityFlag.Name)))
 	log.Root().SetHandler(glogger)
+
+	if ctx.GlobalString(OvmStateDumpFlag.Name) != "" {
+		return runOvmCmd(ctx)
+	}
 	logconfig := &vm.LogConfig{
 		DisableMemory: ctx.GlobalBool(DisableMemoryFlag.Name),
 		DisableStack:  ctx.GlobalBool(DisableStackFlag.Name),
@@ -129,10 +150,10 @@ func runCmd(ctx *cli.Context) error {
 		genesisConfig = gen
 		db := rawdb.NewMemoryDatabase()
 		genesis := gen.ToBlock(db)
-		statedb, _ = state.New(genesis.Root(), state.NewDatabase(db))
+		statedb, _ = state.New(genesis.Root(), state.NewDatabase(db), nil)
 		chainConfig = gen.Config
 	} else {
-		statedb, _ = state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+		statedb, _ = state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
 		genesisConfig = new(core.Genesis)
 	}
 	if ctx.GlobalString(SenderFlag.Name) != "" {
@@ -228,17 +249,7 @@ func runCmd(ctx *cli.Context) error {
 		runtimeConfig.ChainConfig = params.AllEthashProtocolChanges
 	}
 
-	var hexInput []byte
-	if inputFileFlag := ctx.GlobalString(InputFileFlag.Name); inputFileFlag != "" {
-		var err error
-		if hexInput, err = ioutil.ReadFile(inputFileFlag); err != nil {
-			fmt.Printf("could not load input from file: %v\n", err)
-			os.Exit(1)
-		}
-	} else {
-		hexInput = []byte(ctx.GlobalString(InputFlag.Name))
-	}
-	input := common.FromHex(string(bytes.TrimSpace(hexInput)))
+	input := readInput(ctx)
 
 	var execFunc func() ([]byte, uint64, error)
 	if ctx.GlobalBool(CreateFlag.Name) {
@@ -307,3 +318,109 @@ Gas used:           %d
 
 	return nil
 }
+
+// runOvmCmd executes a call to the receiver as an L1 to L2 transaction on top
+// of the genesis state built from the OVM state dump.
+func runOvmCmd(ctx *cli.Context) error {
+	if ctx.GlobalBool(CreateFlag.Name) {
+		return errors.New("contract creation is not supported in OVM mode, deploy through a contract instead")
+	}
+	if ctx.GlobalString(CodeFlag.Name) != "" || ctx.GlobalString(CodeFileFlag.Name) != "" {
+		return errors.New("OVM mode executes the code in the state dump, --code and --codefile are not supported")
+	}
+	var genesis *core.Genesis
+	if ctx.GlobalString(GenesisFlag.Name) != "" {
+		genesis = readGenesis(ctx.GlobalString(GenesisFlag.Name))
+	}
+	env, err := newOvmEnv(ctx.GlobalString(OvmStateDumpFlag.Name), genesis)
+	if err != nil {
+		return err
+	}
+	var (
+		sender   = common.BytesToAddress([]byte("sender"))
+		receiver = common.BytesToAddress([]byte("receiver"))
+	)
+	if ctx.GlobalString(SenderFlag.Name) != "" {
+		sender = common.HexToAddress(ctx.GlobalString(SenderFlag.Name))
+	}
+	if ctx.GlobalString(ReceiverFlag.Name) != "" {
+		receiver = common.HexToAddress(ctx.GlobalString(ReceiverFlag.Name))
+	}
+	gas := ctx.GlobalUint64(GasFlag.Name)
+	if gas > env.genesis.GasLimit {
+		gas = env.genesis.GasLimit
+	}
+	tx := newOvmCallTx(sender, receiver, readInput(ctx), gas, new(big.Int).SetUint64(env.genesis.Number), env.genesis.Timestamp)
+	header := env.header(0, tx)
+
+	tracer, debugLogger, summary := makeOvmTracer(ctx, env, os.Stdout)
+	cfg := vm.Config{
+		Tracer: tracer,
+		Debug:  tracer != nil,
+	}
+	output, gasUsed, execTime, err := timedExec(ctx.GlobalBool(BenchFlag.Name), func() ([]byte, uint64, error) {
+		output, gasUsed, _, err := env.call(tx, header, cfg)
+		return output, gasUsed, err
+	})
+
+	if ctx.GlobalBool(DumpFlag.Name) {
+		env.statedb.Commit(true)
+		env.statedb.IntermediateRoot(true)
+		fmt.Println(string(env.statedb.Dump(false, false, true)))
+	}
+	if ctx.GlobalBool(DebugFlag.Name) {
+		if debugLogger != nil {
+			fmt.Fprintln(os.Stderr, "#### TRACE ####")
+			vm.WriteTrace(os.Stderr, debugLogger.StructLogs())
+		}
+		fmt.Fprintln(os.Stderr, "#### LOGS ####")
+		vm.WriteLogs(os.Stderr, env.statedb.Logs())
+	}
+	if summary != nil {
+		summary.Write(os.Stderr)
+	}
+	if ctx.GlobalBool(StatDumpFlag.Name) {
+		fmt.Fprintf(os.Stderr, "evm execution time: %v\nGas used:           %d\n\n", execTime, gasUsed)
+	}
+	if !ctx.GlobalBool(MachineFlag.Name) {
+		fmt.Printf("0x%x\n", output)
+		if err != nil {
+			fmt.Printf(" error: %v\n", err)
+		}
+	}
+	return nil
+}
+
+// makeOvmTracer assembles the tracers requested on the command line. The JSON
+// logger writes into w, the struct logger and the OVM call summary are
+// returned so their results can be printed after execution.
+func makeOvmTracer(ctx *cli.Context, env *ovmEnv, w io.Writer) (vm.Tracer, *vm.StructLogger, *ovmCallSummary) {
+	logconfig := &vm.LogConfig{
+		DisableMemory: ctx.GlobalBool(DisableMemoryFlag.Name),
+		DisableStack:  ctx.GlobalBool(DisableStackFlag.Name),
+		Debug:         ctx.GlobalBool(DebugFlag.Name),
+	}
+	var (
+		tracers     multiTracer
+		debugLogger *vm.StructLogger
+		summary     *ovmCallSummary
+	)
+	if ctx.GlobalBool(MachineFlag.Name) {
+		tracers = append(tracers, vm.NewJSONLogger(logconfig, w))
+	} else if ctx.GlobalBool(DebugFlag.Name) {
+		debugLogger = vm.NewStructLogger(logconfig)
+		tracers = append(tracers, debugLogger)
+	}
+	if ctx.GlobalBool(OvmSummaryFlag.Name) {
+		summary = newOvmCallSummary(env)
+		tracers = append(tracers, summary)
+	}
+	switch len(tracers) {
+	case 0:
+		return nil, nil, nil
+	case 1:
+		return tracers[0], debugLogger, summary
+	default:
+		return tracers, debugLogger, summary
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rollup"
+	cli "gopkg.in/urfave/cli.v1"
+)
+
+var (
+	InputTxsFlag = cli.StringFlag{
+		Name:  "input.txs",
+		Usage: "JSON file with the transactions to apply, in the data transport layer format",
+	}
+	OutputAllocFlag = cli.StringFlag{
+		Name:  "output.alloc",
+		Usage: "file to write the post-state to, 'stdout' for standard output",
+	}
+	OutputResultFlag = cli.StringFlag{
+		Name:  "output.result",
+		Usage: "file to write the state root and receipts to, 'stdout' for standard output",
+		Value: "stdout",
+	}
+)
+
+var transitionCommand = cli.Command{
+	Action: transitionCmd,
+	Name:   "transition",
+	Usage:  "applies a list of OVM transactions to the state dump",
+	Flags: []cli.Flag{
+		InputTxsFlag,
+		OutputAllocFlag,
+		OutputResultFlag,
+	},
+	Description: `
+The transition command builds the genesis state from the state dump given by
+--ovm.statedump (and the optional --prestate genesis) and applies the
+transactions in --input.txs on top of it. Every transaction is executed in a
+block of its own, like on the L2 chain.
+
+The transactions are given as a JSON list of objects in the format served by
+the data transport layer at /transaction/index/{index}.`,
+}
+
+// ovmRejectedTx is a transaction that could not be applied to the state.
+type ovmRejectedTx struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// ovmTransitionResult is the outcome of applying the transactions.
+type ovmTransitionResult struct {
+	StateRoot common.Hash      `json:"stateRoot"`
+	Receipts  []*types.Receipt `json:"receipts"`
+	Rejected  []ovmRejectedTx  `json:"rejected,omitempty"`
+}
+
+func transitionCmd(ctx *cli.Context) error {
+	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(false)))
+	glogger.Verbosity(log.Lvl(ctx.GlobalInt(VerbosityFlag.Name)))
+	log.Root().SetHandler(glogger)
+
+	if ctx.GlobalString(OvmStateDumpFlag.Name) == "" {
+		return errors.New("--ovm.statedump is required")
+	}
+	if ctx.String(InputTxsFlag.Name) == "" {
+		return errors.New("--input.txs is required")
+	}
+	var genesis *core.Genesis
+	if ctx.GlobalString(GenesisFlag.Name) != "" {
+		genesis = readGenesis(ctx.GlobalString(GenesisFlag.Name))
+	}
+	env, err := newOvmEnv(ctx.GlobalString(OvmStateDumpFlag.Name), genesis)
+	if err != nil {
+		return err
+	}
+	txs, err := readOvmTransactions(ctx.String(InputTxsFlag.Name), env)
+	if err != nil {
+		return err
+	}
+	result := &ovmTransitionResult{Receipts: make([]*types.Receipt, 0, len(txs))}
+	for i, tx := range txs {
+		tracer, debugLogger, summary := makeOvmTracer(ctx, env, os.Stderr)
+		cfg := vm.Config{
+			Tracer: tracer,
+			Debug:  tracer != nil,
+		}
+		receipt, err := env.apply(uint64(i+1), tx, cfg)
+		if err != nil {
+			log.Warn("Rejected transaction", "index", i, "hash", tx.Hash(), "err", err)
+			result.Rejected = append(result.Rejected, ovmRejectedTx{i, err.Error()})
+			continue
+		}
+		result.Receipts = append(result.Receipts, receipt)
+
+		if debugLogger != nil {
+			fmt.Fprintf(os.Stderr, "#### TRACE %d ####\n", i)
+			vm.WriteTrace(os.Stderr, debugLogger.StructLogs())
+		}
+		if summary != nil {
+			summary.Write(os.Stderr)
+		}
+	}
+	root, err := env.statedb.Commit(true)
+	if err != nil {
+		return err
+	}
+	result.StateRoot = root
+
+	if err := writeOutput(ctx.String(OutputResultFlag.Name), result); err != nil {
+		return err
+	}
+	if path := ctx.String(OutputAllocFlag.Name); path != "" {
+		alloc := env.statedb.RawDump(false, false, false)
+		if err := writeOutput(path, &alloc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply executes the transaction in the block at the given offset from the
+// genesis and returns its receipt.
+func (env *ovmEnv) apply(offset uint64, tx *types.Transaction, cfg vm.Config) (*types.Receipt, error) {
+	var (
+		header  = env.header(offset, tx)
+		gp      = new(core.GasPool).AddGas(header.GasLimit)
+		usedGas uint64
+	)
+	env.statedb.Prepare(tx.Hash(), common.Hash{}, 0)
+	return core.ApplyTransaction(env.config, ovmChainContext{}, &header.Coinbase, gp, env.statedb, header, tx, &usedGas, cfg)
+}
+
+// readOvmTransactions loads the transactions in the data transport layer
+// format from the given file.
+func readOvmTransactions(path string, env *ovmEnv) ([]*types.Transaction, error) {
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var responses []*rollup.TransactionResponse
+	if err := json.Unmarshal(blob, &responses); err != nil {
+		return nil, fmt.Errorf("invalid transactions file: %v", err)
+	}
+	txs := make([]*types.Transaction, 0, len(responses))
+	for i, res := range responses {
+		tx, err := rollup.ParseTransactionResponse(res, env.config.ChainID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid transaction %d: %v", i, err)
+		}
+		if tx == nil {
+			return nil, fmt.Errorf("missing transaction %d", i)
+		}
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
+
+// writeOutput encodes v as JSON into the given file or standard output.
+func writeOutput(path string, v interface{}) error {
+	blob, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if path == "stdout" {
+		fmt.Println(string(blob))
+		return nil
+	}
+	return ioutil.WriteFile(path, blob, 0644)
+}
@@ -0,0 +1,136 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// The execution manager in testdata/ovm/statedump.json records the selector
+// of its calldata in slot 1, the timestamp of the OVM transaction in slot 2
+// and the state manager it was given in slot 3, then calls the entrypoint of
+// the transaction. The target in testdata/ovm/genesis.json sets its slot 0.
+var (
+	testOvmTarget           = common.HexToAddress("0x1111111111111111111111111111111111111111")
+	testOvmSelectorSlot     = common.BigToHash(big.NewInt(1))
+	testOvmTimestampSlot    = common.BigToHash(big.NewInt(2))
+	testOvmStateManagerSlot = common.BigToHash(big.NewInt(3))
+)
+
+// newTestOvmEnv builds the OVM environment from the fixtures. Callers must
+// switch vm.UsingOVM off again when done.
+func newTestOvmEnv(t *testing.T) *ovmEnv {
+	t.Helper()
+	env, err := newOvmEnv("testdata/ovm/statedump.json", readGenesis("testdata/ovm/genesis.json"))
+	if err != nil {
+		t.Fatalf("failed to build OVM environment: %v", err)
+	}
+	return env
+}
+
+// checkExecutionManagerRun verifies that the execution manager was invoked
+// through run with the given timestamp and reached the target.
+func checkExecutionManagerRun(t *testing.T, env *ovmEnv, timestamp uint64) {
+	t.Helper()
+	em := env.config.StateDump.Accounts["OVM_ExecutionManager"]
+	selector := common.BytesToHash(em.ABI.Methods["run"].ID())
+	if have := env.statedb.GetState(em.Address, testOvmSelectorSlot); have != selector {
+		t.Errorf("execution manager selector mismatch: have %x, want %x", have, selector)
+	}
+	if have := env.statedb.GetState(em.Address, testOvmTimestampSlot).Big().Uint64(); have != timestamp {
+		t.Errorf("transaction timestamp mismatch: have %d, want %d", have, timestamp)
+	}
+	want := common.BytesToHash(env.stateManager().Bytes())
+	if have := env.statedb.GetState(em.Address, testOvmStateManagerSlot); have != want {
+		t.Errorf("state manager mismatch: have %x, want %x", have, want)
+	}
+	if have := env.statedb.GetState(testOvmTarget, common.Hash{}); have != common.BigToHash(common.Big1) {
+		t.Errorf("target not executed: slot 0 is %x", have)
+	}
+}
+
+func TestOvmTransition(t *testing.T) {
+	defer func() { vm.UsingOVM = false }()
+
+	env := newTestOvmEnv(t)
+	txs, err := readOvmTransactions("testdata/ovm/txs.json", env)
+	if err != nil {
+		t.Fatalf("failed to read transactions: %v", err)
+	}
+	if len(txs) != 1 {
+		t.Fatalf("transaction count mismatch: have %d, want 1", len(txs))
+	}
+	if qo := txs[0].QueueOrigin(); qo == nil || qo.Uint64() != uint64(types.QueueOriginL1ToL2) {
+		t.Fatalf("queue origin mismatch: have %v, want %d", qo, types.QueueOriginL1ToL2)
+	}
+	receipt, err := env.apply(1, txs[0], vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to apply transaction: %v", err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		t.Fatalf("receipt status mismatch: have %d, want %d", receipt.Status, types.ReceiptStatusSuccessful)
+	}
+	if receipt.TxHash != txs[0].Hash() {
+		t.Errorf("receipt hash mismatch: have %x, want %x", receipt.TxHash, txs[0].Hash())
+	}
+	checkExecutionManagerRun(t, env, 1600000000)
+
+	// Applying the same transactions to a fresh environment must be deterministic
+	root, err := env.statedb.Commit(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := newTestOvmEnv(t)
+	if _, err := other.apply(1, txs[0], vm.Config{}); err != nil {
+		t.Fatalf("failed to apply transaction: %v", err)
+	}
+	if have, _ := other.statedb.Commit(true); have != root {
+		t.Errorf("state root mismatch: have %x, want %x", have, root)
+	}
+}
+
+func TestOvmCall(t *testing.T) {
+	defer func() { vm.UsingOVM = false }()
+
+	env := newTestOvmEnv(t)
+
+	var (
+		sender = common.HexToAddress("0x2222222222222222222222222222222222222222")
+		tx     = newOvmCallTx(sender, testOvmTarget, []byte{0xde, 0xad, 0xbe, 0xef}, 1000000, big.NewInt(10), 1234)
+	)
+	summary := newOvmCallSummary(env)
+	_, _, failed, err := env.call(tx, env.header(1, tx), vm.Config{Debug: true, Tracer: summary})
+	if err != nil {
+		t.Fatalf("failed to execute call: %v", err)
+	}
+	if failed {
+		t.Fatalf("call failed: %v", summary.err)
+	}
+	checkExecutionManagerRun(t, env, 1234)
+
+	if len(summary.calls) != 1 {
+		t.Fatalf("OVM call count mismatch: have %d, want 1", len(summary.calls))
+	}
+	if call := summary.calls[0]; call.Op != vm.CALL || call.Target != testOvmTarget {
+		t.Errorf("OVM call mismatch: have %v %x, want %v %x", call.Op, call.Target, vm.CALL, testOvmTarget)
+	}
+}
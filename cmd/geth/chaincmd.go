@@ -28,11 +28,13 @@ import (
 
 	"github.com/ethereum/go-ethereum/cmd/utils"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/console"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
@@ -67,8 +69,10 @@ It expects the genesis file as argument.`,
 			utils.CacheFlag,
 			utils.SyncModeFlag,
 			utils.GCModeFlag,
+			utils.SnapshotFlag,
 			utils.CacheDatabaseFlag,
 			utils.CacheGCFlag,
+			utils.CacheSnapshotFlag,
 		},
 		Category: "BLOCKCHAIN COMMANDS",
 		Description: `
@@ -76,7 +80,11 @@ The import command imports blocks from an RLP-encoded form. The form can be one
 with several RLP-encoded blocks, or several files can be used.
 
 If only one file is used, import error will result in failure. If several files are used,
-processing will proceed even if an individual RLP-file import failure occurs.`,
+processing will proceed even if an individual RLP-file import failure occurs.
+
+Files written by the export command also carry the metadata of every transaction.
+When running the OVM, files in the legacy format of plain block RLP are refused,
+as the blocks cannot be executed without their transaction metadata.`,
 	}
 	exportCommand = cli.Command{
 		Action:    utils.MigrateFlags(exportChain),
@@ -94,7 +102,9 @@ Requires a first argument of the file to write to.
 Optional second and third arguments control the first and
 last block to write. In this mode, the file will be appended
 if already existing. If the file ends with .gz, the output will
-be gzipped.`,
+be gzipped. The metadata of every transaction is exported along
+with the blocks. When running the OVM, appending to a file in the
+legacy format without transaction metadata is refused.`,
 	}
 	importPreimagesCommand = cli.Command{
 		Action:    utils.MigrateFlags(importPreimages),
@@ -166,11 +176,19 @@ Remove blockchain and state databases`,
 			utils.ExcludeCodeFlag,
 			utils.ExcludeStorageFlag,
 			utils.IncludeIncompletesFlag,
+			utils.DumpStartFlag,
+			utils.DumpLimitFlag,
+			utils.DumpOutputFlag,
 		},
 		Category: "BLOCKCHAIN COMMANDS",
 		Description: `
 The arguments are interpreted as block numbers or hashes.
-Use "ethereum dump 0" to dump the genesis block.`,
+Use "ethereum dump 0" to dump the genesis block.
+
+Large states can be dumped in parts with --start and --limit. If the dump is
+cut short by the limit, the cursor to resume from is logged, which can be passed
+as --start to the next invocation. With --output=jsonl every account is written
+as a separate line.`,
 	}
 	inspectCommand = cli.Command{
 		Action:    utils.MigrateFlags(inspect),
@@ -521,27 +539,72 @@ func dump(ctx *cli.Context) error {
 			fmt.Println("{}")
 			utils.Fatalf("block not found")
 		} else {
-			state, err := state.New(block.Root(), state.NewDatabase(chainDb))
+			statedb, err := state.New(block.Root(), state.NewDatabase(chainDb), nil)
 			if err != nil {
 				utils.Fatalf("could not create new state: %v", err)
 			}
 			excludeCode := ctx.Bool(utils.ExcludeCodeFlag.Name)
 			excludeStorage := ctx.Bool(utils.ExcludeStorageFlag.Name)
 			includeMissing := ctx.Bool(utils.IncludeIncompletesFlag.Name)
-			if ctx.Bool(utils.IterativeOutputFlag.Name) {
-				state.IterativeDump(excludeCode, excludeStorage, !includeMissing, json.NewEncoder(os.Stdout))
+			conf := &state.DumpConfig{
+				SkipCode:          excludeCode,
+				SkipStorage:       excludeStorage,
+				OnlyWithAddresses: !includeMissing,
+				Start:             dumpStart(ctx.String(utils.DumpStartFlag.Name)),
+				Max:               ctx.Uint64(utils.DumpLimitFlag.Name),
+			}
+			iterative := ctx.Bool(utils.IterativeOutputFlag.Name)
+			switch output := ctx.String(utils.DumpOutputFlag.Name); output {
+			case "json":
+			case "jsonl":
+				iterative = true
+			default:
+				utils.Fatalf("Unknown dump output format %q", output)
+			}
+			if iterative {
+				if next := statedb.IterativeDumpRange(conf, json.NewEncoder(os.Stdout)); next != nil {
+					log.Info("Dump limit reached", "next", hexutil.Bytes(next))
+				}
+			} else if conf.Start != nil || conf.Max > 0 {
+				page := statedb.PagedDump(conf)
+				out, err := json.MarshalIndent(page, "", "    ")
+				if err != nil {
+					utils.Fatalf("Failed to encode dump: %v", err)
+				}
+				fmt.Println(string(out))
 			} else {
 				if includeMissing {
 					fmt.Printf("If you want to include accounts with missing preimages, you need iterative output, since" +
 						" otherwise the accounts will overwrite each other in the resulting mapping.")
 				}
-				fmt.Printf("%v %s\n", includeMissing, state.Dump(excludeCode, excludeStorage, false))
+				fmt.Printf("%v %s\n", includeMissing, statedb.Dump(excludeCode, excludeStorage, false))
 			}
 		}
 	}
 	return nil
 }
 
+// dumpStart parses the start position of a state dump, which is either an
+// account address, a hashed account address or a cursor of a previous dump.
+func dumpStart(start string) []byte {
+	if start == "" {
+		return nil
+	}
+	blob, err := hexutil.Decode(start)
+	if err != nil {
+		utils.Fatalf("Invalid dump start %q: %v", start, err)
+	}
+	switch len(blob) {
+	case common.AddressLength:
+		return crypto.Keccak256(blob)
+	case common.HashLength, 2 * common.HashLength:
+		return blob
+	default:
+		utils.Fatalf("Invalid dump start length %d", len(blob))
+	}
+	return nil
+}
+
 func inspect(ctx *cli.Context) error {
 	node, _ := makeConfigNode(ctx)
 	defer node.Close()
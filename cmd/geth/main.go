@@ -91,6 +91,7 @@ var (
 		utils.SyncModeFlag,
 		utils.ExitWhenSyncedFlag,
 		utils.GCModeFlag,
+		utils.SnapshotFlag,
 		utils.LightServeFlag,
 		utils.LightLegacyServFlag,
 		utils.LightIngressFlag,
@@ -106,6 +107,7 @@ var (
 		utils.CacheDatabaseFlag,
 		utils.CacheTrieFlag,
 		utils.CacheGCFlag,
+		utils.CacheSnapshotFlag,
 		utils.CacheNoPrefetchFlag,
 		utils.ListenPortFlag,
 		utils.MaxPeersFlag,
@@ -162,6 +164,7 @@ var (
 		utils.RollupTimstampRefreshFlag,
 		utils.RollupPollIntervalFlag,
 		utils.RollupStateDumpPathFlag,
+		utils.RollupStateDumpHashFlag,
 		utils.RollupDiffDbFlag,
 		utils.RollupMaxCalldataSizeFlag,
 		utils.RollupL1GasPriceFlag,
@@ -184,10 +187,28 @@ var (
 		utils.WSPortFlag,
 		utils.WSApiFlag,
 		utils.WSAllowedOriginsFlag,
+		utils.RPCJWTAuthFlag,
+		utils.WSJWTAuthFlag,
+		utils.AuthRPCEnabledFlag,
+		utils.AuthRPCListenAddrFlag,
+		utils.AuthRPCPortFlag,
+		utils.AuthRPCApiFlag,
+		utils.AuthRPCVirtualHostsFlag,
+		utils.RPCRecordFlag,
+		utils.RPCRecordMethodsFlag,
+		utils.RPCRecordSampleFlag,
+		utils.RPCRecordMaxSizeFlag,
+		utils.JWTSecretFlag,
+		utils.JWTClockSkewFlag,
 		utils.IPCDisabledFlag,
 		utils.IPCPathFlag,
 		utils.InsecureUnlockAllowedFlag,
 		utils.RPCGlobalGasCap,
+		utils.RPCStateReexecFlag,
+		utils.RPCStateReexecCacheFlag,
+		utils.RPCStateReexecMemoryFlag,
+		utils.RPCLogsMaxRangeFlag,
+		utils.RPCLogsMaxResultsFlag,
 	}
 
 	whisperFlags = []cli.Flag{
@@ -241,6 +262,10 @@ func init() {
 		dumpConfigCommand,
 		// See retesteth.go
 		retestethCommand,
+		// See ovmdumpcmd.go
+		ovmDumpCommand,
+		// See snapshot.go
+		snapshotCommand,
 	}
 	sort.Sort(cli.CommandsByName(app.Commands))
 
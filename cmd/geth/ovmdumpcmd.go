@@ -0,0 +1,186 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	ovmdump "github.com/ethereum/go-ethereum/rollup/dump"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	ovmDumpCommand = cli.Command{
+		Name:     "ovm-dump",
+		Usage:    "Inspect and compare OVM state dumps",
+		Category: "OPTIMISM COMMANDS",
+		Description: `
+The state dump defines the predeployed contracts of the OVM genesis state. Nodes
+built from different dumps produce different genesis blocks, so these commands
+can be used to check a dump before starting a node with it.`,
+		Subcommands: []cli.Command{
+			{
+				Name:      "verify",
+				Usage:     "Check the version and content hash of a state dump",
+				ArgsUsage: "<path or url>",
+				Action:    utils.MigrateFlags(ovmDumpVerify),
+				Flags: []cli.Flag{
+					utils.RollupStateDumpHashFlag,
+				},
+				Description: `
+    geth ovm-dump verify [--rollup.statedumphash <hash>] <path or url>
+
+Loads the state dump, checks that its format version is supported and that its
+contents match the embedded hash. If a hash is passed, the dump must also match
+that hash.`,
+			},
+			{
+				Name:      "inspect",
+				Usage:     "Print a summary of the contracts in a state dump",
+				ArgsUsage: "<path or url>",
+				Action:    utils.MigrateFlags(ovmDumpInspect),
+			},
+			{
+				Name:      "diff",
+				Usage:     "Report the differences between two state dumps",
+				ArgsUsage: "<path or url> <path or url>",
+				Action:    utils.MigrateFlags(ovmDumpDiff),
+				Description: `
+    geth ovm-dump diff <a> <b>
+
+Compares two state dumps by contract name and reports added and removed
+contracts, as well as changes to the address, code hash, nonce and storage of
+the contracts present in both. Exits with an error if the dumps differ.`,
+			},
+			{
+				Name:      "seal",
+				Usage:     "Upgrade a state dump to the current version",
+				ArgsUsage: "<input> <output>",
+				Action:    utils.MigrateFlags(ovmDumpSeal),
+				Description: `
+    geth ovm-dump seal <input> <output>
+
+Writes a copy of the dump that carries the current format version and the
+content hash, which is then checked by every node loading the dump.`,
+			},
+		},
+	}
+)
+
+func ovmDumpVerify(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	d, err := ovmdump.Load(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	hash := d.ComputeHash()
+	if ctx.IsSet(utils.RollupStateDumpHashFlag.Name) {
+		if err := d.Verify(common.HexToHash(ctx.String(utils.RollupStateDumpHashFlag.Name))); err != nil {
+			return err
+		}
+	}
+	if d.Version == 0 {
+		fmt.Printf("Legacy state dump, hash %x\n", hash)
+	} else {
+		fmt.Printf("State dump version %d, hash %x\n", d.Version, hash)
+	}
+	return nil
+}
+
+func ovmDumpInspect(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	d, err := ovmdump.Load(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Version:  %d\n", d.Version)
+	fmt.Printf("Hash:     %x\n", d.ComputeHash())
+	fmt.Printf("Accounts: %d\n\n", len(d.Accounts))
+	for _, name := range d.AccountNames() {
+		account := d.Accounts[name]
+		code := common.FromHex(account.Code)
+		fmt.Printf("%-40s %s nonce=%d code=%d bytes codehash=%x slots=%d\n",
+			name, account.Address.Hex(), account.Nonce, len(code), crypto.Keccak256(code), len(account.Storage))
+	}
+	return nil
+}
+
+func ovmDumpDiff(ctx *cli.Context) error {
+	if len(ctx.Args()) != 2 {
+		utils.Fatalf("This command requires two arguments.")
+	}
+	a, err := ovmdump.Load(ctx.Args().Get(0))
+	if err != nil {
+		return err
+	}
+	b, err := ovmdump.Load(ctx.Args().Get(1))
+	if err != nil {
+		return err
+	}
+	diffs := ovmdump.Diff(a, b)
+	for _, diff := range diffs {
+		switch {
+		case diff.Added:
+			fmt.Printf("+ %s %s codehash=%x\n", diff.Name, diff.NewAddress.Hex(), diff.NewCodeHash)
+		case diff.Removed:
+			fmt.Printf("- %s %s codehash=%x\n", diff.Name, diff.OldAddress.Hex(), diff.OldCodeHash)
+		default:
+			fmt.Printf("~ %s\n", diff.Name)
+			if diff.AddressChanged() {
+				fmt.Printf("    address:  %s -> %s\n", diff.OldAddress.Hex(), diff.NewAddress.Hex())
+			}
+			if diff.CodeChanged() {
+				fmt.Printf("    codehash: %x -> %x\n", diff.OldCodeHash, diff.NewCodeHash)
+			}
+			if diff.OldNonce != diff.NewNonce {
+				fmt.Printf("    nonce:    %d -> %d\n", diff.OldNonce, diff.NewNonce)
+			}
+			for _, slot := range diff.Storage {
+				fmt.Printf("    storage %x: %x -> %x\n", slot.Key, slot.Old, slot.New)
+			}
+		}
+	}
+	if len(diffs) > 0 {
+		return errors.New("state dumps differ")
+	}
+	fmt.Println("State dumps are identical")
+	return nil
+}
+
+func ovmDumpSeal(ctx *cli.Context) error {
+	if len(ctx.Args()) != 2 {
+		utils.Fatalf("This command requires two arguments.")
+	}
+	blob, err := ioutil.ReadFile(ctx.Args().Get(0))
+	if err != nil {
+		return err
+	}
+	sealed, err := ovmdump.SealJSON(blob)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(ctx.Args().Get(1), sealed, 0644)
+}
@@ -0,0 +1,78 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/core/state/pruner"
+	"github.com/ethereum/go-ethereum/log"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	snapshotCommand = cli.Command{
+		Name:     "snapshot",
+		Usage:    "A set of commands operating on the state database",
+		Category: "BLOCKCHAIN COMMANDS",
+		Subcommands: []cli.Command{
+			{
+				Name:      "prune-state",
+				Usage:     "Prune stale state trie nodes from the database",
+				ArgsUsage: " ",
+				Action:    utils.MigrateFlags(pruneState),
+				Category:  "BLOCKCHAIN COMMANDS",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.AncientFlag,
+					utils.CacheFlag,
+					utils.CacheDatabaseFlag,
+					utils.BloomFilterSizeFlag,
+					utils.PruneRecentFlag,
+				},
+				Description: `
+    geth snapshot prune-state [--prune.recent <n>] [--bloomfilter.size <MB>]
+
+Deletes all the state trie nodes which are not reachable from the head state,
+the n most recent states before it (127 by default), the genesis state and the
+base of the state snapshot. Contract codes and preimages are always retained.
+
+The node must be stopped while pruning. The retained nodes are recorded in a
+bloom filter which is stored in the data directory, so an interrupted pruning
+is finished by the next run of this command or by the next node startup.`,
+			},
+		},
+	}
+)
+
+func pruneState(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chaindb := utils.MakeChainDatabase(ctx, stack)
+	defer chaindb.Close()
+
+	pruner, err := pruner.NewPruner(chaindb, stack.ResolvePath(""), ctx.Uint64(utils.PruneRecentFlag.Name), ctx.Uint64(utils.BloomFilterSizeFlag.Name))
+	if err != nil {
+		log.Error("Failed to create state pruner", "error", err)
+		return err
+	}
+	if err = pruner.Prune(); err != nil {
+		log.Error("Failed to prune state", "error", err)
+		return err
+	}
+	return nil
+}
@@ -76,6 +76,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.RollupTimstampRefreshFlag,
 			utils.RollupPollIntervalFlag,
 			utils.RollupStateDumpPathFlag,
+			utils.RollupStateDumpHashFlag,
 			utils.RollupDiffDbFlag,
 			utils.RollupMaxCalldataSizeFlag,
 			utils.RollupL1GasPriceFlag,
@@ -98,6 +99,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.SyncModeFlag,
 			utils.ExitWhenSyncedFlag,
 			utils.GCModeFlag,
+			utils.SnapshotFlag,
 			utils.EthStatsURLFlag,
 			utils.IdentityFlag,
 			utils.LightKDFFlag,
@@ -157,6 +159,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.CacheDatabaseFlag,
 			utils.CacheTrieFlag,
 			utils.CacheGCFlag,
+			utils.CacheSnapshotFlag,
 			utils.CacheNoPrefetchFlag,
 		},
 	},
@@ -179,6 +182,11 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.RPCPortFlag,
 			utils.RPCApiFlag,
 			utils.RPCGlobalGasCap,
+			utils.RPCStateReexecFlag,
+			utils.RPCStateReexecCacheFlag,
+			utils.RPCStateReexecMemoryFlag,
+			utils.RPCLogsMaxRangeFlag,
+			utils.RPCLogsMaxResultsFlag,
 			utils.RPCCORSDomainFlag,
 			utils.RPCVirtualHostsFlag,
 			utils.WSEnabledFlag,
@@ -186,6 +194,19 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.WSPortFlag,
 			utils.WSApiFlag,
 			utils.WSAllowedOriginsFlag,
+			utils.RPCJWTAuthFlag,
+			utils.WSJWTAuthFlag,
+			utils.AuthRPCEnabledFlag,
+			utils.AuthRPCListenAddrFlag,
+			utils.AuthRPCPortFlag,
+			utils.AuthRPCApiFlag,
+			utils.AuthRPCVirtualHostsFlag,
+			utils.RPCRecordFlag,
+			utils.RPCRecordMethodsFlag,
+			utils.RPCRecordSampleFlag,
+			utils.RPCRecordMaxSizeFlag,
+			utils.JWTSecretFlag,
+			utils.JWTClockSkewFlag,
 			utils.GraphQLEnabledFlag,
 			utils.GraphQLListenAddrFlag,
 			utils.GraphQLPortFlag,
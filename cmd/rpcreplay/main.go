@@ -0,0 +1,280 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+// rpcreplay replays the RPC calls recorded by a node against another node and
+// reports the responses which differ from the recorded ones.
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	target  = flag.String("target", "http://localhost:8545", "RPC endpoint of the node to replay the calls against")
+	methods = flag.String("methods", "", "comma separated list of methods to replay, accepting 'prefix*' wildcards (all if empty)")
+	ignore  = flag.String("ignore", "", "comma separated list of object fields to ignore when comparing responses")
+	timeout = flag.Duration("timeout", 30*time.Second, "timeout of each replayed call")
+	verbose = flag.Bool("verbose", false, "print the parameters of the mismatching calls")
+	writes  = flag.Bool("writes", false, "also replay the calls which may change the state of the target node")
+)
+
+// writeMethods are the patterns of the methods whose calls may change the state
+// of the node they are sent to, skipped unless explicitly enabled.
+var writeMethods = []string{
+	"eth_sendRawTransaction", "eth_sendTransaction", "eth_submitWork", "eth_submitHashrate",
+	"eth_sign*", "personal_*", "admin_*", "miner_*", "account_*", "debug_setHead",
+}
+
+func init() {
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage:", os.Args[0], "[-target <url>] [-methods <list>] [-ignore <fields>] [-writes] <recordfile>...")
+		flag.PrintDefaults()
+		fmt.Fprintln(os.Stderr, `
+Replays the RPC calls of the given record files, written by a node started with
+--rpcrecord, against the target node and diffs its responses with the recorded
+ones. Calls which may change the state of the target node, like transactions or
+account and admin calls, are skipped unless -writes is given. The exit status is
+1 if any response differs.`)
+	}
+}
+
+func main() {
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	client, err := rpc.Dial(*target)
+	if err != nil {
+		die(err)
+	}
+	defer client.Close()
+
+	r := &replayer{
+		client:  client,
+		methods: splitList(*methods),
+		ignore:  make(map[string]bool),
+		timeout: *timeout,
+		writes:  *writes,
+	}
+	for _, field := range splitList(*ignore) {
+		r.ignore[field] = true
+	}
+	for _, path := range flag.Args() {
+		if err := r.replayFile(path); err != nil {
+			die(err)
+		}
+	}
+	fmt.Printf("Replayed %d calls: %d matched, %d differed, %d skipped\n", r.matched+r.differed, r.matched, r.differed, r.skipped)
+	if r.differed > 0 {
+		os.Exit(1)
+	}
+}
+
+// replayer replays recorded calls against a node, keeping statistics.
+type replayer struct {
+	client  *rpc.Client
+	methods []string
+	ignore  map[string]bool
+	timeout time.Duration
+	writes  bool // Whether calls changing the state of the node are replayed
+
+	matched  int
+	differed int
+	skipped  int
+}
+
+// replayFile replays all the selected calls of a record file in order.
+func (r *replayer) replayFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		rec := new(rpc.Record)
+		if err := json.Unmarshal(scanner.Bytes(), rec); err != nil {
+			return fmt.Errorf("%s:%d: invalid record: %v", path, line, err)
+		}
+		if !r.selected(rec.Method) {
+			r.skipped++
+			continue
+		}
+		diffs := r.replay(rec)
+		if len(diffs) == 0 {
+			r.matched++
+			continue
+		}
+		r.differed++
+		fmt.Printf("%s:%d: %s differs\n", path, line, rec.Method)
+		if *verbose {
+			fmt.Printf("  params: %s\n", rec.Params)
+		}
+		for _, diff := range diffs {
+			fmt.Printf("  %s\n", diff)
+		}
+	}
+	return scanner.Err()
+}
+
+// selected returns whether calls of a method are replayed.
+func (r *replayer) selected(method string) bool {
+	if !r.writes && matchAny(writeMethods, method) {
+		return false
+	}
+	return len(r.methods) == 0 || matchAny(r.methods, method)
+}
+
+// matchAny returns whether a method matches any of the given patterns, being
+// exact method names or prefixes ending in '*'.
+func matchAny(patterns []string, method string) bool {
+	for _, pattern := range patterns {
+		if strings.HasSuffix(pattern, "*") && strings.HasPrefix(method, strings.TrimSuffix(pattern, "*")) {
+			return true
+		}
+		if pattern == method {
+			return true
+		}
+	}
+	return false
+}
+
+// replay executes a recorded call and returns the differences between the
+// response and the recorded one.
+func (r *replayer) replay(rec *rpc.Record) []string {
+	var params []json.RawMessage
+	if len(rec.Params) > 0 {
+		if err := json.Unmarshal(rec.Params, &params); err != nil {
+			return []string{fmt.Sprintf("invalid recorded params: %v", err)}
+		}
+	}
+	args := make([]interface{}, len(params))
+	for i, param := range params {
+		args[i] = param
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+
+	var result json.RawMessage
+	err := r.client.CallContext(ctx, &result, rec.Method, args...)
+
+	// Compare errors first, results only matter if both calls succeeded
+	switch {
+	case err != nil && rec.Error == nil:
+		return []string{fmt.Sprintf("error: have %q, want none", err)}
+	case err == nil && rec.Error != nil:
+		return []string{fmt.Sprintf("error: have none, want %q", rec.Error.Message)}
+	case err != nil:
+		var diffs []string
+		code := 0
+		if rpcErr, ok := err.(rpc.Error); ok {
+			code = rpcErr.ErrorCode()
+		}
+		if code != rec.Error.Code {
+			diffs = append(diffs, fmt.Sprintf("error code: have %d, want %d", code, rec.Error.Code))
+		}
+		if err.Error() != rec.Error.Message {
+			diffs = append(diffs, fmt.Sprintf("error message: have %q, want %q", err.Error(), rec.Error.Message))
+		}
+		return diffs
+	}
+	var have, want interface{}
+	if err := json.Unmarshal(result, &have); err != nil {
+		return []string{fmt.Sprintf("invalid result: %v", err)}
+	}
+	if len(rec.Result) > 0 {
+		if err := json.Unmarshal(rec.Result, &want); err != nil {
+			return []string{fmt.Sprintf("invalid recorded result: %v", err)}
+		}
+	}
+	return diffJSON("result", have, want, r.ignore, nil)
+}
+
+// diffJSON appends the differences between two decoded JSON values to diffs,
+// each one prefixed with the path of the differing value. Object fields listed
+// in ignore are not compared.
+func diffJSON(path string, have, want interface{}, ignore map[string]bool, diffs []string) []string {
+	switch want := want.(type) {
+	case map[string]interface{}:
+		have, ok := have.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(want)+len(have))
+		for key := range want {
+			keys = append(keys, key)
+		}
+		for key := range have {
+			if _, ok := want[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if ignore[key] {
+				continue
+			}
+			diffs = diffJSON(path+"."+key, have[key], want[key], ignore, diffs)
+		}
+		return diffs
+
+	case []interface{}:
+		have, ok := have.([]interface{})
+		if !ok || len(have) != len(want) {
+			break
+		}
+		for i := range want {
+			diffs = diffJSON(fmt.Sprintf("%s[%d]", path, i), have[i], want[i], ignore, diffs)
+		}
+		return diffs
+	}
+	if !reflect.DeepEqual(have, want) {
+		haveJSON, _ := json.Marshal(have)
+		wantJSON, _ := json.Marshal(want)
+		diffs = append(diffs, fmt.Sprintf("%s: have %s, want %s", path, haveJSON, wantJSON))
+	}
+	return diffs
+}
+
+// splitList splits a comma separated list, dropping empty entries.
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func die(args ...interface{}) {
+	fmt.Fprintln(os.Stderr, args...)
+	os.Exit(1)
+}
@@ -0,0 +1,114 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+type testService struct{}
+
+type testBlock struct {
+	Number int    `json:"number"`
+	Hash   string `json:"hash"`
+	Time   int64  `json:"time"`
+}
+
+func (s *testService) Block(n int) testBlock {
+	return testBlock{Number: n, Hash: "0xabc", Time: time.Now().UnixNano()}
+}
+
+func (s *testService) Fail() error {
+	return errors.New("failed")
+}
+
+func TestReplay(t *testing.T) {
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("test", new(testService)); err != nil {
+		t.Fatal(err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	r := &replayer{client: client, ignore: map[string]bool{"time": true}, timeout: time.Second}
+	tests := []struct {
+		rec   rpc.Record
+		diffs []string
+	}{
+		{
+			rpc.Record{Method: "test_block", Params: json.RawMessage(`[1]`), Result: json.RawMessage(`{"number":1,"hash":"0xabc","time":0}`)},
+			nil,
+		},
+		{
+			rpc.Record{Method: "test_block", Params: json.RawMessage(`[2]`), Result: json.RawMessage(`{"number":3,"hash":"0xabc","extra":[1]}`)},
+			[]string{`result.extra: have null, want [1]`, `result.number: have 2, want 3`},
+		},
+		{
+			rpc.Record{Method: "test_fail", Error: &rpc.RecordError{Code: -32000, Message: "failed"}},
+			nil,
+		},
+		{
+			rpc.Record{Method: "test_fail", Result: json.RawMessage(`null`)},
+			[]string{`error: have "failed", want none`},
+		},
+		{
+			rpc.Record{Method: "test_block", Params: json.RawMessage(`[1]`), Error: &rpc.RecordError{Code: -32601, Message: "missing"}},
+			[]string{`error: have none, want "missing"`},
+		},
+	}
+	for i, tt := range tests {
+		if diffs := r.replay(&tt.rec); !reflect.DeepEqual(diffs, tt.diffs) {
+			t.Errorf("test %d: diff mismatch:\nhave %q\nwant %q", i, diffs, tt.diffs)
+		}
+	}
+}
+
+func TestReplaySelection(t *testing.T) {
+	r := &replayer{methods: []string{"eth_get*", "debug_traceTransaction"}}
+	for method, want := range map[string]bool{
+		"eth_getLogs":            true,
+		"eth_call":               false,
+		"debug_traceTransaction": true,
+		"debug_traceBlock":       false,
+	} {
+		if have := r.selected(method); have != want {
+			t.Errorf("%s: selection mismatch: have %v, want %v", method, have, want)
+		}
+	}
+	// Calls changing the node's state are only replayed if enabled
+	for _, writes := range []bool{false, true} {
+		r := &replayer{writes: writes}
+		for method, write := range map[string]bool{
+			"eth_getLogs":            false,
+			"eth_sendRawTransaction": true,
+			"eth_signTransaction":    true,
+			"personal_unlockAccount": true,
+			"admin_addPeer":          true,
+		} {
+			if have, want := r.selected(method), writes || !write; have != want {
+				t.Errorf("%s (writes %v): selection mismatch: have %v, want %v", method, writes, have, want)
+			}
+		}
+	}
+}
@@ -31,6 +31,7 @@ import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/internal/debug"
@@ -123,7 +124,9 @@ func ImportChain(chain *core.BlockChain, fn string) error {
 			return err
 		}
 	}
-	stream := rlp.NewStream(reader, 0)
+	// The legacy format does not carry the transaction metadata, which is
+	// required to execute the blocks of an OVM chain.
+	stream := core.NewChainExportReader(reader, !vm.UsingOVM)
 
 	// Run actual the import.
 	blocks := make(types.Blocks, importBatchSize)
@@ -135,8 +138,8 @@ func ImportChain(chain *core.BlockChain, fn string) error {
 		}
 		i := 0
 		for ; i < importBatchSize; i++ {
-			var b types.Block
-			if err := stream.Decode(&b); err == io.EOF {
+			b, err := stream.Next()
+			if err == io.EOF {
 				break
 			} else if err != nil {
 				return fmt.Errorf("at block %d: %v", n, err)
@@ -146,7 +149,7 @@ func ImportChain(chain *core.BlockChain, fn string) error {
 				i--
 				continue
 			}
-			blocks[i] = &b
+			blocks[i] = b
 			n++
 		}
 		if i == 0 {
@@ -217,6 +220,14 @@ func ExportChain(blockchain *core.BlockChain, fn string) error {
 func ExportAppendChain(blockchain *core.BlockChain, fn string, first uint64, last uint64) error {
 	log.Info("Exporting blockchain", "file", fn)
 
+	// Appending to a legacy export would mix the formats, so make sure the
+	// existing blocks carry their transaction metadata on OVM chains.
+	if vm.UsingOVM {
+		if err := checkChainExport(fn); err != nil {
+			return err
+		}
+	}
+
 	// Open the file handle and potentially wrap with a gzip stream
 	fh, err := os.OpenFile(fn, os.O_CREATE|os.O_APPEND|os.O_WRONLY, os.ModePerm)
 	if err != nil {
@@ -237,6 +248,32 @@ func ExportAppendChain(blockchain *core.BlockChain, fn string, first uint64, las
 	return nil
 }
 
+// checkChainExport ensures that the chain export in the specified file, if
+// any, is not in the legacy format.
+func checkChainExport(fn string) error {
+	fh, err := os.Open(fn)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	var reader io.Reader = fh
+	if strings.HasSuffix(fn, ".gz") {
+		if reader, err = gzip.NewReader(reader); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+	if _, err := core.NewChainExportReader(reader, false).Next(); err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
+
 // ImportPreimages imports a batch of exported hash preimages into the database.
 func ImportPreimages(db ethdb.Database, fn string) error {
 	log.Info("Importing preimages", "file", fn)
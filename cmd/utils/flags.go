@@ -61,6 +61,7 @@ import (
 	"github.com/ethereum/go-ethereum/p2p/netutil"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rollup"
+	"github.com/ethereum/go-ethereum/rollup/dump"
 	"github.com/ethereum/go-ethereum/rpc"
 	whisper "github.com/ethereum/go-ethereum/whisper/whisperv6"
 	pcsclite "github.com/gballet/go-libpcsclite"
@@ -229,6 +230,29 @@ var (
 		Name:  "nocode",
 		Usage: "Exclude contract code (save db lookups)",
 	}
+	DumpStartFlag = cli.StringFlag{
+		Name:  "start",
+		Usage: "Start dumping at the given account address, hashed address or resume cursor",
+	}
+	DumpLimitFlag = cli.Uint64Flag{
+		Name:  "limit",
+		Usage: "Maximum number of accounts and storage slots to dump (0 = no limit)",
+	}
+	DumpOutputFlag = cli.StringFlag{
+		Name:  "output",
+		Usage: `Output format of the dump ("json" or "jsonl")`,
+		Value: "json",
+	}
+	BloomFilterSizeFlag = cli.Uint64Flag{
+		Name:  "bloomfilter.size",
+		Usage: "Megabytes of memory allocated to bloom-filter for pruning",
+		Value: 2048,
+	}
+	PruneRecentFlag = cli.Uint64Flag{
+		Name:  "prune.recent",
+		Usage: "Number of recent block states to retain in addition to the head state",
+		Value: 127,
+	}
 	defaultSyncMode = eth.DefaultConfig.SyncMode
 	SyncModeFlag    = TextMarshalerFlag{
 		Name:  "syncmode",
@@ -242,6 +266,10 @@ var (
 
 		EnvVar: "GCMODE",
 	}
+	SnapshotFlag = cli.BoolFlag{
+		Name:  "snapshot",
+		Usage: "Enables the flat state snapshot for fast account and storage reads",
+	}
 	LightKDFFlag = cli.BoolFlag{
 		Name:  "lightkdf",
 		Usage: "Reduce key-derivation RAM & CPU usage at some expense of KDF strength",
@@ -410,6 +438,11 @@ var (
 		Usage: "Percentage of cache memory allowance to use for trie pruning (default = 25% full mode, 0% archive mode)",
 		Value: 25,
 	}
+	CacheSnapshotFlag = cli.IntFlag{
+		Name:  "cache.snapshot",
+		Usage: "Percentage of cache memory allowance to use for snapshot caching (default = 10%)",
+		Value: 10,
+	}
 	CacheNoPrefetchFlag = cli.BoolFlag{
 		Name:  "cache.noprefetch",
 		Usage: "Disable heuristic state prefetch during block import (less CPU and disk IO, more time waiting for data)",
@@ -523,6 +556,28 @@ var (
 		Name:  "rpc.gascap",
 		Usage: "Sets a cap on gas that can be used in eth_call/estimateGas",
 	}
+	RPCStateReexecFlag = cli.Uint64Flag{
+		Name:  "rpc.reexec",
+		Usage: "Number of blocks to re-execute for serving states missing from disk (0 = disabled)",
+	}
+	RPCStateReexecCacheFlag = cli.IntFlag{
+		Name:  "rpc.reexec.cache",
+		Usage: "Number of re-executed historical states to keep in memory",
+		Value: eth.DefaultConfig.StateReexecCache,
+	}
+	RPCStateReexecMemoryFlag = cli.IntFlag{
+		Name:  "rpc.reexec.memory",
+		Usage: "Megabytes of memory allowed for re-executed historical states",
+		Value: eth.DefaultConfig.StateReexecMemory,
+	}
+	RPCLogsMaxRangeFlag = cli.Uint64Flag{
+		Name:  "rpc.logs.maxrange",
+		Usage: "Maximum number of blocks scanned by a single log query (0 = unlimited)",
+	}
+	RPCLogsMaxResultsFlag = cli.IntFlag{
+		Name:  "rpc.logs.maxresults",
+		Usage: "Maximum number of logs returned by a single log query (0 = unlimited)",
+	}
 	// Logging and debug settings
 	EthStatsURLFlag = cli.StringFlag{
 		Name:  "ethstats",
@@ -622,6 +677,94 @@ var (
 
 		EnvVar: "WS_ORIGINS",
 	}
+	RPCJWTAuthFlag = cli.BoolFlag{
+		Name:  "rpcjwtauth",
+		Usage: "Require HTTP-RPC clients to authenticate with JWT tokens",
+
+		EnvVar: "RPC_JWT_AUTH",
+	}
+	WSJWTAuthFlag = cli.BoolFlag{
+		Name:  "wsjwtauth",
+		Usage: "Require WS-RPC clients to authenticate with JWT tokens",
+
+		EnvVar: "WS_JWT_AUTH",
+	}
+	AuthRPCEnabledFlag = cli.BoolFlag{
+		Name:  "authrpc",
+		Usage: "Enable the JWT authenticated HTTP and WS RPC server",
+
+		EnvVar: "AUTHRPC",
+	}
+	AuthRPCListenAddrFlag = cli.StringFlag{
+		Name:  "authrpcaddr",
+		Usage: "Authenticated RPC server listening interface",
+		Value: node.DefaultAuthHost,
+
+		EnvVar: "AUTHRPC_ADDR",
+	}
+	AuthRPCPortFlag = cli.IntFlag{
+		Name:  "authrpcport",
+		Usage: "Authenticated RPC server listening port",
+		Value: node.DefaultAuthPort,
+
+		EnvVar: "AUTHRPC_PORT",
+	}
+	AuthRPCApiFlag = cli.StringFlag{
+		Name:  "authrpcapi",
+		Usage: "API's offered over the authenticated RPC interface",
+		Value: "",
+
+		EnvVar: "AUTHRPC_API",
+	}
+	AuthRPCVirtualHostsFlag = cli.StringFlag{
+		Name:  "authrpcvhosts",
+		Usage: "Comma separated list of virtual hostnames from which to accept authenticated RPC requests (server enforced). Accepts '*' wildcard.",
+		Value: strings.Join(node.DefaultConfig.AuthVirtualHosts, ","),
+
+		EnvVar: "AUTHRPC_VHOSTS",
+	}
+	RPCRecordFlag = cli.StringFlag{
+		Name:  "rpcrecord",
+		Usage: "File to record the served HTTP, WS and authenticated RPC calls into (disabled if empty)",
+		Value: "",
+
+		EnvVar: "RPC_RECORD",
+	}
+	RPCRecordMethodsFlag = cli.StringFlag{
+		Name:  "rpcrecordmethods",
+		Usage: "Comma separated list of RPC methods to record, accepting 'prefix*' wildcards (all but the account and admin ones if empty)",
+		Value: "",
+
+		EnvVar: "RPC_RECORD_METHODS",
+	}
+	RPCRecordSampleFlag = cli.Float64Flag{
+		Name:  "rpcrecordsample",
+		Usage: "Fraction of the matching RPC calls to record (0 = all)",
+		Value: 0,
+
+		EnvVar: "RPC_RECORD_SAMPLE",
+	}
+	RPCRecordMaxSizeFlag = cli.IntFlag{
+		Name:  "rpcrecordmaxsize",
+		Usage: "Megabytes beyond which the RPC record file is rotated (0 = no rotation)",
+		Value: 0,
+
+		EnvVar: "RPC_RECORD_MAX_SIZE",
+	}
+	JWTSecretFlag = cli.StringFlag{
+		Name:  "jwtsecret",
+		Usage: "Path to the hex encoded 32 byte secret of RPC JWT authentication (generated if missing)",
+		Value: "",
+
+		EnvVar: "JWT_SECRET",
+	}
+	JWTClockSkewFlag = cli.DurationFlag{
+		Name:  "jwtclockskew",
+		Usage: "Maximum difference tolerated between the issuance time of JWT tokens and the local time",
+		Value: rpc.DefaultJWTClockSkew,
+
+		EnvVar: "JWT_CLOCK_SKEW",
+	}
 	GraphQLEnabledFlag = cli.BoolFlag{
 		Name:  "graphql",
 		Usage: "Enable the GraphQL server",
@@ -867,6 +1010,11 @@ var (
 		Value:  eth.DefaultConfig.Rollup.StateDumpPath,
 		EnvVar: "ROLLUP_STATE_DUMP_PATH",
 	}
+	RollupStateDumpHashFlag = cli.StringFlag{
+		Name:   "rollup.statedumphash",
+		Usage:  "Expected content hash of the state dump",
+		EnvVar: "ROLLUP_STATE_DUMP_HASH",
+	}
 	RollupDiffDbFlag = cli.Uint64Flag{
 		Name:   "rollup.diffdbcache",
 		Usage:  "Number of diffdb batch updates",
@@ -1056,6 +1204,9 @@ func setHTTP(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(RPCVirtualHostsFlag.Name) {
 		cfg.HTTPVirtualHosts = splitAndTrim(ctx.GlobalString(RPCVirtualHostsFlag.Name))
 	}
+	if ctx.GlobalIsSet(RPCJWTAuthFlag.Name) {
+		cfg.HTTPJWTAuth = ctx.GlobalBool(RPCJWTAuthFlag.Name)
+	}
 }
 
 // setGraphQL creates the GraphQL listener interface string from the set
@@ -1094,6 +1245,53 @@ func setWS(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(WSApiFlag.Name) {
 		cfg.WSModules = splitAndTrim(ctx.GlobalString(WSApiFlag.Name))
 	}
+	if ctx.GlobalIsSet(WSJWTAuthFlag.Name) {
+		cfg.WSJWTAuth = ctx.GlobalBool(WSJWTAuthFlag.Name)
+	}
+}
+
+// setAuthRPC creates the authenticated RPC listener interface string and the JWT
+// settings from the set command line flags, returning empty if the authenticated
+// endpoint is disabled.
+func setAuthRPC(ctx *cli.Context, cfg *node.Config) {
+	if ctx.GlobalBool(AuthRPCEnabledFlag.Name) && cfg.AuthHost == "" {
+		cfg.AuthHost = "127.0.0.1"
+		if ctx.GlobalIsSet(AuthRPCListenAddrFlag.Name) {
+			cfg.AuthHost = ctx.GlobalString(AuthRPCListenAddrFlag.Name)
+		}
+	}
+	if ctx.GlobalIsSet(AuthRPCPortFlag.Name) {
+		cfg.AuthPort = ctx.GlobalInt(AuthRPCPortFlag.Name)
+	}
+	if ctx.GlobalIsSet(AuthRPCApiFlag.Name) {
+		cfg.AuthModules = splitAndTrim(ctx.GlobalString(AuthRPCApiFlag.Name))
+	}
+	if ctx.GlobalIsSet(AuthRPCVirtualHostsFlag.Name) {
+		cfg.AuthVirtualHosts = splitAndTrim(ctx.GlobalString(AuthRPCVirtualHostsFlag.Name))
+	}
+	if ctx.GlobalIsSet(JWTSecretFlag.Name) {
+		cfg.JWTSecret = ctx.GlobalString(JWTSecretFlag.Name)
+	}
+	if ctx.GlobalIsSet(JWTClockSkewFlag.Name) {
+		cfg.JWTClockSkew = ctx.GlobalDuration(JWTClockSkewFlag.Name)
+	}
+}
+
+// setRPCRecord configures the recording of the served RPC calls from the set
+// command line flags.
+func setRPCRecord(ctx *cli.Context, cfg *node.Config) {
+	if ctx.GlobalIsSet(RPCRecordFlag.Name) {
+		cfg.RPCRecord.Path = ctx.GlobalString(RPCRecordFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCRecordMethodsFlag.Name) {
+		cfg.RPCRecord.Methods = splitAndTrim(ctx.GlobalString(RPCRecordMethodsFlag.Name))
+	}
+	if ctx.GlobalIsSet(RPCRecordSampleFlag.Name) {
+		cfg.RPCRecord.SampleRate = ctx.GlobalFloat64(RPCRecordSampleFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCRecordMaxSizeFlag.Name) {
+		cfg.RPCRecord.MaxFileSize = int64(ctx.GlobalInt(RPCRecordMaxSizeFlag.Name)) * 1024 * 1024
+	}
 }
 
 // setIPC creates an IPC path configuration from the set command line flags,
@@ -1146,6 +1344,9 @@ func setRollup(ctx *cli.Context, cfg *rollup.Config) {
 	} else {
 		cfg.StateDumpPath = eth.DefaultConfig.Rollup.StateDumpPath
 	}
+	if ctx.GlobalIsSet(RollupStateDumpHashFlag.Name) {
+		cfg.StateDumpHash = common.HexToHash(ctx.GlobalString(RollupStateDumpHashFlag.Name))
+	}
 	if ctx.GlobalIsSet(RollupMaxCalldataSizeFlag.Name) {
 		cfg.MaxCallDataSize = ctx.GlobalInt(RollupMaxCalldataSizeFlag.Name)
 	}
@@ -1362,6 +1563,8 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	setHTTP(ctx, cfg)
 	setGraphQL(ctx, cfg)
 	setWS(ctx, cfg)
+	setAuthRPC(ctx, cfg)
+	setRPCRecord(ctx, cfg)
 	setNodeUserIdent(ctx, cfg)
 	setDataDir(ctx, cfg)
 	setSmartCard(ctx, cfg)
@@ -1655,6 +1858,12 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheGCFlag.Name) {
 		cfg.TrieDirtyCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheGCFlag.Name) / 100
 	}
+	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheSnapshotFlag.Name) {
+		cfg.SnapshotCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheSnapshotFlag.Name) / 100
+	}
+	if !ctx.GlobalIsSet(SnapshotFlag.Name) {
+		cfg.SnapshotCache = 0 // Disabled
+	}
 	if ctx.GlobalIsSet(DocRootFlag.Name) {
 		cfg.DocRoot = ctx.GlobalString(DocRootFlag.Name)
 	}
@@ -1673,6 +1882,21 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	if ctx.GlobalIsSet(RPCGlobalGasCap.Name) {
 		cfg.RPCGasCap = new(big.Int).SetUint64(ctx.GlobalUint64(RPCGlobalGasCap.Name))
 	}
+	if ctx.GlobalIsSet(RPCStateReexecFlag.Name) {
+		cfg.StateReexec = ctx.GlobalUint64(RPCStateReexecFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCStateReexecCacheFlag.Name) {
+		cfg.StateReexecCache = ctx.GlobalInt(RPCStateReexecCacheFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCStateReexecMemoryFlag.Name) {
+		cfg.StateReexecMemory = ctx.GlobalInt(RPCStateReexecMemoryFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCLogsMaxRangeFlag.Name) {
+		cfg.LogQueryLimits.MaxBlockRange = ctx.GlobalUint64(RPCLogsMaxRangeFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCLogsMaxResultsFlag.Name) {
+		cfg.LogQueryLimits.MaxResults = ctx.GlobalInt(RPCLogsMaxResultsFlag.Name)
+	}
 
 	// Override any default configs for hard coded networks.
 	switch {
@@ -1727,14 +1951,37 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 		xdomainAddress := cfg.Rollup.L1CrossDomainMessengerAddress
 		addrManagerOwnerAddress := cfg.Rollup.AddressManagerOwnerAddress
 		l1ETHGatewayAddress := cfg.Rollup.L1ETHGatewayAddress
-		stateDumpPath := cfg.Rollup.StateDumpPath
-		cfg.Genesis = core.DeveloperGenesisBlock(uint64(ctx.GlobalInt(DeveloperPeriodFlag.Name)), developer.Address, xdomainAddress, l1ETHGatewayAddress, addrManagerOwnerAddress, stateDumpPath, chainID, gasLimit)
+		var stateDump *dump.OvmDump
+		if vm.UsingOVM {
+			stateDump = loadStateDump(cfg.Rollup.StateDumpPath, cfg.Rollup.StateDumpHash)
+		}
+		cfg.Genesis = core.DeveloperGenesisBlock(uint64(ctx.GlobalInt(DeveloperPeriodFlag.Name)), developer.Address, xdomainAddress, l1ETHGatewayAddress, addrManagerOwnerAddress, stateDump, chainID, gasLimit)
 		if !ctx.GlobalIsSet(MinerGasPriceFlag.Name) && !ctx.GlobalIsSet(MinerLegacyGasPriceFlag.Name) {
 			cfg.Miner.GasPrice = big.NewInt(1)
 		}
 	}
 }
 
+// loadStateDump fetches the OVM state dump from the given path or URL and
+// verifies it against the pinned content hash, if one is configured.
+func loadStateDump(path string, pinned common.Hash) *dump.OvmDump {
+	if path == "" {
+		Fatalf("Must pass state dump path")
+	}
+	log.Info("Fetching state dump", "path", path)
+	stateDump, err := dump.Load(path)
+	if err != nil {
+		Fatalf("Cannot fetch state dump: %v", err)
+	}
+	if pinned != (common.Hash{}) {
+		if err := stateDump.Verify(pinned); err != nil {
+			Fatalf("Cannot use state dump %s: %v", path, err)
+		}
+	}
+	log.Info("Loaded state dump", "version", stateDump.Version, "hash", stateDump.ComputeHash(), "accounts", len(stateDump.Accounts))
+	return stateDump
+}
+
 // RegisterEthService adds an Ethereum client to the stack.
 func RegisterEthService(stack *node.Node, cfg *eth.Config) {
 	var err error
@@ -1790,12 +2037,12 @@ func RegisterGraphQLService(stack *node.Node, endpoint string, cors, vhosts []st
 		// Try to construct the GraphQL service backed by a full node
 		var ethServ *eth.Ethereum
 		if err := ctx.Service(&ethServ); err == nil {
-			return graphql.New(ethServ.APIBackend, endpoint, cors, vhosts, timeouts)
+			return graphql.New(ethServ.APIBackend, false, endpoint, cors, vhosts, timeouts)
 		}
 		// Try to construct the GraphQL service backed by a light node
 		var lesServ *les.LightEthereum
 		if err := ctx.Service(&lesServ); err == nil {
-			return graphql.New(lesServ.ApiBackend, endpoint, cors, vhosts, timeouts)
+			return graphql.New(lesServ.ApiBackend, true, endpoint, cors, vhosts, timeouts)
 		}
 		// Well, this should not have happened, bail out
 		return nil, errors.New("no Ethereum service")
@@ -1907,6 +2154,10 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 		TrieDirtyLimit:      eth.DefaultConfig.TrieDirtyCache,
 		TrieDirtyDisabled:   ctx.GlobalString(GCModeFlag.Name) == "archive",
 		TrieTimeLimit:       eth.DefaultConfig.TrieTimeout,
+		SnapshotLimit:       eth.DefaultConfig.SnapshotCache,
+	}
+	if !ctx.GlobalIsSet(SnapshotFlag.Name) {
+		cache.SnapshotLimit = 0 // Disabled
 	}
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheTrieFlag.Name) {
 		cache.TrieCleanLimit = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheTrieFlag.Name) / 100
@@ -1914,6 +2165,9 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheGCFlag.Name) {
 		cache.TrieDirtyLimit = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheGCFlag.Name) / 100
 	}
+	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheSnapshotFlag.Name) {
+		cache.SnapshotLimit = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheSnapshotFlag.Name) / 100
+	}
 	vmcfg := vm.Config{EnablePreimageRecording: ctx.GlobalBool(VMEnableDebugFlag.Name)}
 	chain, err = core.NewBlockChain(chainDb, cache, config, engine, vmcfg, nil)
 	if err != nil {
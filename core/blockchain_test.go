@@ -144,7 +144,7 @@ func testBlockChainImport(chain types.Blocks, blockchain *BlockChain) error {
 			}
 			return err
 		}
-		statedb, err := state.New(blockchain.GetBlockByHash(block.ParentHash()).Root(), blockchain.stateCache)
+		statedb, err := state.New(blockchain.GetBlockByHash(block.ParentHash()).Root(), blockchain.stateCache, nil)
 		if err != nil {
 			return err
 		}
@@ -980,6 +980,283 @@ func TestLogReorgs(t *testing.T) {
 	}
 }
 
+func TestSetHeadRemovedLogs(t *testing.T) {
+	var (
+		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr1   = crypto.PubkeyToAddress(key1.PublicKey)
+		db      = rawdb.NewMemoryDatabase()
+		// this code generates a log
+		code    = common.Hex2Bytes("60606040525b7f24ec1d3ff24c2f6ff210738839dbc339cd45a5294d85c79361016243157aae7b60405180905060405180910390a15b600a8060416000396000f360606040526008565b00")
+		gspec   = &Genesis{Config: params.TestChainConfig, Alloc: GenesisAlloc{addr1: {Balance: big.NewInt(10000000000000)}}}
+		genesis = gspec.MustCommit(db)
+		signer  = types.NewEIP155Signer(gspec.Config.ChainID)
+	)
+
+	blockchain, _ := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
+	defer blockchain.Stop()
+
+	rmLogsCh := make(chan RemovedLogsEvent, 1)
+	blockchain.SubscribeRemovedLogsEvent(rmLogsCh)
+	chain, _ := GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 4, func(i int, gen *BlockGen) {
+		if i == 1 {
+			tx, err := types.SignTx(types.NewContractCreation(gen.TxNonce(addr1), new(big.Int), 1000000, new(big.Int), code), signer, key1)
+			if err != nil {
+				t.Fatalf("failed to create tx: %v", err)
+			}
+			gen.AddTx(tx)
+		}
+	})
+	if _, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	if err := blockchain.SetHead(1); err != nil {
+		t.Fatalf("failed to rewind chain: %v", err)
+	}
+	select {
+	case ev := <-rmLogsCh:
+		if len(ev.Logs) != 1 {
+			t.Fatalf("removed log count mismatch: have %d, want 1", len(ev.Logs))
+		}
+		if log := ev.Logs[0]; log.BlockNumber != 2 || log.BlockHash != chain[1].Hash() || !log.Removed {
+			t.Errorf("removed log mismatch: block %d, hash %x, removed %v", log.BlockNumber, log.BlockHash, log.Removed)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no RemovedLogsEvent sent on rewind")
+	}
+}
+
+// Tests that rewinding to a block whose state is missing announces the logs of
+// all the blocks above the head actually reached as removed.
+func TestSetHeadRemovedLogsMissingState(t *testing.T) {
+	var (
+		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr1   = crypto.PubkeyToAddress(key1.PublicKey)
+		db      = rawdb.NewMemoryDatabase()
+		// this code generates a log
+		code    = common.Hex2Bytes("60606040525b7f24ec1d3ff24c2f6ff210738839dbc339cd45a5294d85c79361016243157aae7b60405180905060405180910390a15b600a8060416000396000f360606040526008565b00")
+		gspec   = &Genesis{Config: params.TestChainConfig, Alloc: GenesisAlloc{addr1: {Balance: big.NewInt(10000000000000)}}}
+		genesis = gspec.MustCommit(db)
+		signer  = types.NewEIP155Signer(gspec.Config.ChainID)
+	)
+	blockchain, _ := NewBlockChain(db, &CacheConfig{TrieDirtyDisabled: true}, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
+	defer blockchain.Stop()
+
+	rmLogsCh := make(chan RemovedLogsEvent, 1)
+	blockchain.SubscribeRemovedLogsEvent(rmLogsCh)
+	chain, _ := GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 4, func(i int, gen *BlockGen) {
+		if i == 1 {
+			tx, err := types.SignTx(types.NewContractCreation(gen.TxNonce(addr1), new(big.Int), 1000000, new(big.Int), code), signer, key1)
+			if err != nil {
+				t.Fatalf("failed to create tx: %v", err)
+			}
+			gen.AddTx(tx)
+		}
+	})
+	if _, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	// Drop the state of the target head, the chain rewinds to genesis instead
+	db.Delete(chain[2].Root().Bytes())
+	if err := blockchain.SetHead(3); err != nil {
+		t.Fatalf("failed to rewind chain: %v", err)
+	}
+	if head := blockchain.CurrentBlock().NumberU64(); head != 0 {
+		t.Fatalf("head mismatch: have %d, want %d", head, 0)
+	}
+	select {
+	case ev := <-rmLogsCh:
+		if len(ev.Logs) != 1 {
+			t.Fatalf("removed log count mismatch: have %d, want 1", len(ev.Logs))
+		}
+		if log := ev.Logs[0]; log.BlockNumber != 2 || log.BlockHash != chain[1].Hash() || !log.Removed {
+			t.Errorf("removed log mismatch: block %d, hash %x, removed %v", log.BlockNumber, log.BlockHash, log.Removed)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no RemovedLogsEvent sent on rewind")
+	}
+}
+
+// newEnqueue creates an L1 to L2 transaction with the given queue index and L1
+// sender. Such transactions are unsigned and all share the same sender on L2,
+// so the nonce is the number of enqueues before it.
+func newEnqueue(nonce uint64, gas uint64, sender common.Address, index uint64) *types.Transaction {
+	tx := types.NewTransaction(nonce, common.Address{0x11}, new(big.Int), gas, new(big.Int), nil)
+	tx.SetTransactionMeta(types.NewTransactionMeta(big.NewInt(1), 0, &sender, types.SighashEIP155, types.QueueOriginL1ToL2, nil, &index, nil))
+	return tx
+}
+
+// Tests that rewinding the chain drops the enqueue lookups and the sender
+// index of the rewound L1 to L2 transactions.
+func TestSetHeadEnqueueIndexes(t *testing.T) {
+	var (
+		senderA = common.HexToAddress("0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa")
+		senderB = common.HexToAddress("0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb")
+		db      = rawdb.NewMemoryDatabase()
+		gspec   = &Genesis{Config: params.TestChainConfig}
+		genesis = gspec.MustCommit(db)
+		senders = []common.Address{senderA, senderB, senderA}
+	)
+	blockchain, _ := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
+	defer blockchain.Stop()
+
+	chain, _ := GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 3, func(i int, gen *BlockGen) {
+		gen.AddTx(newEnqueue(uint64(i), 21000, senders[i], uint64(i)))
+	})
+	if _, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	if indices := rawdb.ReadEnqueueIndicesBySender(db, senderA, 0, 10); !reflect.DeepEqual(indices, []uint64{0, 2}) {
+		t.Fatalf("sender indices mismatch: have %v, want %v", indices, []uint64{0, 2})
+	}
+	if err := blockchain.SetHead(1); err != nil {
+		t.Fatalf("failed to rewind chain: %v", err)
+	}
+	if entry := rawdb.ReadEnqueueLookupEntry(db, 0); entry == nil || entry.TxHash != chain[0].Transactions()[0].Hash() {
+		t.Errorf("enqueue 0: lookup entry mismatch: %v", entry)
+	}
+	for index := uint64(1); index < 3; index++ {
+		if entry := rawdb.ReadEnqueueLookupEntry(db, index); entry != nil {
+			t.Errorf("enqueue %d: rewound lookup entry retained: %v", index, entry)
+		}
+	}
+	if indices := rawdb.ReadEnqueueIndicesBySender(db, senderA, 0, 10); !reflect.DeepEqual(indices, []uint64{0}) {
+		t.Errorf("sender indices mismatch: have %v, want %v", indices, []uint64{0})
+	}
+	if indices := rawdb.ReadEnqueueIndicesBySender(db, senderB, 0, 10); len(indices) != 0 {
+		t.Errorf("rewound sender indices retained: %v", indices)
+	}
+}
+
+// Tests that a reorg drops the enqueue lookups and the sender index of the L1
+// to L2 transactions on the old chain, unless the new chain includes the same
+// queue index again.
+func TestReorgEnqueueIndexes(t *testing.T) {
+	var (
+		sender  = common.HexToAddress("0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa")
+		db      = rawdb.NewMemoryDatabase()
+		gspec   = &Genesis{Config: params.TestChainConfig}
+		genesis = gspec.MustCommit(db)
+	)
+	blockchain, _ := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
+	defer blockchain.Stop()
+
+	oldChain, _ := GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 2, func(i int, gen *BlockGen) {
+		gen.AddTx(newEnqueue(uint64(i), 21000, sender, uint64(i)))
+	})
+	if _, err := blockchain.InsertChain(oldChain); err != nil {
+		t.Fatalf("failed to insert old chain: %v", err)
+	}
+	// The new chain includes queue index 0 in a different transaction and
+	// drops queue index 1
+	newChain, _ := GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 3, func(i int, gen *BlockGen) {
+		gen.SetCoinbase(common.Address{0x22})
+		if i == 0 {
+			gen.AddTx(newEnqueue(0, 22000, sender, 0))
+		}
+	})
+	if _, err := blockchain.InsertChain(newChain); err != nil {
+		t.Fatalf("failed to insert new chain: %v", err)
+	}
+	if head := blockchain.CurrentBlock().Hash(); head != newChain[2].Hash() {
+		t.Fatalf("head mismatch: have %x, want %x", head, newChain[2].Hash())
+	}
+	if entry := rawdb.ReadEnqueueLookupEntry(db, 0); entry == nil || entry.TxHash != newChain[0].Transactions()[0].Hash() {
+		t.Errorf("enqueue 0: lookup entry mismatch: %v", entry)
+	}
+	if entry := rawdb.ReadEnqueueLookupEntry(db, 1); entry != nil {
+		t.Errorf("enqueue 1: reorged lookup entry retained: %v", entry)
+	}
+	if indices := rawdb.ReadEnqueueIndicesBySender(db, sender, 0, 10); !reflect.DeepEqual(indices, []uint64{0}) {
+		t.Errorf("sender indices mismatch: have %v, want %v", indices, []uint64{0})
+	}
+}
+
+// Tests that the receipts of access list transactions are typed, so that the
+// receipt root of the block commits to their EIP-2718 envelopes.
+func TestAccessListTxReceipt(t *testing.T) {
+	var (
+		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr1   = crypto.PubkeyToAddress(key1.PublicKey)
+		db      = rawdb.NewMemoryDatabase()
+		gspec   = &Genesis{Config: params.TestChainConfig, Alloc: GenesisAlloc{addr1: {Balance: big.NewInt(10000000000000)}}}
+		genesis = gspec.MustCommit(db)
+		signer  = types.NewOVMSigner(gspec.Config.ChainID)
+		to      = common.Address{0x11}
+	)
+	blockchain, _ := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
+	defer blockchain.Stop()
+
+	chain, receipts := GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 1, func(i int, gen *BlockGen) {
+		tx, err := types.SignTx(types.NewTx(&types.AccessListTx{
+			ChainID:    gspec.Config.ChainID,
+			Nonce:      gen.TxNonce(addr1),
+			To:         &to,
+			Gas:        30000,
+			GasPrice:   big.NewInt(1),
+			AccessList: types.AccessList{{Address: to, StorageKeys: []common.Hash{{0x01}}}},
+		}), signer, key1)
+		if err != nil {
+			t.Fatalf("failed to create tx: %v", err)
+		}
+		gen.AddTx(tx)
+	})
+	if typ := receipts[0][0].Type; typ != types.AccessListTxType {
+		t.Fatalf("receipt type mismatch: have %d, want %d", typ, types.AccessListTxType)
+	}
+	legacy := *receipts[0][0]
+	legacy.Type = types.LegacyTxType
+	if chain[0].ReceiptHash() == types.DeriveSha(types.Receipts{&legacy}) {
+		t.Fatal("receipt root commits to the legacy receipt encoding")
+	}
+	// The block is only accepted if the receipt root of the processed block
+	// matches the header
+	if _, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	stored := rawdb.ReadReceipts(db, chain[0].Hash(), chain[0].NumberU64(), gspec.Config)
+	if len(stored) != 1 || stored[0].Type != types.AccessListTxType {
+		t.Fatalf("stored receipts mismatch: %v", stored)
+	}
+}
+
+// Tests that when running the OVM, the state accessed by a block is prefetched
+// ahead of its child, but not ahead of unrelated blocks.
+func TestPrefetchAccessedStateOVM(t *testing.T) {
+	defer func(using bool) { vm.UsingOVM = using }(vm.UsingOVM)
+	vm.UsingOVM = true
+
+	var (
+		db      = state.NewDatabase(rawdb.NewMemoryDatabase())
+		addr    = common.HexToAddress("0x4200000000000000000000000000000000000005")
+		slot    = common.HexToHash("0x01")
+		parent  = types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1)})
+		child   = types.NewBlockWithHeader(&types.Header{Number: big.NewInt(2), ParentHash: parent.Hash()})
+		unknown = types.NewBlockWithHeader(&types.Header{Number: big.NewInt(2)})
+	)
+	statedb, _ := state.New(common.Hash{}, db, nil)
+	statedb.SetState(addr, slot, common.HexToHash("0x02"))
+	root, _ := statedb.Commit(false)
+
+	// Process the parent and record the state it accessed
+	statedb, _ = state.New(root, db, nil)
+	statedb.GetState(addr, slot)
+
+	prefetcher := newStatePrefetcher(params.TestChainConfig, nil, nil)
+	prefetcher.RecordAccessedState(parent, statedb)
+
+	want := types.AccessList{{Address: addr, StorageKeys: []common.Hash{slot}}}
+	fresh, _ := state.New(root, db, nil)
+	prefetcher.PrefetchAccessLists(child, fresh)
+	if have := fresh.AccessedState(); !reflect.DeepEqual(have, want) {
+		t.Errorf("prefetched state mismatch: have %v, want %v", have, want)
+	}
+	fresh, _ = state.New(root, db, nil)
+	prefetcher.PrefetchAccessLists(unknown, fresh)
+	if have := fresh.AccessedState(); len(have) != 0 {
+		t.Errorf("state prefetched for unrelated block: %v", have)
+	}
+}
+
 func TestLogRebirth(t *testing.T) {
 	t.Skip("OVM Genesis breaks this test because it adds the OVM contracts to the state.")
 
@@ -2364,3 +2641,80 @@ func TestDeleteCreateRevert(t *testing.T) {
 		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
 	}
 }
+
+// Tests that the state snapshot tracks the chain head during imports, survives
+// a restart through its journal and is regenerated on a chain rewind.
+func TestSnapshotTracksHead(t *testing.T) {
+	var (
+		engine  = ethash.NewFaker()
+		db      = rawdb.NewMemoryDatabase()
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  GenesisAlloc{address: {Balance: big.NewInt(1000000000)}},
+		}
+		genesis = gspec.MustCommit(db)
+		signer  = types.HomesteadSigner{}
+	)
+	blocks, _ := GenerateChain(params.TestChainConfig, genesis, engine, db, 8, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(address), common.Address{0x01}, big.NewInt(1000), params.TxGas, nil, nil), signer, key)
+		b.AddTx(tx)
+	})
+	diskdb := rawdb.NewMemoryDatabase()
+	gspec.MustCommit(diskdb)
+
+	chain, err := NewBlockChain(diskdb, nil, params.TestChainConfig, engine, vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	head := chain.CurrentBlock()
+
+	checkSnapshot := func(chain *BlockChain, root common.Hash) {
+		t.Helper()
+
+		snap := chain.Snapshot().Snapshot(root)
+		if snap == nil {
+			t.Fatalf("snapshot missing for root %x", root)
+		}
+		statedb, err := chain.StateAt(root)
+		if err != nil {
+			t.Fatalf("failed to retrieve state: %v", err)
+		}
+		for _, addr := range []common.Address{address, {0x01}} {
+			acc, err := snap.Account(crypto.Keccak256Hash(addr[:]))
+			if err != nil {
+				t.Fatalf("account %x: failed to retrieve from snapshot: %v", addr, err)
+			}
+			if acc == nil || acc.Balance.Cmp(statedb.GetBalance(addr)) != 0 || acc.Nonce != statedb.GetNonce(addr) {
+				t.Errorf("account %x: snapshot mismatch: have %+v, want balance %v nonce %d", addr, acc, statedb.GetBalance(addr), statedb.GetNonce(addr))
+			}
+		}
+	}
+	checkSnapshot(chain, head.Root())
+
+	// Restart the chain and ensure the journalled layers are reloaded
+	chain.Stop()
+
+	chain, err = NewBlockChain(diskdb, nil, params.TestChainConfig, engine, vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to recreate tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	checkSnapshot(chain, head.Root())
+
+	// Rewind the chain and ensure the snapshot follows the new head
+	if err := chain.SetHead(head.NumberU64() - 1); err != nil {
+		t.Fatalf("failed to rewind chain: %v", err)
+	}
+	if chain.Snapshot().Snapshot(head.Root()) != nil {
+		t.Errorf("snapshot retained for rewound head")
+	}
+	if root := chain.CurrentBlock().Root(); chain.Snapshot().Snapshot(root) == nil {
+		t.Errorf("snapshot missing for new head %x", root)
+	}
+}
@@ -0,0 +1,121 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// ChainExportVersion is the version of the chain export format written by
+// BlockChain.Export. An export starts with a header carrying the version and
+// is followed by the blocks, each along with the metadata of its
+// transactions. Version 0 is the legacy format of plain block RLP, which
+// loses the rollup transaction metadata.
+const ChainExportVersion = 1
+
+// chainExportMagic identifies the header of a versioned chain export.
+const chainExportMagic = "l2geth-chain-export"
+
+var (
+	// ErrLegacyChainExport is returned when reading a legacy chain export
+	// that is not allowed to be imported.
+	ErrLegacyChainExport = errors.New("legacy chain export without transaction metadata")
+
+	// errUnsupportedChainExport is returned when reading a chain export with
+	// a newer version than supported.
+	errUnsupportedChainExport = errors.New("unsupported chain export version")
+)
+
+// chainExportHeader precedes the blocks of a versioned chain export. Appending
+// to an export writes another header, so it may appear more than once.
+type chainExportHeader struct {
+	Magic   string
+	Version uint64
+}
+
+// exportedBlock is a block along with the encoded metadata of each of its
+// transactions.
+type exportedBlock struct {
+	Block *types.Block
+	Metas [][]byte
+}
+
+// writeChainExportHeader writes the header of a versioned chain export.
+func writeChainExportHeader(w io.Writer) error {
+	return rlp.Encode(w, &chainExportHeader{
+		Magic:   chainExportMagic,
+		Version: ChainExportVersion,
+	})
+}
+
+// writeExportedBlock writes a block and the metadata of its transactions.
+func writeExportedBlock(w io.Writer, block *types.Block) error {
+	return rlp.Encode(w, &exportedBlock{
+		Block: block,
+		Metas: types.EncodeTxMetas(block.Transactions()),
+	})
+}
+
+// ChainExportReader reads blocks from a chain export, restoring the metadata
+// of their transactions. Both the versioned and the legacy format are
+// understood.
+type ChainExportReader struct {
+	stream      *rlp.Stream
+	version     uint64
+	allowLegacy bool
+}
+
+// NewChainExportReader creates a reader of the chain export in r. If
+// allowLegacy is false, reading a block in the legacy format fails with
+// ErrLegacyChainExport.
+func NewChainExportReader(r io.Reader, allowLegacy bool) *ChainExportReader {
+	return &ChainExportReader{
+		stream:      rlp.NewStream(r, 0),
+		allowLegacy: allowLegacy,
+	}
+}
+
+// Version returns the format version of the blocks most recently read.
+func (r *ChainExportReader) Version() uint64 {
+	return r.version
+}
+
+// Next returns the next block in the export, or io.EOF once all blocks have
+// been read.
+func (r *ChainExportReader) Next() (*types.Block, error) {
+	for {
+		raw, err := r.stream.Raw()
+		if err != nil {
+			return nil, err
+		}
+		var header chainExportHeader
+		if err := rlp.DecodeBytes(raw, &header); err == nil && header.Magic == chainExportMagic {
+			if header.Version > ChainExportVersion {
+				return nil, fmt.Errorf("%w: %d", errUnsupportedChainExport, header.Version)
+			}
+			r.version = header.Version
+			continue
+		}
+		if r.version == 0 {
+			if !r.allowLegacy {
+				return nil, ErrLegacyChainExport
+			}
+			block := new(types.Block)
+			if err := rlp.DecodeBytes(raw, block); err != nil {
+				return nil, err
+			}
+			return block, nil
+		}
+		var entry exportedBlock
+		if err := rlp.DecodeBytes(raw, &entry); err != nil {
+			return nil, err
+		}
+		if err := types.SetTxMetas(entry.Block.Transactions(), entry.Metas); err != nil {
+			return nil, fmt.Errorf("block %d: %v", entry.Block.NumberU64(), err)
+		}
+		return entry.Block, nil
+	}
+}
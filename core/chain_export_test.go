@@ -0,0 +1,136 @@
+package core
+
+import (
+	"bytes"
+	"io"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// newExportTestChain creates a chain of blocks holding a single transaction
+// each, with the transaction metadata set as the rollup would.
+func newExportTestChain(t *testing.T, n int) (*BlockChain, []*types.Block) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  GenesisAlloc{address: {Balance: big.NewInt(1000000000)}},
+		}
+		genesis = gspec.MustCommit(db)
+		signer  = types.NewEIP155Signer(gspec.Config.ChainID)
+		sender  = common.HexToAddress("0x1111111111111111111111111111111111111111")
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, n, func(i int, block *BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(block.TxNonce(address), common.Address{0x00}, big.NewInt(1000), params.TxGas, nil, nil), signer, key)
+		if err != nil {
+			panic(err)
+		}
+		index := uint64(i)
+		tx.SetTransactionMeta(types.NewTransactionMeta(big.NewInt(int64(i+100)), uint64(i+200), &sender, types.SighashEIP155, types.QueueOriginSequencer, &index, nil, []byte{0xff, byte(i)}))
+		block.AddTx(tx)
+	})
+	chain, err := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	return chain, blocks
+}
+
+// readChainExport reads all blocks from a chain export.
+func readChainExport(data []byte, allowLegacy bool) ([]*types.Block, error) {
+	reader := NewChainExportReader(bytes.NewReader(data), allowLegacy)
+	var blocks []*types.Block
+	for {
+		block, err := reader.Next()
+		if err == io.EOF {
+			return blocks, nil
+		} else if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, block)
+	}
+}
+
+// Tests that exporting and reading back a chain preserves the transaction
+// metadata.
+func TestChainExportMeta(t *testing.T) {
+	chain, blocks := newExportTestChain(t, 8)
+	defer chain.Stop()
+
+	// Export the chain in two parts, appending the second one
+	buf := new(bytes.Buffer)
+	if err := chain.ExportN(buf, 0, 4); err != nil {
+		t.Fatalf("failed to export chain: %v", err)
+	}
+	if err := chain.ExportN(buf, 5, 8); err != nil {
+		t.Fatalf("failed to export chain: %v", err)
+	}
+	exported, err := readChainExport(buf.Bytes(), false)
+	if err != nil {
+		t.Fatalf("failed to read export: %v", err)
+	}
+	if len(exported) != len(blocks)+1 {
+		t.Fatalf("exported block count mismatch: have %d, want %d", len(exported), len(blocks)+1)
+	}
+	for i, block := range blocks {
+		have := exported[i+1]
+		if have.Hash() != block.Hash() {
+			t.Fatalf("block %d: hash mismatch: have %x, want %x", i+1, have.Hash(), block.Hash())
+		}
+		want := block.Transactions()[0].GetMeta()
+		if meta := have.Transactions()[0].GetMeta(); !reflect.DeepEqual(meta, want) {
+			t.Fatalf("block %d: meta mismatch: have %+v, want %+v", i+1, meta, want)
+		}
+	}
+}
+
+// Tests that the legacy format of plain block RLP can only be read if allowed.
+func TestChainExportLegacy(t *testing.T) {
+	chain, blocks := newExportTestChain(t, 4)
+	defer chain.Stop()
+
+	buf := new(bytes.Buffer)
+	for _, block := range blocks {
+		if err := rlp.Encode(buf, block); err != nil {
+			t.Fatalf("failed to encode block: %v", err)
+		}
+	}
+	if _, err := readChainExport(buf.Bytes(), false); err != ErrLegacyChainExport {
+		t.Fatalf("unexpected error reading legacy export: have %v, want %v", err, ErrLegacyChainExport)
+	}
+	exported, err := readChainExport(buf.Bytes(), true)
+	if err != nil {
+		t.Fatalf("failed to read legacy export: %v", err)
+	}
+	if len(exported) != len(blocks) {
+		t.Fatalf("exported block count mismatch: have %d, want %d", len(exported), len(blocks))
+	}
+	for i, block := range blocks {
+		if exported[i].Hash() != block.Hash() {
+			t.Fatalf("block %d: hash mismatch: have %x, want %x", i, exported[i].Hash(), block.Hash())
+		}
+	}
+}
+
+// Tests that exports of a newer version are rejected.
+func TestChainExportUnsupportedVersion(t *testing.T) {
+	data, _ := rlp.EncodeToBytes(&chainExportHeader{Magic: chainExportMagic, Version: ChainExportVersion + 1})
+	if _, err := readChainExport(data, true); err == nil {
+		t.Fatal("unsupported version accepted")
+	}
+}
@@ -22,9 +22,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"math/big"
-	"net/http"
 	"sort"
 	"strings"
 
@@ -190,7 +188,7 @@ func SetupGenesisBlockWithOverride(db ethdb.Database, genesis *Genesis, override
 	// We have the genesis block in database(perhaps in ancient database)
 	// but the corresponding state is missing.
 	header := rawdb.ReadHeader(db, stored, 0)
-	if _, err := state.New(header.Root, state.NewDatabaseWithCache(db, 0)); err != nil {
+	if _, err := state.New(header.Root, state.NewDatabaseWithCache(db, 0), nil); err != nil {
 		if genesis == nil {
 			genesis = DefaultGenesisBlock()
 		}
@@ -208,6 +206,11 @@ func SetupGenesisBlockWithOverride(db ethdb.Database, genesis *Genesis, override
 
 	// Check whether the genesis block is already written.
 	if genesis != nil {
+		if storedcfg := rawdb.ReadChainConfig(db, stored); storedcfg != nil {
+			if err := checkStateDumpHash(storedcfg, genesis.Config); err != nil {
+				return genesis.Config, stored, err
+			}
+		}
 		hash := genesis.ToBlock(nil).Hash()
 		if hash != stored {
 			return genesis.Config, hash, &GenesisMismatchError{stored, hash}
@@ -252,6 +255,28 @@ func SetupGenesisBlockWithOverride(db ethdb.Database, genesis *Genesis, override
 	return newcfg, stored, nil
 }
 
+// StateDumpMismatchError is returned when the genesis is being built from a
+// different OVM state dump than the one the stored chain was created with.
+type StateDumpMismatchError struct {
+	Stored, New common.Hash
+}
+
+func (e *StateDumpMismatchError) Error() string {
+	return fmt.Sprintf("database was created from a different state dump (have %x, new %x)", e.Stored, e.New)
+}
+
+// checkStateDumpHash ensures that the state dump pinned in the stored chain
+// configuration matches the one in the new configuration, if both are known.
+func checkStateDumpHash(stored, new *params.ChainConfig) error {
+	if stored.StateDumpHash == nil || new.StateDumpHash == nil {
+		return nil
+	}
+	if *stored.StateDumpHash != *new.StateDumpHash {
+		return &StateDumpMismatchError{*stored.StateDumpHash, *new.StateDumpHash}
+	}
+	return nil
+}
+
 func (g *Genesis) configOrDefault(ghash common.Hash) *params.ChainConfig {
 	switch {
 	case g != nil:
@@ -334,7 +359,7 @@ func (g *Genesis) ToBlock(db ethdb.Database) *types.Block {
 	if db == nil {
 		db = rawdb.NewMemoryDatabase()
 	}
-	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db), nil)
 
 	if vm.UsingOVM {
 		// OVM_ENABLED
@@ -465,7 +490,7 @@ func DefaultGoerliGenesisBlock() *Genesis {
 }
 
 // DeveloperGenesisBlock returns the 'geth --dev' genesis block.
-func DeveloperGenesisBlock(period uint64, faucet, l1XDomainMessengerAddress common.Address, l1ETHGatewayAddress common.Address, addrManagerOwnerAddress common.Address, stateDumpPath string, chainID *big.Int, gasLimit uint64) *Genesis {
+func DeveloperGenesisBlock(period uint64, faucet, l1XDomainMessengerAddress common.Address, l1ETHGatewayAddress common.Address, addrManagerOwnerAddress common.Address, stateDump *dump.OvmDump, chainID *big.Int, gasLimit uint64) *Genesis {
 	// Override the default period to the user requested one
 	config := *params.AllCliqueProtocolChanges
 	config.Clique.Period = period
@@ -474,35 +499,17 @@ func DeveloperGenesisBlock(period uint64, faucet, l1XDomainMessengerAddress comm
 		config.ChainID = chainID
 	}
 
-	stateDump := dump.OvmDump{}
-	if vm.UsingOVM {
-		// Fetch the state dump from the state dump path
-		if stateDumpPath == "" {
-			panic("Must pass state dump path")
-		}
-		log.Info("Fetching state dump", "path", stateDumpPath)
-		err := fetchStateDump(stateDumpPath, &stateDump)
-		if err != nil {
-			panic(fmt.Sprintf("Cannot fetch state dump: %s", err))
-		}
-		_, ok := stateDump.Accounts["Lib_AddressManager"]
-		if !ok {
-			panic("Lib_AddressManager not in state dump")
-		}
-		_, ok = stateDump.Accounts["OVM_StateManager"]
-		if !ok {
-			panic("OVM_StateManager not in state dump")
-		}
-		_, ok = stateDump.Accounts["OVM_ExecutionManager"]
-		if !ok {
-			panic("OVM_ExecutionManager not in state dump")
-		}
-		_, ok = stateDump.Accounts["OVM_SequencerEntrypoint"]
-		if !ok {
-			panic("OVM_SequencerEntrypoint not in state dump")
+	if stateDump == nil {
+		if vm.UsingOVM {
+			panic("Must pass state dump")
 		}
+		stateDump = new(dump.OvmDump)
+	}
+	config.StateDump = stateDump
+	if len(stateDump.Accounts) > 0 {
+		hash := stateDump.ComputeHash()
+		config.StateDumpHash = &hash
 	}
-	config.StateDump = &stateDump
 
 	// Assemble and return the genesis with the precompiles and faucet pre-funded
 	return &Genesis{
@@ -538,26 +545,3 @@ func decodePrealloc(data string) GenesisAlloc {
 	}
 	return ga
 }
-
-func fetchStateDump(path string, stateDump *dump.OvmDump) error {
-	if stateDump == nil {
-		return errors.New("Unable to fetch state dump")
-	}
-	resp, err := http.Get(path)
-	if err != nil {
-		return fmt.Errorf("Unable to GET state dump: %w", err)
-	}
-	if resp.StatusCode >= 400 {
-		return errors.New("State dump not found")
-	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("Unable to read response body: %w", err)
-	}
-	err = json.Unmarshal(body, stateDump)
-	if err != nil {
-		return fmt.Errorf("Unable to unmarshal response body: %w", err)
-	}
-	return nil
-}
@@ -166,3 +166,27 @@ func TestSetupGenesis(t *testing.T) {
 		}
 	}
 }
+
+func TestSetupGenesisStateDumpMismatch(t *testing.T) {
+	var (
+		db     = rawdb.NewMemoryDatabase()
+		hash1  = common.HexToHash("0x01")
+		hash2  = common.HexToHash("0x02")
+		config = *params.AllCliqueProtocolChanges
+	)
+	config.StateDumpHash = &hash1
+	genesis := &Genesis{Config: &config, Difficulty: big.NewInt(1)}
+	genesis.MustCommit(db)
+
+	// Reopening with the same pinned dump must succeed
+	if _, _, err := SetupGenesisBlock(db, genesis); err != nil {
+		t.Fatalf("failed to reopen chain: %v", err)
+	}
+	// A genesis built from a different dump must be rejected
+	changed := config
+	changed.StateDumpHash = &hash2
+	_, _, err := SetupGenesisBlock(db, &Genesis{Config: &changed, Difficulty: big.NewInt(1)})
+	if _, ok := err.(*StateDumpMismatchError); !ok {
+		t.Fatalf("wrong error: have %v, want *StateDumpMismatchError", err)
+	}
+}
@@ -362,6 +362,11 @@ func ReadTransactionMetaRaw(db ethdb.Reader, number uint64) []byte {
 	if len(data) > 0 {
 		return data
 	}
+	// The metadata of frozen blocks is moved into the ancient database.
+	data, _ = db.Ancient(freezerMetaTable, number)
+	if len(data) > 0 {
+		return data
+	}
 	return nil
 }
 
@@ -613,12 +618,17 @@ func WriteAncientBlock(db ethdb.AncientWriter, block *types.Block, receipts type
 	if err != nil {
 		log.Crit("Failed to RLP encode block total difficulty", "err", err)
 	}
+	// The metadata is stored per block, blocks without transactions have none.
+	var metaBlob []byte
+	if txs := block.Transactions(); len(txs) > 0 {
+		metaBlob = types.TxMetaEncode(txs[0].GetMeta())
+	}
 	// Write all blob to flatten files.
-	err = db.AppendAncient(block.NumberU64(), block.Hash().Bytes(), headerBlob, bodyBlob, receiptBlob, tdBlob)
+	err = db.AppendAncient(block.NumberU64(), block.Hash().Bytes(), headerBlob, bodyBlob, receiptBlob, tdBlob, metaBlob)
 	if err != nil {
 		log.Crit("Failed to write block data to ancient store", "err", err)
 	}
-	return len(headerBlob) + len(bodyBlob) + len(receiptBlob) + len(tdBlob) + common.HashLength
+	return len(headerBlob) + len(bodyBlob) + len(receiptBlob) + len(tdBlob) + len(metaBlob) + common.HashLength
 }
 
 // DeleteBlock removes all block data associated with a hash.
@@ -0,0 +1,55 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// ReadCode retrieves the contract code of the provided code hash. Codes written
+// before the introduction of the code prefix are stored under the bare hash,
+// sharing the key space with trie nodes, so that is checked as a fallback.
+func ReadCode(db ethdb.KeyValueReader, hash common.Hash) []byte {
+	if data := ReadCodeWithPrefix(db, hash); len(data) != 0 {
+		return data
+	}
+	data, _ := db.Get(hash[:])
+	return data
+}
+
+// ReadCodeWithPrefix retrieves the contract code of the provided code hash,
+// only checking the prefixed database key.
+func ReadCodeWithPrefix(db ethdb.KeyValueReader, hash common.Hash) []byte {
+	data, _ := db.Get(codeKey(hash))
+	return data
+}
+
+// HasCodeWithPrefix checks if the contract code corresponding to the provided
+// code hash is present under the prefixed database key.
+func HasCodeWithPrefix(db ethdb.KeyValueReader, hash common.Hash) bool {
+	ok, _ := db.Has(codeKey(hash))
+	return ok
+}
+
+// WriteCode writes the provided contract code to the database.
+func WriteCode(db ethdb.KeyValueWriter, hash common.Hash, code []byte) {
+	if err := db.Put(codeKey(hash), code); err != nil {
+		log.Crit("Failed to store contract code", "err", err)
+	}
+}
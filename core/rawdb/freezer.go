@@ -177,7 +177,7 @@ func (f *freezer) AncientSize(kind string) (uint64, error) {
 // Notably, this function is lock free but kind of thread-safe. All out-of-order
 // injection will be rejected. But if two injections with same number happen at
 // the same time, we can get into the trouble.
-func (f *freezer) AppendAncient(number uint64, hash, header, body, receipts, td []byte) (err error) {
+func (f *freezer) AppendAncient(number uint64, hash, header, body, receipts, td, meta []byte) (err error) {
 	// Ensure the binary blobs we are appending is continuous with freezer.
 	if atomic.LoadUint64(&f.frozen) != number {
 		return errOutOrderInsertion
@@ -193,7 +193,13 @@ func (f *freezer) AppendAncient(number uint64, hash, header, body, receipts, td
 			log.Info("Append ancient failed", "number", number, "err", err)
 		}
 	}()
-	// Inject all the components into the relevant data tables
+	// Inject all the components into the relevant data tables. The metadata
+	// goes first, as repair doesn't consider the meta table being shorter
+	// than the rest as a failed append.
+	if err := f.tables[freezerMetaTable].Append(f.frozen, meta); err != nil {
+		log.Error("Failed to append ancient transaction metadata", "number", f.frozen, "hash", hash, "err", err)
+		return err
+	}
 	if err := f.tables[freezerHashTable].Append(f.frozen, hash[:]); err != nil {
 		log.Error("Failed to append ancient hash", "number", f.frozen, "hash", hash, "err", err)
 		return err
@@ -321,9 +327,12 @@ func (f *freezer) freeze(db ethdb.KeyValueStore) {
 				log.Error("Total difficulty missing, can't freeze", "number", f.frozen, "hash", hash)
 				break
 			}
+			// Blocks without transactions don't have any metadata
+			meta := ReadTransactionMetaRaw(nfdb, f.frozen)
+
 			log.Trace("Deep froze ancient block", "number", f.frozen, "hash", hash)
 			// Inject all the components into the relevant data tables
-			if err := f.AppendAncient(f.frozen, hash[:], header, body, receipts, td); err != nil {
+			if err := f.AppendAncient(f.frozen, hash[:], header, body, receipts, td, meta); err != nil {
 				break
 			}
 			ancients = append(ancients, hash)
@@ -339,6 +348,7 @@ func (f *freezer) freeze(db ethdb.KeyValueStore) {
 			if first+uint64(i) != 0 {
 				DeleteBlockWithoutNumber(batch, ancients[i], first+uint64(i))
 				DeleteCanonicalHash(batch, first+uint64(i))
+				DeleteTransactionMeta(batch, first+uint64(i))
 			}
 		}
 		if err := batch.Write(); err != nil {
@@ -373,10 +383,15 @@ func (f *freezer) freeze(db ethdb.KeyValueStore) {
 	}
 }
 
-// repair truncates all data tables to the same length.
+// repair truncates all data tables to the same length. The meta table is
+// allowed to be shorter, as it is missing from freezers created before it was
+// introduced. It's filled up from the key-value store by migrateMeta.
 func (f *freezer) repair() error {
 	min := uint64(math.MaxUint64)
-	for _, table := range f.tables {
+	for name, table := range f.tables {
+		if name == freezerMetaTable {
+			continue
+		}
 		items := atomic.LoadUint64(&table.items)
 		if min > items {
 			min = items
@@ -390,3 +405,49 @@ func (f *freezer) repair() error {
 	atomic.StoreUint64(&f.frozen, min)
 	return nil
 }
+
+// migrateMeta moves the transaction metadata of the blocks frozen before the
+// meta table existed from the key-value store into the meta table. It resumes
+// where a previous, interrupted migration stopped.
+func (f *freezer) migrateMeta(db ethdb.KeyValueStore) error {
+	var (
+		table  = f.tables[freezerMetaTable]
+		first  = atomic.LoadUint64(&table.items)
+		frozen = atomic.LoadUint64(&f.frozen)
+	)
+	if first >= frozen {
+		return nil
+	}
+	log.Info("Migrating transaction metadata into ancient database", "from", first, "to", frozen)
+	var (
+		nfdb  = &nofreezedb{KeyValueStore: db}
+		start = time.Now()
+	)
+	for number := first; number < frozen; number++ {
+		if err := table.Append(number, ReadTransactionMetaRaw(nfdb, number)); err != nil {
+			return err
+		}
+	}
+	if err := table.Sync(); err != nil {
+		return err
+	}
+	// The metadata is frozen, wipe it from the key-value store
+	batch := db.NewBatch()
+	for number := first; number < frozen; number++ {
+		if number == 0 {
+			continue
+		}
+		DeleteTransactionMeta(batch, number)
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	log.Info("Migrated transaction metadata into ancient database", "count", frozen-first, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
@@ -0,0 +1,157 @@
+package rawdb
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+)
+
+// newMetaTestBlock creates a block holding a single transaction with rollup
+// metadata, or an empty block for the genesis.
+func newMetaTestBlock(number uint64) *types.Block {
+	header := &types.Header{Number: new(big.Int).SetUint64(number)}
+	if number == 0 {
+		return types.NewBlockWithHeader(header)
+	}
+	var (
+		sender = common.HexToAddress("0x1111111111111111111111111111111111111111")
+		index  = number - 1
+		tx     = types.NewTransaction(number, common.Address{0x01}, big.NewInt(1), 21000, big.NewInt(1), nil)
+	)
+	tx.SetTransactionMeta(types.NewTransactionMeta(new(big.Int).SetUint64(number+100), number+200, &sender, types.SighashEIP155, types.QueueOriginSequencer, &index, nil, []byte{0xff, byte(number)}))
+	return types.NewBlock(header, []*types.Transaction{tx}, nil, nil)
+}
+
+// newMetaTestFreezer creates a freezer database in a temporary directory.
+func newMetaTestFreezer(t *testing.T) (*freezerdb, string) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temp freezer dir: %v", err)
+	}
+	frdb, err := newFreezer(dir, "")
+	if err != nil {
+		t.Fatalf("failed to create freezer: %v", err)
+	}
+	return &freezerdb{KeyValueStore: memorydb.New(), AncientStore: frdb}, dir
+}
+
+// Tests that the transaction metadata of ancient blocks is stored in and read
+// back from the freezer, and discarded along with the blocks.
+func TestFreezerTransactionMeta(t *testing.T) {
+	db, dir := newMetaTestFreezer(t)
+	defer os.RemoveAll(dir)
+	defer db.Close()
+
+	for i := uint64(0); i < 4; i++ {
+		WriteAncientBlock(db, newMetaTestBlock(i), nil, big.NewInt(int64(i)))
+	}
+	if meta := ReadTransactionMetaRaw(db, 0); meta != nil {
+		t.Fatalf("genesis metadata returned: %x", meta)
+	}
+	for i := uint64(1); i < 4; i++ {
+		want := types.TxMetaEncode(newMetaTestBlock(i).Transactions()[0].GetMeta())
+		if have := ReadTransactionMetaRaw(db, i); !bytes.Equal(have, want) {
+			t.Fatalf("block %d: metadata mismatch: have %x, want %x", i, have, want)
+		}
+	}
+	if err := db.TruncateAncients(2); err != nil {
+		t.Fatalf("failed to truncate ancients: %v", err)
+	}
+	if meta := ReadTransactionMetaRaw(db, 2); meta != nil {
+		t.Fatalf("truncated metadata returned: %x", meta)
+	}
+	if meta := ReadTransactionMetaRaw(db, 1); meta == nil {
+		t.Fatalf("retained metadata missing")
+	}
+}
+
+// Tests that a meta table ahead of the other tables is truncated on repair,
+// while a meta table behind them is left for the migration.
+func TestFreezerRepairMeta(t *testing.T) {
+	db, dir := newMetaTestFreezer(t)
+	defer os.RemoveAll(dir)
+
+	frdb := db.AncientStore.(*freezer)
+	for i := uint64(0); i < 3; i++ {
+		WriteAncientBlock(db, newMetaTestBlock(i), nil, big.NewInt(int64(i)))
+	}
+	// Simulate a crash in the middle of appending a block
+	if err := frdb.tables[freezerMetaTable].Append(3, []byte{0x01}); err != nil {
+		t.Fatalf("failed to append metadata: %v", err)
+	}
+	db.Close()
+
+	frdb, err := newFreezer(dir, "")
+	if err != nil {
+		t.Fatalf("failed to reopen freezer: %v", err)
+	}
+	if frozen, _ := frdb.Ancients(); frozen != 3 {
+		t.Fatalf("frozen count mismatch: have %d, want %d", frozen, 3)
+	}
+	if items := frdb.tables[freezerMetaTable].items; items != 3 {
+		t.Fatalf("meta table not repaired: have %d items, want %d", items, 3)
+	}
+	// Simulate a freezer from before the meta table existed
+	if err := frdb.tables[freezerMetaTable].truncate(1); err != nil {
+		t.Fatalf("failed to truncate meta table: %v", err)
+	}
+	frdb.Close()
+
+	frdb, err = newFreezer(dir, "")
+	if err != nil {
+		t.Fatalf("failed to reopen freezer: %v", err)
+	}
+	defer frdb.Close()
+
+	if frozen, _ := frdb.Ancients(); frozen != 3 {
+		t.Fatalf("frozen count mismatch: have %d, want %d", frozen, 3)
+	}
+	if items := frdb.tables[freezerMetaTable].items; items != 1 {
+		t.Fatalf("meta table changed by repair: have %d items, want %d", items, 1)
+	}
+}
+
+// Tests that the metadata of blocks frozen before the meta table existed is
+// moved over from the key-value store.
+func TestFreezerMigrateMeta(t *testing.T) {
+	db, dir := newMetaTestFreezer(t)
+	defer os.RemoveAll(dir)
+	defer db.Close()
+
+	frdb := db.AncientStore.(*freezer)
+	for i := uint64(0); i < 4; i++ {
+		WriteAncientBlock(db, newMetaTestBlock(i), nil, big.NewInt(int64(i)))
+	}
+	// Drop the frozen metadata, leaving it in the key-value store instead
+	if err := frdb.tables[freezerMetaTable].truncate(2); err != nil {
+		t.Fatalf("failed to truncate meta table: %v", err)
+	}
+	for i := uint64(2); i < 4; i++ {
+		WriteTransactionMeta(db, i, newMetaTestBlock(i).Transactions()[0].GetMeta())
+	}
+	if err := frdb.migrateMeta(db.KeyValueStore); err != nil {
+		t.Fatalf("failed to migrate metadata: %v", err)
+	}
+	if items := frdb.tables[freezerMetaTable].items; items != 4 {
+		t.Fatalf("meta table item count mismatch: have %d, want %d", items, 4)
+	}
+	for i := uint64(1); i < 4; i++ {
+		want := types.TxMetaEncode(newMetaTestBlock(i).Transactions()[0].GetMeta())
+		if have, _ := db.Ancient(freezerMetaTable, i); !bytes.Equal(have, want) {
+			t.Fatalf("block %d: ancient metadata mismatch: have %x, want %x", i, have, want)
+		}
+		if has, _ := db.Has(txMetaKey(i)); has {
+			t.Fatalf("block %d: metadata not wiped from key-value store", i)
+		}
+	}
+	// Migrating again is a noop
+	if err := frdb.migrateMeta(db.KeyValueStore); err != nil {
+		t.Fatalf("failed to rerun migration: %v", err)
+	}
+}
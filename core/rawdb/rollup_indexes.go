@@ -1,10 +1,15 @@
 package rawdb
 
 import (
+	"bytes"
+	"encoding/binary"
 	"math/big"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
 )
 
 func ReadHeadIndex(db ethdb.KeyValueReader) *uint64 {
@@ -44,3 +49,109 @@ func WriteHeadQueueIndex(db ethdb.KeyValueWriter, index uint64) {
 		log.Crit("Failed to store queue index", "err", err)
 	}
 }
+
+// EnqueueLookupEntry is the positional metadata stored for every L1 to L2
+// transaction, allowing it to be found by its queue index.
+type EnqueueLookupEntry struct {
+	TxHash      common.Hash
+	BlockNumber uint64
+}
+
+// ReadEnqueueLookupEntry retrieves the positional metadata of the L1 to L2
+// transaction with the given queue index. The entry is not guaranteed to
+// reference a canonical block, callers must check it against the chain.
+func ReadEnqueueLookupEntry(db ethdb.Reader, index uint64) *EnqueueLookupEntry {
+	data, _ := db.Get(enqueueLookupKey(index))
+	if len(data) == 0 {
+		return nil
+	}
+	var entry EnqueueLookupEntry
+	if err := rlp.DecodeBytes(data, &entry); err != nil {
+		log.Error("Invalid enqueue lookup entry RLP", "index", index, "blob", data, "err", err)
+		return nil
+	}
+	return &entry
+}
+
+// WriteEnqueueLookupEntries stores the positional metadata of every L1 to L2
+// transaction in a block, keyed by queue index. The queue indices are also
+// indexed by the L1 transaction origin.
+func WriteEnqueueLookupEntries(db ethdb.KeyValueWriter, block *types.Block) {
+	for _, tx := range block.Transactions() {
+		meta := tx.GetMeta()
+		if meta == nil || meta.QueueIndex == nil {
+			continue
+		}
+		if meta.QueueOrigin == nil || meta.QueueOrigin.Uint64() != uint64(types.QueueOriginL1ToL2) {
+			continue
+		}
+		data, err := rlp.EncodeToBytes(EnqueueLookupEntry{
+			TxHash:      tx.Hash(),
+			BlockNumber: block.NumberU64(),
+		})
+		if err != nil {
+			log.Crit("Failed to encode enqueue lookup entry", "err", err)
+		}
+		if err := db.Put(enqueueLookupKey(*meta.QueueIndex), data); err != nil {
+			log.Crit("Failed to store enqueue lookup entry", "err", err)
+		}
+		if meta.L1MessageSender != nil {
+			if err := db.Put(enqueueSenderKey(*meta.L1MessageSender, *meta.QueueIndex), nil); err != nil {
+				log.Crit("Failed to store enqueue sender entry", "err", err)
+			}
+		}
+	}
+}
+
+// DeleteEnqueueLookupEntry removes the positional metadata of the L1 to L2
+// transaction with the given queue index.
+func DeleteEnqueueLookupEntry(db ethdb.KeyValueWriter, index uint64) {
+	if err := db.Delete(enqueueLookupKey(index)); err != nil {
+		log.Crit("Failed to delete enqueue lookup entry", "err", err)
+	}
+}
+
+// DeleteEnqueueLookupEntries removes the positional metadata and the sender
+// index of every L1 to L2 transaction in a block.
+func DeleteEnqueueLookupEntries(db ethdb.KeyValueWriter, block *types.Block) {
+	for _, tx := range block.Transactions() {
+		meta := tx.GetMeta()
+		if meta == nil || meta.QueueIndex == nil {
+			continue
+		}
+		if meta.QueueOrigin == nil || meta.QueueOrigin.Uint64() != uint64(types.QueueOriginL1ToL2) {
+			continue
+		}
+		DeleteEnqueueLookupEntry(db, *meta.QueueIndex)
+		if meta.L1MessageSender != nil {
+			DeleteEnqueueSenderEntry(db, *meta.L1MessageSender, *meta.QueueIndex)
+		}
+	}
+}
+
+// DeleteEnqueueSenderEntry removes the queue index from the index of L1 to L2
+// transactions sent by the given L1 account.
+func DeleteEnqueueSenderEntry(db ethdb.KeyValueWriter, sender common.Address, index uint64) {
+	if err := db.Delete(enqueueSenderKey(sender, index)); err != nil {
+		log.Crit("Failed to delete enqueue sender entry", "err", err)
+	}
+}
+
+// ReadEnqueueIndicesBySender retrieves, in ascending order, up to limit queue
+// indices of the L1 to L2 transactions originating from the given L1 account,
+// starting at queue index start.
+func ReadEnqueueIndicesBySender(db ethdb.Iteratee, sender common.Address, start uint64, limit int) []uint64 {
+	prefix := append(enqueueSenderPrefix, sender.Bytes()...)
+	it := db.NewIteratorWithStart(enqueueSenderKey(sender, start))
+	defer it.Release()
+
+	var indices []uint64
+	for len(indices) < limit && it.Next() {
+		key := it.Key()
+		if len(key) != len(prefix)+8 || !bytes.HasPrefix(key, prefix) {
+			break
+		}
+		indices = append(indices, binary.BigEndian.Uint64(key[len(prefix):]))
+	}
+	return indices
+}
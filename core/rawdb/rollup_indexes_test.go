@@ -1,7 +1,12 @@
 package rawdb
 
 import (
+	"math/big"
+	"reflect"
 	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 )
 
 func TestReadWriteHeadIndex(t *testing.T) {
@@ -39,3 +44,68 @@ func TestReadWriteHeadQueueIndex(t *testing.T) {
 		}
 	}
 }
+
+func TestEnqueueLookupStorage(t *testing.T) {
+	db := NewMemoryDatabase()
+
+	sender := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	other := common.HexToAddress("0x2222222222222222222222222222222222222222")
+
+	newEnqueue := func(nonce uint64, origin *common.Address, queueIndex uint64) *types.Transaction {
+		tx := types.NewTransaction(nonce, common.Address{0x11}, big.NewInt(0), 1000000, big.NewInt(0), nil)
+		index := queueIndex
+		meta := types.NewTransactionMeta(big.NewInt(1), 0, origin, types.SighashEIP155, types.QueueOriginL1ToL2, nil, &index, nil)
+		tx.SetTransactionMeta(meta)
+		return tx
+	}
+	tx1 := newEnqueue(1, &sender, 0)
+	tx2 := newEnqueue(2, &other, 1)
+	tx3 := newEnqueue(3, &sender, 2)
+	// Sequencer transactions must not be indexed
+	tx4 := types.NewTransaction(4, common.Address{0x11}, big.NewInt(0), 1000000, big.NewInt(0), nil)
+
+	block := types.NewBlock(&types.Header{Number: big.NewInt(314)}, []*types.Transaction{tx1, tx2, tx3, tx4}, nil, nil)
+	WriteEnqueueLookupEntries(db, block)
+
+	for i, tx := range []*types.Transaction{tx1, tx2, tx3} {
+		entry := ReadEnqueueLookupEntry(db, uint64(i))
+		if entry == nil {
+			t.Fatalf("enqueue %d: lookup entry not found", i)
+		}
+		if entry.TxHash != tx.Hash() || entry.BlockNumber != block.NumberU64() {
+			t.Fatalf("enqueue %d: lookup mismatch: have %x/%d, want %x/%d", i, entry.TxHash, entry.BlockNumber, tx.Hash(), block.NumberU64())
+		}
+	}
+	if entry := ReadEnqueueLookupEntry(db, 3); entry != nil {
+		t.Fatalf("unexpected lookup entry: %v", entry)
+	}
+	if indices := ReadEnqueueIndicesBySender(db, sender, 0, 10); !reflect.DeepEqual(indices, []uint64{0, 2}) {
+		t.Fatalf("sender indices mismatch: have %v, want %v", indices, []uint64{0, 2})
+	}
+	if indices := ReadEnqueueIndicesBySender(db, sender, 1, 10); !reflect.DeepEqual(indices, []uint64{2}) {
+		t.Fatalf("sender indices from start mismatch: have %v, want %v", indices, []uint64{2})
+	}
+	if indices := ReadEnqueueIndicesBySender(db, sender, 0, 1); !reflect.DeepEqual(indices, []uint64{0}) {
+		t.Fatalf("limited sender indices mismatch: have %v, want %v", indices, []uint64{0})
+	}
+	if indices := ReadEnqueueIndicesBySender(db, other, 0, 10); !reflect.DeepEqual(indices, []uint64{1}) {
+		t.Fatalf("other sender indices mismatch: have %v, want %v", indices, []uint64{1})
+	}
+	DeleteEnqueueLookupEntry(db, 1)
+	if entry := ReadEnqueueLookupEntry(db, 1); entry != nil {
+		t.Fatalf("deleted lookup entry returned: %v", entry)
+	}
+	DeleteEnqueueSenderEntry(db, other, 1)
+	if indices := ReadEnqueueIndicesBySender(db, other, 0, 10); len(indices) != 0 {
+		t.Fatalf("deleted sender indices returned: %v", indices)
+	}
+	DeleteEnqueueLookupEntries(db, block)
+	for i := uint64(0); i < 3; i++ {
+		if entry := ReadEnqueueLookupEntry(db, i); entry != nil {
+			t.Fatalf("enqueue %d: deleted lookup entry returned: %v", i, entry)
+		}
+	}
+	if indices := ReadEnqueueIndicesBySender(db, sender, 0, 10); len(indices) != 0 {
+		t.Fatalf("deleted sender indices returned: %v", indices)
+	}
+}
@@ -18,6 +18,7 @@
 package rawdb
 
 import (
+	"bytes"
 	"encoding/binary"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -41,6 +42,12 @@ var (
 	// fastTrieProgressKey tracks the number of trie entries imported during fast sync.
 	fastTrieProgressKey = []byte("TrieSync")
 
+	// snapshotRootKey tracks the hash of the last snapshot.
+	snapshotRootKey = []byte("SnapshotRoot")
+
+	// snapshotJournalKey tracks the in-memory diff layers across restarts.
+	snapshotJournalKey = []byte("SnapshotJournal")
+
 	// Data item prefixes (use single byte to avoid mixing data types, avoid `i`, used for indexes).
 	headerPrefix       = []byte("h") // headerPrefix + num (uint64 big endian) + hash -> header
 	headerTDSuffix     = []byte("t") // headerPrefix + num (uint64 big endian) + hash + headerTDSuffix -> td
@@ -53,9 +60,16 @@ var (
 	txLookupPrefix  = []byte("l") // txLookupPrefix + hash -> transaction/receipt lookup metadata
 	bloomBitsPrefix = []byte("B") // bloomBitsPrefix + bit (uint16 big endian) + section (uint64 big endian) + hash -> bloom bits
 
+	SnapshotAccountPrefix = []byte("a") // SnapshotAccountPrefix + account hash -> account trie value
+	SnapshotStoragePrefix = []byte("o") // SnapshotStoragePrefix + account hash + storage hash -> storage trie value
+	CodePrefix            = []byte("c") // CodePrefix + code hash -> account code
+
 	// Optimism specific
 	txMetaPrefix = []byte("x") // txMetaPrefix + hash -> transaction metadata
 
+	enqueueLookupPrefix = []byte("q") // enqueueLookupPrefix + queue index (uint64 big endian) -> enqueue lookup metadata
+	enqueueSenderPrefix = []byte("Q") // enqueueSenderPrefix + l1 tx origin + queue index (uint64 big endian) -> nil
+
 	// headIndexKey tracks the last processed ctc index
 	headIndexKey = []byte("LastIndex")
 	// headQueueIndexKey tracks th last processed queue index
@@ -86,6 +100,9 @@ const (
 
 	// freezerDifficultyTable indicates the name of the freezer total difficulty table.
 	freezerDifficultyTable = "diffs"
+
+	// freezerMetaTable indicates the name of the freezer transaction metadata table.
+	freezerMetaTable = "meta"
 )
 
 // freezerNoSnappy configures whether compression is disabled for the ancient-tables.
@@ -96,6 +113,7 @@ var freezerNoSnappy = map[string]bool{
 	freezerBodiesTable:     false,
 	freezerReceiptTable:    false,
 	freezerDifficultyTable: true,
+	freezerMetaTable:       false,
 }
 
 // LegacyTxLookupEntry is the legacy TxLookupEntry definition with some unnecessary
@@ -158,6 +176,45 @@ func txMetaKey(number uint64) []byte {
 	return append(txMetaPrefix, encodeBlockNumber(number)...)
 }
 
+// enqueueLookupKey = enqueueLookupPrefix + queue index (uint64 big endian)
+func enqueueLookupKey(index uint64) []byte {
+	return append(enqueueLookupPrefix, encodeBlockNumber(index)...)
+}
+
+// enqueueSenderKey = enqueueSenderPrefix + l1 tx origin + queue index (uint64 big endian)
+func enqueueSenderKey(sender common.Address, index uint64) []byte {
+	return append(append(enqueueSenderPrefix, sender.Bytes()...), encodeBlockNumber(index)...)
+}
+
+// accountSnapshotKey = SnapshotAccountPrefix + hash
+func accountSnapshotKey(hash common.Hash) []byte {
+	return append(SnapshotAccountPrefix, hash.Bytes()...)
+}
+
+// storageSnapshotKey = SnapshotStoragePrefix + account hash + storage hash
+func storageSnapshotKey(accountHash, storageHash common.Hash) []byte {
+	return append(append(SnapshotStoragePrefix, accountHash.Bytes()...), storageHash.Bytes()...)
+}
+
+// storageSnapshotsKey = SnapshotStoragePrefix + account hash
+func storageSnapshotsKey(accountHash common.Hash) []byte {
+	return append(SnapshotStoragePrefix, accountHash.Bytes()...)
+}
+
+// codeKey = CodePrefix + hash
+func codeKey(hash common.Hash) []byte {
+	return append(CodePrefix, hash.Bytes()...)
+}
+
+// IsCodeKey reports whether the given byte slice is the key of contract code,
+// if so return the raw code hash as well.
+func IsCodeKey(key []byte) (bool, []byte) {
+	if bytes.HasPrefix(key, CodePrefix) && len(key) == common.HashLength+len(CodePrefix) {
+		return true, key[len(CodePrefix):]
+	}
+	return false, nil
+}
+
 // bloomBitsKey = bloomBitsPrefix + bit (uint16 big endian) + section (uint64 big endian) + hash
 func bloomBitsKey(bit uint, section uint64, hash common.Hash) []byte {
 	key := append(append(bloomBitsPrefix, make([]byte, 10)...), hash.Bytes()...)
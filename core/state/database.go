@@ -20,6 +20,7 @@ import (
 	"fmt"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/trie"
 	lru "github.com/hashicorp/golang-lru"
@@ -139,8 +140,13 @@ func (db *cachingDB) CopyTrie(t Trie) Trie {
 	}
 }
 
-// ContractCode retrieves a particular contract's code.
+// ContractCode retrieves a particular contract's code. Codes written before the
+// introduction of the code prefix are looked up among the trie nodes.
 func (db *cachingDB) ContractCode(addrHash, codeHash common.Hash) ([]byte, error) {
+	if code := rawdb.ReadCodeWithPrefix(db.db.DiskDB(), codeHash); len(code) > 0 {
+		db.codeSizeCache.Add(codeHash, len(code))
+		return code, nil
+	}
 	code, err := db.db.Node(codeHash)
 	if err == nil {
 		db.codeSizeCache.Add(codeHash, len(code))
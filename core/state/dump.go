@@ -17,6 +17,7 @@
 package state
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 
@@ -88,12 +89,66 @@ func (d iterativeDump) onRoot(root common.Hash) {
 	}{root})
 }
 
-func (s *StateDB) dump(c collector, excludeCode, excludeStorage, excludeMissingPreimages bool) {
-	emptyAddress := (common.Address{})
-	missingPreimages := 0
+// DumpConfig configures a (partial) dump of the state.
+type DumpConfig struct {
+	SkipCode          bool
+	SkipStorage       bool
+	OnlyWithAddresses bool
+
+	// Start is the cursor to resume a dump from, as returned in DumpPage.Next.
+	// It consists of the hashed address of the first account to dump, which
+	// may be followed by the hashed key of the first storage slot to dump from
+	// that account.
+	Start []byte
+
+	// Max is the maximum number of accounts and storage slots to dump, zero
+	// meaning no limit.
+	Max uint64
+}
+
+// DumpPage is a single page of a state dump, with the accounts ordered by
+// their hashed addresses.
+type DumpPage struct {
+	Root     common.Hash   `json:"root"`
+	Accounts []DumpAccount `json:"accounts"`
+	Next     hexutil.Bytes `json:"next,omitempty"` // Cursor of the next page, nil if the dump is complete
+}
+
+func (p *DumpPage) onRoot(root common.Hash) {
+	p.Root = root
+}
+
+func (p *DumpPage) onAccount(addr common.Address, account DumpAccount) {
+	if addr != (common.Address{}) {
+		account.Address = &addr
+	}
+	p.Accounts = append(p.Accounts, account)
+}
+
+// dump iterates over the state as configured, feeding the accounts into the
+// collector. If the dump is cut short by the item limit, the cursor to resume
+// from is returned. An account whose storage is split across pages is reported
+// in both of them, each time with its own part of the storage.
+func (s *StateDB) dump(c collector, conf *DumpConfig) []byte {
+	var (
+		emptyAddress     = (common.Address{})
+		missingPreimages = 0
+		paged            = conf.Max > 0 || len(conf.Start) > 0
+		count            = uint64(0)
+
+		accountStart, storageStart []byte
+	)
+	if len(conf.Start) > common.HashLength {
+		accountStart, storageStart = conf.Start[:common.HashLength], conf.Start[common.HashLength:]
+	} else {
+		accountStart = conf.Start
+	}
 	c.onRoot(s.trie.Hash())
-	it := trie.NewIterator(s.trie.NodeIterator(nil))
+	it := trie.NewIterator(s.trie.NodeIterator(accountStart))
 	for it.Next() {
+		if conf.Max > 0 && count >= conf.Max {
+			return common.CopyBytes(it.Key)
+		}
 		var data Account
 		if err := rlp.DecodeBytes(it.Value, &data); err != nil {
 			panic(err)
@@ -109,24 +164,42 @@ func (s *StateDB) dump(c collector, excludeCode, excludeStorage, excludeMissingP
 		if emptyAddress == addr {
 			// Preimage missing
 			missingPreimages++
-			if excludeMissingPreimages {
+			if conf.OnlyWithAddresses {
 				continue
 			}
 			account.SecureKey = it.Key
 		}
-		if !excludeCode {
+		if paged {
+			account.SecureKey = it.Key
+		}
+		// Resumed accounts don't count against the limit, ensuring every page
+		// makes progress through the storage
+		resumed := storageStart != nil && bytes.Equal(it.Key, accountStart)
+		if !resumed {
+			count++
+		}
+		if !conf.SkipCode {
 			account.Code = common.Bytes2Hex(obj.Code(s.db))
 		}
-		if !excludeStorage {
+		if !conf.SkipStorage {
+			var start []byte
+			if resumed {
+				start = storageStart
+			}
 			account.Storage = make(map[common.Hash]string)
-			storageIt := trie.NewIterator(obj.getTrie(s.db).NodeIterator(nil))
+			storageIt := trie.NewIterator(obj.getTrie(s.db).NodeIterator(start))
 			for storageIt.Next() {
+				if conf.Max > 0 && count >= conf.Max {
+					c.onAccount(addr, account)
+					return append(common.CopyBytes(it.Key), storageIt.Key...)
+				}
 				_, content, _, err := rlp.Split(storageIt.Value)
 				if err != nil {
 					log.Error("Failed to decode the value returned by iterator", "error", err)
 					continue
 				}
 				account.Storage[common.BytesToHash(s.trie.GetKey(storageIt.Key))] = common.Bytes2Hex(content)
+				count++
 			}
 		}
 		c.onAccount(addr, account)
@@ -134,6 +207,7 @@ func (s *StateDB) dump(c collector, excludeCode, excludeStorage, excludeMissingP
 	if missingPreimages > 0 {
 		log.Warn("Dump incomplete due to missing preimages", "missing", missingPreimages)
 	}
+	return nil
 }
 
 // RawDump returns the entire state an a single large object
@@ -141,7 +215,11 @@ func (s *StateDB) RawDump(excludeCode, excludeStorage, excludeMissingPreimages b
 	dump := &Dump{
 		Accounts: make(map[common.Address]DumpAccount),
 	}
-	s.dump(dump, excludeCode, excludeStorage, excludeMissingPreimages)
+	s.dump(dump, &DumpConfig{
+		SkipCode:          excludeCode,
+		SkipStorage:       excludeStorage,
+		OnlyWithAddresses: excludeMissingPreimages,
+	})
 	return *dump
 }
 
@@ -157,5 +235,26 @@ func (s *StateDB) Dump(excludeCode, excludeStorage, excludeMissingPreimages bool
 
 // IterativeDump dumps out accounts as json-objects, delimited by linebreaks on stdout
 func (s *StateDB) IterativeDump(excludeCode, excludeStorage, excludeMissingPreimages bool, output *json.Encoder) {
-	s.dump(iterativeDump{output}, excludeCode, excludeStorage, excludeMissingPreimages)
+	s.dump(iterativeDump{output}, &DumpConfig{
+		SkipCode:          excludeCode,
+		SkipStorage:       excludeStorage,
+		OnlyWithAddresses: excludeMissingPreimages,
+	})
+}
+
+// PagedDump returns a single page of the state as configured, along with the
+// cursor of the next page.
+func (s *StateDB) PagedDump(conf *DumpConfig) DumpPage {
+	page := &DumpPage{
+		Accounts: []DumpAccount{},
+	}
+	page.Next = s.dump(page, conf)
+	return *page
+}
+
+// IterativeDumpRange dumps out the accounts selected by the configuration as
+// json-objects delimited by linebreaks, returning the cursor to resume from if
+// the dump was cut short.
+func (s *StateDB) IterativeDumpRange(conf *DumpConfig, output *json.Encoder) []byte {
+	return s.dump(iterativeDump{output}, conf)
 }
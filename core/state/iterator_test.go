@@ -21,6 +21,7 @@ import (
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/ethdb"
 )
 
@@ -29,7 +30,7 @@ func TestNodeIteratorCoverage(t *testing.T) {
 	// Create some arbitrary test state to iterate
 	db, root, _ := makeTestState()
 
-	state, err := New(root, db)
+	state, err := New(root, db, nil)
 	if err != nil {
 		t.Fatalf("failed to create state trie at %x: %v", root, err)
 	}
@@ -43,7 +44,9 @@ func TestNodeIteratorCoverage(t *testing.T) {
 	// Cross check the iterated hashes and the database/nodepool content
 	for hash := range hashes {
 		if _, err := db.TrieDB().Node(hash); err != nil {
-			t.Errorf("failed to retrieve reported node %x", hash)
+			if _, err := db.ContractCode(common.Hash{}, hash); err != nil {
+				t.Errorf("failed to retrieve reported node %x", hash)
+			}
 		}
 	}
 	for _, hash := range db.TrieDB().Nodes() {
@@ -57,6 +60,9 @@ func TestNodeIteratorCoverage(t *testing.T) {
 		if bytes.HasPrefix(key, []byte("secure-key-")) {
 			continue
 		}
+		if ok, hash := rawdb.IsCodeKey(key); ok {
+			key = hash
+		}
 		if _, ok := hashes[common.BytesToHash(key)]; !ok {
 			t.Errorf("state entry not reported %x", key)
 		}
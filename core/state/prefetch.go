@@ -0,0 +1,216 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// prefetchResult is an account along with a set of its storage slots, loaded
+// from the database by a prefetch worker.
+type prefetchResult struct {
+	addr  common.Address
+	data  *Account                    // Account data, nil if the account doesn't exist
+	trie  Trie                        // Storage trie opened to load the slots, if any
+	slots map[common.Hash]common.Hash // Committed values of the requested slots
+	err   error
+}
+
+// Prefetch loads the accounts and storage slots of the given access list into
+// the state using the given number of concurrent workers, so that retrieving
+// them from disk does not stall the subsequent transaction execution.
+//
+// Accounts already live in the state are left untouched and accounts missing
+// from the state are not created. Prefetch must not be called concurrently with
+// any other state operation.
+func (s *StateDB) Prefetch(list types.AccessList, threads int) {
+	// Group the requested slots by account, skipping anything already loaded
+	tasks := make(map[common.Address][]common.Hash)
+	for _, tuple := range list {
+		if _, live := s.stateObjects[tuple.Address]; live {
+			continue
+		}
+		tasks[tuple.Address] = append(tasks[tuple.Address], tuple.StorageKeys...)
+	}
+	if len(tasks) == 0 {
+		return
+	}
+	if threads > len(tasks) {
+		threads = len(tasks)
+	}
+	if threads < 1 {
+		threads = 1
+	}
+	// Load the accounts concurrently, each worker using a private copy of the
+	// account trie since trie reads are not thread safe
+	var (
+		jobs    = make(chan common.Address, len(tasks))
+		results = make(chan *prefetchResult, len(tasks))
+	)
+	for addr := range tasks {
+		jobs <- addr
+	}
+	close(jobs)
+
+	for i := 0; i < threads; i++ {
+		go func() {
+			var tr Trie
+			for addr := range jobs {
+				results <- s.prefetchAccount(&tr, addr, tasks[addr])
+			}
+		}()
+	}
+	// Insert the loaded accounts and slots into the live set. Failures are not
+	// fatal, execution will retry any data it actually needs.
+	for range tasks {
+		res := <-results
+		if res.err != nil {
+			log.Debug("Failed to prefetch account", "addr", res.addr, "err", res.err)
+			continue
+		}
+		if res.data == nil {
+			continue
+		}
+		obj := newObject(s, res.addr, *res.data)
+		obj.trie = res.trie
+		for key, value := range res.slots {
+			obj.originStorage[key] = value
+		}
+		s.setStateObject(obj)
+	}
+}
+
+// AccessedState returns the accounts and storage slots loaded or modified in
+// the state so far, sorted by address and slot. It can be fed back into Prefetch
+// to warm up a later state that is expected to touch the same data.
+func (s *StateDB) AccessedState() types.AccessList {
+	list := make(types.AccessList, 0, len(s.stateObjects))
+	for addr, obj := range s.stateObjects {
+		tuple := types.AccessTuple{Address: addr, StorageKeys: []common.Hash{}}
+		for _, storage := range []Storage{obj.originStorage, obj.pendingStorage, obj.dirtyStorage} {
+			for key := range storage {
+				tuple.StorageKeys = append(tuple.StorageKeys, key)
+			}
+		}
+		sort.Slice(tuple.StorageKeys, func(i, j int) bool {
+			return bytes.Compare(tuple.StorageKeys[i][:], tuple.StorageKeys[j][:]) < 0
+		})
+		// Slots may be both loaded and modified, drop the duplicates
+		keys := tuple.StorageKeys[:0]
+		for i, key := range tuple.StorageKeys {
+			if i == 0 || key != tuple.StorageKeys[i-1] {
+				keys = append(keys, key)
+			}
+		}
+		tuple.StorageKeys = keys
+		list = append(list, tuple)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return bytes.Compare(list[i].Address[:], list[j].Address[:]) < 0
+	})
+	return list
+}
+
+// prefetchAccount loads an account and the requested storage slots, preferring
+// the snapshot if available. The account trie is copied into tr on first use.
+func (s *StateDB) prefetchAccount(tr *Trie, addr common.Address, keys []common.Hash) *prefetchResult {
+	var (
+		res      = &prefetchResult{addr: addr}
+		data     Account
+		addrHash = crypto.Keccak256Hash(addr[:])
+		loaded   bool
+	)
+	if s.snap != nil {
+		if acc, err := s.snap.Account(addrHash); err == nil {
+			if acc == nil {
+				return res
+			}
+			data.Nonce, data.Balance, data.CodeHash = acc.Nonce, acc.Balance, acc.CodeHash
+			if len(data.CodeHash) == 0 {
+				data.CodeHash = emptyCodeHash
+			}
+			data.Root = common.BytesToHash(acc.Root)
+			if data.Root == (common.Hash{}) {
+				data.Root = emptyRoot
+			}
+			loaded = true
+		}
+	}
+	if !loaded {
+		if *tr == nil {
+			*tr = s.db.CopyTrie(s.trie)
+		}
+		enc, err := (*tr).TryGet(addr[:])
+		if err != nil {
+			res.err = err
+			return res
+		}
+		if len(enc) == 0 {
+			return res
+		}
+		if err := rlp.DecodeBytes(enc, &data); err != nil {
+			res.err = err
+			return res
+		}
+	}
+	res.data = &data
+
+	// Account loaded, retrieve the requested storage slots
+	res.slots = make(map[common.Hash]common.Hash, len(keys))
+	for _, key := range keys {
+		if _, done := res.slots[key]; done {
+			continue
+		}
+		var (
+			enc []byte
+			err error
+		)
+		if s.snap != nil {
+			enc, err = s.snap.Storage(addrHash, crypto.Keccak256Hash(key[:]))
+		}
+		if s.snap == nil || err != nil {
+			if res.trie == nil {
+				if res.trie, err = s.db.OpenStorageTrie(addrHash, data.Root); err != nil {
+					res.err = err
+					return res
+				}
+			}
+			if enc, err = res.trie.TryGet(key[:]); err != nil {
+				res.err = err
+				return res
+			}
+		}
+		var value common.Hash
+		if len(enc) > 0 {
+			_, content, _, err := rlp.Split(enc)
+			if err != nil {
+				res.err = err
+				return res
+			}
+			value.SetBytes(content)
+		}
+		res.slots[key] = value
+	}
+	return res
+}
@@ -0,0 +1,458 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package pruner implements offline pruning of the stale state trie nodes.
+package pruner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+const (
+	// stateBloomFilePrefix is the filename prefix of state bloom filter.
+	stateBloomFilePrefix = "statebloom"
+
+	// stateBloomFileSuffix is the filename suffix of state bloom filter.
+	stateBloomFileSuffix = "bf.gz"
+
+	// stateBloomFileTempSuffix is the filename suffix of state bloom filter
+	// while it is being written out to detect write aborts.
+	stateBloomFileTempSuffix = ".tmp"
+)
+
+var (
+	// emptyRoot is the known root hash of an empty trie.
+	emptyRoot = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+
+	// emptyCode is the known hash of the empty EVM bytecode.
+	emptyCode = crypto.Keccak256Hash(nil)
+)
+
+// Pruner is an offline tool to prune the stale state trie nodes from the key-value
+// store. It marks every trie node and contract code reachable from the head state
+// and a number of recent states in a bloom filter, then sweeps the database and
+// deletes all the trie nodes which are not marked.
+//
+// Contract codes and preimages live under their own prefixes and are never
+// deleted. Codes written before the introduction of the code prefix are stored
+// under their bare hash just like trie nodes: the ones of the retained accounts
+// are moved under the code prefix while marking, so the sweep can't lose them.
+//
+// The pruning is resumable. Once the marking is done, the bloom filter is stored
+// in the data directory, and if the sweep is interrupted it is picked up again
+// by the next prune run or node startup (see RecoverPruning).
+type Pruner struct {
+	db      ethdb.Database
+	datadir string
+	recent  uint64
+	bloom   *stateBloom
+}
+
+// NewPruner creates a pruner instance retaining the head state plus the given
+// number of recent states, using a bloom filter of bloomSize megabytes.
+func NewPruner(db ethdb.Database, datadir string, recent uint64, bloomSize uint64) (*Pruner, error) {
+	if rawdb.ReadHeadBlockHash(db) == (common.Hash{}) {
+		return nil, errors.New("failed to load head block")
+	}
+	// Sanitize the bloom filter size if it's too small
+	if bloomSize < 256 {
+		log.Warn("Sanitizing bloomfilter size", "provided(MB)", bloomSize, "updated(MB)", 256)
+		bloomSize = 256
+	}
+	bloom, err := newStateBloomWithSize(bloomSize)
+	if err != nil {
+		return nil, err
+	}
+	return &Pruner{
+		db:      db,
+		datadir: datadir,
+		recent:  recent,
+		bloom:   bloom,
+	}, nil
+}
+
+// Prune marks the retained states and deletes every other trie node from the
+// database. If a previous pruning was interrupted during its sweep, that one is
+// finished instead.
+func (p *Pruner) Prune() error {
+	// If a previous sweep was interrupted, resume it. The bloom filter of that
+	// run is authoritative, since the head state may have moved since.
+	bloomPath, err := findBloomFilter(p.datadir)
+	if err != nil {
+		return err
+	}
+	if bloomPath != "" {
+		log.Info("Resuming interrupted state pruning", "bloom", bloomPath)
+		return RecoverPruning(p.datadir, p.db)
+	}
+	head := rawdb.ReadHeadBlockHash(p.db)
+	number := rawdb.ReadHeaderNumber(p.db, head)
+	if number == nil {
+		return fmt.Errorf("head block %x missing", head)
+	}
+	header := rawdb.ReadHeader(p.db, head, *number)
+	if header == nil {
+		return fmt.Errorf("head header %x missing", head)
+	}
+	// The head state must be complete, otherwise the node would rewind below it
+	// after restart and find nothing there either.
+	if !hasTrieNode(p.db, header.Root) {
+		return fmt.Errorf("head state %x missing, restart the node to repair it before pruning", header.Root)
+	}
+	start := time.Now()
+
+	// Mark the head state fully, then only the differences of every additional
+	// retained state against the last marked one.
+	triedb := trie.NewDatabase(p.db)
+	if err := p.markState(triedb, header.Root, common.Hash{}); err != nil {
+		return err
+	}
+	marked := header.Root
+	for i := uint64(1); i <= p.recent && i <= *number; i++ {
+		hash := rawdb.ReadCanonicalHash(p.db, *number-i)
+		if hash == (common.Hash{}) {
+			break
+		}
+		recent := rawdb.ReadHeader(p.db, hash, *number-i)
+		if recent == nil || !hasTrieNode(p.db, recent.Root) {
+			continue
+		}
+		if err := p.markState(triedb, recent.Root, marked); err != nil {
+			return err
+		}
+		marked = recent.Root
+	}
+	// Retain the genesis state and the base of the state snapshot too, they are
+	// needed on rewinds and to resume snapshot generation.
+	extras := []common.Hash{rawdb.ReadSnapshotRoot(p.db)}
+	if genesis := rawdb.ReadCanonicalHash(p.db, 0); genesis != (common.Hash{}) {
+		if header := rawdb.ReadHeader(p.db, genesis, 0); header != nil {
+			extras = append(extras, header.Root)
+		}
+	}
+	for _, root := range extras {
+		if root == (common.Hash{}) || !hasTrieNode(p.db, root) {
+			continue
+		}
+		if err := p.markState(triedb, root, header.Root); err != nil {
+			return err
+		}
+	}
+	log.Info("Marked retained state", "head", header.Number, "root", header.Root, "elapsed", common.PrettyDuration(time.Since(start)))
+
+	// Persist the bloom filter to make the sweep resumable
+	filterName := bloomFilterName(p.datadir, header.Root)
+	if err := p.bloom.Commit(filterName, filterName+stateBloomFileTempSuffix); err != nil {
+		return err
+	}
+	return prune(p.db, p.bloom, filterName, start)
+}
+
+// markState adds all the trie nodes and contract codes of the state with the
+// given root into the bloom filter. If a previously marked state is given, only
+// the nodes not shared with it are visited.
+func (p *Pruner) markState(triedb *trie.Database, root common.Hash, marked common.Hash) error {
+	tr, err := trie.New(root, triedb)
+	if err != nil {
+		return err
+	}
+	var (
+		prev *trie.Trie
+		it   = tr.NodeIterator(nil)
+	)
+	if marked != (common.Hash{}) {
+		if prev, err = trie.New(marked, triedb); err != nil {
+			return err
+		}
+		it, _ = trie.NewDifferenceIterator(prev.NodeIterator(nil), it)
+	}
+	var (
+		nodes    int
+		accounts int
+		logged   = time.Now()
+	)
+	for it.Next(true) {
+		if hash := it.Hash(); hash != (common.Hash{}) {
+			p.bloom.Put(hash)
+			nodes++
+		}
+		if !it.Leaf() {
+			continue
+		}
+		var acc state.Account
+		if err := rlp.DecodeBytes(it.LeafBlob(), &acc); err != nil {
+			return err
+		}
+		accounts++
+
+		if !bytes.Equal(acc.CodeHash, emptyCode[:]) {
+			code := common.BytesToHash(acc.CodeHash)
+			if err := p.migrateCode(code); err != nil {
+				return err
+			}
+			p.bloom.Put(code)
+		}
+		if acc.Root == emptyRoot {
+			continue
+		}
+		// Look up the storage root of the same account in the marked state, so
+		// only the modified storage nodes need visiting
+		var prevStorage common.Hash
+		if prev != nil {
+			if blob, err := prev.TryGet(it.LeafKey()); err == nil && len(blob) > 0 {
+				var prevAcc state.Account
+				if err := rlp.DecodeBytes(blob, &prevAcc); err == nil && prevAcc.Root != emptyRoot {
+					prevStorage = prevAcc.Root
+				}
+			}
+		}
+		n, err := p.markStorage(triedb, acc.Root, prevStorage)
+		if err != nil {
+			return err
+		}
+		nodes += n
+
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Marking retained state", "root", root, "accounts", accounts, "nodes", nodes)
+			logged = time.Now()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	log.Info("Marked state trie", "root", root, "accounts", accounts, "nodes", nodes)
+	return nil
+}
+
+// migrateCode moves a contract code stored under its bare hash, as written
+// before the introduction of the code prefix, under the code prefix.
+func (p *Pruner) migrateCode(hash common.Hash) error {
+	if rawdb.HasCodeWithPrefix(p.db, hash) {
+		return nil
+	}
+	code, err := p.db.Get(hash[:])
+	if err != nil || len(code) == 0 {
+		return fmt.Errorf("contract code %x missing", hash)
+	}
+	rawdb.WriteCode(p.db, hash, code)
+	return nil
+}
+
+// markStorage adds all the nodes of a storage trie into the bloom filter, only
+// visiting the ones not shared with the previously marked storage trie if any.
+func (p *Pruner) markStorage(triedb *trie.Database, root common.Hash, marked common.Hash) (int, error) {
+	tr, err := trie.New(root, triedb)
+	if err != nil {
+		return 0, err
+	}
+	it := tr.NodeIterator(nil)
+	if marked != (common.Hash{}) {
+		prev, err := trie.New(marked, triedb)
+		if err != nil {
+			return 0, err
+		}
+		it, _ = trie.NewDifferenceIterator(prev.NodeIterator(nil), it)
+	}
+	var nodes int
+	for it.Next(true) {
+		if hash := it.Hash(); hash != (common.Hash{}) {
+			p.bloom.Put(hash)
+			nodes++
+		}
+	}
+	return nodes, it.Error()
+}
+
+// prune deletes every trie node not contained in the bloom filter, compacts
+// the database and removes the bloom filter file to mark the pruning done.
+// Contract codes are never deleted.
+func prune(db ethdb.Database, bloom *stateBloom, bloomPath string, start time.Time) error {
+	var (
+		count  int
+		size   common.StorageSize
+		pstart = time.Now()
+		logged = time.Now()
+		batch  = db.NewBatch()
+		iter   = db.NewIterator()
+	)
+	for iter.Next() {
+		key := iter.Key()
+
+		// Contract codes live under their own prefix and are always kept
+		if ok, _ := rawdb.IsCodeKey(key); ok {
+			continue
+		}
+		// Only the 32 byte hash keys are trie nodes (or legacy codes), everything
+		// else is chain data, preimages or metadata which must be kept.
+		if len(key) != common.HashLength || bloom.Contain(key) {
+			continue
+		}
+		// Trie nodes are always a single RLP list, skip anything else as it can
+		// only be a legacy contract code.
+		if !isTrieNode(iter.Value()) {
+			continue
+		}
+		count++
+		size += common.StorageSize(len(key) + len(iter.Value()))
+		batch.Delete(key)
+
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			// Flush the deletions and recreate the iterator to release the
+			// underlying database snapshot
+			iter.Release()
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+			iter = db.NewIteratorWithStart(key)
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Pruning state data", "nodes", count, "size", size, "elapsed", common.PrettyDuration(time.Since(pstart)))
+			logged = time.Now()
+		}
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	if batch.ValueSize() > 0 {
+		if err := batch.Write(); err != nil {
+			return err
+		}
+	}
+	log.Info("Pruned state data", "nodes", count, "size", size, "elapsed", common.PrettyDuration(time.Since(pstart)))
+
+	// Compact the entire database to reclaim the space of the deleted nodes
+	cstart := time.Now()
+	for b := 0x00; b <= 0xf0; b += 0x10 {
+		var (
+			start = []byte{byte(b)}
+			end   = []byte{byte(b + 0x10)}
+		)
+		if b == 0xf0 {
+			end = nil
+		}
+		log.Info("Compacting database", "range", fmt.Sprintf("%#x-%#x", start, end), "elapsed", common.PrettyDuration(time.Since(cstart)))
+		if err := db.Compact(start, end); err != nil {
+			log.Error("Database compaction failed", "error", err)
+			return err
+		}
+	}
+	log.Info("Database compaction finished", "elapsed", common.PrettyDuration(time.Since(cstart)))
+
+	// Pruning is done, delete the bloom filter so it's not resumed again
+	if err := os.RemoveAll(bloomPath); err != nil {
+		return err
+	}
+	log.Info("State pruning successful", "pruned", size, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// RecoverPruning resumes a pruning which was interrupted during its sweep. It
+// must be called before the node writes any new state, since those nodes would
+// not be in the bloom filter. It's a noop if no interrupted pruning is found.
+func RecoverPruning(datadir string, db ethdb.Database) error {
+	if datadir == "" {
+		return nil // ephemeral node, nothing could have been persisted
+	}
+	bloomPath, err := findBloomFilter(datadir)
+	if err != nil {
+		return err
+	}
+	if bloomPath == "" {
+		return nil // nothing to recover
+	}
+	bloom, err := newStateBloomFromDisk(bloomPath)
+	if err != nil {
+		return err
+	}
+	log.Info("Loaded state bloom filter", "path", bloomPath)
+	return prune(db, bloom, bloomPath, time.Now())
+}
+
+// findBloomFilter returns the path of a fully committed state bloom filter in
+// the data directory, removing any left over partial one.
+func findBloomFilter(datadir string) (string, error) {
+	var path string
+	err := filepath.Walk(datadir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if file != datadir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		name := filepath.Base(file)
+		if !strings.HasPrefix(name, stateBloomFilePrefix) {
+			return nil
+		}
+		if strings.HasSuffix(name, stateBloomFileTempSuffix) {
+			// Marking was interrupted before the filter was committed, the sweep
+			// never started so the partial filter can be dropped.
+			log.Info("Removing partial state bloom filter", "path", file)
+			return os.Remove(file)
+		}
+		if strings.HasSuffix(name, stateBloomFileSuffix) {
+			path = file
+		}
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	return path, err
+}
+
+// bloomFilterName returns the file name of the bloom filter marking the state
+// with the given head root.
+func bloomFilterName(datadir string, hash common.Hash) string {
+	return filepath.Join(datadir, fmt.Sprintf("%s.%s.%s", stateBloomFilePrefix, hash.Hex(), stateBloomFileSuffix))
+}
+
+// hasTrieNode reports whether the trie node with the given hash is present in
+// the database.
+func hasTrieNode(db ethdb.KeyValueReader, hash common.Hash) bool {
+	if hash == emptyRoot {
+		return true
+	}
+	ok, _ := db.Has(hash[:])
+	return ok
+}
+
+// isTrieNode reports whether the blob is a single RLP list. All trie nodes are
+// encoded as such, but so may be a legacy contract code stored under its bare
+// hash, so a positive result doesn't prove the blob is a trie node.
+func isTrieNode(blob []byte) bool {
+	kind, _, rest, err := rlp.Split(blob)
+	return err == nil && kind == rlp.List && len(rest) == 0
+}
@@ -0,0 +1,228 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pruner
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+var (
+	testAccount  = common.HexToAddress("0x01")
+	testContract = common.HexToAddress("0x02")
+	testCode     = []byte{0x60, 0x00, 0x60, 0x00, 0xf3}
+)
+
+// makeTestChain writes a chain of headers into the database, each block with
+// its own fully persisted state, and returns the state roots.
+func makeTestChain(t *testing.T, db ethdb.Database, blocks int) []common.Hash {
+	var (
+		sdb    = state.NewDatabase(db)
+		parent = common.Hash{}
+		roots  []common.Hash
+	)
+	for i := 0; i < blocks; i++ {
+		statedb, _ := state.New(parent, sdb, nil)
+		if i == 0 {
+			statedb.SetCode(testContract, testCode)
+		}
+		statedb.SetBalance(testAccount, big.NewInt(int64(i+1)))
+		statedb.SetState(testContract, common.Hash{byte(i)}, common.Hash{byte(i + 1)})
+
+		root, err := statedb.Commit(false)
+		if err != nil {
+			t.Fatalf("block %d: failed to commit state: %v", i, err)
+		}
+		if err := sdb.TrieDB().Commit(root, false); err != nil {
+			t.Fatalf("block %d: failed to flush state: %v", i, err)
+		}
+		header := &types.Header{Number: big.NewInt(int64(i)), Root: root, Difficulty: common.Big1}
+		rawdb.WriteHeader(db, header)
+		rawdb.WriteCanonicalHash(db, header.Hash(), header.Number.Uint64())
+		rawdb.WriteHeadBlockHash(db, header.Hash())
+
+		roots = append(roots, root)
+		parent = root
+	}
+	return roots
+}
+
+// checkState verifies that the entire state with the given root is present.
+func checkState(t *testing.T, db ethdb.Database, root common.Hash) {
+	t.Helper()
+
+	tr, err := trie.New(root, trie.NewDatabase(db))
+	if err != nil {
+		t.Fatalf("state %x: missing root: %v", root, err)
+	}
+	it := tr.NodeIterator(nil)
+	for it.Next(true) {
+	}
+	if err := it.Error(); err != nil {
+		t.Fatalf("state %x: incomplete: %v", root, err)
+	}
+	statedb, err := state.New(root, state.NewDatabase(db), nil)
+	if err != nil {
+		t.Fatalf("state %x: failed to open: %v", root, err)
+	}
+	if val := statedb.GetState(testContract, common.Hash{0}); val != (common.Hash{1}) {
+		t.Errorf("state %x: storage mismatch: have %x, want %x", root, val, common.Hash{1})
+	}
+	if code := statedb.GetCode(testContract); string(code) != string(testCode) {
+		t.Errorf("state %x: code mismatch: have %x, want %x", root, code, testCode)
+	}
+}
+
+// newTestPruner creates a pruner with a small bloom filter, bypassing the
+// size sanitization of the public constructor.
+func newTestPruner(t *testing.T, db ethdb.Database, datadir string, recent uint64) *Pruner {
+	bloom, err := newStateBloomWithSize(4)
+	if err != nil {
+		t.Fatalf("failed to create bloom filter: %v", err)
+	}
+	return &Pruner{db: db, datadir: datadir, recent: recent, bloom: bloom}
+}
+
+// Tests that pruning retains the head, the recent and the genesis states along
+// with codes and preimages, and deletes everything else.
+func TestPruneState(t *testing.T) {
+	datadir, err := ioutil.TempDir("", "pruner-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(datadir)
+
+	db := rawdb.NewMemoryDatabase()
+	roots := makeTestChain(t, db, 5)
+
+	// Inject an unreferenced code blob and a preimage which must both survive
+	orphan := []byte{0x60, 0x01, 0x60, 0x01, 0x01}
+	db.Put(crypto.Keccak256(orphan), orphan)
+
+	// Inject an unreferenced code under the code prefix which parses as a trie
+	// node, it must survive too
+	listCode := []byte{0xc2, 0x60, 0x01}
+	rawdb.WriteCode(db, crypto.Keccak256Hash(listCode), listCode)
+	rawdb.WritePreimages(db, map[common.Hash][]byte{crypto.Keccak256Hash(testAccount[:]): testAccount[:]})
+
+	if err := newTestPruner(t, db, datadir, 1).Prune(); err != nil {
+		t.Fatalf("failed to prune state: %v", err)
+	}
+	for _, i := range []int{0, 3, 4} {
+		checkState(t, db, roots[i])
+	}
+	for _, i := range []int{1, 2} {
+		if ok, _ := db.Has(roots[i][:]); ok {
+			t.Errorf("state %d: root %x not pruned", i, roots[i])
+		}
+	}
+	if blob, _ := db.Get(crypto.Keccak256(orphan)); string(blob) != string(orphan) {
+		t.Errorf("unreferenced code pruned")
+	}
+	if code := rawdb.ReadCodeWithPrefix(db, crypto.Keccak256Hash(listCode)); string(code) != string(listCode) {
+		t.Errorf("unreferenced code shaped like a trie node pruned")
+	}
+	if preimage := rawdb.ReadPreimage(db, crypto.Keccak256Hash(testAccount[:])); len(preimage) == 0 {
+		t.Errorf("preimage pruned")
+	}
+	if path, _ := findBloomFilter(datadir); path != "" {
+		t.Errorf("bloom filter left behind after pruning: %s", path)
+	}
+}
+
+// Tests that the codes of retained accounts stored under their bare hash, as
+// written before the introduction of the code prefix, are moved under it.
+func TestPruneLegacyCode(t *testing.T) {
+	datadir, err := ioutil.TempDir("", "pruner-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(datadir)
+
+	db := rawdb.NewMemoryDatabase()
+	roots := makeTestChain(t, db, 3)
+
+	hash := crypto.Keccak256Hash(testCode)
+	db.Delete(append(rawdb.CodePrefix, hash[:]...))
+	db.Put(hash[:], testCode)
+
+	if err := newTestPruner(t, db, datadir, 0).Prune(); err != nil {
+		t.Fatalf("failed to prune state: %v", err)
+	}
+	checkState(t, db, roots[2])
+	if code := rawdb.ReadCodeWithPrefix(db, hash); string(code) != string(testCode) {
+		t.Errorf("legacy code not migrated: have %x, want %x", code, testCode)
+	}
+}
+
+// Tests that an interrupted sweep is resumed with the bloom filter of the
+// original run, rather than marking the states again.
+func TestPruneStateResume(t *testing.T) {
+	datadir, err := ioutil.TempDir("", "pruner-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(datadir)
+
+	db := rawdb.NewMemoryDatabase()
+	roots := makeTestChain(t, db, 3)
+
+	// Simulate a pruning which marked only the head state, and crashed after
+	// committing its bloom filter
+	pruner := newTestPruner(t, db, datadir, 0)
+	if err := pruner.markState(trie.NewDatabase(db), roots[2], common.Hash{}); err != nil {
+		t.Fatalf("failed to mark state: %v", err)
+	}
+	name := bloomFilterName(datadir, roots[2])
+	if err := pruner.bloom.Commit(name, name+stateBloomFileTempSuffix); err != nil {
+		t.Fatalf("failed to commit bloom filter: %v", err)
+	}
+	// A partially written filter must be discarded
+	if err := ioutil.WriteFile(name+stateBloomFileTempSuffix, []byte{0x00}, 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Running a new pruning which would retain everything must resume the old one
+	if err := newTestPruner(t, db, datadir, 2).Prune(); err != nil {
+		t.Fatalf("failed to resume pruning: %v", err)
+	}
+	checkState(t, db, roots[2])
+	for _, i := range []int{0, 1} {
+		if ok, _ := db.Has(roots[i][:]); ok {
+			t.Errorf("state %d: root %x not pruned", i, roots[i])
+		}
+	}
+	if _, err := os.Stat(name + stateBloomFileTempSuffix); !os.IsNotExist(err) {
+		t.Errorf("partial bloom filter left behind: %v", err)
+	}
+	if path, _ := findBloomFilter(datadir); path != "" {
+		t.Errorf("bloom filter left behind after pruning: %s", path)
+	}
+	// With nothing left to resume, recovery must be a noop
+	if err := RecoverPruning(datadir, db); err != nil {
+		t.Fatalf("failed to run noop recovery: %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// testAccount is the full consensus account format stored in the state trie.
+type testAccount struct {
+	Nonce    uint64
+	Balance  *big.Int
+	Root     common.Hash
+	CodeHash []byte
+}
+
+// makeTestState creates a small state trie with a few accounts, one of which
+// has storage, and flushes it into the given database.
+func makeTestState(t *testing.T, db ethdb.Database) (common.Hash, *trie.Database) {
+	triedb := trie.NewDatabase(db)
+
+	storage, _ := trie.NewSecure(common.Hash{}, triedb)
+	storage.Update([]byte("key-1"), []byte("val-1"))
+	storage.Update([]byte("key-2"), []byte("val-2"))
+	storageRoot, err := storage.Commit(nil)
+	if err != nil {
+		t.Fatalf("failed to commit storage trie: %v", err)
+	}
+	accounts, _ := trie.NewSecure(common.Hash{}, triedb)
+	for i := byte(0); i < 8; i++ {
+		acc := testAccount{Nonce: uint64(i), Balance: big.NewInt(int64(i)), Root: emptyRoot, CodeHash: emptyCode[:]}
+		if i == 0 {
+			acc.Root = storageRoot
+		}
+		blob, _ := rlp.EncodeToBytes(acc)
+		accounts.Update([]byte{i}, blob)
+	}
+	root, err := accounts.Commit(nil)
+	if err != nil {
+		t.Fatalf("failed to commit account trie: %v", err)
+	}
+	if err := triedb.Commit(root, false); err != nil {
+		t.Fatalf("failed to flush tries: %v", err)
+	}
+	return root, triedb
+}
+
+// Tests that a snapshot generated from a state trie contains all the accounts
+// and storage slots in the slim format.
+func TestGenerateFromTrie(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	root, triedb := makeTestState(t, db)
+
+	snaps := New(db, triedb, 16, root, false)
+	snap := snaps.Snapshot(root)
+	if snap == nil {
+		t.Fatalf("snapshot missing for root %x", root)
+	}
+	for i := byte(0); i < 8; i++ {
+		acc, err := snap.Account(crypto.Keccak256Hash([]byte{i}))
+		if err != nil {
+			t.Fatalf("account %d: failed to retrieve: %v", i, err)
+		}
+		if acc == nil || acc.Nonce != uint64(i) || acc.Balance.Cmp(big.NewInt(int64(i))) != 0 {
+			t.Fatalf("account %d: mismatch: %+v", i, acc)
+		}
+		if i != 0 && len(acc.Root) != 0 {
+			t.Errorf("account %d: empty root not slimmed: %x", i, acc.Root)
+		}
+	}
+	blob, err := snap.Storage(crypto.Keccak256Hash([]byte{0}), crypto.Keccak256Hash([]byte("key-1")))
+	if err != nil || !bytes.Equal(blob, []byte("val-1")) {
+		t.Errorf("storage slot mismatch: have %q, want %q (err: %v)", blob, "val-1", err)
+	}
+	if have := rawdb.ReadSnapshotRoot(db); have != root {
+		t.Errorf("persisted snapshot root mismatch: have %x, want %x", have, root)
+	}
+}
+
+// Tests that the in-memory diff layers survive a journal and reload cycle, and
+// that a journal for a different head is rejected and regenerated.
+func TestJournalReload(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	root, triedb := makeTestState(t, db)
+
+	snaps := New(db, triedb, 16, root, false)
+
+	var (
+		head    = common.HexToHash("0x02")
+		account = common.HexToHash("0xa1")
+		blob    = randomAccount()
+	)
+	if err := snaps.Update(head, root, nil, map[common.Hash][]byte{account: blob}, nil); err != nil {
+		t.Fatalf("failed to create a diff layer: %v", err)
+	}
+	base, err := snaps.Journal(head)
+	if err != nil {
+		t.Fatalf("failed to journal snapshot: %v", err)
+	}
+	if base != root {
+		t.Errorf("journalled base mismatch: have %x, want %x", base, root)
+	}
+	// Reload the snapshot and ensure the diff layer was restored
+	snaps = New(db, triedb, 16, head, false)
+	if n := len(snaps.layers); n != 2 {
+		t.Fatalf("reloaded layer count mismatch: have %d, want %d", n, 2)
+	}
+	if data, err := snaps.Snapshot(head).AccountRLP(account); err != nil || !bytes.Equal(data, blob) {
+		t.Errorf("reloaded account mismatch: have %x, want %x (err: %v)", data, blob, err)
+	}
+	// Reloading against a different head must discard the journal and regenerate
+	snaps = New(db, triedb, 16, root, false)
+	if n := len(snaps.layers); n != 1 {
+		t.Fatalf("regenerated layer count mismatch: have %d, want %d", n, 1)
+	}
+	if snaps.Snapshot(head) != nil {
+		t.Errorf("stale diff layer retained after regeneration")
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"bytes"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/VictoriaMetrics/fastcache"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// randomHash generates a random blob of data and returns it as a hash.
+func randomHash() common.Hash {
+	var hash common.Hash
+	if n, err := rand.Read(hash[:]); n != common.HashLength || err != nil {
+		panic(err)
+	}
+	return hash
+}
+
+// randomAccount generates a random account and returns it RLP encoded.
+func randomAccount() []byte {
+	root := randomHash()
+	a := Account{
+		Balance:  big.NewInt(rand.Int63()),
+		Nonce:    rand.Uint64(),
+		Root:     root[:],
+		CodeHash: emptyCode[:],
+	}
+	data, _ := rlp.EncodeToBytes(a)
+	return data
+}
+
+// newTestDiskLayer creates an empty, fully generated disk layer on top of an
+// in-memory database.
+func newTestDiskLayer(root common.Hash) *diskLayer {
+	db := rawdb.NewMemoryDatabase()
+	return &diskLayer{
+		diskdb: db,
+		triedb: trie.NewDatabase(db),
+		cache:  fastcache.New(1024 * 500),
+		root:   root,
+	}
+}
+
+// Tests that if a disk layer becomes stale, no active external references will
+// be returned with junk data. This version of the test flattens every diff layer
+// to check internal corner case around the bottom-most memory accumulator.
+func TestDiskLayerExternalInvalidationFullFlatten(t *testing.T) {
+	base := newTestDiskLayer(common.HexToHash("0x01"))
+	snaps := &Tree{
+		layers: map[common.Hash]snapshot{
+			base.root: base,
+		},
+	}
+	// Retrieve a reference to the base and commit a diff on top
+	ref := snaps.Snapshot(base.root)
+
+	accounts := map[common.Hash][]byte{
+		common.HexToHash("0xa1"): randomAccount(),
+	}
+	if err := snaps.Update(common.HexToHash("0x02"), common.HexToHash("0x01"), nil, accounts, nil); err != nil {
+		t.Fatalf("failed to create a diff layer: %v", err)
+	}
+	if n := len(snaps.layers); n != 2 {
+		t.Errorf("pre-cap layer count mismatch: have %d, want %d", n, 2)
+	}
+	// Commit the diff layer onto the disk and ensure it's persisted
+	if err := snaps.Cap(common.HexToHash("0x02"), 0); err != nil {
+		t.Fatalf("failed to merge diff layer onto disk: %v", err)
+	}
+	// Since the base layer was modified, ensure that data retrieval on the external reference fail
+	if acc, err := ref.Account(common.HexToHash("0x01")); err != ErrSnapshotStale {
+		t.Errorf("stale reference returned account: %#x (err: %v)", acc, err)
+	}
+	if slot, err := ref.Storage(common.HexToHash("0xa1"), common.HexToHash("0xb1")); err != ErrSnapshotStale {
+		t.Errorf("stale reference returned storage slot: %#x (err: %v)", slot, err)
+	}
+	if n := len(snaps.layers); n != 1 {
+		t.Errorf("post-cap layer count mismatch: have %d, want %d", n, 1)
+	}
+	// The merged account must be readable from the new disk layer
+	if blob, err := snaps.Snapshot(common.HexToHash("0x02")).AccountRLP(common.HexToHash("0xa1")); err != nil || !bytes.Equal(blob, accounts[common.HexToHash("0xa1")]) {
+		t.Errorf("merged account mismatch: have %x, want %x (err: %v)", blob, accounts[common.HexToHash("0xa1")], err)
+	}
+}
+
+// Tests that if a diff layer becomes stale, no active external references will
+// be returned with junk data. This version of the test retains the bottom diff
+// layer to check the usual mode of operation where the accumulator is retained.
+func TestDiffLayerExternalInvalidationPartialFlatten(t *testing.T) {
+	base := newTestDiskLayer(common.HexToHash("0x01"))
+	snaps := &Tree{
+		layers: map[common.Hash]snapshot{
+			base.root: base,
+		},
+	}
+	// Commit three diffs on top and retrieve a reference to the bottommost
+	accounts := map[common.Hash][]byte{
+		common.HexToHash("0xa1"): randomAccount(),
+	}
+	if err := snaps.Update(common.HexToHash("0x02"), common.HexToHash("0x01"), nil, accounts, nil); err != nil {
+		t.Fatalf("failed to create a diff layer: %v", err)
+	}
+	if err := snaps.Update(common.HexToHash("0x03"), common.HexToHash("0x02"), nil, accounts, nil); err != nil {
+		t.Fatalf("failed to create a diff layer: %v", err)
+	}
+	if err := snaps.Update(common.HexToHash("0x04"), common.HexToHash("0x03"), nil, accounts, nil); err != nil {
+		t.Fatalf("failed to create a diff layer: %v", err)
+	}
+	if n := len(snaps.layers); n != 4 {
+		t.Errorf("pre-cap layer count mismatch: have %d, want %d", n, 4)
+	}
+	ref := snaps.Snapshot(common.HexToHash("0x02"))
+
+	// Doing a Cap operation with many allowed layers should be a no-op
+	exp := len(snaps.layers)
+	if err := snaps.Cap(common.HexToHash("0x04"), 2000); err != nil {
+		t.Fatalf("failed to flatten diff layer into accumulator: %v", err)
+	}
+	if got := len(snaps.layers); got != exp {
+		t.Errorf("layers modified, got %d exp %d", got, exp)
+	}
+	// Flatten the diff layer into the bottom accumulator
+	if err := snaps.Cap(common.HexToHash("0x04"), 2); err != nil {
+		t.Fatalf("failed to flatten diff layer into accumulator: %v", err)
+	}
+	// Since the accumulator diff layer was modified, ensure that data retrievals on the external reference fail
+	if acc, err := ref.Account(common.HexToHash("0x01")); err != ErrSnapshotStale {
+		t.Errorf("stale reference returned account: %#x (err: %v)", acc, err)
+	}
+	if slot, err := ref.Storage(common.HexToHash("0xa1"), common.HexToHash("0xb1")); err != ErrSnapshotStale {
+		t.Errorf("stale reference returned storage slot: %#x (err: %v)", slot, err)
+	}
+	if n := len(snaps.layers); n != 3 {
+		t.Errorf("post-cap layer count mismatch: have %d, want %d", n, 3)
+	}
+}
+
+// Tests that updates linking to an unknown parent or to themselves are rejected.
+func TestUpdateInvalidParent(t *testing.T) {
+	base := newTestDiskLayer(common.HexToHash("0x01"))
+	snaps := &Tree{
+		layers: map[common.Hash]snapshot{
+			base.root: base,
+		},
+	}
+	if err := snaps.Update(common.HexToHash("0x03"), common.HexToHash("0x02"), nil, nil, nil); err == nil {
+		t.Errorf("update with missing parent accepted")
+	}
+	if err := snaps.Update(common.HexToHash("0x01"), common.HexToHash("0x01"), nil, nil, nil); err == nil {
+		t.Errorf("self-referencing update accepted")
+	}
+	if n := len(snaps.layers); n != 1 {
+		t.Errorf("layer count mismatch: have %d, want %d", n, 1)
+	}
+}
+
+// Tests that destructed accounts shadow any older data from the layers below,
+// and that newly written slots are visible on top of the destruction.
+func TestDiffLayerDestructShadowing(t *testing.T) {
+	base := newTestDiskLayer(common.HexToHash("0x01"))
+	snaps := &Tree{
+		layers: map[common.Hash]snapshot{
+			base.root: base,
+		},
+	}
+	var (
+		acc  = common.HexToHash("0xa1")
+		slot = common.HexToHash("0xb1")
+		blob = randomAccount()
+	)
+	storage := map[common.Hash]map[common.Hash][]byte{
+		acc: {slot: []byte{0x01}},
+	}
+	if err := snaps.Update(common.HexToHash("0x02"), common.HexToHash("0x01"), nil, map[common.Hash][]byte{acc: blob}, storage); err != nil {
+		t.Fatalf("failed to create a diff layer: %v", err)
+	}
+	destructs := map[common.Hash]struct{}{acc: {}}
+	if err := snaps.Update(common.HexToHash("0x03"), common.HexToHash("0x02"), destructs, nil, nil); err != nil {
+		t.Fatalf("failed to create a diff layer: %v", err)
+	}
+	head := snaps.Snapshot(common.HexToHash("0x03"))
+	if data, err := head.AccountRLP(acc); err != nil || len(data) != 0 {
+		t.Errorf("destructed account returned: %x (err: %v)", data, err)
+	}
+	if data, err := head.Storage(acc, slot); err != nil || len(data) != 0 {
+		t.Errorf("destructed slot returned: %x (err: %v)", data, err)
+	}
+	// The parent layer must still serve the pre-destruction data
+	parent := snaps.Snapshot(common.HexToHash("0x02"))
+	if data, err := parent.Storage(acc, slot); err != nil || !bytes.Equal(data, []byte{0x01}) {
+		t.Errorf("parent slot mismatch: have %x, want %x (err: %v)", data, []byte{0x01}, err)
+	}
+	// Flatten everything and ensure the disk layer agrees
+	if err := snaps.Cap(common.HexToHash("0x03"), 0); err != nil {
+		t.Fatalf("failed to merge diff layers onto disk: %v", err)
+	}
+	head = snaps.Snapshot(common.HexToHash("0x03"))
+	if data, err := head.AccountRLP(acc); err != nil || len(data) != 0 {
+		t.Errorf("destructed account persisted: %x (err: %v)", data, err)
+	}
+	if data, err := head.Storage(acc, slot); err != nil || len(data) != 0 {
+		t.Errorf("destructed slot persisted: %x (err: %v)", data, err)
+	}
+}
@@ -36,7 +36,7 @@ type stateTest struct {
 
 func newStateTest() *stateTest {
 	db := rawdb.NewMemoryDatabase()
-	sdb, _ := New(common.Hash{}, NewDatabase(db))
+	sdb, _ := New(common.Hash{}, NewDatabase(db), nil)
 	return &stateTest{db: db, state: sdb}
 }
 
@@ -87,6 +87,75 @@ func TestDump(t *testing.T) {
 	}
 }
 
+// Tests that paging through the state with any page size yields the same
+// accounts and storage as a full dump.
+func TestPagedDump(t *testing.T) {
+	s := newStateTest()
+
+	for i := byte(0); i < 10; i++ {
+		addr := toAddr([]byte{i + 1})
+		s.state.SetBalance(addr, big.NewInt(int64(i)))
+		for j := byte(0); j < i%4*3; j++ {
+			s.state.SetState(addr, common.Hash{j}, common.Hash{i, j + 1})
+		}
+	}
+	root, _ := s.state.Commit(false)
+	s.state.Database().TrieDB().Commit(root, false)
+
+	full := s.state.PagedDump(&DumpConfig{})
+	if full.Next != nil {
+		t.Fatalf("unlimited dump cut short at %x", full.Next)
+	}
+	if len(full.Accounts) != 10 {
+		t.Fatalf("account count mismatch: have %d, want %d", len(full.Accounts), 10)
+	}
+	for _, max := range []uint64{1, 2, 3, 7, 100} {
+		var (
+			accounts = make(map[common.Address]DumpAccount)
+			conf     = &DumpConfig{Max: max}
+			pages    int
+		)
+		for {
+			page := s.state.PagedDump(conf)
+			if page.Root != root {
+				t.Fatalf("max %d: root mismatch: have %x, want %x", max, page.Root, root)
+			}
+			for _, account := range page.Accounts {
+				if prev, ok := accounts[*account.Address]; ok {
+					for key, val := range account.Storage {
+						prev.Storage[key] = val
+					}
+					continue
+				}
+				accounts[*account.Address] = account
+			}
+			pages++
+			if page.Next == nil {
+				break
+			}
+			conf.Start = page.Next
+		}
+		if max < 100 && pages == 1 {
+			t.Errorf("max %d: dump not paged", max)
+		}
+		if len(accounts) != len(full.Accounts) {
+			t.Fatalf("max %d: account count mismatch: have %d, want %d", max, len(accounts), len(full.Accounts))
+		}
+		for _, want := range full.Accounts {
+			have := accounts[*want.Address]
+			if have.Balance != want.Balance || len(have.Storage) != len(want.Storage) {
+				t.Errorf("max %d: account %x mismatch: have %+v, want %+v", max, *want.Address, have, want)
+				continue
+			}
+			for key, val := range want.Storage {
+				if have.Storage[key] != val {
+					t.Errorf("max %d: account %x slot %x mismatch: have %s, want %s", max, *want.Address, key, have.Storage[key], val)
+				}
+			}
+		}
+	}
+}
+
 func TestNull(t *testing.T) {
 	s := newStateTest()
 	address := common.HexToAddress("0x823140710bf13990e4500136726d8b55")
@@ -146,7 +215,7 @@ func TestSnapshotEmpty(t *testing.T) {
 }
 
 func TestSnapshot2(t *testing.T) {
-	state, _ := New(common.Hash{}, NewDatabase(rawdb.NewMemoryDatabase()))
+	state, _ := New(common.Hash{}, NewDatabase(rawdb.NewMemoryDatabase()), nil)
 
 	stateobjaddr0 := toAddr([]byte("so0"))
 	stateobjaddr1 := toAddr([]byte("so1"))
@@ -39,7 +39,7 @@ import (
 func TestUpdateLeaks(t *testing.T) {
 	// Create an empty state database
 	db := rawdb.NewMemoryDatabase()
-	state, _ := New(common.Hash{}, NewDatabase(db))
+	state, _ := New(common.Hash{}, NewDatabase(db), nil)
 
 	// Update it with some accounts
 	for i := byte(0); i < 255; i++ {
@@ -73,8 +73,8 @@ func TestIntermediateLeaks(t *testing.T) {
 	// Create two state databases, one transitioning to the final state, the other final from the beginning
 	transDb := rawdb.NewMemoryDatabase()
 	finalDb := rawdb.NewMemoryDatabase()
-	transState, _ := New(common.Hash{}, NewDatabase(transDb))
-	finalState, _ := New(common.Hash{}, NewDatabase(finalDb))
+	transState, _ := New(common.Hash{}, NewDatabase(transDb), nil)
+	finalState, _ := New(common.Hash{}, NewDatabase(finalDb), nil)
 
 	modify := func(state *StateDB, addr common.Address, i, tweak byte) {
 		state.SetBalance(addr, big.NewInt(int64(11*i)+int64(tweak)))
@@ -149,7 +149,7 @@ func TestIntermediateLeaks(t *testing.T) {
 // https://github.com/ethereum/go-ethereum/pull/15549.
 func TestCopy(t *testing.T) {
 	// Create a random state test to copy and modify "independently"
-	orig, _ := New(common.Hash{}, NewDatabase(rawdb.NewMemoryDatabase()))
+	orig, _ := New(common.Hash{}, NewDatabase(rawdb.NewMemoryDatabase()), nil)
 
 	for i := byte(0); i < 255; i++ {
 		obj := orig.GetOrNewStateObject(common.BytesToAddress([]byte{i}))
@@ -385,7 +385,7 @@ func (test *snapshotTest) String() string {
 func (test *snapshotTest) run() bool {
 	// Run all actions and create snapshots.
 	var (
-		state, _     = New(common.Hash{}, NewDatabase(rawdb.NewMemoryDatabase()))
+		state, _     = New(common.Hash{}, NewDatabase(rawdb.NewMemoryDatabase()), nil)
 		snapshotRevs = make([]int, len(test.snapshots))
 		sindex       = 0
 	)
@@ -399,7 +399,7 @@ func (test *snapshotTest) run() bool {
 	// Revert all snapshots in reverse order. Each revert must yield a state
 	// that is equivalent to fresh state with all actions up the snapshot applied.
 	for sindex--; sindex >= 0; sindex-- {
-		checkstate, _ := New(common.Hash{}, state.Database())
+		checkstate, _ := New(common.Hash{}, state.Database(), nil)
 		for _, action := range test.actions[:test.snapshots[sindex]] {
 			action.fn(action, checkstate)
 		}
@@ -477,7 +477,7 @@ func TestTouchDelete(t *testing.T) {
 // TestCopyOfCopy tests that modified objects are carried over to the copy, and the copy of the copy.
 // See https://github.com/ethereum/go-ethereum/pull/15225#issuecomment-380191512
 func TestCopyOfCopy(t *testing.T) {
-	state, _ := New(common.Hash{}, NewDatabase(rawdb.NewMemoryDatabase()))
+	state, _ := New(common.Hash{}, NewDatabase(rawdb.NewMemoryDatabase()), nil)
 	addr := common.HexToAddress("aaaa")
 	state.SetBalance(addr, big.NewInt(42))
 
@@ -494,7 +494,7 @@ func TestCopyOfCopy(t *testing.T) {
 //
 // See https://github.com/ethereum/go-ethereum/issues/20106.
 func TestCopyCommitCopy(t *testing.T) {
-	state, _ := New(common.Hash{}, NewDatabase(rawdb.NewMemoryDatabase()))
+	state, _ := New(common.Hash{}, NewDatabase(rawdb.NewMemoryDatabase()), nil)
 
 	// Create an account and check if the retrieved balance is correct
 	addr := common.HexToAddress("0xaffeaffeaffeaffeaffeaffeaffeaffeaffeaffe")
@@ -566,7 +566,7 @@ func TestCopyCommitCopy(t *testing.T) {
 //
 // See https://github.com/ethereum/go-ethereum/issues/20106.
 func TestCopyCopyCommitCopy(t *testing.T) {
-	state, _ := New(common.Hash{}, NewDatabase(rawdb.NewMemoryDatabase()))
+	state, _ := New(common.Hash{}, NewDatabase(rawdb.NewMemoryDatabase()), nil)
 
 	// Create an account and check if the retrieved balance is correct
 	addr := common.HexToAddress("0xaffeaffeaffeaffeaffeaffeaffeaffeaffeaffe")
@@ -656,7 +656,7 @@ func TestCopyCopyCommitCopy(t *testing.T) {
 // first, but the journal wiped the entire state object on create-revert.
 func TestDeleteCreateRevert(t *testing.T) {
 	// Create an initial state with a single contract
-	state, _ := New(common.Hash{}, NewDatabase(rawdb.NewMemoryDatabase()))
+	state, _ := New(common.Hash{}, NewDatabase(rawdb.NewMemoryDatabase()), nil)
 
 	addr := toAddr([]byte("so"))
 	state.SetBalance(addr, big.NewInt(1))
@@ -680,3 +680,97 @@ func TestDeleteCreateRevert(t *testing.T) {
 		t.Fatalf("self-destructed contract came alive")
 	}
 }
+
+// Tests that prefetching an access list loads the listed accounts and slots
+// into the live state without creating missing accounts.
+func TestPrefetch(t *testing.T) {
+	db := NewDatabase(rawdb.NewMemoryDatabase())
+	state, _ := New(common.Hash{}, db, nil)
+
+	var (
+		addrs   = make([]common.Address, 8)
+		missing = common.HexToAddress("0xdead")
+		list    types.AccessList
+	)
+	for i := range addrs {
+		addrs[i] = common.BigToAddress(big.NewInt(int64(i + 1)))
+		state.SetBalance(addrs[i], big.NewInt(int64(i+1)))
+		state.SetNonce(addrs[i], uint64(i))
+		for j := 0; j < i; j++ {
+			state.SetState(addrs[i], common.BigToHash(big.NewInt(int64(j))), common.BigToHash(big.NewInt(int64(i*j+1))))
+		}
+		list = append(list, types.AccessTuple{Address: addrs[i], StorageKeys: []common.Hash{
+			common.BigToHash(big.NewInt(0)), common.BigToHash(big.NewInt(100)),
+		}})
+	}
+	list = append(list, types.AccessTuple{Address: missing})
+
+	root, err := state.Commit(false)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	state, _ = New(root, db, nil)
+	state.Prefetch(list, 4)
+
+	for i, addr := range addrs {
+		obj, ok := state.stateObjects[addr]
+		if !ok {
+			t.Fatalf("account %d not prefetched", i)
+		}
+		if _, ok := obj.originStorage[common.BigToHash(big.NewInt(100))]; !ok {
+			t.Errorf("account %d: missing slot not prefetched", i)
+		}
+		if have := state.GetBalance(addr); have.Cmp(big.NewInt(int64(i+1))) != 0 {
+			t.Errorf("account %d: balance mismatch: have %v, want %d", i, have, i+1)
+		}
+		if have := state.GetNonce(addr); have != uint64(i) {
+			t.Errorf("account %d: nonce mismatch: have %d, want %d", i, have, i)
+		}
+		for j := 0; j < i; j++ {
+			want := common.BigToHash(big.NewInt(int64(i*j + 1)))
+			if have := state.GetState(addr, common.BigToHash(big.NewInt(int64(j)))); have != want {
+				t.Errorf("account %d slot %d: value mismatch: have %x, want %x", i, j, have, want)
+			}
+		}
+	}
+	if _, ok := state.stateObjects[missing]; ok {
+		t.Errorf("missing account created by prefetch")
+	}
+	if have := state.IntermediateRoot(false); have != root {
+		t.Errorf("root changed by prefetch: have %x, want %x", have, root)
+	}
+}
+
+// Tests that the accessed state contains every loaded and modified account and
+// slot exactly once, in sorted order.
+func TestAccessedState(t *testing.T) {
+	db := NewDatabase(rawdb.NewMemoryDatabase())
+	state, _ := New(common.Hash{}, db, nil)
+
+	var (
+		a = common.HexToAddress("0x01")
+		b = common.HexToAddress("0x02")
+		c = common.HexToAddress("0x03")
+	)
+	state.SetState(a, common.HexToHash("0x01"), common.HexToHash("0x01"))
+	state.SetState(a, common.HexToHash("0x02"), common.HexToHash("0x02"))
+	state.SetBalance(b, big.NewInt(1))
+	root, _ := state.Commit(false)
+
+	state, _ = New(root, db, nil)
+	state.GetState(a, common.HexToHash("0x02"))
+	state.SetState(a, common.HexToHash("0x02"), common.HexToHash("0x03"))
+	state.Finalise(false)
+	state.SetState(a, common.HexToHash("0x02"), common.HexToHash("0x04"))
+	state.SetState(c, common.HexToHash("0x05"), common.HexToHash("0x05"))
+	state.GetBalance(b)
+
+	want := types.AccessList{
+		{Address: a, StorageKeys: []common.Hash{common.HexToHash("0x02")}},
+		{Address: b, StorageKeys: []common.Hash{}},
+		{Address: c, StorageKeys: []common.Hash{common.HexToHash("0x05")}},
+	}
+	if have := state.AccessedState(); !reflect.DeepEqual(have, want) {
+		t.Errorf("accessed state mismatch:\nhave %v\nwant %v", have, want)
+	}
+}
@@ -17,6 +17,7 @@
 package core
 
 import (
+	"runtime"
 	"sync/atomic"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -34,6 +35,9 @@ type statePrefetcher struct {
 	config *params.ChainConfig // Chain configuration options
 	bc     *BlockChain         // Canonical block chain
 	engine consensus.Engine    // Consensus engine used for block rewards
+
+	accessed     types.AccessList // State accessed by the last processed block (OVM only)
+	accessedHash common.Hash      // Hash of the block the accessed state belongs to
 }
 
 // newStatePrefetcher initialises a new statePrefetcher.
@@ -67,6 +71,37 @@ func (p *statePrefetcher) Prefetch(block *types.Block, statedb *state.StateDB, c
 	}
 }
 
+// PrefetchAccessLists loads the accounts and storage slots declared in the access
+// lists of the block's transactions into the statedb in parallel, so they are
+// readily available when the block is processed.
+//
+// When running the OVM the sequencer only accepts legacy transactions, and most
+// storage is accessed by the execution manager through the state manager rather
+// than by the transaction itself. The state accessed by the parent block, as
+// recorded by RecordAccessedState, is prefetched instead.
+func (p *statePrefetcher) PrefetchAccessLists(block *types.Block, statedb *state.StateDB) {
+	var list types.AccessList
+	for _, tx := range block.Transactions() {
+		list = append(list, tx.AccessList()...)
+	}
+	if vm.UsingOVM && p.accessedHash == block.ParentHash() {
+		list = append(list, p.accessed...)
+	}
+	if len(list) > 0 {
+		statedb.Prefetch(list, runtime.NumCPU())
+	}
+}
+
+// RecordAccessedState stores the accounts and storage slots accessed while
+// processing the given block, to be prefetched ahead of its child. Nothing is
+// recorded unless running the OVM.
+func (p *statePrefetcher) RecordAccessedState(block *types.Block, statedb *state.StateDB) {
+	if !vm.UsingOVM {
+		return
+	}
+	p.accessed, p.accessedHash = statedb.AccessedState(), block.Hash()
+}
+
 // precacheTransaction attempts to apply a transaction to the given state database
 // and uses the input parameters for its environment. The goal is not to execute
 // the transaction successfully, rather to warm up touched data slots.
@@ -152,6 +152,7 @@ func modMessage(
 		gasLimit,
 		msg.GasPrice(),
 		data,
+		msg.AccessList(),
 		false,
 		msg.L1MessageSender(),
 		msg.L1BlockNumber(),
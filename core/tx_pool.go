@@ -89,6 +89,15 @@ var (
 	// than some meaningful limit a user might use. This is not a consensus error
 	// making the transaction invalid, rather a DOS protection.
 	ErrOversizedData = errors.New("oversized data")
+
+	// ErrTxTypeNotSupported is returned if a transaction is not supported in the
+	// current network configuration.
+	ErrTxTypeNotSupported = types.ErrTxTypeNotSupported
+
+	// ErrTypedTxNotSupportedOVM is returned if a typed transaction is submitted
+	// to an OVM node. The sequencer entrypoint only accepts a fixed encoding of
+	// legacy transactions, leaving no room for fields such as access lists.
+	ErrTypedTxNotSupportedOVM = errors.New("typed transactions not supported by the sequencer")
 )
 
 var (
@@ -529,6 +538,13 @@ func (pool *TxPool) ValidateTx(tx *types.Transaction) error {
 // validateTx checks whether a transaction is valid according to the consensus
 // rules and adheres to some heuristic limits of the local node (price and size).
 func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
+	// Accept only the transaction types the signer is able to handle
+	if tx.Type() != types.LegacyTxType && tx.Type() != types.AccessListTxType {
+		return ErrTxTypeNotSupported
+	}
+	if vm.UsingOVM && tx.Type() != types.LegacyTxType {
+		return ErrTypedTxNotSupportedOVM
+	}
 	// Reject transactions over defined size to prevent DOS attacks
 	if uint64(tx.Size()) > txMaxSize {
 		return ErrOversizedData
@@ -571,7 +587,7 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 			return ErrInsufficientFunds
 		}
 		// Ensure the transaction has more gas than the basic tx fee.
-		intrGas, err := IntrinsicGas(tx.Data(), tx.To() == nil, true, pool.istanbul)
+		intrGas, err := IntrinsicGas(tx.Data(), tx.AccessList(), tx.To() == nil, true, pool.istanbul)
 		if err != nil {
 			return err
 		}
@@ -40,6 +40,14 @@ type Prefetcher interface {
 	// the transaction messages using the statedb, but any changes are discarded. The
 	// only goal is to pre-cache transaction signatures and state trie nodes.
 	Prefetch(block *types.Block, statedb *state.StateDB, cfg vm.Config, interrupt *uint32)
+
+	// PrefetchAccessLists loads the accounts and storage slots declared by the
+	// transactions of a block into the statedb ahead of processing it.
+	PrefetchAccessLists(block *types.Block, statedb *state.StateDB)
+
+	// RecordAccessedState stores the state accessed while processing a block,
+	// to be prefetched ahead of the block's child.
+	RecordAccessedState(block *types.Block, statedb *state.StateDB)
 }
 
 // Processor is an interface for processing blocks using a given initial state.
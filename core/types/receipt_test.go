@@ -27,6 +27,7 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
 )
 
 func TestLegacyReceiptDecoding(t *testing.T) {
@@ -281,3 +282,97 @@ func clearComputedFieldsOnLog(t *testing.T, log *Log) {
 	log.TxIndex = math.MaxUint32
 	log.Index = math.MaxUint32
 }
+
+// Tests that legacy receipts round trip through the binary encoding, and that
+// typed receipt envelopes of unknown types are rejected.
+func TestReceiptBinary(t *testing.T) {
+	receipt := &Receipt{
+		Status:            ReceiptStatusSuccessful,
+		CumulativeGasUsed: 1,
+		Logs: []*Log{
+			{Address: common.BytesToAddress([]byte{0x11}), Topics: []common.Hash{common.HexToHash("dead")}, Data: []byte{0x01, 0x00, 0xff}},
+		},
+	}
+	receipt.Bloom = CreateBloom(Receipts{receipt})
+
+	enc, err := receipt.MarshalBinary()
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	legacy, _ := rlp.EncodeToBytes(receipt)
+	if !bytes.Equal(enc, legacy) {
+		t.Errorf("binary encoding mismatch: have %x, want %x", enc, legacy)
+	}
+	var dec Receipt
+	if err := dec.UnmarshalBinary(enc); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if dec.Type != LegacyTxType || dec.Status != receipt.Status || dec.CumulativeGasUsed != receipt.CumulativeGasUsed || dec.Bloom != receipt.Bloom {
+		t.Errorf("decoded receipt mismatch: have %+v, want %+v", dec, receipt)
+	}
+	if err := dec.UnmarshalBinary(nil); err != errEmptyTypedReceipt {
+		t.Errorf("empty receipt error mismatch: have %v, want %v", err, errEmptyTypedReceipt)
+	}
+	if err := dec.UnmarshalBinary(common.FromHex("7fc0")); err != ErrTxTypeNotSupported {
+		t.Errorf("typed receipt error mismatch: have %v, want %v", err, ErrTxTypeNotSupported)
+	}
+}
+
+// Tests that access list receipts round trip through the binary and RLP
+// encodings as EIP-2718 envelopes, and that the receipt root commits to the
+// envelope rather than the legacy encoding.
+func TestTypedReceiptBinary(t *testing.T) {
+	receipt := &Receipt{
+		Type:              AccessListTxType,
+		Status:            ReceiptStatusFailed,
+		CumulativeGasUsed: 1,
+		Logs: []*Log{
+			{Address: common.BytesToAddress([]byte{0x11}), Topics: []common.Hash{common.HexToHash("dead")}, Data: []byte{0x01, 0x00, 0xff}},
+		},
+	}
+	receipt.Bloom = CreateBloom(Receipts{receipt})
+
+	enc, err := receipt.MarshalBinary()
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	legacy, _ := rlp.EncodeToBytes(&receiptRLP{receipt.statusEncoding(), receipt.CumulativeGasUsed, receipt.Bloom, receipt.Logs})
+	if want := append([]byte{AccessListTxType}, legacy...); !bytes.Equal(enc, want) {
+		t.Errorf("binary encoding mismatch: have %x, want %x", enc, want)
+	}
+	var dec Receipt
+	if err := dec.UnmarshalBinary(enc); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if dec.Type != AccessListTxType || dec.Status != receipt.Status || dec.CumulativeGasUsed != receipt.CumulativeGasUsed || dec.Bloom != receipt.Bloom || len(dec.Logs) != 1 {
+		t.Errorf("decoded receipt mismatch: have %+v, want %+v", dec, receipt)
+	}
+	// Inside an RLP list, the envelope is encoded as a byte string
+	blob, err := rlp.EncodeToBytes(Receipts{receipt})
+	if err != nil {
+		t.Fatalf("RLP encode error: %v", err)
+	}
+	var decoded Receipts
+	if err := rlp.DecodeBytes(blob, &decoded); err != nil {
+		t.Fatalf("RLP decode error: %v", err)
+	}
+	if len(decoded) != 1 || decoded[0].Type != AccessListTxType || decoded[0].Status != receipt.Status {
+		t.Errorf("RLP decoded receipt mismatch: have %+v", decoded)
+	}
+	// The receipt root is built from the binary encodings
+	plain := *receipt
+	plain.Type = LegacyTxType
+	receipts := Receipts{&plain, receipt}
+
+	tr := new(trie.Trie)
+	for i, value := range [][]byte{legacy, enc} {
+		key, _ := rlp.EncodeToBytes(uint(i))
+		tr.Update(key, value)
+	}
+	if have, want := DeriveSha(receipts), tr.Hash(); have != want {
+		t.Errorf("receipt root mismatch: have %x, want %x", have, want)
+	}
+	if DeriveSha(receipts) == DeriveSha(Receipts{&plain, &plain}) {
+		t.Error("receipt root does not commit to the receipt type")
+	}
+}
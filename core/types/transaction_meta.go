@@ -7,6 +7,7 @@ package types
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -49,6 +50,31 @@ func NewTransactionMeta(l1BlockNumber *big.Int, l1timestamp uint64, l1MessageSen
 	}
 }
 
+// EncodeTxMetas serializes the metadata of each of the transactions.
+func EncodeTxMetas(txs Transactions) [][]byte {
+	metas := make([][]byte, len(txs))
+	for i, tx := range txs {
+		metas[i] = TxMetaEncode(tx.GetMeta())
+	}
+	return metas
+}
+
+// SetTxMetas deserializes the metadata of each of the transactions and sets it
+// on the corresponding transaction.
+func SetTxMetas(txs Transactions, metas [][]byte) error {
+	if len(metas) != len(txs) {
+		return fmt.Errorf("transaction metadata count mismatch: have %d, want %d", len(metas), len(txs))
+	}
+	for i, tx := range txs {
+		meta, err := TxMetaDecode(metas[i])
+		if err != nil {
+			return fmt.Errorf("invalid metadata of transaction %d: %v", i, err)
+		}
+		tx.SetTransactionMeta(meta)
+	}
+	return nil
+}
+
 // TxMetaDecode deserializes bytes as a TransactionMeta struct.
 // The schema is:
 //   varbytes(SignatureHashType) ||
@@ -102,6 +102,102 @@ func TestTransactionEncode(t *testing.T) {
 	}
 }
 
+// Tests that legacy transactions keep their plain RLP list encoding in the
+// binary format and survive a round trip unmodified.
+func TestLegacyTransactionBinary(t *testing.T) {
+	enc, err := rightvrsTx.MarshalBinary()
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	should := common.FromHex("f86103018207d094b94f5374fce5edbc8e2a8697c15331677e6ebf0b0a8255441ca098ff921201554726367d2be8c804a7ff89ccf285ebc57dff8ae4c44b9c19ac4aa08887321be575c8095f789dd4c743dfe42c1820f9231f98a962b210e3ac2452a3")
+	if !bytes.Equal(enc, should) {
+		t.Errorf("encoded binary mismatch, got %x", enc)
+	}
+	var tx Transaction
+	if err := tx.UnmarshalBinary(enc); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if tx.Type() != LegacyTxType {
+		t.Errorf("type mismatch: have %d, want %d", tx.Type(), LegacyTxType)
+	}
+	if tx.Hash() != rightvrsTx.Hash() {
+		t.Errorf("hash mismatch: have %x, want %x", tx.Hash(), rightvrsTx.Hash())
+	}
+}
+
+// Tests that typed transaction envelopes of unknown types are rejected, both in
+// the binary and in the RLP string form used inside blocks.
+func TestTypedTransactionUnsupported(t *testing.T) {
+	var tx Transaction
+	if err := tx.UnmarshalBinary(nil); err != errEmptyTypedTx {
+		t.Errorf("empty binary error mismatch: have %v, want %v", err, errEmptyTypedTx)
+	}
+	if err := tx.UnmarshalBinary(common.FromHex("7fc0")); err != ErrTxTypeNotSupported {
+		t.Errorf("binary error mismatch: have %v, want %v", err, ErrTxTypeNotSupported)
+	}
+	enc, _ := rlp.EncodeToBytes(common.FromHex("7fc0"))
+	if _, err := decodeTx(enc); err != ErrTxTypeNotSupported {
+		t.Errorf("rlp error mismatch: have %v, want %v", err, ErrTxTypeNotSupported)
+	}
+	enc, _ = rlp.EncodeToBytes([]byte{})
+	if _, err := decodeTx(enc); err != errEmptyTypedTx {
+		t.Errorf("empty rlp error mismatch: have %v, want %v", err, errEmptyTypedTx)
+	}
+	if err := json.Unmarshal([]byte(`{"type":"0x7f"}`), &tx); err != ErrTxTypeNotSupported {
+		t.Errorf("json error mismatch: have %v, want %v", err, ErrTxTypeNotSupported)
+	}
+}
+
+// Tests that access list transactions are signed over the typed envelope and
+// survive a binary round trip with their sender intact.
+func TestAccessListTransaction(t *testing.T) {
+	key, from := defaultTestKey()
+	to := common.HexToAddress("0x0000000000000000000000000000000000000aaa")
+
+	tx := NewTx(&AccessListTx{
+		ChainID:    big.NewInt(420),
+		Nonce:      3,
+		To:         &to,
+		Value:      big.NewInt(10),
+		Gas:        25000,
+		GasPrice:   big.NewInt(1),
+		AccessList: AccessList{{Address: to, StorageKeys: []common.Hash{{1}, {2}}}},
+	})
+	signed, err := SignTx(tx, NewOVMSigner(big.NewInt(420)), key)
+	if err != nil {
+		t.Fatalf("could not sign transaction: %v", err)
+	}
+	enc, err := signed.MarshalBinary()
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	if enc[0] != AccessListTxType {
+		t.Fatalf("type prefix mismatch: have %d, want %d", enc[0], AccessListTxType)
+	}
+	var decoded Transaction
+	if err := decoded.UnmarshalBinary(enc); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if decoded.Hash() != signed.Hash() {
+		t.Errorf("hash mismatch: have %x, want %x", decoded.Hash(), signed.Hash())
+	}
+	if have := decoded.AccessList().StorageKeys(); have != 2 {
+		t.Errorf("storage key count mismatch: have %d, want 2", have)
+	}
+	for _, signer := range []Signer{NewOVMSigner(big.NewInt(420)), NewEIP2930Signer(big.NewInt(420))} {
+		sender, err := Sender(signer, &decoded)
+		if err != nil {
+			t.Fatalf("could not recover sender: %v", err)
+		}
+		if sender != from {
+			t.Errorf("sender mismatch: have %x, want %x", sender, from)
+		}
+	}
+	if _, err := Sender(NewOVMSigner(big.NewInt(1)), &decoded); err != ErrInvalidChainId {
+		t.Errorf("chain id error mismatch: have %v, want %v", err, ErrInvalidChainId)
+	}
+}
+
 func decodeTx(data []byte) (*Transaction, error) {
 	var tx Transaction
 	t, err := &tx, rlp.Decode(bytes.NewReader(data), &tx)
@@ -213,11 +309,22 @@ func TestTransactionJSON(t *testing.T) {
 	transactions := make([]*Transaction, 0, 50)
 	for i := uint64(0); i < 25; i++ {
 		var tx *Transaction
-		switch i % 2 {
+		switch i % 3 {
 		case 0:
 			tx = NewTransaction(i, common.Address{1}, common.Big0, 1, common.Big2, []byte("abcdef"))
 		case 1:
 			tx = NewContractCreation(i, common.Big0, 1, common.Big2, []byte("abcdef"))
+		case 2:
+			addr := common.HexToAddress("0x0000000000000000000000000000000000000001")
+			tx = NewTx(&AccessListTx{
+				ChainID:    common.Big1,
+				Nonce:      i,
+				To:         &addr,
+				Gas:        123457,
+				GasPrice:   common.Big2,
+				AccessList: AccessList{{Address: addr, StorageKeys: []common.Hash{{0}}}},
+				Data:       []byte("abcdef"),
+			})
 		}
 		transactions = append(transactions, tx)
 
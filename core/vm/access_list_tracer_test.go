@@ -0,0 +1,142 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the access list tracer collects the accounts and storage slots
+// touched by opcodes, skipping the sender, recipient and precompiles.
+func TestAccessListTracer(t *testing.T) {
+	var (
+		from     = common.HexToAddress("0xaa")
+		contract = common.HexToAddress("0xcc")
+		other    = common.HexToAddress("0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb")
+	)
+	code := hexutil.MustDecode("0x" +
+		"6005" + "54" + "50" + // SLOAD(5)
+		"6001" + "6007" + "55" + // SSTORE(7, 1)
+		"73" + other.Hex()[2:] + "31" + "50" + // BALANCE(other)
+		"6001" + "3b" + "50" + // EXTCODESIZE(ecrecover)
+		"33" + "31" + "50" + // BALANCE(caller)
+		"00") // STOP
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.SetCode(contract, code)
+
+	precompiles := ActivePrecompiles(params.AllEthashProtocolChanges.Rules(new(big.Int)))
+	run := func(acl types.AccessList) *AccessListTracer {
+		tracer := NewAccessListTracer(acl, from, contract, precompiles)
+		vmctx := Context{
+			CanTransfer: func(StateDB, common.Address, *big.Int) bool { return true },
+			Transfer:    func(StateDB, common.Address, common.Address, *big.Int) {},
+			BlockNumber: new(big.Int),
+		}
+		vmenv := NewEVM(vmctx, statedb.Copy(), params.AllEthashProtocolChanges, Config{Debug: true, Tracer: tracer})
+		if _, _, err := vmenv.Call(AccountRef(from), contract, nil, 100000, new(big.Int)); err != nil {
+			t.Fatalf("failed to execute contract: %v", err)
+		}
+		return tracer
+	}
+	tracer := run(nil)
+
+	want := types.AccessList{
+		{Address: contract, StorageKeys: []common.Hash{common.BigToHash(big.NewInt(5)), common.BigToHash(big.NewInt(7))}},
+		{Address: other, StorageKeys: []common.Hash{}},
+	}
+	if have := tracer.AccessList(); !reflect.DeepEqual(have, want) {
+		t.Fatalf("access list mismatch:\nhave %+v\nwant %+v", have, want)
+	}
+	// Running with the generated list must converge, while an extra account
+	// must be retained in the output
+	if !tracer.Equal(run(want)) {
+		t.Errorf("access list did not converge")
+	}
+	extra := append(want, types.AccessTuple{Address: common.HexToAddress("0xdd")})
+	if run(extra).Equal(tracer) {
+		t.Errorf("extra account dropped from the access list")
+	}
+	// The excluded sender must not be added even if explicitly listed
+	excluded := append(want, types.AccessTuple{Address: from})
+	if !run(excluded).Equal(tracer) {
+		t.Errorf("excluded sender added to the access list")
+	}
+}
+
+// Tests that calls into the native OVM state manager are decoded into the
+// accounts and storage slots they operate on.
+func TestAccessListTracerStateManager(t *testing.T) {
+	const definition = `[
+		{"type": "function", "name": "getContractStorage", "inputs": [{"name": "_contract", "type": "address"}, {"name": "_key", "type": "bytes32"}], "outputs": [{"name": "", "type": "bytes32"}]},
+		{"type": "function", "name": "getAccountNonce", "inputs": [{"name": "_address", "type": "address"}], "outputs": [{"name": "", "type": "uint256"}]}
+	]`
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		t.Fatalf("failed to parse abi: %v", err)
+	}
+	env := NewEVM(Context{}, nil, params.TestChainConfig, Config{})
+	env.Context.OvmStateManager.ABI = parsed
+
+	var (
+		target = common.HexToAddress("0x1234")
+		owner  = common.HexToAddress("0x5678")
+		key    = common.HexToHash("0x01")
+	)
+	tracer := NewAccessListTracer(nil, common.Address{}, common.Address{}, nil)
+	for _, input := range [][]interface{}{
+		{"getContractStorage", target, key},
+		{"getAccountNonce", owner},
+	} {
+		data, err := parsed.Pack(input[0].(string), input[1:]...)
+		if err != nil {
+			t.Fatalf("failed to pack %s: %v", input[0], err)
+		}
+		memory := NewMemory()
+		memory.Resize(uint64(len(data)))
+		memory.Set(0, uint64(len(data)), data)
+
+		// STATICCALL(gas, addr, inOffset, inSize, outOffset, outSize)
+		stack := newstack()
+		stack.push(new(big.Int))
+		stack.push(new(big.Int))
+		stack.push(big.NewInt(int64(len(data))))
+		stack.push(new(big.Int))
+		stack.push(new(big.Int).SetBytes(env.Context.OvmStateManager.Address.Bytes()))
+		stack.push(big.NewInt(100000))
+
+		tracer.captureStateManagerCall(env, STATICCALL, memory, stack)
+	}
+	want := types.AccessList{
+		{Address: target, StorageKeys: []common.Hash{key}},
+		{Address: owner, StorageKeys: []common.Hash{}},
+	}
+	if have := tracer.AccessList(); !reflect.DeepEqual(have, want) {
+		t.Fatalf("access list mismatch:\nhave %+v\nwant %+v", have, want)
+	}
+}
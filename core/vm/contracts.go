@@ -77,6 +77,23 @@ var PrecompiledContractsIstanbul = map[common.Address]PrecompiledContract{
 	common.BytesToAddress([]byte{9}): &blake2F{},
 }
 
+// ActivePrecompiles returns the addresses of the precompiled contracts enabled
+// with the given chain rules.
+func ActivePrecompiles(rules params.Rules) []common.Address {
+	precompiles := PrecompiledContractsHomestead
+	if rules.IsByzantium {
+		precompiles = PrecompiledContractsByzantium
+	}
+	if rules.IsIstanbul {
+		precompiles = PrecompiledContractsIstanbul
+	}
+	addrs := make([]common.Address, 0, len(precompiles))
+	for addr := range precompiles {
+		addrs = append(addrs, addr)
+	}
+	return addrs
+}
+
 // RunPrecompiledContract runs and evaluates the output of a precompiled contract.
 func RunPrecompiledContract(p PrecompiledContract, input []byte, contract *Contract) (ret []byte, err error) {
 	gas := p.RequiredGas(input)
@@ -1,6 +1,8 @@
 package vm
 
 import (
+	"bytes"
+	"math/big"
 	"os"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -18,3 +20,34 @@ var UsingOVM bool
 func init() {
 	UsingOVM = os.Getenv("USING_OVM") == "true"
 }
+
+// decodeOvmResult decodes the (bool success, bytes returndata) tuple returned
+// by the OVM account contracts and the execution manager. The ok flag is false
+// if the result does not start with an ABI encoded bool followed by the rest of
+// the tuple head. The success flag alone decides the outcome of the call. The
+// returned data is exactly the data produced by the target contract, without
+// the ABI padding; if the dynamic bytes value is malformed, everything behind
+// the tuple head is returned instead.
+func decodeOvmResult(result []byte) (success bool, data []byte, ok bool) {
+	if len(result) < 96 {
+		return false, nil, false
+	}
+	switch {
+	case bytes.Equal(result[:32], AbiBytesTrue):
+		success = true
+	case bytes.Equal(result[:32], AbiBytesFalse):
+		success = false
+	default:
+		return false, nil, false
+	}
+	// The offset of the dynamic bytes value must point right behind the
+	// head of the tuple.
+	if new(big.Int).SetBytes(result[32:64]).Cmp(big.NewInt(64)) != 0 {
+		return success, result[96:], true
+	}
+	size := new(big.Int).SetBytes(result[64:96])
+	if !size.IsUint64() || size.Uint64() > uint64(len(result)-96) {
+		return success, result[96:], true
+	}
+	return success, result[96 : 96+size.Uint64()], true
+}
@@ -0,0 +1,123 @@
+package vm
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// encodeOvmResult ABI encodes (bool success, bytes returndata) like the OVM
+// account contracts do.
+func encodeOvmResult(success bool, data []byte) []byte {
+	var out []byte
+	if success {
+		out = append(out, AbiBytesTrue...)
+	} else {
+		out = append(out, AbiBytesFalse...)
+	}
+	out = append(out, math.PaddedBigBytes(big.NewInt(64), 32)...)
+	out = append(out, math.PaddedBigBytes(big.NewInt(int64(len(data))), 32)...)
+	out = append(out, common.RightPadBytes(data, (len(data)+31)/32*32)...)
+	return out
+}
+
+// malformedOffset encodes the result like encodeOvmResult, but with an offset
+// of the return data that does not point behind the tuple head.
+func malformedOffset(success bool, data []byte) []byte {
+	result := encodeOvmResult(success, data)
+	copy(result[32:64], math.PaddedBigBytes(big.NewInt(32), 32))
+	return result
+}
+
+func TestDecodeOvmResult(t *testing.T) {
+	var (
+		errorString = common.FromHex("0x08c379a00000000000000000000000000000000000000000000000000000000000000020000000000000000000000000000000000000000000000000000000000000000d72657665727420726561736f6e00000000000000000000000000000000000000")
+		panicCode   = common.FromHex("0x4e487b710000000000000000000000000000000000000000000000000000000000000011")
+		customError = common.FromHex("0xcf4791810000000000000000000000000000000000000000000000000000000000000001")
+		returnValue = common.FromHex("0x000000000000000000000000000000000000000000000000000000000000002a")
+	)
+	tests := []struct {
+		name    string
+		result  []byte
+		success bool
+		data    []byte
+		ok      bool
+	}{
+		{"return value", encodeOvmResult(true, returnValue), true, returnValue, true},
+		{"empty return", encodeOvmResult(true, nil), true, []byte{}, true},
+		{"Error(string)", encodeOvmResult(false, errorString), false, errorString, true},
+		{"Panic(uint256)", encodeOvmResult(false, panicCode), false, panicCode, true},
+		{"custom error", encodeOvmResult(false, customError), false, customError, true},
+		{"empty revert", encodeOvmResult(false, nil), false, []byte{}, true},
+		{"too short", AbiBytesTrue, false, nil, false},
+		{"invalid bool", append(common.LeftPadBytes([]byte{2}, 32), encodeOvmResult(true, nil)[32:]...), false, nil, false},
+		{"invalid offset", malformedOffset(true, returnValue), true, malformedOffset(true, returnValue)[96:], true},
+		{"length out of bounds", encodeOvmResult(true, returnValue)[:100], true, encodeOvmResult(true, returnValue)[96:100], true},
+		{"revert with invalid offset", malformedOffset(false, errorString), false, malformedOffset(false, errorString)[96:], true},
+		{"revert length out of bounds", encodeOvmResult(false, errorString)[:100], false, encodeOvmResult(false, errorString)[96:100], true},
+	}
+	for _, tt := range tests {
+		success, data, ok := decodeOvmResult(tt.result)
+		if ok != tt.ok {
+			t.Errorf("%s: ok mismatch: have %v, want %v", tt.name, ok, tt.ok)
+			continue
+		}
+		if success != tt.success {
+			t.Errorf("%s: success mismatch: have %v, want %v", tt.name, success, tt.success)
+		}
+		if !bytes.Equal(data, tt.data) {
+			t.Errorf("%s: data mismatch: have %x, want %x", tt.name, data, tt.data)
+		}
+	}
+}
+
+// Tests that the success flag returned by the target of an OVM transaction
+// decides whether the transaction reverts, regardless of how the return data
+// is encoded.
+func TestOvmTargetResultStatus(t *testing.T) {
+	defer func(using bool) { UsingOVM = using }(UsingOVM)
+	UsingOVM = true
+
+	var (
+		executionManager = common.HexToAddress("0xeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee")
+		target           = common.HexToAddress("0xcccccccccccccccccccccccccccccccccccccccc")
+		errorString      = common.FromHex("0x08c379a00000000000000000000000000000000000000000000000000000000000000020000000000000000000000000000000000000000000000000000000000000000d72657665727420726561736f6e00000000000000000000000000000000000000")
+	)
+	tests := []struct {
+		name   string
+		result []byte
+		err    error
+		data   []byte
+	}{
+		{"revert", encodeOvmResult(false, errorString), ErrExecutionReverted, errorString},
+		{"revert with invalid offset", malformedOffset(false, errorString), ErrExecutionReverted, malformedOffset(false, errorString)[96:]},
+		{"revert length out of bounds", encodeOvmResult(false, errorString)[:100], ErrExecutionReverted, encodeOvmResult(false, errorString)[96:100]},
+		{"success with invalid offset", malformedOffset(true, errorString), nil, malformedOffset(true, errorString)[96:]},
+		{"invalid bool", append(common.LeftPadBytes([]byte{2}, 32), encodeOvmResult(true, nil)[32:]...), nil, []byte{}},
+	}
+	for _, tt := range tests {
+		// PUSH1 len PUSH1 12 PUSH1 0 CODECOPY PUSH1 len PUSH1 0 RETURN, followed by the result
+		size := byte(len(tt.result))
+		code := append([]byte{0x60, size, 0x60, 12, 0x60, 0, 0x39, 0x60, size, 0x60, 0, 0xf3}, tt.result...)
+
+		statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		statedb.SetCode(target, code)
+
+		env := NewEVM(Context{BlockNumber: big.NewInt(1)}, statedb, params.TestChainConfig, Config{})
+		env.Context.OvmExecutionManager.Address = executionManager
+
+		ret, _, err := env.Call(AccountRef(executionManager), target, nil, 100000, new(big.Int))
+		if err != tt.err {
+			t.Errorf("%s: error mismatch: have %v, want %v", tt.name, err, tt.err)
+		}
+		if !bytes.Equal(ret, tt.data) {
+			t.Errorf("%s: return data mismatch: have %x, want %x", tt.name, ret, tt.data)
+		}
+	}
+}
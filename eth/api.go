@@ -34,6 +34,8 @@ import (
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
@@ -231,15 +233,16 @@ func (api *PrivateAdminAPI) ImportChain(file string) (bool, error) {
 		}
 	}
 
-	// Run actual the import in pre-configured batches
-	stream := rlp.NewStream(reader, 0)
+	// Run actual the import in pre-configured batches. The legacy format
+	// does not carry the transaction metadata required by OVM chains.
+	stream := core.NewChainExportReader(reader, !vm.UsingOVM)
 
 	blocks, index := make([]*types.Block, 0, 2500), 0
 	for batch := 0; ; batch++ {
 		// Load a batch of blocks from the input file
 		for len(blocks) < cap(blocks) {
-			block := new(types.Block)
-			if err := stream.Decode(block); err == io.EOF {
+			block, err := stream.Next()
+			if err == io.EOF {
 				break
 			} else if err != nil {
 				return false, fmt.Errorf("block %d: failed to parse: %v", index, err)
@@ -301,6 +304,92 @@ func (api *PublicDebugAPI) DumpBlock(blockNr rpc.BlockNumber) (state.Dump, error
 	return stateDb.RawDump(false, false, true), nil
 }
 
+// DumpRangeMaxResults is the maximum number of accounts and storage slots to be
+// returned per page of a state dump.
+const DumpRangeMaxResults = 1024
+
+// StateDumpConfig are the options of a paged state dump.
+type StateDumpConfig struct {
+	Start          hexutil.Bytes `json:"start"`          // Cursor to resume from, or an address to start at
+	Limit          int           `json:"limit"`          // Maximum number of accounts and storage slots per page
+	ExcludeCode    bool          `json:"excludeCode"`    // Whether to omit the contract codes
+	ExcludeStorage bool          `json:"excludeStorage"` // Whether to omit the storage slots
+}
+
+// dumpConfig converts the RPC dump options into a state dump configuration.
+func (config *StateDumpConfig) dumpConfig() (*state.DumpConfig, error) {
+	conf := &state.DumpConfig{Max: DumpRangeMaxResults}
+	if config == nil {
+		return conf, nil
+	}
+	switch len(config.Start) {
+	case 0, common.HashLength, 2 * common.HashLength:
+		conf.Start = config.Start
+	case common.AddressLength:
+		conf.Start = crypto.Keccak256(config.Start)
+	default:
+		return nil, fmt.Errorf("invalid dump cursor length %d", len(config.Start))
+	}
+	if config.Limit > 0 && config.Limit < DumpRangeMaxResults {
+		conf.Max = uint64(config.Limit)
+	}
+	conf.SkipCode = config.ExcludeCode
+	conf.SkipStorage = config.ExcludeStorage
+	return conf, nil
+}
+
+// DumpRange retrieves a single page of the state at a given block. The dump
+// is resumed by passing the returned cursor as the start of the next request.
+func (api *PublicDebugAPI) DumpRange(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash, config *StateDumpConfig) (state.DumpPage, error) {
+	conf, err := config.dumpConfig()
+	if err != nil {
+		return state.DumpPage{}, err
+	}
+	stateDb, _, err := api.eth.APIBackend.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return state.DumpPage{}, err
+	}
+	return stateDb.PagedDump(conf), nil
+}
+
+// StateDump streams the state at a given block to the subscriber, one page per
+// notification. The last page carries no cursor, while the cursor of any other
+// page can be used to resume an interrupted dump.
+func (api *PublicDebugAPI) StateDump(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash, config *StateDumpConfig) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	conf, err := config.dumpConfig()
+	if err != nil {
+		return nil, err
+	}
+	stateDb, _, err := api.eth.APIBackend.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		for {
+			page := stateDb.PagedDump(conf)
+			if err := notifier.Notify(rpcSub.ID, page); err != nil || page.Next == nil {
+				return
+			}
+			conf.Start = page.Next
+
+			select {
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			default:
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
 // PrivateDebugAPI is the collection of Ethereum full node APIs exposed over
 // the private debugging endpoint.
 type PrivateDebugAPI struct {
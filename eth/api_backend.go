@@ -61,6 +61,10 @@ func (b *EthAPIBackend) IsSyncing() bool {
 	return b.eth.syncService.IsSyncing()
 }
 
+func (b *EthAPIBackend) IsDegraded() bool {
+	return b.eth.syncService.IsDegraded()
+}
+
 func (b *EthAPIBackend) GasLimit() uint64 {
 	return b.gasLimit
 }
@@ -213,7 +217,7 @@ func (b *EthAPIBackend) StateAndHeaderByNumber(ctx context.Context, number rpc.B
 	if header == nil {
 		return nil, nil, errors.New("header not found")
 	}
-	stateDb, err := b.eth.BlockChain().StateAt(header.Root)
+	stateDb, err := b.eth.stateRegen.StateAt(header, b.eth.config.StateReexec)
 	return stateDb, header, err
 }
 
@@ -232,7 +236,7 @@ func (b *EthAPIBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockN
 		if blockNrOrHash.RequireCanonical && b.eth.blockchain.GetCanonicalHash(header.Number.Uint64()) != hash {
 			return nil, nil, errors.New("hash is not currently canonical")
 		}
-		stateDb, err := b.eth.BlockChain().StateAt(header.Root)
+		stateDb, err := b.eth.stateRegen.StateAt(header, b.eth.config.StateReexec)
 		return stateDb, header, err
 	}
 	return nil, nil, errors.New("invalid arguments; neither block nor hash specified")
@@ -258,12 +262,14 @@ func (b *EthAPIBackend) GetTd(blockHash common.Hash) *big.Int {
 	return b.eth.blockchain.GetTdByHash(blockHash)
 }
 
-func (b *EthAPIBackend) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header) (*vm.EVM, func() error, error) {
+func (b *EthAPIBackend) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header, vmCfg *vm.Config) (*vm.EVM, func() error, error) {
 	state.SetBalance(msg.From(), math.MaxBig256)
 	vmError := func() error { return nil }
-
+	if vmCfg == nil {
+		vmCfg = b.eth.blockchain.GetVMConfig()
+	}
 	context := core.NewEVMContext(msg, header, b.eth.BlockChain(), nil)
-	return vm.NewEVM(context, state, b.eth.blockchain.Config(), *b.eth.blockchain.GetVMConfig()), vmError, nil
+	return vm.NewEVM(context, state, b.eth.blockchain.Config(), *vmCfg), vmError, nil
 }
 
 func (b *EthAPIBackend) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription {
@@ -356,7 +362,12 @@ func (b *EthAPIBackend) TxPoolContent() (map[common.Address]types.Transactions,
 	return b.eth.TxPool().Content()
 }
 
+// SubscribeNewTxsEvent subscribes to the transactions accepted for execution,
+// which are fed by the sync service in OVM mode as they bypass the tx pool.
 func (b *EthAPIBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subscription {
+	if b.UsingOVM {
+		return b.eth.syncService.SubscribeNewTxsEvent(ch)
+	}
 	return b.eth.TxPool().SubscribeNewTxsEvent(ch)
 }
 
@@ -400,6 +411,10 @@ func (b *EthAPIBackend) RPCGasCap() *big.Int {
 	return b.eth.config.RPCGasCap
 }
 
+func (b *EthAPIBackend) LogQueryLimits() (uint64, int) {
+	return b.eth.config.LogQueryLimits.MaxBlockRange, b.eth.config.LogQueryLimits.MaxResults
+}
+
 func (b *EthAPIBackend) BloomStatus() (uint64, uint64) {
 	sections, _, _ := b.eth.bloomIndexer.Sections()
 	return params.BloomBitsBlocks, sections
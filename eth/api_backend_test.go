@@ -5,9 +5,20 @@ import (
 	"fmt"
 	"math/big"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rollup"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 func TestGasLimit(t *testing.T) {
@@ -40,3 +51,290 @@ func TestGasLimit(t *testing.T) {
 		t.Fatalf("Unexpected error type: %s", err)
 	}
 }
+
+// revertCode returns contract code that reverts with the given data.
+func revertCode(data []byte) []byte {
+	// PUSH1 len PUSH1 12 PUSH1 0 CODECOPY PUSH1 len PUSH1 0 REVERT, followed by the data
+	size := byte(len(data))
+	return append([]byte{0x60, size, 0x60, 12, 0x60, 0, 0x39, 0x60, size, 0x60, 0, 0xfd}, data...)
+}
+
+// Tests that the data of a reverted eth_call or eth_estimateGas reaches the
+// client unchanged as the data of the returned error, while Error(string) and
+// Panic(uint256) payloads are decoded into the error message.
+func TestCallRevertData(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		message string
+	}{
+		{
+			name:    "custom error",
+			data:    "0xdeadbeef",
+			message: "execution reverted",
+		},
+		{
+			name:    "Error(string)",
+			data:    "0x08c379a00000000000000000000000000000000000000000000000000000000000000020000000000000000000000000000000000000000000000000000000000000000d72657665727420726561736f6e00000000000000000000000000000000000000",
+			message: "execution reverted: revert reason",
+		},
+		{
+			name:    "Panic(uint256)",
+			data:    "0x4e487b710000000000000000000000000000000000000000000000000000000000000011",
+			message: "execution reverted: arithmetic underflow or overflow",
+		},
+	}
+	var (
+		db    = rawdb.NewMemoryDatabase()
+		alloc = make(core.GenesisAlloc)
+	)
+	for i, tt := range tests {
+		alloc[common.BigToAddress(big.NewInt(int64(0xc0de+i)))] = core.GenesisAccount{Code: revertCode(common.FromHex(tt.data)), Balance: new(big.Int)}
+	}
+	gspec := &core.Genesis{Config: params.TestChainConfig, Alloc: alloc}
+	gspec.MustCommit(db)
+	chain, err := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	backend := &EthAPIBackend{eth: &Ethereum{
+		config:     &Config{},
+		blockchain: chain,
+		chainDb:    db,
+		stateRegen: newStateRegenerator(chain, db, 1, 16),
+	}, gasLimit: 1000000}
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("eth", ethapi.NewPublicBlockChainAPI(backend)); err != nil {
+		t.Fatalf("failed to register eth api: %v", err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	checkRevert := func(name string, err error, data, message string) {
+		t.Helper()
+		if err == nil {
+			t.Errorf("%s: reverting call succeeded", name)
+			return
+		}
+		if code := err.(rpc.Error).ErrorCode(); code != 3 {
+			t.Errorf("%s: error code mismatch: have %d, want 3", name, code)
+		}
+		if have := err.(rpc.DataError).ErrorData(); have != data {
+			t.Errorf("%s: revert data mismatch: have %v, want %v", name, have, data)
+		}
+		if msg := err.Error(); msg != message {
+			t.Errorf("%s: error message mismatch: have %q, want %q", name, msg, message)
+		}
+	}
+	for i, tt := range tests {
+		var (
+			from     = common.Address{0x01}
+			contract = common.BigToAddress(big.NewInt(int64(0xc0de + i)))
+			input    = hexutil.Bytes{}
+			gas      = hexutil.Uint64(100000)
+			result   hexutil.Bytes
+		)
+		err := client.Call(&result, "eth_call", map[string]interface{}{"from": from, "to": contract}, "latest")
+		checkRevert("eth_call "+tt.name, err, tt.data, tt.message)
+
+		// The pending block is only known by the miner, estimate on top of the
+		// latest block instead
+		args := ethapi.CallArgs{From: &from, To: &contract, Gas: &gas, Data: &input}
+		_, err = ethapi.DoEstimateGas(context.Background(), backend, args, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), nil)
+		checkRevert("eth_estimateGas "+tt.name, err, tt.data, tt.message)
+	}
+}
+
+// Tests that in OVM mode the new transactions are fed by the sync service, as
+// they bypass the transaction pool.
+func TestSubscribeNewTxsEventOVM(t *testing.T) {
+	var (
+		key, _ = crypto.GenerateKey()
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		db     = rawdb.NewMemoryDatabase()
+		gspec  = &core.Genesis{Config: params.TestChainConfig, Alloc: core.GenesisAlloc{addr: {Balance: big.NewInt(1000000000)}}}
+	)
+	gspec.MustCommit(db)
+	chain, err := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	pool := core.NewTxPool(core.DefaultTxPoolConfig, gspec.Config, chain)
+	defer pool.Stop()
+
+	service, err := rollup.NewSyncService(context.Background(), rollup.Config{CanonicalTransactionChainDeployHeight: new(big.Int)}, pool, chain, db)
+	if err != nil {
+		t.Fatalf("failed to create sync service: %v", err)
+	}
+	backend := &EthAPIBackend{eth: &Ethereum{txPool: pool, syncService: service}, UsingOVM: true}
+
+	txs := make(chan core.NewTxsEvent, 1)
+	sub := backend.SubscribeNewTxsEvent(txs)
+	defer sub.Unsubscribe()
+
+	tx, err := types.SignTx(types.NewTransaction(0, common.Address{0x01}, new(big.Int), 21000, big.NewInt(1), nil), types.NewEIP155Signer(gspec.Config.ChainID), key)
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	if err := service.ApplyTransaction(tx); err != nil {
+		t.Fatalf("failed to apply transaction: %v", err)
+	}
+	select {
+	case ev := <-txs:
+		if len(ev.Txs) != 1 || ev.Txs[0].Hash() != tx.Hash() {
+			t.Fatalf("transaction mismatch: have %v, want %x", ev.Txs, tx.Hash())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("transaction of the sync service not delivered")
+	}
+}
+
+// Tests that the L1 to L2 transactions of the chain can be looked up by their
+// queue index and L1 sender through the rollup API.
+func TestEnqueueRPCs(t *testing.T) {
+	var (
+		db       = rawdb.NewMemoryDatabase()
+		senderA  = common.HexToAddress("0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa")
+		senderB  = common.HexToAddress("0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb")
+		reverter = common.HexToAddress("0xc0de")
+		// PUSH1 0 PUSH1 0 REVERT
+		code    = common.FromHex("0x60006000fd")
+		gspec   = &core.Genesis{Config: params.TestChainConfig, Alloc: core.GenesisAlloc{reverter: {Code: code, Balance: new(big.Int)}}}
+		genesis = gspec.MustCommit(db)
+		senders = []common.Address{senderA, senderB, senderA}
+		targets = []common.Address{{0x11}, reverter, {0x11}}
+	)
+	chain, err := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	blocks, _ := core.GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 3, func(i int, gen *core.BlockGen) {
+		index := uint64(i)
+		tx := types.NewTransaction(index, targets[i], new(big.Int), 100000, new(big.Int), []byte{byte(i)})
+		tx.SetTransactionMeta(types.NewTransactionMeta(big.NewInt(10), 1000, &senders[i], types.SighashEIP155, types.QueueOriginL1ToL2, &index, &index, nil))
+		gen.AddTx(tx)
+	})
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	backend := &EthAPIBackend{eth: &Ethereum{
+		config:     &Config{},
+		blockchain: chain,
+		chainDb:    db,
+	}}
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("rollup", ethapi.NewPublicRollupAPI(backend)); err != nil {
+		t.Fatalf("failed to register rollup api: %v", err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	type enqueue struct {
+		QueueIndex      hexutil.Uint64  `json:"queueIndex"`
+		Index           *hexutil.Uint64 `json:"index"`
+		L1TxOrigin      *common.Address `json:"l1TxOrigin"`
+		Target          *common.Address `json:"target"`
+		TransactionHash common.Hash     `json:"transactionHash"`
+		BlockHash       common.Hash     `json:"blockHash"`
+		BlockNumber     hexutil.Uint64  `json:"blockNumber"`
+	}
+	checkEnqueue := func(have *enqueue, index int) {
+		t.Helper()
+		block := blocks[index]
+		if have == nil {
+			t.Fatalf("enqueue %d: not found", index)
+		}
+		if uint64(have.QueueIndex) != uint64(index) || have.Index == nil || uint64(*have.Index) != uint64(index) {
+			t.Errorf("enqueue %d: index mismatch: have %d/%v", index, have.QueueIndex, have.Index)
+		}
+		if have.L1TxOrigin == nil || *have.L1TxOrigin != senders[index] {
+			t.Errorf("enqueue %d: origin mismatch: have %v, want %x", index, have.L1TxOrigin, senders[index])
+		}
+		if have.Target == nil || *have.Target != targets[index] {
+			t.Errorf("enqueue %d: target mismatch: have %v, want %x", index, have.Target, targets[index])
+		}
+		if have.TransactionHash != block.Transactions()[0].Hash() || have.BlockHash != block.Hash() || uint64(have.BlockNumber) != block.NumberU64() {
+			t.Errorf("enqueue %d: position mismatch: have %x/%x/%d", index, have.TransactionHash, have.BlockHash, have.BlockNumber)
+		}
+	}
+
+	// Look up the enqueues by queue index
+	for i := range blocks {
+		var result *enqueue
+		if err := client.Call(&result, "rollup_getEnqueueByIndex", hexutil.Uint64(i)); err != nil {
+			t.Fatalf("enqueue %d: failed to retrieve: %v", i, err)
+		}
+		checkEnqueue(result, i)
+	}
+	var missing *enqueue
+	if err := client.Call(&missing, "rollup_getEnqueueByIndex", hexutil.Uint64(3)); err != nil {
+		t.Fatalf("failed to retrieve pending enqueue: %v", err)
+	}
+	if missing != nil {
+		t.Errorf("pending enqueue returned: %v", missing)
+	}
+
+	// Look up the enqueues by L1 sender
+	zero, one := hexutil.Uint64(0), hexutil.Uint64(1)
+	tests := []struct {
+		sender common.Address
+		start  uint64
+		limit  *hexutil.Uint64
+		want   []int
+	}{
+		{senderA, 0, nil, []int{0, 2}},
+		{senderA, 1, nil, []int{2}},
+		{senderA, 0, &one, []int{0}},
+		{senderA, 0, &zero, []int{}},
+		{senderB, 0, nil, []int{1}},
+		{common.Address{0x01}, 0, nil, []int{}},
+	}
+	for i, tt := range tests {
+		var result []*enqueue
+		if err := client.Call(&result, "rollup_getEnqueuesBySender", tt.sender, hexutil.Uint64(tt.start), tt.limit); err != nil {
+			t.Fatalf("test %d: failed to retrieve enqueues: %v", i, err)
+		}
+		if len(result) != len(tt.want) {
+			t.Fatalf("test %d: enqueue count mismatch: have %d, want %d", i, len(result), len(tt.want))
+		}
+		for j, index := range tt.want {
+			checkEnqueue(result[j], index)
+		}
+	}
+	// Check the execution status of the enqueues
+	statuses := []string{"success", "failed", "success", "pending"}
+	for i, want := range statuses {
+		var result struct {
+			QueueIndex      hexutil.Uint64  `json:"queueIndex"`
+			Status          string          `json:"status"`
+			TransactionHash *common.Hash    `json:"transactionHash"`
+			BlockNumber     *hexutil.Uint64 `json:"blockNumber"`
+		}
+		if err := client.Call(&result, "rollup_getEnqueueStatus", hexutil.Uint64(i)); err != nil {
+			t.Fatalf("enqueue %d: failed to retrieve status: %v", i, err)
+		}
+		if result.Status != want {
+			t.Errorf("enqueue %d: status mismatch: have %s, want %s", i, result.Status, want)
+		}
+		if i >= len(blocks) {
+			if result.TransactionHash != nil || result.BlockNumber != nil {
+				t.Errorf("enqueue %d: pending enqueue has position %v/%v", i, result.TransactionHash, result.BlockNumber)
+			}
+			continue
+		}
+		if result.TransactionHash == nil || *result.TransactionHash != blocks[i].Transactions()[0].Hash() {
+			t.Errorf("enqueue %d: transaction hash mismatch: have %v", i, result.TransactionHash)
+		}
+		if result.BlockNumber == nil || uint64(*result.BlockNumber) != blocks[i].NumberU64() {
+			t.Errorf("enqueue %d: block number mismatch: have %v", i, result.BlockNumber)
+		}
+	}
+}
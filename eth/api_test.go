@@ -64,7 +64,7 @@ func (h resultHash) Less(i, j int) bool { return bytes.Compare(h[i].Bytes(), h[j
 func TestAccountRange(t *testing.T) {
 	var (
 		statedb  = state.NewDatabase(rawdb.NewMemoryDatabase())
-		state, _ = state.New(common.Hash{}, statedb)
+		state, _ = state.New(common.Hash{}, statedb, nil)
 		addrs    = [AccountRangeMaxResults * 2]common.Address{}
 		m        = map[common.Address]bool{}
 	)
@@ -162,7 +162,7 @@ func TestAccountRange(t *testing.T) {
 func TestEmptyAccountRange(t *testing.T) {
 	var (
 		statedb  = state.NewDatabase(rawdb.NewMemoryDatabase())
-		state, _ = state.New(common.Hash{}, statedb)
+		state, _ = state.New(common.Hash{}, statedb, nil)
 	)
 
 	state.Commit(true)
@@ -188,7 +188,7 @@ func TestEmptyAccountRange(t *testing.T) {
 func TestStorageRangeAt(t *testing.T) {
 	// Create a state where account 0x010000... has a few storage entries.
 	var (
-		state, _ = state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+		state, _ = state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
 		addr     = common.Address{0x01}
 		keys     = []common.Hash{ // hashes of Keys of storage
 			common.HexToHash("340dd630ad21bf010b4e676dbfa9ba9a02175262d1fa356232cfde6cb5b47ef2"),
@@ -245,3 +245,40 @@ func TestStorageRangeAt(t *testing.T) {
 		}
 	}
 }
+
+// Tests that the dump options of the RPC API are converted and sanitized.
+func TestStateDumpConfig(t *testing.T) {
+	addr := common.HexToAddress("0x01")
+	cursor := append(crypto.Keccak256(addr[:]), crypto.Keccak256([]byte{0x01})...)
+
+	tests := []struct {
+		config *StateDumpConfig
+		start  []byte
+		max    uint64
+		fail   bool
+	}{
+		{config: nil, max: DumpRangeMaxResults},
+		{config: &StateDumpConfig{Limit: 10}, max: 10},
+		{config: &StateDumpConfig{Limit: 2 * DumpRangeMaxResults}, max: DumpRangeMaxResults},
+		{config: &StateDumpConfig{Start: addr[:]}, start: crypto.Keccak256(addr[:]), max: DumpRangeMaxResults},
+		{config: &StateDumpConfig{Start: cursor[:32]}, start: cursor[:32], max: DumpRangeMaxResults},
+		{config: &StateDumpConfig{Start: cursor}, start: cursor, max: DumpRangeMaxResults},
+		{config: &StateDumpConfig{Start: cursor[:33]}, fail: true},
+	}
+	for i, tt := range tests {
+		conf, err := tt.config.dumpConfig()
+		if tt.fail {
+			if err == nil {
+				t.Errorf("test %d: expected failure", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: unexpected failure: %v", i, err)
+			continue
+		}
+		if !bytes.Equal(conf.Start, tt.start) || conf.Max != tt.max {
+			t.Errorf("test %d: config mismatch: have start %x max %d, want start %x max %d", i, conf.Start, conf.Max, tt.start, tt.max)
+		}
+	}
+}
@@ -145,6 +145,49 @@ func (api *PrivateDebugAPI) traceChain(ctx context.Context, start, end *types.Bl
 	}
 	sub := notifier.CreateSubscription()
 
+	reexec := defaultTraceReexec
+	if config != nil && config.Reexec != nil {
+		reexec = *config.Reexec
+	}
+	trace := func(task *blockTraceTask) {
+		signer := types.MakeSigner(api.eth.blockchain.Config(), task.block.Number())
+
+		// Trace all the transactions contained within
+		for i, tx := range task.block.Transactions() {
+			msg, _ := tx.AsMessage(signer)
+			vmctx := core.NewEVMContext(msg, task.block.Header(), api.eth.blockchain, nil)
+
+			res, err := api.traceTx(ctx, msg, vmctx, task.statedb, config)
+			if err != nil {
+				task.results[i] = &txTraceResult{Error: err.Error()}
+				log.Warn("Tracing failed", "hash", tx.Hash(), "block", task.block.NumberU64(), "err", err)
+				break
+			}
+			// Only delete empty objects if EIP158/161 (a.k.a Spurious Dragon) is in effect
+			task.statedb.Finalise(api.eth.blockchain.Config().IsEIP158(task.block.Number()))
+			task.results[i] = &txTraceResult{Result: res}
+		}
+	}
+	emit := func(task *blockTraceTask) {
+		if len(task.results) > 0 || task.block.NumberU64() == end.NumberU64() {
+			notifier.Notify(sub.ID, &blockTraceResult{
+				Block:  hexutil.Uint64(task.block.NumberU64()),
+				Hash:   task.block.Hash(),
+				Traces: task.results,
+			})
+		}
+	}
+	if err := api.traceBlocks(notifier, start, end, reexec, runtime.NumCPU(), trace, emit); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// traceBlocks re-executes all the blocks after start up to and including end,
+// handing each of them over to a pool of concurrent workers running trace, and
+// passing the traced blocks in order to emit. Tracing stops early if the
+// subscription of the notifier is closed.
+func (api *PrivateDebugAPI) traceBlocks(notifier *rpc.Notifier, start, end *types.Block, reexec uint64, threads int, trace func(task *blockTraceTask), emit func(task *blockTraceTask)) error {
 	// Ensure we have a valid starting state before doing any work
 	origin := start.NumberU64()
 	database := state.NewDatabaseWithCache(api.eth.ChainDb(), 16) // Chain tracing will probably start at genesis
@@ -152,23 +195,19 @@ func (api *PrivateDebugAPI) traceChain(ctx context.Context, start, end *types.Bl
 	if number := start.NumberU64(); number > 0 {
 		start = api.eth.blockchain.GetBlock(start.ParentHash(), start.NumberU64()-1)
 		if start == nil {
-			return nil, fmt.Errorf("parent block #%d not found", number-1)
+			return fmt.Errorf("parent block #%d not found", number-1)
 		}
 	}
-	statedb, err := state.New(start.Root(), database)
+	statedb, err := state.New(start.Root(), database, nil)
 	if err != nil {
 		// If the starting state is missing, allow some number of blocks to be reexecuted
-		reexec := defaultTraceReexec
-		if config != nil && config.Reexec != nil {
-			reexec = *config.Reexec
-		}
 		// Find the most recent block that has the state available
 		for i := uint64(0); i < reexec; i++ {
 			start = api.eth.blockchain.GetBlock(start.ParentHash(), start.NumberU64()-1)
 			if start == nil {
 				break
 			}
-			if statedb, err = state.New(start.Root(), database); err == nil {
+			if statedb, err = state.New(start.Root(), database, nil); err == nil {
 				break
 			}
 		}
@@ -176,16 +215,15 @@ func (api *PrivateDebugAPI) traceChain(ctx context.Context, start, end *types.Bl
 		if err != nil {
 			switch err.(type) {
 			case *trie.MissingNodeError:
-				return nil, errors.New("required historical state unavailable")
+				return errors.New("required historical state unavailable")
 			default:
-				return nil, err
+				return err
 			}
 		}
 	}
 	// Execute all the transaction contained within the chain concurrently for each block
 	blocks := int(end.NumberU64() - origin)
 
-	threads := runtime.NumCPU()
 	if threads > blocks {
 		threads = blocks
 	}
@@ -201,23 +239,8 @@ func (api *PrivateDebugAPI) traceChain(ctx context.Context, start, end *types.Bl
 
 			// Fetch and execute the next block trace tasks
 			for task := range tasks {
-				signer := types.MakeSigner(api.eth.blockchain.Config(), task.block.Number())
-
-				// Trace all the transactions contained within
-				for i, tx := range task.block.Transactions() {
-					msg, _ := tx.AsMessage(signer)
-					vmctx := core.NewEVMContext(msg, task.block.Header(), api.eth.blockchain, nil)
-
-					res, err := api.traceTx(ctx, msg, vmctx, task.statedb, config)
-					if err != nil {
-						task.results[i] = &txTraceResult{Error: err.Error()}
-						log.Warn("Tracing failed", "hash", tx.Hash(), "block", task.block.NumberU64(), "err", err)
-						break
-					}
-					// Only delete empty objects if EIP158/161 (a.k.a Spurious Dragon) is in effect
-					task.statedb.Finalise(api.eth.blockchain.Config().IsEIP158(task.block.Number()))
-					task.results[i] = &txTraceResult{Result: res}
-				}
+				trace(task)
+
 				// Stream the result back to the user or abort on teardown
 				select {
 				case results <- task:
@@ -319,35 +342,28 @@ func (api *PrivateDebugAPI) traceChain(ctx context.Context, start, end *types.Bl
 		}
 	}()
 
-	// Keep reading the trace results and stream the to the user
+	// Keep reading the trace results and stream them to the user in order
 	go func() {
 		var (
-			done = make(map[uint64]*blockTraceResult)
+			done = make(map[uint64]*blockTraceTask)
 			next = origin + 1
 		)
 		for res := range results {
 			// Queue up next received result
-			result := &blockTraceResult{
-				Block:  hexutil.Uint64(res.block.NumberU64()),
-				Hash:   res.block.Hash(),
-				Traces: res.results,
-			}
-			done[uint64(result.Block)] = result
+			done[res.block.NumberU64()] = res
 
 			// Dereference any paret tries held in memory by this task
 			database.TrieDB().Dereference(res.rootref)
 
 			// Stream completed traces to the user, aborting on the first error
-			for result, ok := done[next]; ok; result, ok = done[next] {
-				if len(result.Traces) > 0 || next == end.NumberU64() {
-					notifier.Notify(sub.ID, result)
-				}
+			for task, ok := done[next]; ok; task, ok = done[next] {
+				emit(task)
 				delete(done, next)
 				next++
 			}
 		}
 	}()
-	return sub, nil
+	return nil
 }
 
 // TraceBlockByNumber returns the structured logs created during the execution of
@@ -634,69 +650,7 @@ func containsTx(block *types.Block, hash common.Hash) bool {
 // If no state is locally available for the given block, a number of blocks are
 // attempted to be reexecuted to generate the desired state.
 func (api *PrivateDebugAPI) computeStateDB(block *types.Block, reexec uint64) (*state.StateDB, error) {
-	// If we have the state fully available, use that
-	statedb, err := api.eth.blockchain.StateAt(block.Root())
-	if err == nil {
-		return statedb, nil
-	}
-	// Otherwise try to reexec blocks until we find a state or reach our limit
-	origin := block.NumberU64()
-	database := state.NewDatabaseWithCache(api.eth.ChainDb(), 16)
-
-	for i := uint64(0); i < reexec; i++ {
-		block = api.eth.blockchain.GetBlock(block.ParentHash(), block.NumberU64()-1)
-		if block == nil {
-			break
-		}
-		if statedb, err = state.New(block.Root(), database); err == nil {
-			break
-		}
-	}
-	if err != nil {
-		switch err.(type) {
-		case *trie.MissingNodeError:
-			return nil, fmt.Errorf("required historical state unavailable (reexec=%d)", reexec)
-		default:
-			return nil, err
-		}
-	}
-	// State was available at historical point, regenerate
-	var (
-		start  = time.Now()
-		logged time.Time
-		proot  common.Hash
-	)
-	for block.NumberU64() < origin {
-		// Print progress logs if long enough time elapsed
-		if time.Since(logged) > 8*time.Second {
-			log.Info("Regenerating historical state", "block", block.NumberU64()+1, "target", origin, "remaining", origin-block.NumberU64()-1, "elapsed", time.Since(start))
-			logged = time.Now()
-		}
-		// Retrieve the next block to regenerate and process it
-		if block = api.eth.blockchain.GetBlockByNumber(block.NumberU64() + 1); block == nil {
-			return nil, fmt.Errorf("block #%d not found", block.NumberU64()+1)
-		}
-		_, _, _, err := api.eth.blockchain.Processor().Process(block, statedb, vm.Config{})
-		if err != nil {
-			return nil, fmt.Errorf("processing block %d failed: %v", block.NumberU64(), err)
-		}
-		// Finalize the state so any modifications are written to the trie
-		root, err := statedb.Commit(api.eth.blockchain.Config().IsEIP158(block.Number()))
-		if err != nil {
-			return nil, err
-		}
-		if err := statedb.Reset(root); err != nil {
-			return nil, fmt.Errorf("state reset after block %d failed: %v", block.NumberU64(), err)
-		}
-		database.TrieDB().Reference(root, common.Hash{})
-		if proot != (common.Hash{}) {
-			database.TrieDB().Dereference(proot)
-		}
-		proot = root
-	}
-	nodes, imgs := database.TrieDB().Size()
-	log.Info("Historical state regenerated", "block", block.NumberU64(), "elapsed", time.Since(start), "nodes", nodes, "preimages", imgs)
-	return statedb, nil
+	return api.eth.stateRegen.StateAt(block.Header(), reexec)
 }
 
 // TraceTransaction returns the structured logs created during the execution of EVM
@@ -723,7 +677,7 @@ func (api *PrivateDebugAPI) TraceTransaction(ctx context.Context, hash common.Ha
 // executes the given message in the provided environment. The return value will
 // be tracer dependent.
 func (api *PrivateDebugAPI) traceTx(ctx context.Context, message core.Message, vmctx vm.Context, statedb *state.StateDB, config *TraceConfig) (interface{}, error) {
-	// Assemble the structured logger or the JavaScript tracer
+	// Assemble the structured logger, the native or the JavaScript tracer
 	var (
 		tracer vm.Tracer
 		err    error
@@ -737,15 +691,21 @@ func (api *PrivateDebugAPI) traceTx(ctx context.Context, message core.Message, v
 				return nil, err
 			}
 		}
-		// Constuct the JavaScript tracer to execute with
-		if tracer, err = tracers.New(*config.Tracer); err != nil {
+		// Construct the native tracer if one exists by that name, falling back to
+		// the JavaScript tracer otherwise
+		var resultTracer tracers.ResultTracer
+		if native, ok := tracers.NewNative(*config.Tracer); ok {
+			resultTracer = native
+		} else if resultTracer, err = tracers.New(*config.Tracer); err != nil {
 			return nil, err
 		}
+		tracer = resultTracer
+
 		// Handle timeouts and RPC cancellations
 		deadlineCtx, cancel := context.WithTimeout(ctx, timeout)
 		go func() {
 			<-deadlineCtx.Done()
-			tracer.(*tracers.Tracer).Stop(errors.New("execution timeout"))
+			resultTracer.Stop(errors.New("execution timeout"))
 		}()
 		defer cancel()
 
@@ -772,7 +732,7 @@ func (api *PrivateDebugAPI) traceTx(ctx context.Context, message core.Message, v
 			StructLogs:  ethapi.FormatLogs(tracer.StructLogs()),
 		}, nil
 
-	case *tracers.Tracer:
+	case tracers.ResultTracer:
 		return tracer.GetResult()
 
 	default:
@@ -0,0 +1,285 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// filterTracer is the tracer producing the output of filtered traces.
+const filterTracer = "callTracer"
+
+// TraceCursor is a position in the chain to resume a trace filter from. The
+// transaction at the given index of the given block is the next one traced.
+type TraceCursor struct {
+	Block hexutil.Uint64 `json:"block"`
+	Index hexutil.Uint   `json:"index"`
+}
+
+// TraceFilterArgs holds the parameters of a trace filter. The block range is
+// inclusive and defaults to the whole chain. Transactions are reported if any
+// of their calls matches all the non-empty address and selector criteria.
+type TraceFilterArgs struct {
+	FromBlock   *rpc.BlockNumber `json:"fromBlock"`
+	ToBlock     *rpc.BlockNumber `json:"toBlock"`
+	FromAddress []common.Address `json:"fromAddress"`
+	ToAddress   []common.Address `json:"toAddress"`
+	Selector    []hexutil.Bytes  `json:"selector"`
+
+	Threads *int         `json:"threads"` // Number of blocks traced concurrently
+	Cursor  *TraceCursor `json:"cursor"`  // Position to resume a previous trace from
+	Timeout *string      `json:"timeout"`
+	Reexec  *uint64      `json:"reexec"`
+}
+
+// filterTraceResult is a single notification of a trace filter, carrying either
+// the call trace of a matching transaction or, once the whole range has been
+// traced, only the final cursor.
+type filterTraceResult struct {
+	Block     hexutil.Uint64 `json:"block"`
+	BlockHash common.Hash    `json:"blockHash"`
+	TxHash    *common.Hash   `json:"txHash,omitempty"`
+	TxIndex   *hexutil.Uint  `json:"txIndex,omitempty"`
+	Result    interface{}    `json:"result,omitempty"`
+	Error     string         `json:"error,omitempty"`
+	Cursor    TraceCursor    `json:"cursor"` // Position to resume after this notification
+}
+
+// callTraceFrame is the subset of a call tracer frame needed for filtering.
+type callTraceFrame struct {
+	From  common.Address    `json:"from"`
+	To    common.Address    `json:"to"`
+	Input hexutil.Bytes     `json:"input"`
+	Calls []*callTraceFrame `json:"calls"`
+}
+
+// traceFilter is the set of criteria a call needs to match.
+type traceFilter struct {
+	from      map[common.Address]struct{}
+	to        map[common.Address]struct{}
+	selectors map[[4]byte]struct{}
+}
+
+// newTraceFilter creates a trace filter from the user supplied criteria.
+func newTraceFilter(args *TraceFilterArgs) (*traceFilter, error) {
+	f := &traceFilter{
+		from:      make(map[common.Address]struct{}),
+		to:        make(map[common.Address]struct{}),
+		selectors: make(map[[4]byte]struct{}),
+	}
+	for _, addr := range args.FromAddress {
+		f.from[addr] = struct{}{}
+	}
+	for _, addr := range args.ToAddress {
+		f.to[addr] = struct{}{}
+	}
+	for _, sel := range args.Selector {
+		if len(sel) != 4 {
+			return nil, fmt.Errorf("invalid selector %v, want 4 bytes", sel)
+		}
+		var key [4]byte
+		copy(key[:], sel)
+		f.selectors[key] = struct{}{}
+	}
+	return f, nil
+}
+
+// match returns whether the given call or any of its inner calls matches the
+// filter criteria.
+func (f *traceFilter) match(frame *callTraceFrame) bool {
+	if f.matchCall(frame) {
+		return true
+	}
+	for _, call := range frame.Calls {
+		if f.match(call) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchCall returns whether the given call, disregarding its inner calls,
+// matches the filter criteria.
+func (f *traceFilter) matchCall(frame *callTraceFrame) bool {
+	if _, ok := f.from[frame.From]; len(f.from) > 0 && !ok {
+		return false
+	}
+	if _, ok := f.to[frame.To]; len(f.to) > 0 && !ok {
+		return false
+	}
+	if len(f.selectors) > 0 {
+		if len(frame.Input) < 4 {
+			return false
+		}
+		var key [4]byte
+		copy(key[:], frame.Input)
+		if _, ok := f.selectors[key]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// TraceFilter traces all the transactions of a block range with the call tracer
+// and streams the traces of the ones matching the filter criteria, in chain
+// order. Every notification carries a cursor which can be passed back to resume
+// tracing right after it, and a final notification without a transaction marks
+// the end of the range.
+func (api *PrivateDebugAPI) TraceFilter(ctx context.Context, args TraceFilterArgs) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	filter, err := newTraceFilter(&args)
+	if err != nil {
+		return nil, err
+	}
+	// Resolve the block range to trace, narrowed down by the cursor if resuming
+	first, last := uint64(0), api.eth.blockchain.CurrentBlock().NumberU64()
+	if args.FromBlock != nil && *args.FromBlock >= 0 {
+		first = uint64(*args.FromBlock)
+	}
+	if args.ToBlock != nil && *args.ToBlock >= 0 && uint64(*args.ToBlock) < last {
+		last = uint64(*args.ToBlock)
+	}
+	var skip uint64
+	if args.Cursor != nil {
+		if uint64(args.Cursor.Block) < first {
+			return nil, fmt.Errorf("cursor block #%d before start block #%d", args.Cursor.Block, first)
+		}
+		first, skip = uint64(args.Cursor.Block), uint64(args.Cursor.Index)
+	}
+	if first > last {
+		return nil, fmt.Errorf("end block #%d needs to come after start block #%d", last, first)
+	}
+	start := api.eth.blockchain.GetBlockByNumber(first)
+	if start == nil {
+		return nil, fmt.Errorf("start block #%d not found", first)
+	}
+	end := api.eth.blockchain.GetBlockByNumber(last)
+	if end == nil {
+		return nil, fmt.Errorf("end block #%d not found", last)
+	}
+	// Block tracing excludes the starting block, so start from its parent
+	if first > 0 {
+		if start = api.eth.blockchain.GetBlock(start.ParentHash(), first-1); start == nil {
+			return nil, fmt.Errorf("parent block #%d not found", first-1)
+		}
+	}
+	threads := runtime.NumCPU()
+	if args.Threads != nil {
+		if *args.Threads < 1 {
+			return nil, errors.New("at least one tracing thread required")
+		}
+		if *args.Threads < threads {
+			threads = *args.Threads
+		}
+	}
+	reexec := defaultTraceReexec
+	if args.Reexec != nil {
+		reexec = *args.Reexec
+	}
+	tracer := filterTracer
+	config := &TraceConfig{Tracer: &tracer, Timeout: args.Timeout, Reexec: args.Reexec}
+
+	// Trace the range, keeping only the matching transactions of each block
+	trace := func(task *blockTraceTask) {
+		var (
+			number = task.block.NumberU64()
+			signer = types.MakeSigner(api.eth.blockchain.Config(), task.block.Number())
+		)
+		for i, tx := range task.block.Transactions() {
+			msg, _ := tx.AsMessage(signer)
+			vmctx := core.NewEVMContext(msg, task.block.Header(), api.eth.blockchain, nil)
+
+			res, err := api.traceTx(ctx, msg, vmctx, task.statedb, config)
+			if err != nil {
+				task.results[i] = &txTraceResult{Error: err.Error()}
+				log.Warn("Tracing failed", "hash", tx.Hash(), "block", number, "err", err)
+				break
+			}
+			// Only delete empty objects if EIP158/161 (a.k.a Spurious Dragon) is in effect
+			task.statedb.Finalise(api.eth.blockchain.Config().IsEIP158(task.block.Number()))
+
+			// Transactions before the cursor were already delivered
+			if number == first && uint64(i) < skip {
+				continue
+			}
+			var frame callTraceFrame
+			if err := json.Unmarshal(res.(json.RawMessage), &frame); err != nil {
+				task.results[i] = &txTraceResult{Error: err.Error()}
+				continue
+			}
+			if filter.match(&frame) {
+				task.results[i] = &txTraceResult{Result: res}
+			}
+		}
+	}
+	sub := notifier.CreateSubscription()
+	emit := func(task *blockTraceTask) {
+		var (
+			number = task.block.NumberU64()
+			txs    = task.block.Transactions()
+		)
+		for i, res := range task.results {
+			if res == nil {
+				continue
+			}
+			hash, index := txs[i].Hash(), hexutil.Uint(i)
+			notifier.Notify(sub.ID, &filterTraceResult{
+				Block:     hexutil.Uint64(number),
+				BlockHash: task.block.Hash(),
+				TxHash:    &hash,
+				TxIndex:   &index,
+				Result:    res.Result,
+				Error:     res.Error,
+				Cursor:    TraceCursor{Block: hexutil.Uint64(number), Index: index + 1},
+			})
+		}
+		if number == last {
+			notifier.Notify(sub.ID, &filterTraceResult{
+				Block:     hexutil.Uint64(number),
+				BlockHash: task.block.Hash(),
+				Cursor:    TraceCursor{Block: hexutil.Uint64(number + 1)},
+			})
+		}
+	}
+	// The genesis block has no transactions to trace, end the range right away if
+	// it is the only block in it
+	if last == 0 {
+		notifier.Notify(sub.ID, &filterTraceResult{
+			BlockHash: end.Hash(),
+			Cursor:    TraceCursor{Block: 1},
+		})
+		return sub, nil
+	}
+	if err := api.traceBlocks(notifier, start, end, reexec, threads, trace, emit); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
@@ -0,0 +1,147 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Tests that trace filters match transactions by any of their calls, with all
+// the given criteria applying to the same call.
+func TestTraceFilterMatch(t *testing.T) {
+	trace := `{
+		"type": "CALL", "from": "0x00000000000000000000000000000000000000aa", "to": "0x00000000000000000000000000000000000000bb", "input": "0x11223344",
+		"calls": [
+			{"type": "STATICCALL", "from": "0x00000000000000000000000000000000000000bb", "to": "0x00000000000000000000000000000000000000cc", "input": "0xdeadbeef00"},
+			{"type": "SELFDESTRUCT"}
+		]
+	}`
+	var frame callTraceFrame
+	if err := json.Unmarshal([]byte(trace), &frame); err != nil {
+		t.Fatalf("failed to decode trace: %v", err)
+	}
+	var (
+		aa = common.HexToAddress("0xaa")
+		bb = common.HexToAddress("0xbb")
+		cc = common.HexToAddress("0xcc")
+		dd = common.HexToAddress("0xdd")
+	)
+	tests := []struct {
+		args  TraceFilterArgs
+		match bool
+	}{
+		{TraceFilterArgs{}, true},
+		{TraceFilterArgs{FromAddress: []common.Address{aa}}, true},
+		{TraceFilterArgs{FromAddress: []common.Address{bb}}, true},
+		{TraceFilterArgs{FromAddress: []common.Address{cc}}, false},
+		{TraceFilterArgs{ToAddress: []common.Address{cc, dd}}, true},
+		{TraceFilterArgs{ToAddress: []common.Address{dd}}, false},
+		{TraceFilterArgs{FromAddress: []common.Address{bb}, ToAddress: []common.Address{cc}}, true},
+		{TraceFilterArgs{FromAddress: []common.Address{aa}, ToAddress: []common.Address{cc}}, false},
+		{TraceFilterArgs{Selector: []hexutil.Bytes{hexutil.MustDecode("0xdeadbeef")}}, true},
+		{TraceFilterArgs{ToAddress: []common.Address{bb}, Selector: []hexutil.Bytes{hexutil.MustDecode("0xdeadbeef")}}, false},
+		{TraceFilterArgs{Selector: []hexutil.Bytes{hexutil.MustDecode("0x00000000")}}, false},
+	}
+	for i, tt := range tests {
+		filter, err := newTraceFilter(&tt.args)
+		if err != nil {
+			t.Fatalf("test %d: failed to create filter: %v", i, err)
+		}
+		if have := filter.match(&frame); have != tt.match {
+			t.Errorf("test %d: match mismatch: have %v, want %v", i, have, tt.match)
+		}
+	}
+	if _, err := newTraceFilter(&TraceFilterArgs{Selector: []hexutil.Bytes{{0x01}}}); err == nil {
+		t.Errorf("short selector accepted")
+	}
+}
+
+// Tests that trace filters stream the matching transactions of the requested
+// range in order, resuming from a cursor if given.
+func TestTraceFilter(t *testing.T) {
+	chain, db, _ := newRegenTestChain(t, 8, 8)
+	defer chain.Stop()
+
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("debug", &PrivateDebugAPI{eth: &Ethereum{blockchain: chain, chainDb: db}}); err != nil {
+		t.Fatalf("failed to register debug api: %v", err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	tests := []struct {
+		args   map[string]interface{}
+		blocks []uint64
+		end    uint64
+	}{
+		{map[string]interface{}{"toAddress": []common.Address{{0x01}}}, []uint64{1, 2, 3, 4, 5, 6, 7, 8}, 8},
+		{map[string]interface{}{"fromBlock": "0x2", "toAddress": []common.Address{{0x01}}, "threads": 2}, []uint64{2, 3, 4, 5, 6, 7, 8}, 8},
+		{map[string]interface{}{"fromBlock": "0x2", "cursor": &TraceCursor{Block: 5, Index: 1}}, []uint64{6, 7, 8}, 8},
+		{map[string]interface{}{"toAddress": []common.Address{{0x02}}}, nil, 8},
+		{map[string]interface{}{"toBlock": "0x3"}, []uint64{1, 2, 3}, 3},
+		{map[string]interface{}{"fromBlock": "0x0", "toBlock": "0x0"}, nil, 0},
+	}
+	for i, tt := range tests {
+		results := make(chan *filterTraceResult)
+		sub, err := client.Subscribe(context.Background(), "debug", results, "traceFilter", tt.args)
+		if err != nil {
+			t.Fatalf("test %d: failed to subscribe: %v", i, err)
+		}
+		var blocks []uint64
+		for done := false; !done; {
+			select {
+			case res := <-results:
+				if res.TxHash == nil {
+					if uint64(res.Block) != tt.end || uint64(res.Cursor.Block) != tt.end+1 {
+						t.Errorf("test %d: final notification mismatch: block %d, cursor %d", i, res.Block, res.Cursor.Block)
+					}
+					done = true
+					break
+				}
+				if res.Error != "" || res.Result == nil {
+					t.Errorf("test %d: block %d: trace failed: %v", i, res.Block, res.Error)
+				}
+				if res.Cursor.Block != res.Block || res.Cursor.Index != *res.TxIndex+1 {
+					t.Errorf("test %d: block %d: cursor mismatch: %+v", i, res.Block, res.Cursor)
+				}
+				blocks = append(blocks, uint64(res.Block))
+			case err := <-sub.Err():
+				t.Fatalf("test %d: subscription failed: %v", i, err)
+			case <-time.After(5 * time.Second):
+				t.Fatalf("test %d: timeout waiting for traces", i)
+			}
+		}
+		sub.Unsubscribe()
+
+		if len(blocks) != len(tt.blocks) {
+			t.Fatalf("test %d: traced blocks mismatch: have %v, want %v", i, blocks, tt.blocks)
+		}
+		for j := range blocks {
+			if blocks[j] != tt.blocks[j] {
+				t.Fatalf("test %d: traced blocks mismatch: have %v, want %v", i, blocks, tt.blocks)
+			}
+		}
+	}
+}
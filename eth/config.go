@@ -28,6 +28,7 @@ import (
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/eth/downloader"
+	"github.com/ethereum/go-ethereum/eth/filters"
 	"github.com/ethereum/go-ethereum/eth/gasprice"
 	"github.com/ethereum/go-ethereum/miner"
 	"github.com/ethereum/go-ethereum/params"
@@ -51,6 +52,9 @@ var DefaultConfig = Config{
 	TrieCleanCache:     256,
 	TrieDirtyCache:     256,
 	TrieTimeout:        60 * time.Minute,
+	SnapshotCache:      256,
+	StateReexecCache:   16,
+	StateReexecMemory:  256,
 	Miner: miner.Config{
 		GasFloor: 8000000,
 		GasCeil:  8000000,
@@ -145,6 +149,14 @@ type Config struct {
 	TrieCleanCache int
 	TrieDirtyCache int
 	TrieTimeout    time.Duration
+	SnapshotCache  int
+
+	// Historical state regeneration options. If StateReexec is non-zero, RPC
+	// requests against states missing from disk re-execute at most that many
+	// blocks on top of the nearest available state.
+	StateReexec       uint64
+	StateReexecCache  int // Number of regenerated states to keep in memory
+	StateReexecMemory int // Memory allowance (MB) for regenerated states
 
 	// Mining options
 	Miner miner.Config
@@ -173,6 +185,9 @@ type Config struct {
 	// RPCGasCap is the global gas cap for eth-call variants.
 	RPCGasCap *big.Int `toml:",omitempty"`
 
+	// LogQueryLimits bounds the block range and result count of log queries.
+	LogQueryLimits filters.Limits `toml:",omitempty"`
+
 	// Checkpoint is a hardcoded checkpoint which can be nil.
 	Checkpoint *params.TrustedCheckpoint `toml:",omitempty"`
 
@@ -92,7 +92,7 @@ func newTester() *fetcherTester {
 		blocks: map[common.Hash]*types.Block{genesis.Hash(): genesis},
 		drops:  make(map[string]bool),
 	}
-	tester.fetcher = New(tester.getBlock, tester.verifyHeader, tester.broadcastBlock, tester.chainHeight, tester.insertChain, tester.dropPeer)
+	tester.fetcher = New(tester.getBlock, tester.verifyHeader, tester.verifyBody, tester.broadcastBlock, tester.chainHeight, tester.insertChain, tester.dropPeer)
 	tester.fetcher.Start()
 
 	return tester
@@ -111,6 +111,11 @@ func (f *fetcherTester) verifyHeader(header *types.Header) error {
 	return nil
 }
 
+// verifyBody is a nop placeholder for the block body verification.
+func (f *fetcherTester) verifyBody(block *types.Block) error {
+	return nil
+}
+
 // broadcastBlock is a nop placeholder for the block broadcasting.
 func (f *fetcherTester) broadcastBlock(block *types.Block, propagate bool) {
 }
@@ -644,6 +649,38 @@ func testInvalidNumberAnnouncement(t *testing.T, protocol int) {
 	verifyImportDone(t, imported)
 }
 
+// Tests that peers propagating blocks whose body fails verification get dropped
+// and the blocks are neither imported nor propagated.
+func TestInvalidBodyDrop(t *testing.T) {
+	hashes, blocks := makeChain(1, 0, genesis)
+
+	tester := newTester()
+	tester.fetcher.verifyBody = func(block *types.Block) error {
+		return errors.New("invalid body")
+	}
+	broadcasts := make(chan *types.Block, 2)
+	tester.fetcher.broadcastBlock = func(block *types.Block, propagate bool) { broadcasts <- block }
+
+	imported := make(chan *types.Block)
+	tester.fetcher.importedHook = func(block *types.Block) { imported <- block }
+
+	tester.fetcher.Enqueue("bad", blocks[hashes[0]])
+	verifyImportEvent(t, imported, false)
+
+	tester.lock.RLock()
+	dropped := tester.drops["bad"]
+	tester.lock.RUnlock()
+
+	if !dropped {
+		t.Fatalf("peer with invalid block body not dropped")
+	}
+	select {
+	case <-broadcasts:
+		t.Fatalf("block with invalid body propagated")
+	default:
+	}
+}
+
 // Tests that if a block is empty (i.e. header only), no body request should be
 // made, and instead the header should be assembled into a whole block in itself.
 func TestEmptyBlockShortCircuit62(t *testing.T) { testEmptyBlockShortCircuit(t, 62) }
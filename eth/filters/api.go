@@ -17,6 +17,7 @@
 package filters
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -28,9 +29,12 @@ import (
 	ethereum "github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
@@ -103,7 +107,7 @@ func (api *PublicFilterAPI) timeoutLoop() {
 // https://github.com/ethereum/wiki/wiki/JSON-RPC#eth_newpendingtransactionfilter
 func (api *PublicFilterAPI) NewPendingTransactionFilter() rpc.ID {
 	var (
-		pendingTxs   = make(chan []common.Hash)
+		pendingTxs   = make(chan []*types.Transaction)
 		pendingTxSub = api.events.SubscribePendingTxs(pendingTxs)
 	)
 
@@ -117,7 +121,9 @@ func (api *PublicFilterAPI) NewPendingTransactionFilter() rpc.ID {
 			case ph := <-pendingTxs:
 				api.filtersMu.Lock()
 				if f, found := api.filters[pendingTxSub.ID]; found {
-					f.hashes = append(f.hashes, ph...)
+					for _, tx := range ph {
+						f.hashes = append(f.hashes, tx.Hash())
+					}
 				}
 				api.filtersMu.Unlock()
 			case <-pendingTxSub.Err():
@@ -132,27 +138,86 @@ func (api *PublicFilterAPI) NewPendingTransactionFilter() rpc.ID {
 	return pendingTxSub.ID
 }
 
+// PendingTxCriteria restricts the transactions delivered by a pending transaction
+// subscription. Empty lists match any transaction. Transactions enqueued on L1
+// have no L2 sender, From matches their L1 origin instead.
+type PendingTxCriteria struct {
+	From      []common.Address `json:"from"`
+	To        []common.Address `json:"to"`
+	Selectors []hexutil.Bytes  `json:"selectors"` // 4 byte method selectors of the called functions
+}
+
+// matches returns whether a pending transaction passes the criteria.
+func (crit *PendingTxCriteria) matches(tx *ethapi.RPCTransaction) bool {
+	if len(crit.From) > 0 {
+		from := tx.From
+		if tx.QueueOrigin == "l1" && tx.L1TxOrigin != nil {
+			from = *tx.L1TxOrigin
+		}
+		if !includes(crit.From, from) {
+			return false
+		}
+	}
+	if len(crit.To) > 0 && (tx.To == nil || !includes(crit.To, *tx.To)) {
+		return false
+	}
+	if len(crit.Selectors) > 0 {
+		if len(tx.Input) < 4 {
+			return false
+		}
+		for _, selector := range crit.Selectors {
+			if bytes.Equal(selector, tx.Input[:4]) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
 // NewPendingTransactions creates a subscription that is triggered each time a transaction
-// enters the transaction pool and was signed from one of the transactions this nodes manages.
-func (api *PublicFilterAPI) NewPendingTransactions(ctx context.Context) (*rpc.Subscription, error) {
+// enters the transaction pool, or is accepted by the sequencer in OVM mode. If fullTx is
+// set, the whole transactions including their rollup metadata are delivered instead of
+// their hashes. The optional criteria restrict the delivered transactions by sender,
+// recipient and called method.
+func (api *PublicFilterAPI) NewPendingTransactions(ctx context.Context, fullTx *bool, crit *PendingTxCriteria) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
 	}
-
-	rpcSub := notifier.CreateSubscription()
-
+	if crit != nil {
+		for _, selector := range crit.Selectors {
+			if len(selector) != 4 {
+				return nil, fmt.Errorf("invalid selector %v, want 4 bytes", selector)
+			}
+		}
+	}
+	var (
+		rpcSub = notifier.CreateSubscription()
+		full   = fullTx != nil && *fullTx
+	)
 	go func() {
-		txHashes := make(chan []common.Hash, 128)
-		pendingTxSub := api.events.SubscribePendingTxs(txHashes)
+		pendingTxs := make(chan []*types.Transaction, 128)
+		pendingTxSub := api.events.SubscribePendingTxs(pendingTxs)
 
 		for {
 			select {
-			case hashes := <-txHashes:
+			case txs := <-pendingTxs:
 				// To keep the original behaviour, send a single tx hash in one notification.
 				// TODO(rjl493456442) Send a batch of tx hashes in one notification
-				for _, h := range hashes {
-					notifier.Notify(rpcSub.ID, h)
+				for _, tx := range txs {
+					if crit == nil && !full {
+						notifier.Notify(rpcSub.ID, tx.Hash())
+						continue
+					}
+					rpcTx := ethapi.NewRPCPendingTransaction(tx)
+					switch {
+					case crit != nil && !crit.matches(rpcTx):
+					case full:
+						notifier.Notify(rpcSub.ID, rpcTx)
+					default:
+						notifier.Notify(rpcSub.ID, tx.Hash())
+					}
 				}
 			case <-rpcSub.Err():
 				pendingTxSub.Unsubscribe()
@@ -270,6 +335,104 @@ func (api *PublicFilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc
 	return rpcSub, nil
 }
 
+// LogsFrom creates a subscription that delivers every log matching the given
+// filter criteria exactly once and in chain order. Logs are backfilled from the
+// database starting at the cursor, or at the criteria's fromBlock if no cursor
+// is given, before following the chain head. If no starting point is given at
+// all, only logs of new blocks are delivered.
+//
+// Delivered logs whose blocks are reorged out or rewound, e.g. by the rollup
+// sync service or debug_setHead, are delivered again with removed set to true,
+// after which the logs of the new chain follow. A client resumes a dropped
+// subscription by passing the position after the last log it received, i.e.
+// its block number, log index + 1 and block hash. If that block left the chain
+// while the client was disconnected, the logs it received from the blocks which
+// left are delivered again as removed first. If these blocks are not available
+// anymore, e.g. because they were rewound, the subscription fails.
+func (api *PublicFilterAPI) LogsFrom(ctx context.Context, crit FilterCriteria, cursor *Cursor) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	if crit.BlockHash != nil || crit.ToBlock != nil {
+		return nil, errors.New("blockHash and toBlock are not supported by log streams")
+	}
+	var start Cursor
+	switch {
+	case cursor != nil:
+		start = *cursor
+	case crit.FromBlock != nil && crit.FromBlock.Sign() >= 0:
+		start.BlockNumber = hexutil.Uint64(crit.FromBlock.Uint64())
+	case crit.FromBlock != nil && crit.FromBlock.Int64() != rpc.LatestBlockNumber.Int64():
+		return nil, errors.New("pending logs are not supported by log streams")
+	default:
+		header, err := api.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+		if header == nil || err != nil {
+			return nil, fmt.Errorf("failed to retrieve chain head: %v", err)
+		}
+		start.BlockNumber = hexutil.Uint64(header.Number.Uint64() + 1)
+	}
+	var (
+		rpcSub  = notifier.CreateSubscription()
+		chainCh = make(chan core.ChainEvent, chainEvChanSize)
+		rmLogCh = make(chan core.RemovedLogsEvent, rmLogsChanSize)
+	)
+	stream := newLogStream(api.backend, crit, start, func(log *types.Log) error {
+		return notifier.Notify(rpcSub.ID, log)
+	})
+	if err := stream.restore(ctx); err != nil {
+		return nil, err
+	}
+	chainSub := api.backend.SubscribeChainEvent(chainCh)
+	rmLogSub := api.backend.SubscribeRemovedLogsEvent(rmLogCh)
+
+	// Chain events only trigger a sync of the stream. They are consumed without
+	// waiting for the client, so a slow one doesn't block the chain.
+	wake, quit := make(chan struct{}, 1), make(chan struct{})
+	go func() {
+		defer chainSub.Unsubscribe()
+		defer rmLogSub.Unsubscribe()
+
+		for {
+			select {
+			case <-chainCh:
+			case <-rmLogCh:
+			case <-quit:
+				return
+			}
+			select {
+			case wake <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	// Backfills may span the whole chain, abort them once the client is gone
+	streamCtx, cancel := context.WithCancel(context.Background())
+	go func() {
+		defer cancel()
+
+		select {
+		case <-rpcSub.Err(): // client send an unsubscribe request
+		case <-notifier.Closed(): // connection dropped
+		}
+	}()
+	go func() {
+		defer close(quit)
+
+		for {
+			if err := stream.sync(streamCtx); err != nil && streamCtx.Err() == nil {
+				log.Warn("Failed to sync log stream", "id", rpcSub.ID, "err", err)
+			}
+			select {
+			case <-wake:
+			case <-streamCtx.Done():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
 // FilterCriteria represents a request to create a new filter.
 // Same as ethereum.FilterQuery but with UnmarshalJSON() method.
 type FilterCriteria ethereum.FilterQuery
@@ -323,31 +486,56 @@ func (api *PublicFilterAPI) NewFilter(crit FilterCriteria) (rpc.ID, error) {
 //
 // https://github.com/ethereum/wiki/wiki/JSON-RPC#eth_getlogs
 func (api *PublicFilterAPI) GetLogs(ctx context.Context, crit FilterCriteria) ([]*types.Log, error) {
-	var filter *Filter
-	if crit.BlockHash != nil {
-		// Block filter requested, construct a single-shot filter
-		filter = NewBlockFilter(api.backend, *crit.BlockHash, crit.Addresses, crit.Topics)
-	} else {
-		// Convert the RPC block numbers into internal representations
-		begin := rpc.LatestBlockNumber.Int64()
-		if crit.FromBlock != nil {
-			begin = crit.FromBlock.Int64()
-		}
-		end := rpc.LatestBlockNumber.Int64()
-		if crit.ToBlock != nil {
-			end = crit.ToBlock.Int64()
-		}
-		// Construct the range filter
-		filter = NewRangeFilter(api.backend, begin, end, crit.Addresses, crit.Topics)
-	}
 	// Run the filter and return all the logs
-	logs, err := filter.Logs(ctx)
+	logs, err := newCriteriaFilter(api.backend, crit).Logs(ctx)
 	if err != nil {
 		return nil, err
 	}
 	return returnLogs(logs), err
 }
 
+// LogPage is a page of logs returned by GetLogsPage, along with the cursor to
+// retrieve the next page from if the query hit a limit.
+type LogPage struct {
+	Logs   []*types.Log `json:"logs"`
+	Cursor *Cursor      `json:"cursor"`
+}
+
+// GetLogsPage returns logs matching the given argument that are stored within
+// the state, starting from the cursor of a previous page if given. Instead of
+// failing when a query limit is hit, the logs found are returned along with
+// the cursor to continue from.
+func (api *PublicFilterAPI) GetLogsPage(ctx context.Context, crit FilterCriteria, cursor *Cursor) (*LogPage, error) {
+	filter := newCriteriaFilter(api.backend, crit)
+	if cursor != nil {
+		filter.Resume(*cursor)
+	}
+	logs, next, err := filter.LogsPage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &LogPage{Logs: returnLogs(logs), Cursor: next}, nil
+}
+
+// newCriteriaFilter creates a block or range filter for the given criteria.
+func newCriteriaFilter(backend Backend, crit FilterCriteria) *Filter {
+	if crit.BlockHash != nil {
+		// Block filter requested, construct a single-shot filter
+		return NewBlockFilter(backend, *crit.BlockHash, crit.Addresses, crit.Topics)
+	}
+	// Convert the RPC block numbers into internal representations
+	begin := rpc.LatestBlockNumber.Int64()
+	if crit.FromBlock != nil {
+		begin = crit.FromBlock.Int64()
+	}
+	end := rpc.LatestBlockNumber.Int64()
+	if crit.ToBlock != nil {
+		end = crit.ToBlock.Int64()
+	}
+	// Construct the range filter
+	return NewRangeFilter(backend, begin, end, crit.Addresses, crit.Topics)
+}
+
 // UninstallFilter removes the filter with the given filter id.
 //
 // https://github.com/ethereum/wiki/wiki/JSON-RPC#eth_uninstallfilter
@@ -377,26 +565,8 @@ func (api *PublicFilterAPI) GetFilterLogs(ctx context.Context, id rpc.ID) ([]*ty
 	if !found || f.typ != LogsSubscription {
 		return nil, fmt.Errorf("filter not found")
 	}
-
-	var filter *Filter
-	if f.crit.BlockHash != nil {
-		// Block filter requested, construct a single-shot filter
-		filter = NewBlockFilter(api.backend, *f.crit.BlockHash, f.crit.Addresses, f.crit.Topics)
-	} else {
-		// Convert the RPC block numbers into internal representations
-		begin := rpc.LatestBlockNumber.Int64()
-		if f.crit.FromBlock != nil {
-			begin = f.crit.FromBlock.Int64()
-		}
-		end := rpc.LatestBlockNumber.Int64()
-		if f.crit.ToBlock != nil {
-			end = f.crit.ToBlock.Int64()
-		}
-		// Construct the range filter
-		filter = NewRangeFilter(api.backend, begin, end, f.crit.Addresses, f.crit.Topics)
-	}
 	// Run the filter and return all the logs
-	logs, err := filter.Logs(ctx)
+	logs, err := newCriteriaFilter(api.backend, f.crit).Logs(ctx)
 	if err != nil {
 		return nil, err
 	}
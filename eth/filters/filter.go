@@ -19,9 +19,11 @@ package filters
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/bloombits"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -45,8 +47,42 @@ type Backend interface {
 
 	BloomStatus() (uint64, uint64)
 	ServiceFilter(ctx context.Context, session *bloombits.MatcherSession)
+
+	LogQueryLimits() (uint64, int)
+}
+
+// Limits bounds the work done and the results returned by a single log query.
+// Zero values disable the respective limit.
+type Limits struct {
+	MaxBlockRange uint64 `toml:",omitempty"` // Maximum number of blocks scanned by a query
+	MaxResults    int    `toml:",omitempty"` // Maximum number of logs returned by a query
+}
+
+// Cursor is the position of a log within the chain, used to resume a log query
+// which hit a limit. It points at the first log not yet returned. The block hash
+// is optional, if set it is the hash of the block at BlockNumber the logs before
+// the cursor were returned from.
+type Cursor struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	LogIndex    hexutil.Uint   `json:"logIndex"`
+	BlockHash   *common.Hash   `json:"blockHash,omitempty"`
+}
+
+// LimitError is returned by log queries which hit a limit before reaching the
+// end of the requested range. It carries the cursor to resume the query from.
+type LimitError struct {
+	Cursor Cursor
+	reason string
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s, resume from block %d log %d", e.reason, e.Cursor.BlockNumber, e.Cursor.LogIndex)
 }
 
+func (e *LimitError) ErrorCode() int { return -32005 }
+
+func (e *LimitError) ErrorData() interface{} { return map[string]interface{}{"cursor": e.Cursor} }
+
 // Filter can be used to retrieve and filter logs.
 type Filter struct {
 	backend Backend
@@ -59,6 +95,11 @@ type Filter struct {
 	begin, end int64       // Range interval if filtering multiple blocks
 
 	matcher *bloombits.Matcher
+
+	limits Limits
+	resume *Cursor // Position to resume an earlier query from, if any
+	cursor *Cursor // Resume position if a limit was hit during the last query
+	reason string  // Limit hit during the last query
 }
 
 // NewRangeFilter creates a new filter which uses a bloom filter on blocks to
@@ -106,32 +147,59 @@ func NewBlockFilter(backend Backend, block common.Hash, addresses []common.Addre
 // newFilter creates a generic filter that can either filter based on a block hash,
 // or based on range queries. The search criteria needs to be explicitly set.
 func newFilter(backend Backend, addresses []common.Address, topics [][]common.Hash) *Filter {
-	return &Filter{
+	filter := &Filter{
 		backend:   backend,
 		addresses: addresses,
 		topics:    topics,
 		db:        backend.ChainDb(),
 	}
+	filter.limits.MaxBlockRange, filter.limits.MaxResults = backend.LogQueryLimits()
+	return filter
+}
+
+// Resume continues the query from the position of a cursor returned by an
+// earlier query hitting a limit. Logs before the cursor are skipped.
+func (f *Filter) Resume(cursor Cursor) {
+	if f.block == (common.Hash{}) && int64(cursor.BlockNumber) > f.begin {
+		f.begin = int64(cursor.BlockNumber)
+	}
+	f.resume = &cursor
 }
 
 // Logs searches the blockchain for matching log entries, returning all from the
 // first block that contains matches, updating the start of the filter accordingly.
+// If a query limit is hit, the logs found so far are returned along with a
+// *LimitError holding the position to resume from.
 func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
+	logs, cursor, err := f.LogsPage(ctx)
+	if err == nil && cursor != nil {
+		err = &LimitError{Cursor: *cursor, reason: f.reason}
+	}
+	return logs, err
+}
+
+// LogsPage searches the blockchain for matching log entries like Logs, but
+// reports a hit query limit by returning the cursor to resume from instead of
+// an error. The cursor is nil if the whole range was searched.
+func (f *Filter) LogsPage(ctx context.Context) ([]*types.Log, *Cursor, error) {
+	f.cursor, f.reason = nil, ""
+
 	// If we're doing singleton block filtering, execute and return
 	if f.block != (common.Hash{}) {
 		header, err := f.backend.HeaderByHash(ctx, f.block)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		if header == nil {
-			return nil, errors.New("unknown block")
+			return nil, nil, errors.New("unknown block")
 		}
-		return f.blockLogs(ctx, header)
+		logs, err := f.blockLogs(ctx, header, nil)
+		return logs, f.cursor, err
 	}
 	// Figure out the limits of the filter range
 	header, _ := f.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
 	if header == nil {
-		return nil, nil
+		return nil, nil, nil
 	}
 	head := header.Number.Uint64()
 
@@ -142,6 +210,12 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 	if f.end == -1 {
 		end = head
 	}
+	// Clamp the range to the maximum allowed, resuming past it afterwards
+	var clamped *Cursor
+	if max := f.limits.MaxBlockRange; max > 0 && int64(end) >= f.begin && end-uint64(f.begin) >= max {
+		end = uint64(f.begin) + max - 1
+		clamped = &Cursor{BlockNumber: hexutil.Uint64(end + 1)}
+	}
 	// Gather all indexed logs, and finish with non indexed ones
 	var (
 		logs []*types.Log
@@ -154,13 +228,37 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 		} else {
 			logs, err = f.indexedLogs(ctx, indexed-1)
 		}
-		if err != nil {
-			return logs, err
+		if err != nil || f.cursor != nil {
+			return logs, f.cursor, err
 		}
 	}
-	rest, err := f.unindexedLogs(ctx, end)
-	logs = append(logs, rest...)
-	return logs, err
+	logs, err = f.unindexedLogs(ctx, end, logs)
+	if err == nil && f.cursor == nil && clamped != nil {
+		f.cursor, f.reason = clamped, fmt.Sprintf("query exceeds max block range %d", f.limits.MaxBlockRange)
+	}
+	return logs, f.cursor, err
+}
+
+// collect appends the logs found in a block to the ones gathered so far,
+// dropping those before the resume position and stopping at the result limit.
+// In the latter case the filter's cursor is set to the first log left out.
+func (f *Filter) collect(logs []*types.Log, number uint64, found []*types.Log) []*types.Log {
+	if f.resume != nil && uint64(f.resume.BlockNumber) == number {
+		kept := found[:0:0]
+		for _, log := range found {
+			if log.Index >= uint(f.resume.LogIndex) {
+				kept = append(kept, log)
+			}
+		}
+		found = kept
+	}
+	if max := f.limits.MaxResults; max > 0 && len(logs)+len(found) > max {
+		left := found[max-len(logs)]
+		f.cursor = &Cursor{BlockNumber: hexutil.Uint64(number), LogIndex: hexutil.Uint(left.Index)}
+		f.reason = fmt.Sprintf("query returns more than %d results", max)
+		found = found[:max-len(logs)]
+	}
+	return append(logs, found...)
 }
 
 // indexedLogs returns the logs matching the filter criteria based on the bloom
@@ -202,7 +300,9 @@ func (f *Filter) indexedLogs(ctx context.Context, end uint64) ([]*types.Log, err
 			if err != nil {
 				return logs, err
 			}
-			logs = append(logs, found...)
+			if logs = f.collect(logs, number, found); f.cursor != nil {
+				return logs, nil
+			}
 
 		case <-ctx.Done():
 			return logs, ctx.Err()
@@ -210,33 +310,30 @@ func (f *Filter) indexedLogs(ctx context.Context, end uint64) ([]*types.Log, err
 	}
 }
 
-// indexedLogs returns the logs matching the filter criteria based on raw block
-// iteration and bloom matching.
-func (f *Filter) unindexedLogs(ctx context.Context, end uint64) ([]*types.Log, error) {
-	var logs []*types.Log
-
+// unindexedLogs appends the logs matching the filter criteria based on raw block
+// iteration and bloom matching to the ones gathered so far.
+func (f *Filter) unindexedLogs(ctx context.Context, end uint64, logs []*types.Log) ([]*types.Log, error) {
 	for ; f.begin <= int64(end); f.begin++ {
 		header, err := f.backend.HeaderByNumber(ctx, rpc.BlockNumber(f.begin))
 		if header == nil || err != nil {
 			return logs, err
 		}
-		found, err := f.blockLogs(ctx, header)
-		if err != nil {
+		if logs, err = f.blockLogs(ctx, header, logs); err != nil || f.cursor != nil {
 			return logs, err
 		}
-		logs = append(logs, found...)
 	}
 	return logs, nil
 }
 
-// blockLogs returns the logs matching the filter criteria within a single block.
-func (f *Filter) blockLogs(ctx context.Context, header *types.Header) (logs []*types.Log, err error) {
+// blockLogs appends the logs matching the filter criteria within a single block
+// to the ones gathered so far.
+func (f *Filter) blockLogs(ctx context.Context, header *types.Header, logs []*types.Log) ([]*types.Log, error) {
 	if bloomFilter(header.Bloom, f.addresses, f.topics) {
 		found, err := f.checkMatches(ctx, header)
 		if err != nil {
 			return logs, err
 		}
-		logs = append(logs, found...)
+		logs = f.collect(logs, header.Number.Uint64(), found)
 	}
 	return logs, nil
 }
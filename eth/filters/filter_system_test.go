@@ -17,7 +17,9 @@
 package filters
 
 import (
+	"bytes"
 	"context"
+	"crypto/ecdsa"
 	"fmt"
 	"math/big"
 	"math/rand"
@@ -27,11 +29,13 @@ import (
 
 	ethereum "github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/bloombits"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/params"
@@ -47,6 +51,7 @@ type testBackend struct {
 	rmLogsFeed      event.Feed
 	pendingLogsFeed event.Feed
 	chainFeed       event.Feed
+	limits          Limits
 }
 
 func (b *testBackend) ChainDb() ethdb.Database {
@@ -121,6 +126,10 @@ func (b *testBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subsc
 	return b.chainFeed.Subscribe(ch)
 }
 
+func (b *testBackend) LogQueryLimits() (uint64, int) {
+	return b.limits.MaxBlockRange, b.limits.MaxResults
+}
+
 func (b *testBackend) BloomStatus() (uint64, uint64) {
 	return params.BloomBitsBlocks, b.sections
 }
@@ -264,6 +273,165 @@ func TestPendingTxFilter(t *testing.T) {
 	}
 }
 
+func TestPendingTxSubscription(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		backend = &testBackend{db: db}
+		api     = NewPublicFilterAPI(backend, false)
+		server  = rpc.NewServer()
+
+		key1, _  = crypto.GenerateKey()
+		key2, _  = crypto.GenerateKey()
+		addr1    = crypto.PubkeyToAddress(key1.PublicKey)
+		to       = common.HexToAddress("0xb794f5ea0ba39494ce83a213fffba74279579268")
+		transfer = common.FromHex("0xa9059cbb0000000000000000000000000000000000000000000000000000000000000001")
+		signer   = types.NewOVMSigner(big.NewInt(1))
+	)
+	defer server.Stop()
+	if err := server.RegisterName("eth", api); err != nil {
+		t.Fatalf("failed to register filter API: %v", err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	sign := func(key *ecdsa.PrivateKey, tx *types.Transaction) *types.Transaction {
+		signed, err := types.SignTx(tx, signer, key)
+		if err != nil {
+			t.Fatalf("failed to sign transaction: %v", err)
+		}
+		return signed
+	}
+	transactions := []*types.Transaction{
+		sign(key1, types.NewTransaction(0, to, new(big.Int), 0, new(big.Int), transfer)),
+		sign(key2, types.NewTransaction(0, to, new(big.Int), 0, new(big.Int), transfer)),
+		sign(key1, types.NewTransaction(1, to, new(big.Int), 0, new(big.Int), common.FromHex("0x12345678"))),
+		sign(key1, types.NewTransaction(2, to, new(big.Int), 0, new(big.Int), transfer[:3])),
+		sign(key1, types.NewContractCreation(3, new(big.Int), 0, new(big.Int), transfer)),
+	}
+	// Subscribe to the hashes of all transactions and to the full matching ones
+	hashes := make(chan common.Hash, len(transactions))
+	hashSub, err := client.EthSubscribe(context.Background(), hashes, "newPendingTransactions")
+	if err != nil {
+		t.Fatalf("failed to subscribe to hashes: %v", err)
+	}
+	defer hashSub.Unsubscribe()
+
+	type pendingTx struct {
+		Hash  common.Hash     `json:"hash"`
+		From  common.Address  `json:"from"`
+		To    *common.Address `json:"to"`
+		Input hexutil.Bytes   `json:"input"`
+	}
+	txs := make(chan *pendingTx, len(transactions))
+	crit := &PendingTxCriteria{From: []common.Address{addr1}, To: []common.Address{to}, Selectors: []hexutil.Bytes{transfer[:4]}}
+	txSub, err := client.EthSubscribe(context.Background(), txs, "newPendingTransactions", true, crit)
+	if err != nil {
+		t.Fatalf("failed to subscribe to transactions: %v", err)
+	}
+	defer txSub.Unsubscribe()
+
+	time.Sleep(100 * time.Millisecond)
+	backend.txFeed.Send(core.NewTxsEvent{Txs: transactions})
+
+	for i, tx := range transactions {
+		select {
+		case hash := <-hashes:
+			if hash != tx.Hash() {
+				t.Errorf("hash %d mismatch: have %x, want %x", i, hash, tx.Hash())
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("hash %d not delivered", i)
+		}
+	}
+	select {
+	case tx := <-txs:
+		if tx.Hash != transactions[0].Hash() || tx.From != addr1 || tx.To == nil || *tx.To != to || !bytes.Equal(tx.Input, transfer) {
+			t.Errorf("transaction mismatch: %+v", tx)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("matching transaction not delivered")
+	}
+	select {
+	case tx := <-txs:
+		t.Errorf("non-matching transaction delivered: %x", tx.Hash)
+	case <-time.After(100 * time.Millisecond):
+	}
+	// Malformed selectors are rejected
+	crit = &PendingTxCriteria{Selectors: []hexutil.Bytes{transfer[:3]}}
+	if _, err := client.EthSubscribe(context.Background(), txs, "newPendingTransactions", true, crit); err == nil {
+		t.Errorf("subscription with malformed selector succeeded")
+	}
+}
+
+// Tests that full pending transactions carry their rollup metadata, and that the
+// transactions enqueued on L1 are matched by their L1 origin.
+func TestPendingTxSubscriptionRollupMeta(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		backend = &testBackend{db: db}
+		api     = NewPublicFilterAPI(backend, false)
+		server  = rpc.NewServer()
+
+		origin     = common.HexToAddress("0x11")
+		to         = common.HexToAddress("0xb794f5ea0ba39494ce83a213fffba74279579268")
+		index      = uint64(3)
+		queueIndex = uint64(2)
+	)
+	defer server.Stop()
+	if err := server.RegisterName("eth", api); err != nil {
+		t.Fatalf("failed to register filter API: %v", err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	enqueued := types.NewTransaction(0, to, new(big.Int), 100000, new(big.Int), []byte{0x01})
+	enqueued.SetTransactionMeta(types.NewTransactionMeta(big.NewInt(10), 100, &origin, types.SighashEIP155, types.QueueOriginL1ToL2, &index, &queueIndex, nil))
+
+	type pendingTx struct {
+		Hash          common.Hash     `json:"hash"`
+		From          common.Address  `json:"from"`
+		QueueOrigin   string          `json:"queueOrigin"`
+		L1TxOrigin    *common.Address `json:"l1TxOrigin"`
+		L1BlockNumber *hexutil.Big    `json:"l1BlockNumber"`
+		L1Timestamp   hexutil.Uint64  `json:"l1Timestamp"`
+		Index         *hexutil.Uint64 `json:"index"`
+		QueueIndex    *hexutil.Uint64 `json:"queueIndex"`
+	}
+	txs := make(chan *pendingTx, 1)
+	sub, err := client.EthSubscribe(context.Background(), txs, "newPendingTransactions", true, &PendingTxCriteria{From: []common.Address{origin}})
+	if err != nil {
+		t.Fatalf("failed to subscribe to transactions: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	time.Sleep(100 * time.Millisecond)
+	backend.txFeed.Send(core.NewTxsEvent{Txs: []*types.Transaction{enqueued}})
+
+	select {
+	case tx := <-txs:
+		switch {
+		case tx.Hash != enqueued.Hash():
+			t.Errorf("hash mismatch: have %x, want %x", tx.Hash, enqueued.Hash())
+		case tx.From != (common.Address{}):
+			t.Errorf("sender mismatch: have %x, want zero address", tx.From)
+		case tx.QueueOrigin != "l1":
+			t.Errorf("queue origin mismatch: have %q, want %q", tx.QueueOrigin, "l1")
+		case tx.L1TxOrigin == nil || *tx.L1TxOrigin != origin:
+			t.Errorf("L1 origin mismatch: have %v, want %x", tx.L1TxOrigin, origin)
+		case tx.L1BlockNumber == nil || tx.L1BlockNumber.ToInt().Uint64() != 10 || tx.L1Timestamp != 100:
+			t.Errorf("L1 block mismatch: number %v, timestamp %d", tx.L1BlockNumber, tx.L1Timestamp)
+		case tx.Index == nil || uint64(*tx.Index) != index || tx.QueueIndex == nil || uint64(*tx.QueueIndex) != queueIndex:
+			t.Errorf("index mismatch: index %v, queue index %v", tx.Index, tx.QueueIndex)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("enqueued transaction not delivered")
+	}
+}
+
 // TestLogFilterCreation test whether a given filter criteria makes sense.
 // If not it must return an error.
 func TestLogFilterCreation(t *testing.T) {
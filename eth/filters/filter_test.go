@@ -21,6 +21,7 @@ import (
 	"io/ioutil"
 	"math/big"
 	"os"
+	"reflect"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -30,6 +31,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 func makeReceipt(addr common.Address) *types.Receipt {
@@ -223,3 +225,123 @@ func TestFilters(t *testing.T) {
 		t.Error("expected 0 log, got", len(logs))
 	}
 }
+
+func TestFilterLimits(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		backend = &testBackend{db: db}
+		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key1.PublicKey)
+	)
+	defer db.Close()
+
+	genesis := core.GenesisBlockForTesting(db, addr, big.NewInt(1000000))
+	chain, receipts := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 50, func(i int, gen *core.BlockGen) {
+		logs := 0
+		switch i {
+		case 1, 2:
+			logs = 3
+		case 9, 39:
+			logs = 1
+		}
+		if logs > 0 {
+			receipt := types.NewReceipt(nil, false, 0)
+			for j := 0; j < logs; j++ {
+				receipt.Logs = append(receipt.Logs, &types.Log{Address: addr})
+			}
+			gen.AddUncheckedReceipt(receipt)
+			gen.AddUncheckedTx(types.NewTransaction(uint64(i), common.HexToAddress("0x1"), big.NewInt(1), 1, big.NewInt(1), nil))
+		}
+	})
+	for i, block := range chain {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(db, block.Hash())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+	type page struct {
+		logs   int
+		cursor *Cursor
+	}
+	tests := []struct {
+		limits Limits
+		pages  []page
+	}{
+		// Result limit splitting a block, the last page being exactly full
+		{
+			Limits{MaxResults: 4},
+			[]page{{4, &Cursor{BlockNumber: 3, LogIndex: 1}}, {4, nil}},
+		},
+		// Block range limit, including a page without any logs
+		{
+			Limits{MaxBlockRange: 20},
+			[]page{{7, &Cursor{BlockNumber: 20}}, {0, &Cursor{BlockNumber: 40}}, {1, nil}},
+		},
+		// Both limits, the result one hit first
+		{
+			Limits{MaxBlockRange: 20, MaxResults: 5},
+			[]page{{5, &Cursor{BlockNumber: 3, LogIndex: 2}}, {2, &Cursor{BlockNumber: 23}}, {1, &Cursor{BlockNumber: 43}}, {0, nil}},
+		},
+	}
+	for i, tt := range tests {
+		backend.limits = tt.limits
+
+		var cursor *Cursor
+		for j, want := range tt.pages {
+			filter := NewRangeFilter(backend, 0, -1, []common.Address{addr}, nil)
+			if cursor != nil {
+				filter.Resume(*cursor)
+			}
+			logs, next, err := filter.LogsPage(context.Background())
+			if err != nil {
+				t.Fatalf("test %d, page %d: query failed: %v", i, j, err)
+			}
+			if len(logs) != want.logs {
+				t.Errorf("test %d, page %d: log count mismatch: have %d, want %d", i, j, len(logs), want.logs)
+			}
+			if !reflect.DeepEqual(next, want.cursor) {
+				t.Fatalf("test %d, page %d: cursor mismatch: have %+v, want %+v", i, j, next, want.cursor)
+			}
+			if cursor != nil && len(logs) > 0 {
+				if first := logs[0]; first.BlockNumber < uint64(cursor.BlockNumber) || (first.BlockNumber == uint64(cursor.BlockNumber) && first.Index < uint(cursor.LogIndex)) {
+					t.Errorf("test %d, page %d: log before cursor returned: block %d, index %d", i, j, first.BlockNumber, first.Index)
+				}
+			}
+			cursor = next
+		}
+	}
+	// Plain queries hitting a limit fail with the cursor to resume from
+	backend.limits = Limits{MaxResults: 4}
+	_, err := NewRangeFilter(backend, 0, -1, []common.Address{addr}, nil).Logs(context.Background())
+	limitErr, ok := err.(*LimitError)
+	if !ok {
+		t.Fatalf("limit error mismatch: have %v, want *LimitError", err)
+	}
+	if want := (Cursor{BlockNumber: 3, LogIndex: 1}); limitErr.Cursor != want {
+		t.Errorf("limit error cursor mismatch: have %+v, want %+v", limitErr.Cursor, want)
+	}
+	if limitErr.ErrorCode() != -32005 {
+		t.Errorf("limit error code mismatch: have %d, want %d", limitErr.ErrorCode(), -32005)
+	}
+	// The cursor reaches RPC clients in the error data
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("eth", NewPublicFilterAPI(backend, false)); err != nil {
+		t.Fatalf("failed to register filter API: %v", err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	var logs []*types.Log
+	err = client.Call(&logs, "eth_getLogs", map[string]interface{}{"fromBlock": "0x0", "address": []common.Address{addr}})
+	if err == nil {
+		t.Fatalf("limited query succeeded with %d logs", len(logs))
+	}
+	if code := err.(rpc.Error).ErrorCode(); code != -32005 {
+		t.Errorf("rpc error code mismatch: have %d, want %d", code, -32005)
+	}
+	want := map[string]interface{}{"cursor": map[string]interface{}{"blockNumber": "0x3", "logIndex": "0x1"}}
+	if data := err.(rpc.DataError).ErrorData(); !reflect.DeepEqual(data, want) {
+		t.Errorf("rpc error data mismatch: have %v, want %v", data, want)
+	}
+}
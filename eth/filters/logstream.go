@@ -0,0 +1,221 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// logStreamReorgWindow is the number of blocks below the head for which the
+// delivered logs are remembered, to be delivered again as removed if their
+// blocks are rewound or reorged out of the chain.
+const logStreamReorgWindow = 1024
+
+// logStream delivers the logs matching a filter exactly once and in chain
+// order, reading them from the database rather than relying on chain events.
+// Events only signal that the stream needs to be synced with the chain again,
+// so a slow consumer falls behind instead of missing logs.
+type logStream struct {
+	backend   Backend
+	addresses []common.Address
+	topics    [][]common.Hash
+	deliver   func(*types.Log) error
+
+	next      Cursor        // Position of the first log not yet delivered
+	scanned   *types.Header // Last block searched for logs, nil if none yet
+	delivered []*types.Log  // Logs delivered within the reorg window
+}
+
+// newLogStream creates a log stream delivering the logs from the cursor on.
+func newLogStream(backend Backend, crit FilterCriteria, cursor Cursor, deliver func(*types.Log) error) *logStream {
+	return &logStream{
+		backend:   backend,
+		addresses: crit.Addresses,
+		topics:    crit.Topics,
+		deliver:   deliver,
+		next:      cursor,
+	}
+}
+
+// sync delivers the logs added to the chain since the last sync. Delivered logs
+// of blocks which are not canonical anymore are delivered again as removed,
+// and the stream resumes from the first of their blocks.
+func (s *logStream) sync(ctx context.Context) error {
+	head, err := s.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if head == nil || err != nil {
+		return err
+	}
+	if err := s.unwind(ctx); err != nil {
+		return err
+	}
+	// Search from the block after the last one searched if it's still canonical,
+	// or from the position after the last log delivered otherwise
+	begin := uint64(s.next.BlockNumber)
+	if s.scanned != nil && s.scanned.Number.Uint64() >= begin {
+		header, err := s.backend.HeaderByNumber(ctx, rpc.BlockNumber(s.scanned.Number.Int64()))
+		if err != nil {
+			return err
+		}
+		if header != nil && header.Hash() == s.scanned.Hash() {
+			begin = s.scanned.Number.Uint64() + 1
+		}
+	}
+	if begin > head.Number.Uint64() {
+		s.scanned = head
+		return nil
+	}
+	var cursor *Cursor
+	if begin == uint64(s.next.BlockNumber) {
+		cursor = &s.next
+	}
+	for {
+		filter := NewRangeFilter(s.backend, int64(begin), head.Number.Int64(), s.addresses, s.topics)
+		if cursor != nil {
+			filter.Resume(*cursor)
+		}
+		logs, next, err := filter.LogsPage(ctx)
+		if err != nil {
+			return err
+		}
+		for _, log := range logs {
+			if err := s.deliver(log); err != nil {
+				return err
+			}
+			hash := log.BlockHash
+			s.next = Cursor{BlockNumber: hexutil.Uint64(log.BlockNumber), LogIndex: hexutil.Uint(log.Index + 1), BlockHash: &hash}
+			s.delivered = append(s.delivered, log)
+		}
+		if next == nil {
+			break
+		}
+		begin, cursor = uint64(next.BlockNumber), next
+	}
+	s.scanned = head
+
+	// Forget the delivered logs which left the reorg window
+	var drop int
+	for drop < len(s.delivered) && s.delivered[drop].BlockNumber+logStreamReorgWindow < head.Number.Uint64() {
+		drop++
+	}
+	s.delivered = s.delivered[drop:]
+	return nil
+}
+
+// unwind delivers the delivered logs of the blocks which are not canonical
+// anymore again as removed, oldest first, and moves the stream back to the
+// first of these blocks.
+func (s *logStream) unwind(ctx context.Context) error {
+	keep := len(s.delivered)
+	for keep > 0 {
+		number := s.delivered[keep-1].BlockNumber
+		header, err := s.backend.HeaderByNumber(ctx, rpc.BlockNumber(number))
+		if err != nil {
+			return err
+		}
+		if header != nil && header.Hash() == s.delivered[keep-1].BlockHash {
+			break
+		}
+		for keep > 0 && s.delivered[keep-1].BlockNumber == number {
+			keep--
+		}
+	}
+	if keep == len(s.delivered) {
+		return nil
+	}
+	removed := s.delivered[keep:]
+	for _, log := range removed {
+		l := *log
+		l.Removed = true
+		if err := s.deliver(&l); err != nil {
+			return err
+		}
+	}
+	s.next = Cursor{BlockNumber: hexutil.Uint64(removed[0].BlockNumber)}
+	s.delivered = s.delivered[:keep]
+	return nil
+}
+
+// restore checks whether the block the stream was resumed in is still canonical.
+// If it is not, the logs delivered before the stream was resumed from the blocks
+// which left the chain are read from the database and delivered again as removed,
+// oldest first, and the stream moves back to the block after the common ancestor.
+// Rewound blocks are deleted from the database, in which case restore fails as
+// the removed logs can't be reproduced.
+func (s *logStream) restore(ctx context.Context) error {
+	if s.next.BlockHash == nil {
+		return nil
+	}
+	var (
+		resumed = *s.next.BlockHash
+		hash    = resumed
+		removed []*types.Log
+		filter  = NewBlockFilter(s.backend, hash, s.addresses, s.topics)
+	)
+	s.next.BlockHash = nil
+
+	for depth := 0; ; depth++ {
+		header, err := s.backend.HeaderByHash(ctx, hash)
+		if err != nil {
+			return err
+		}
+		if header == nil {
+			return fmt.Errorf("block %x of the cursor not found, the chain was rewound past it", hash)
+		}
+		canonical, err := s.backend.HeaderByNumber(ctx, rpc.BlockNumber(header.Number.Int64()))
+		if err != nil {
+			return err
+		}
+		if canonical != nil && canonical.Hash() == hash {
+			if depth > 0 {
+				s.next = Cursor{BlockNumber: hexutil.Uint64(header.Number.Uint64() + 1)}
+			}
+			break
+		}
+		if depth >= logStreamReorgWindow {
+			return fmt.Errorf("block %x of the cursor reorged out more than %d blocks deep", resumed, logStreamReorgWindow)
+		}
+		logs, err := filter.checkMatches(ctx, header)
+		if err != nil {
+			return err
+		}
+		if depth == 0 {
+			var delivered []*types.Log
+			for _, log := range logs {
+				if log.Index < uint(s.next.LogIndex) {
+					delivered = append(delivered, log)
+				}
+			}
+			logs = delivered
+		}
+		removed = append(logs, removed...)
+		hash = header.ParentHash
+	}
+	for _, log := range removed {
+		l := *log
+		l.Removed = true
+		if err := s.deliver(&l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
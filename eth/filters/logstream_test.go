@@ -0,0 +1,180 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// writeLogChain generates n blocks on top of parent, emitting a log in those
+// listed, and writes them into the database as the canonical chain.
+func writeLogChain(db ethdb.Database, parent *types.Block, n int, logBlocks ...uint64) []*types.Block {
+	addr := common.BytesToAddress([]byte("stream"))
+	chain, receipts := core.GenerateChain(params.TestChainConfig, parent, ethash.NewFaker(), db, n, func(i int, gen *core.BlockGen) {
+		for _, number := range logBlocks {
+			if gen.Number().Uint64() == number {
+				receipt := types.NewReceipt(nil, false, 0)
+				receipt.Logs = []*types.Log{{Address: addr}}
+				gen.AddUncheckedReceipt(receipt)
+				gen.AddUncheckedTx(types.NewTransaction(number, common.HexToAddress("0x1"), big.NewInt(1), 1, big.NewInt(1), nil))
+			}
+		}
+	})
+	for i, block := range chain {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+	return chain
+}
+
+func TestLogStream(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		backend = &testBackend{db: db, limits: Limits{MaxResults: 1}}
+		genesis = core.GenesisBlockForTesting(db, common.Address{}, big.NewInt(1000000))
+		chain   = writeLogChain(db, genesis, 10, 2, 5, 8)
+	)
+	var events []string
+	stream := newLogStream(backend, FilterCriteria{}, Cursor{}, func(log *types.Log) error {
+		events = append(events, fmt.Sprintf("%d/%v", log.BlockNumber, log.Removed))
+		return nil
+	})
+	check := func(want ...string) {
+		t.Helper()
+		events = nil
+		if err := stream.sync(context.Background()); err != nil {
+			t.Fatalf("sync failed: %v", err)
+		}
+		if !reflect.DeepEqual(events, want) {
+			t.Fatalf("delivered logs mismatch: have %v, want %v", events, want)
+		}
+	}
+	// Backfill up to the head, paging through the result limit, then follow it
+	rawdb.WriteHeadBlockHash(db, chain[5].Hash())
+	check("2/false", "5/false")
+	check()
+	rawdb.WriteHeadBlockHash(db, chain[9].Hash())
+	check("8/false")
+
+	// Rewind the chain and extend it with a different history
+	for number := uint64(5); number <= 10; number++ {
+		rawdb.DeleteCanonicalHash(db, number)
+	}
+	fork := writeLogChain(db, chain[3], 3, 6)
+	rawdb.WriteHeadBlockHash(db, fork[2].Hash())
+	check("5/true", "8/true", "6/false")
+	check()
+
+	// A stream resumed mid-chain skips the logs before the cursor
+	stream = newLogStream(backend, FilterCriteria{}, Cursor{BlockNumber: 2, LogIndex: 1}, stream.deliver)
+	check("6/false")
+}
+
+// Tests that a log stream resumed after its last block left the chain delivers
+// the logs received from the blocks which left as removed, and that it fails if
+// they are not available anymore.
+func TestLogsFromResumeAfterReorg(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		backend = &testBackend{db: db}
+		api     = NewPublicFilterAPI(backend, false)
+		server  = rpc.NewServer()
+		genesis = core.GenesisBlockForTesting(db, common.Address{}, big.NewInt(1000000))
+		chain   = writeLogChain(db, genesis, 10, 2, 5, 8)
+	)
+	defer server.Stop()
+	if err := server.RegisterName("eth", api); err != nil {
+		t.Fatalf("failed to register filter API: %v", err)
+	}
+	rawdb.WriteHeadBlockHash(db, chain[9].Hash())
+
+	// stream subscribes from the given cursor on, returning the delivered logs
+	// and the cursor after the last of them
+	stream := func(cursor *Cursor, want int) ([]string, *Cursor, error) {
+		client := rpc.DialInProc(server)
+		defer client.Close()
+
+		logs := make(chan types.Log)
+		sub, err := client.EthSubscribe(context.Background(), logs, "logsFrom", map[string]interface{}{"fromBlock": "0x0"}, cursor)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer sub.Unsubscribe()
+
+		var events []string
+		for len(events) < want {
+			select {
+			case log := <-logs:
+				events = append(events, fmt.Sprintf("%d/%v", log.BlockNumber, log.Removed))
+				cursor = &Cursor{BlockNumber: hexutil.Uint64(log.BlockNumber), LogIndex: hexutil.Uint(log.Index + 1), BlockHash: &log.BlockHash}
+			case err := <-sub.Err():
+				return events, cursor, err
+			case <-time.After(time.Second):
+				t.Fatalf("timeout, delivered logs: %v", events)
+			}
+		}
+		return events, cursor, nil
+	}
+	events, cursor, err := stream(nil, 3)
+	if err != nil {
+		t.Fatalf("failed to stream logs: %v", err)
+	}
+	if want := []string{"2/false", "5/false", "8/false"}; !reflect.DeepEqual(events, want) {
+		t.Fatalf("delivered logs mismatch: have %v, want %v", events, want)
+	}
+	// Reorg the chain while the client is disconnected
+	for number := uint64(5); number <= 10; number++ {
+		rawdb.DeleteCanonicalHash(db, number)
+	}
+	fork := writeLogChain(db, chain[3], 3, 6)
+	rawdb.WriteHeadBlockHash(db, fork[2].Hash())
+
+	events, cursor, err = stream(cursor, 3)
+	if err != nil {
+		t.Fatalf("failed to resume log stream: %v", err)
+	}
+	if want := []string{"5/true", "8/true", "6/false"}; !reflect.DeepEqual(events, want) {
+		t.Fatalf("delivered logs mismatch: have %v, want %v", events, want)
+	}
+	// Rewind the chain below the last delivered log while disconnected, deleting
+	// the blocks, resuming can't deliver the removed logs anymore
+	for _, block := range fork {
+		rawdb.DeleteCanonicalHash(db, block.NumberU64())
+		rawdb.DeleteBlock(db, block.Hash(), block.NumberU64())
+	}
+	rawdb.WriteHeadBlockHash(db, chain[3].Hash())
+
+	if _, _, err := stream(cursor, 0); err == nil {
+		t.Fatalf("resumed log stream past a rewind without removed logs")
+	}
+}
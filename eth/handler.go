@@ -17,6 +17,7 @@
 package eth
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -53,6 +54,10 @@ const (
 
 	// minimim number of peers to broadcast new blocks to
 	minBroadcastPeers = 4
+
+	// txMetaValidationTimeout is the maximum time the transaction metadata of a
+	// batch of blocks is validated against the L1 data, stalling its import.
+	txMetaValidationTimeout = 3 * time.Second
 )
 
 var (
@@ -88,6 +93,10 @@ type ProtocolManager struct {
 
 	whitelist map[uint64]common.Hash
 
+	// txMetaValidator checks the transaction metadata received from peers
+	// against the L1 data. It is nil if no L1 data is available.
+	txMetaValidator func(context.Context, []*types.Transaction) error
+
 	// channels for fetcher, syncer, txsyncLoop
 	newPeerCh   chan *peer
 	txsyncCh    chan *txsync
@@ -152,12 +161,15 @@ func NewProtocolManager(config *params.ChainConfig, checkpoint *params.TrustedCh
 	if atomic.LoadUint32(&manager.fastSync) == 1 {
 		stateBloom = trie.NewSyncBloom(uint64(cacheLimit), chaindb)
 	}
-	manager.downloader = downloader.New(manager.checkpointNumber, chaindb, stateBloom, manager.eventMux, blockchain, nil, manager.removePeer)
+	manager.downloader = downloader.New(manager.checkpointNumber, chaindb, stateBloom, manager.eventMux, &txMetaValidatingChain{blockchain, manager}, nil, manager.removePeer)
 
 	// Construct the fetcher (short sync)
 	validator := func(header *types.Header) error {
 		return engine.VerifyHeader(blockchain, header, true)
 	}
+	bodyValidator := func(block *types.Block) error {
+		return manager.validateTxMetas(types.Blocks{block})
+	}
 	heighter := func() uint64 {
 		return blockchain.CurrentBlock().NumberU64()
 	}
@@ -187,7 +199,7 @@ func NewProtocolManager(config *params.ChainConfig, checkpoint *params.TrustedCh
 		}
 		return n, err
 	}
-	manager.fetcher = fetcher.New(blockchain.GetBlockByHash, validator, manager.BroadcastBlock, heighter, inserter, manager.removePeer)
+	manager.fetcher = fetcher.New(blockchain.GetBlockByHash, validator, bodyValidator, manager.BroadcastBlock, heighter, inserter, manager.removePeer)
 
 	return manager, nil
 }
@@ -543,7 +555,7 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 				return errResp(ErrDecode, "msg %v: %v", msg, err)
 			}
 			// Retrieve the requested block body, stopping if enough was found
-			if data := pm.blockchain.GetBodyRLP(hash); len(data) != 0 {
+			if data := pm.getBodyRLP(p, hash); len(data) != 0 {
 				bodies = append(bodies, data)
 				bytes += len(data)
 			}
@@ -552,15 +564,30 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 
 	case msg.Code == BlockBodiesMsg:
 		// A batch of block bodies arrived to one of our previous requests
-		var request blockBodiesData
-		if err := msg.Decode(&request); err != nil {
-			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		var request blockBodiesDataOVM
+		if p.version >= ovm64 {
+			if err := msg.Decode(&request); err != nil {
+				return errResp(ErrDecode, "msg %v: %v", msg, err)
+			}
+		} else {
+			var legacy blockBodiesData
+			if err := msg.Decode(&legacy); err != nil {
+				return errResp(ErrDecode, "msg %v: %v", msg, err)
+			}
+			for _, body := range legacy {
+				request = append(request, &blockBodyOVM{Transactions: body.Transactions, Uncles: body.Uncles})
+			}
 		}
 		// Deliver them all to the downloader for queuing
 		transactions := make([][]*types.Transaction, len(request))
 		uncles := make([][]*types.Header, len(request))
 
 		for i, body := range request {
+			if p.version >= ovm64 {
+				if err := types.SetTxMetas(body.Transactions, body.Metas); err != nil {
+					return errResp(ErrInvalidTxMeta, "msg %v: body %d: %v", msg, i, err)
+				}
+			}
 			transactions[i] = body.Transactions
 			uncles[i] = body.Uncles
 		}
@@ -596,7 +623,12 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 				return errResp(ErrDecode, "msg %v: %v", msg, err)
 			}
 			// Retrieve the requested state entry, stopping if enough was found
-			if entry, err := pm.blockchain.TrieNode(hash); err == nil {
+			entry, err := pm.blockchain.TrieNode(hash)
+			if len(entry) == 0 || err != nil {
+				// Contract codes are stored apart from the trie nodes
+				entry, err = pm.blockchain.ContractCode(hash)
+			}
+			if err == nil && len(entry) > 0 {
 				data = append(data, entry)
 				bytes += len(entry)
 			}
@@ -684,7 +716,16 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 	case msg.Code == NewBlockMsg:
 		// Retrieve and decode the propagated block
 		var request newBlockData
-		if err := msg.Decode(&request); err != nil {
+		if p.version >= ovm64 {
+			var ovm newBlockDataOVM
+			if err := msg.Decode(&ovm); err != nil {
+				return errResp(ErrDecode, "%v: %v", msg, err)
+			}
+			if err := types.SetTxMetas(ovm.Block.Transactions(), ovm.Metas); err != nil {
+				return errResp(ErrInvalidTxMeta, "%v: %v", msg, err)
+			}
+			request = newBlockData{Block: ovm.Block, TD: ovm.TD}
+		} else if err := msg.Decode(&request); err != nil {
 			return errResp(ErrDecode, "%v: %v", msg, err)
 		}
 		if hash := types.CalcUncleHash(request.Block.Uncles()); hash != request.Block.UncleHash() {
@@ -749,6 +790,76 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 	return nil
 }
 
+// getBodyRLP retrieves the body of a block in the encoding of the peer's
+// protocol version, including the metadata of its transactions since ovm64.
+func (pm *ProtocolManager) getBodyRLP(p *peer, hash common.Hash) rlp.RawValue {
+	if p.version < ovm64 {
+		return pm.blockchain.GetBodyRLP(hash)
+	}
+	// Only full blocks are read along with the transaction metadata
+	block := pm.blockchain.GetBlockByHash(hash)
+	if block == nil {
+		return nil
+	}
+	data, err := rlp.EncodeToBytes(&blockBodyOVM{
+		Transactions: block.Transactions(),
+		Uncles:       block.Uncles(),
+		Metas:        types.EncodeTxMetas(block.Transactions()),
+	})
+	if err != nil {
+		log.Error("Failed to encode block body", "hash", hash, "err", err)
+		return nil
+	}
+	return data
+}
+
+// validateTxMetas validates the metadata received from peers on the transactions
+// of the given blocks against the L1 data, if available. The validation runs on
+// the import path rather than the peer's message loop, and is bounded by
+// txMetaValidationTimeout, after which the metadata is accepted as is.
+func (pm *ProtocolManager) validateTxMetas(blocks types.Blocks) error {
+	if pm.txMetaValidator == nil {
+		return nil
+	}
+	var txs []*types.Transaction
+	for _, block := range blocks {
+		txs = append(txs, block.Transactions()...)
+	}
+	if len(txs) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), txMetaValidationTimeout)
+	defer cancel()
+
+	return pm.txMetaValidator(ctx, txs)
+}
+
+// txMetaValidatingChain is the chain the downloader imports into, validating the
+// transaction metadata of every batch of blocks before inserting it. A batch
+// failing validation is rejected as an invalid chain, dropping the sync peer.
+type txMetaValidatingChain struct {
+	*core.BlockChain
+	pm *ProtocolManager
+}
+
+// InsertChain validates the transaction metadata of the blocks before inserting
+// them into the chain.
+func (c *txMetaValidatingChain) InsertChain(blocks types.Blocks) (int, error) {
+	if err := c.pm.validateTxMetas(blocks); err != nil {
+		return 0, err
+	}
+	return c.BlockChain.InsertChain(blocks)
+}
+
+// InsertReceiptChain validates the transaction metadata of the blocks before
+// inserting them into the chain along with their receipts.
+func (c *txMetaValidatingChain) InsertReceiptChain(blocks types.Blocks, receipts []types.Receipts, ancientLimit uint64) (int, error) {
+	if err := c.pm.validateTxMetas(blocks); err != nil {
+		return 0, err
+	}
+	return c.BlockChain.InsertReceiptChain(blocks, receipts, ancientLimit)
+}
+
 // BroadcastBlock will either propagate a block to a subset of it's peers, or
 // will only announce it's availability (depending what's requested).
 func (pm *ProtocolManager) BroadcastBlock(block *types.Block, propagate bool) {
@@ -17,6 +17,8 @@
 package eth
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"math"
 	"math/big"
@@ -269,6 +271,157 @@ func testGetBlockBodies(t *testing.T, protocol int) {
 	}
 }
 
+// Tests that block bodies retrieved over ovm64 carry the transaction metadata.
+func TestGetBlockBodiesOVM(t *testing.T) {
+	sender := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	generator := func(i int, block *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(block.TxNonce(testBank), common.Address{0x01}, big.NewInt(1), params.TxGas, nil, nil), types.HomesteadSigner{}, testBankKey)
+		index := uint64(i)
+		tx.SetTransactionMeta(types.NewTransactionMeta(big.NewInt(int64(i+1)), uint64(i+1), &sender, types.SighashEIP155, types.QueueOriginSequencer, &index, nil, nil))
+		block.AddTx(tx)
+	}
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 4, generator, nil)
+	peer, _ := newTestPeer("peer", ovm64, pm, true)
+	defer peer.close()
+
+	var (
+		hashes []common.Hash
+		bodies []*blockBodyOVM
+	)
+	for i := uint64(1); i <= 4; i++ {
+		block := pm.blockchain.GetBlockByNumber(i)
+		if meta := block.Transactions()[0].GetMeta(); meta.Index == nil || *meta.Index != i-1 {
+			t.Fatalf("block %d: transaction metadata not stored", i)
+		}
+		hashes = append(hashes, block.Hash())
+		bodies = append(bodies, &blockBodyOVM{
+			Transactions: block.Transactions(),
+			Uncles:       block.Uncles(),
+			Metas:        types.EncodeTxMetas(block.Transactions()),
+		})
+	}
+	p2p.Send(peer.app, GetBlockBodiesMsg, hashes)
+	if err := p2p.ExpectMsg(peer.app, BlockBodiesMsg, bodies); err != nil {
+		t.Errorf("bodies mismatch: %v", err)
+	}
+}
+
+// Tests that the transaction metadata of blocks propagated over ovm64 is set and
+// validated before the block is imported, without stalling the peer's message
+// loop, and that peers sending metadata not matching the L1 data are dropped.
+func TestNewBlockTxMetaValidation(t *testing.T) {
+	testNewBlockTxMetaValidation(t, true)
+	testNewBlockTxMetaValidation(t, false)
+}
+
+func testNewBlockTxMetaValidation(t *testing.T, valid bool) {
+	pm, db := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	defer pm.Stop()
+
+	var (
+		validated = make(chan []*types.Transaction, 1)
+		release   = make(chan struct{})
+	)
+	pm.txMetaValidator = func(ctx context.Context, txs []*types.Transaction) error {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Errorf("validation not bounded by a deadline")
+		}
+		validated <- txs
+		<-release
+		if !valid {
+			return errors.New("metadata mismatch")
+		}
+		return nil
+	}
+	peer, _ := newTestPeer("peer", ovm64, pm, true)
+	defer peer.close()
+
+	// Propagate a block whose transaction carries metadata
+	sender := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	chain, _ := core.GenerateChain(params.TestChainConfig, pm.blockchain.Genesis(), ethash.NewFaker(), db, 1, func(i int, block *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(block.TxNonce(testBank), common.Address{0x01}, big.NewInt(1), params.TxGas, nil, nil), types.HomesteadSigner{}, testBankKey)
+		block.AddTx(tx)
+	})
+	block := chain[0]
+	index := uint64(7)
+	block.Transactions()[0].SetTransactionMeta(types.NewTransactionMeta(big.NewInt(1), 1, &sender, types.SighashEIP155, types.QueueOriginSequencer, &index, nil, nil))
+	metas := types.EncodeTxMetas(block.Transactions())
+
+	td := new(big.Int).Add(pm.blockchain.GetTdByHash(pm.blockchain.Genesis().Hash()), block.Difficulty())
+	if err := p2p.Send(peer.app, NewBlockMsg, []interface{}{block, td, metas}); err != nil {
+		t.Fatalf("failed to propagate block: %v", err)
+	}
+	select {
+	case txs := <-validated:
+		if meta := txs[0].GetMeta(); meta.Index == nil || *meta.Index != index {
+			t.Fatalf("transaction metadata not set before validation")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("transaction metadata not validated")
+	}
+	// The peer must still be served while its metadata is being validated
+	p2p.Send(peer.app, GetBlockHeadersMsg, &getBlockHeadersData{Origin: hashOrNumber{Number: 0}, Amount: 1})
+	if err := p2p.ExpectMsg(peer.app, BlockHeadersMsg, []*types.Header{pm.blockchain.Genesis().Header()}); err != nil {
+		t.Fatalf("peer stalled by metadata validation: %v", err)
+	}
+	if pm.blockchain.CurrentBlock().NumberU64() != 0 {
+		t.Fatalf("block imported before its transaction metadata was validated")
+	}
+	close(release)
+
+	if !valid {
+		for start := time.Now(); pm.peers.Peer(peer.id) != nil; time.Sleep(10 * time.Millisecond) {
+			if time.Since(start) > time.Second {
+				t.Fatalf("peer with invalid transaction metadata not dropped")
+			}
+		}
+		if pm.blockchain.CurrentBlock().NumberU64() != 0 {
+			t.Fatalf("block with invalid transaction metadata imported")
+		}
+		return
+	}
+	for start := time.Now(); pm.blockchain.CurrentBlock().NumberU64() != 1; time.Sleep(10 * time.Millisecond) {
+		if time.Since(start) > time.Second {
+			t.Fatalf("block with valid transaction metadata not imported")
+		}
+	}
+	imported := pm.blockchain.GetBlockByNumber(1).Transactions()[0].GetMeta()
+	if imported.Index == nil || *imported.Index != index {
+		t.Fatalf("imported transaction metadata mismatch")
+	}
+}
+
+// Tests that the chain handed to the downloader rejects batches of blocks whose
+// transaction metadata fails validation.
+func TestDownloaderTxMetaValidation(t *testing.T) {
+	pm, db := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	defer pm.Stop()
+
+	var invalid bool
+	pm.txMetaValidator = func(ctx context.Context, txs []*types.Transaction) error {
+		if invalid {
+			return errors.New("metadata mismatch")
+		}
+		return nil
+	}
+	chain, _ := core.GenerateChain(params.TestChainConfig, pm.blockchain.Genesis(), ethash.NewFaker(), db, 2, func(i int, block *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(block.TxNonce(testBank), common.Address{0x01}, big.NewInt(1), params.TxGas, nil, nil), types.HomesteadSigner{}, testBankKey)
+		block.AddTx(tx)
+	})
+	validating := &txMetaValidatingChain{pm.blockchain, pm}
+
+	if _, err := validating.InsertChain(chain[:1]); err != nil {
+		t.Fatalf("failed to insert block with valid transaction metadata: %v", err)
+	}
+	invalid = true
+	if _, err := validating.InsertChain(chain[1:]); err == nil {
+		t.Fatalf("block with invalid transaction metadata inserted")
+	}
+	if head := pm.blockchain.CurrentBlock().NumberU64(); head != 1 {
+		t.Fatalf("chain head mismatch: have %d, want 1", head)
+	}
+}
+
 // Tests that the node state database can be retrieved based on hashes.
 func TestGetNodeData63(t *testing.T) { testGetNodeData(t, 63) }
 func TestGetNodeData64(t *testing.T) { testGetNodeData(t, 64) }
@@ -351,7 +504,7 @@ func testGetNodeData(t *testing.T, protocol int) {
 	}
 	accounts := []common.Address{testBank, acc1Addr, acc2Addr}
 	for i := uint64(0); i <= pm.blockchain.CurrentBlock().NumberU64(); i++ {
-		trie, _ := state.New(pm.blockchain.GetBlockByNumber(i).Root(), state.NewDatabase(statedb))
+		trie, _ := state.New(pm.blockchain.GetBlockByNumber(i).Root(), state.NewDatabase(statedb), nil)
 
 		for j, acc := range accounts {
 			state, _ := pm.blockchain.State()
@@ -33,16 +33,21 @@ import (
 const (
 	eth63 = 63
 	eth64 = 64
+
+	// ovm64 is eth/64 with the rollup transaction metadata carried along
+	// with block bodies and propagated blocks. It is numbered well above the
+	// upstream versions so it never collides with them.
+	ovm64 = 164
 )
 
 // protocolName is the official short name of the protocol used during capability negotiation.
 const protocolName = "eth"
 
 // ProtocolVersions are the supported versions of the eth protocol (first is primary).
-var ProtocolVersions = []uint{eth64, eth63}
+var ProtocolVersions = []uint{ovm64, eth64, eth63}
 
 // protocolLengths are the number of implemented message corresponding to different protocol versions.
-var protocolLengths = map[uint]uint64{eth64: 17, eth63: 17}
+var protocolLengths = map[uint]uint64{ovm64: 17, eth64: 17, eth63: 17}
 
 const protocolMaxMsgSize = 10 * 1024 * 1024 // Maximum cap on the size of a protocol message
 
@@ -74,6 +79,7 @@ const (
 	ErrForkIDRejected
 	ErrNoStatusMsg
 	ErrExtraStatusMsg
+	ErrInvalidTxMeta
 )
 
 func (e errCode) String() string {
@@ -91,6 +97,7 @@ var errorToString = map[int]string{
 	ErrForkIDRejected:          "Fork ID rejected",
 	ErrNoStatusMsg:             "No status message",
 	ErrExtraStatusMsg:          "Extra status message",
+	ErrInvalidTxMeta:           "Invalid transaction metadata",
 }
 
 type txPool interface {
@@ -194,6 +201,14 @@ func (request *newBlockData) sanityCheck() error {
 	return nil
 }
 
+// newBlockDataOVM is the network packet for the block propagation message
+// since ovm64, carrying the metadata of the block's transactions.
+type newBlockDataOVM struct {
+	Block *types.Block
+	TD    *big.Int
+	Metas [][]byte
+}
+
 // blockBody represents the data content of a single block.
 type blockBody struct {
 	Transactions []*types.Transaction // Transactions contained within a block
@@ -202,3 +217,15 @@ type blockBody struct {
 
 // blockBodiesData is the network packet for block content distribution.
 type blockBodiesData []*blockBody
+
+// blockBodyOVM represents the data content of a single block since ovm64,
+// including the metadata of its transactions.
+type blockBodyOVM struct {
+	Transactions []*types.Transaction // Transactions contained within a block
+	Uncles       []*types.Header      // Uncles contained within a block
+	Metas        [][]byte             // Metadata of the transactions within a block
+}
+
+// blockBodiesDataOVM is the network packet for block content distribution
+// since ovm64.
+type blockBodiesDataOVM []*blockBodyOVM
@@ -0,0 +1,224 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/trie"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// errRegenMemoryExceeded is returned if regenerating a historical state would
+// hold more trie nodes in memory than permitted.
+var errRegenMemoryExceeded = errors.New("regenerated state exceeds memory allowance")
+
+// regenDatabase is an in-memory trie database holding regenerated states. A
+// state regenerated on top of a cached one is committed into the same database,
+// so a database may be shared by several cached states.
+type regenDatabase struct {
+	database state.Database
+	size     common.StorageSize // Memory used by the trie nodes and preimages
+	states   int                // Number of cached states held by the database
+}
+
+// regeneratedState is a historical state reconstructed by re-executing blocks,
+// along with the in-memory trie database holding its nodes.
+type regeneratedState struct {
+	root     common.Hash
+	database *regenDatabase
+}
+
+// stateRegenerator retrieves the state of historical blocks which are not
+// available on disk any more, by re-executing blocks on top of the nearest
+// available ancestor state. Regenerated states are kept in an LRU cache, so
+// subsequent requests against the same or descendant blocks are cheap.
+type stateRegenerator struct {
+	chain  *core.BlockChain
+	db     ethdb.Database
+	memory common.StorageSize // Memory allowance for all regenerated states
+
+	states *lru.Cache         // Regenerated states, keyed by state root
+	size   common.StorageSize // Total memory used by the cached states
+	lock   sync.Mutex         // Serializes regenerations and protects the cache
+}
+
+// newStateRegenerator creates a state regenerator on top of the given chain,
+// caching at most the given number of states, within a memory allowance given
+// in megabytes.
+func newStateRegenerator(chain *core.BlockChain, db ethdb.Database, cache int, memory int) *stateRegenerator {
+	r := &stateRegenerator{
+		chain:  chain,
+		db:     db,
+		memory: common.StorageSize(memory * 1024 * 1024),
+	}
+	if cache > 0 {
+		r.states, _ = lru.NewWithEvict(cache, r.evicted)
+	}
+	return r
+}
+
+// evicted drops a state from the cache, releasing its database once no other
+// cached state is held by it. The trie nodes are never dereferenced, as states
+// handed out earlier may still be in use. The memory is reclaimed when the last
+// of them is dropped.
+func (r *stateRegenerator) evicted(key interface{}, value interface{}) {
+	regen := value.(*regeneratedState)
+	if regen.database.states--; regen.database.states == 0 {
+		r.size -= regen.database.size
+	}
+}
+
+// StateAt retrieves the state associated with the given header. If the state
+// is not available on disk, at most reexec blocks are re-executed on top of the
+// nearest available ancestor state to regenerate it.
+//
+// The returned state is a private copy which the caller may freely modify.
+func (r *stateRegenerator) StateAt(header *types.Header, reexec uint64) (*state.StateDB, error) {
+	// If we have the state fully available, use that
+	statedb, err := r.chain.StateAt(header.Root)
+	if err == nil || reexec == 0 {
+		return statedb, err
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if regen := r.cached(header.Root); regen != nil {
+		return state.New(header.Root, regen.database.database, nil)
+	}
+	// Otherwise walk back until we find a state or reach our limit, tracking
+	// the blocks which need to be re-executed
+	type blockID struct {
+		hash   common.Hash
+		number uint64
+	}
+	var (
+		blocks   = []blockID{{header.Hash(), header.Number.Uint64()}}
+		parent   = header
+		database *regenDatabase
+	)
+	for i := uint64(0); i < reexec && parent.Number.Uint64() > 0; i++ {
+		if parent = r.chain.GetHeader(parent.ParentHash, parent.Number.Uint64()-1); parent == nil {
+			break
+		}
+		if regen := r.cached(parent.Root); regen != nil {
+			database = regen.database
+		} else {
+			database = &regenDatabase{database: state.NewDatabaseWithCache(r.db, 16)}
+		}
+		if statedb, err = state.New(parent.Root, database.database, nil); err == nil {
+			break
+		}
+		blocks = append(blocks, blockID{parent.Hash(), parent.Number.Uint64()})
+	}
+	if err != nil {
+		switch err.(type) {
+		case *trie.MissingNodeError:
+			return nil, fmt.Errorf("required historical state unavailable (reexec=%d)", reexec)
+		default:
+			return nil, err
+		}
+	}
+	// State was available at historical point, regenerate
+	var (
+		start  = time.Now()
+		logged time.Time
+		proot  common.Hash
+	)
+	triedb := database.database.TrieDB()
+	release := func() {
+		if proot != (common.Hash{}) {
+			triedb.Dereference(proot)
+		}
+	}
+	for i := len(blocks) - 1; i >= 0; i-- {
+		// Print progress logs if long enough time elapsed
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Regenerating historical state", "block", blocks[i].number, "target", header.Number, "remaining", i, "elapsed", time.Since(start))
+			logged = time.Now()
+		}
+		// Retrieve the next block to regenerate and process it
+		block := r.chain.GetBlock(blocks[i].hash, blocks[i].number)
+		if block == nil {
+			release()
+			return nil, fmt.Errorf("block #%d not found", blocks[i].number)
+		}
+		if _, _, _, err := r.chain.Processor().Process(block, statedb, vm.Config{}); err != nil {
+			release()
+			return nil, fmt.Errorf("processing block %d failed: %v", block.NumberU64(), err)
+		}
+		// Finalize the state so any modifications are written to the trie
+		root, err := statedb.Commit(r.chain.Config().IsEIP158(block.Number()))
+		if err != nil {
+			release()
+			return nil, err
+		}
+		if err := statedb.Reset(root); err != nil {
+			release()
+			return nil, fmt.Errorf("state reset after block %d failed: %v", block.NumberU64(), err)
+		}
+		triedb.Reference(root, common.Hash{})
+		release()
+		proot = root
+
+		if nodes, imgs := triedb.Size(); nodes+imgs > r.memory {
+			release()
+			return nil, errRegenMemoryExceeded
+		}
+	}
+	nodes, imgs := triedb.Size()
+	log.Info("Historical state regenerated", "block", header.Number, "elapsed", time.Since(start), "nodes", nodes, "preimages", imgs)
+
+	// Cache the regenerated state, dropping old ones beyond the memory allowance.
+	// The memory of a shared database is accounted for only once.
+	if r.states == nil {
+		return statedb, nil
+	}
+	if database.states > 0 {
+		r.size -= database.size
+	}
+	database.size = nodes + imgs
+	database.states++
+	r.size += database.size
+
+	r.states.Add(proot, &regeneratedState{root: proot, database: database})
+	for r.size > r.memory && r.states.Len() > 1 {
+		r.states.RemoveOldest()
+	}
+	return statedb, nil
+}
+
+// cached returns the regenerated state with the given root from the cache.
+func (r *stateRegenerator) cached(root common.Hash) *regeneratedState {
+	if r.states == nil {
+		return nil
+	}
+	if regen, ok := r.states.Get(root); ok {
+		return regen.(*regeneratedState)
+	}
+	return nil
+}
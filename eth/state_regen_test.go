@@ -0,0 +1,251 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// newRegenTestChain creates an archive chain of the given length with a value
+// transfer in each block, returning it along with the recipient's balance at
+// every block. The trie nodes of the states after block pruned are deleted from
+// the database, simulating a non-archive node.
+func newRegenTestChain(t *testing.T, blocks int, pruned int) (*core.BlockChain, ethdb.Database, []*big.Int) {
+	var (
+		db    = rawdb.NewMemoryDatabase()
+		gspec = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  core.GenesisAlloc{testBank: {Balance: big.NewInt(1000000)}},
+		}
+		genesis = gspec.MustCommit(db)
+		signer  = types.HomesteadSigner{}
+	)
+	chain, _ := core.GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, blocks, func(i int, block *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(block.TxNonce(testBank), common.Address{0x01}, big.NewInt(1), params.TxGas, nil, nil), signer, testBankKey)
+		block.AddTx(tx)
+	})
+	cacheConfig := &core.CacheConfig{TrieDirtyDisabled: true}
+	blockchain, err := core.NewBlockChain(db, cacheConfig, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	if _, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	balances := []*big.Int{new(big.Int)}
+	for _, block := range chain {
+		statedb, err := blockchain.StateAt(block.Root())
+		if err != nil {
+			t.Fatalf("block %d: state missing: %v", block.NumberU64(), err)
+		}
+		balances = append(balances, statedb.GetBalance(common.Address{0x01}))
+	}
+	// Collect the nodes still needed by the retained states, delete all others
+	keep := make(map[common.Hash]bool)
+	iterate := func(root common.Hash, fn func(common.Hash)) {
+		tr, err := state.NewDatabase(db).OpenTrie(root)
+		if err != nil {
+			t.Fatalf("failed to open state trie %x: %v", root, err)
+		}
+		for it := tr.NodeIterator(nil); it.Next(true); {
+			if it.Hash() != (common.Hash{}) {
+				fn(it.Hash())
+			}
+		}
+	}
+	iterate(genesis.Root(), func(hash common.Hash) { keep[hash] = true })
+	for _, block := range chain[:pruned] {
+		iterate(block.Root(), func(hash common.Hash) { keep[hash] = true })
+	}
+	var drop []common.Hash
+	for _, block := range chain[pruned:] {
+		iterate(block.Root(), func(hash common.Hash) {
+			if !keep[hash] {
+				drop = append(drop, hash)
+			}
+		})
+	}
+	for _, hash := range drop {
+		db.Delete(hash.Bytes())
+	}
+	return blockchain, db, balances
+}
+
+// Tests that missing states are regenerated within the reexec limit and cached.
+func TestStateRegeneration(t *testing.T) {
+	chain, db, balances := newRegenTestChain(t, 8, 2)
+	defer chain.Stop()
+
+	regen := newStateRegenerator(chain, db, 4, 256)
+	head := chain.CurrentHeader()
+
+	// Without re-execution or with a too shallow limit, the state is unavailable
+	if _, err := regen.StateAt(head, 0); err == nil {
+		t.Fatalf("missing state retrieved without re-execution")
+	}
+	if _, err := regen.StateAt(head, 5); err == nil {
+		t.Fatalf("missing state regenerated beyond reexec limit")
+	}
+	// Regenerate the head state and ensure it's correct
+	statedb, err := regen.StateAt(head, 6)
+	if err != nil {
+		t.Fatalf("failed to regenerate state: %v", err)
+	}
+	if balance := statedb.GetBalance(common.Address{0x01}); balance.Cmp(balances[8]) != 0 {
+		t.Fatalf("regenerated balance mismatch: have %v, want %v", balance, balances[8])
+	}
+	if n := regen.states.Len(); n != 1 {
+		t.Fatalf("cached state count mismatch: have %d, want %d", n, 1)
+	}
+	// Modifying the returned state must not affect the cached one
+	statedb.SetBalance(common.Address{0x01}, big.NewInt(0))
+	if statedb, err = regen.StateAt(head, 1); err != nil {
+		t.Fatalf("failed to retrieve cached state: %v", err)
+	}
+	if balance := statedb.GetBalance(common.Address{0x01}); balance.Cmp(balances[8]) != 0 {
+		t.Fatalf("cached balance mismatch: have %v, want %v", balance, balances[8])
+	}
+	// Regenerate an intermediate state, which must not evict the head one
+	header := chain.GetHeaderByNumber(5)
+	if statedb, err = regen.StateAt(header, 3); err != nil {
+		t.Fatalf("failed to regenerate state: %v", err)
+	}
+	if balance := statedb.GetBalance(common.Address{0x01}); balance.Cmp(balances[5]) != 0 {
+		t.Fatalf("regenerated balance mismatch: have %v, want %v", balance, balances[5])
+	}
+	if n := regen.states.Len(); n != 2 {
+		t.Fatalf("cached state count mismatch: have %d, want %d", n, 2)
+	}
+	// A descendant of a cached state needs to re-execute only up to it
+	if statedb, err = regen.StateAt(chain.GetHeaderByNumber(6), 1); err != nil {
+		t.Fatalf("failed to regenerate state on top of cached one: %v", err)
+	}
+	if balance := statedb.GetBalance(common.Address{0x01}); balance.Cmp(balances[6]) != 0 {
+		t.Fatalf("regenerated balance mismatch: have %v, want %v", balance, balances[6])
+	}
+}
+
+// Tests that regenerations exceeding the memory allowance are rejected.
+func TestStateRegenerationMemoryLimit(t *testing.T) {
+	chain, db, _ := newRegenTestChain(t, 4, 1)
+	defer chain.Stop()
+
+	regen := newStateRegenerator(chain, db, 4, 0)
+	if _, err := regen.StateAt(chain.CurrentHeader(), 4); err != errRegenMemoryExceeded {
+		t.Fatalf("error mismatch: have %v, want %v", err, errRegenMemoryExceeded)
+	}
+	if n := regen.states.Len(); n != 0 {
+		t.Fatalf("cached state count mismatch: have %d, want %d", n, 0)
+	}
+}
+
+// Tests that the memory of a database shared by several cached states is only
+// accounted for once.
+func TestStateRegenerationSharedMemory(t *testing.T) {
+	chain, db, _ := newRegenTestChain(t, 8, 2)
+	defer chain.Stop()
+
+	regen := newStateRegenerator(chain, db, 4, 256)
+	if _, err := regen.StateAt(chain.GetHeaderByNumber(5), 3); err != nil {
+		t.Fatalf("failed to regenerate state: %v", err)
+	}
+	if _, err := regen.StateAt(chain.GetHeaderByNumber(8), 3); err != nil {
+		t.Fatalf("failed to regenerate state on top of cached one: %v", err)
+	}
+	if n := regen.states.Len(); n != 2 {
+		t.Fatalf("cached state count mismatch: have %d, want %d", n, 2)
+	}
+	database := regen.cached(chain.GetHeaderByNumber(8).Root).database
+	if database != regen.cached(chain.GetHeaderByNumber(5).Root).database {
+		t.Fatalf("descendant state not regenerated into the cached database")
+	}
+	if nodes, imgs := database.database.TrieDB().Size(); regen.size != nodes+imgs {
+		t.Fatalf("accounted memory mismatch: have %v, want %v", regen.size, nodes+imgs)
+	}
+	// Evicting one of the states keeps the database, evicting both releases it
+	regen.states.RemoveOldest()
+	if regen.size != database.size {
+		t.Fatalf("accounted memory mismatch: have %v, want %v", regen.size, database.size)
+	}
+	regen.states.RemoveOldest()
+	if regen.size != 0 {
+		t.Fatalf("accounted memory mismatch: have %v, want %v", regen.size, 0)
+	}
+}
+
+// Tests that states handed out remain usable after being evicted from the cache,
+// while other states are regenerated concurrently.
+func TestStateRegenerationEviction(t *testing.T) {
+	chain, db, balances := newRegenTestChain(t, 8, 2)
+	defer chain.Stop()
+
+	regen := newStateRegenerator(chain, db, 1, 256)
+
+	var (
+		wg   sync.WaitGroup
+		errc = make(chan error, 6*10)
+	)
+	for number := uint64(3); number <= 8; number++ {
+		wg.Add(1)
+		go func(number uint64) {
+			defer wg.Done()
+			for i := 0; i < 10; i++ {
+				statedb, err := regen.StateAt(chain.GetHeaderByNumber(number), 6)
+				if err != nil {
+					errc <- fmt.Errorf("block %d: failed to regenerate state: %v", number, err)
+					return
+				}
+				// Regenerating other states evicts this one in the meantime
+				for _, other := range []uint64{3, 8, 5} {
+					if _, err := regen.StateAt(chain.GetHeaderByNumber(other), 6); err != nil {
+						errc <- fmt.Errorf("block %d: failed to regenerate state: %v", other, err)
+						return
+					}
+				}
+				if balance := statedb.GetBalance(common.Address{0x01}); balance.Cmp(balances[number]) != 0 {
+					errc <- fmt.Errorf("block %d: balance mismatch: have %v, want %v", number, balance, balances[number])
+					return
+				}
+				if err := statedb.Error(); err != nil {
+					errc <- fmt.Errorf("block %d: state access failed: %v", number, err)
+					return
+				}
+			}
+		}(number)
+	}
+	wg.Wait()
+	close(errc)
+	for err := range errc {
+		t.Error(err)
+	}
+	if n := regen.states.Len(); n != 1 {
+		t.Fatalf("cached state count mismatch: have %d, want %d", n, 1)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"encoding/json"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// ResultTracer is a vm.Tracer which assembles its findings into a JSON result
+// after execution and which can be interrupted while running. Both the
+// JavaScript and the native tracers implement it.
+type ResultTracer interface {
+	vm.Tracer
+
+	// GetResult returns the result of the tracing, or any error encountered.
+	GetResult() (json.RawMessage, error)
+
+	// Stop terminates the tracing at the first opportune moment.
+	Stop(err error)
+}
+
+var _ ResultTracer = (*Tracer)(nil)
+
+var (
+	nativeLock sync.RWMutex
+	native     = make(map[string]func() ResultTracer) // Native tracer constructors by name
+)
+
+// RegisterNative makes a tracer implemented in Go available under the given
+// name, taking precedence over any JavaScript tracer of the same name.
+func RegisterNative(name string, ctor func() ResultTracer) {
+	nativeLock.Lock()
+	defer nativeLock.Unlock()
+
+	native[name] = ctor
+}
+
+// NewNative creates a new instance of the native tracer registered under the
+// given name, if there is one.
+func NewNative(name string) (ResultTracer, bool) {
+	nativeLock.RLock()
+	ctor, ok := native[name]
+	nativeLock.RUnlock()
+
+	if !ok {
+		return nil, false
+	}
+	return ctor(), true
+}
+
+// memorySlice returns a copy of the given memory region, or nil if the region
+// is empty or not fully within the memory, same as the JavaScript tracers.
+func memorySlice(memory *vm.Memory, offset, size *big.Int) []byte {
+	if size.Sign() == 0 || !offset.IsUint64() || !size.IsUint64() {
+		return nil
+	}
+	end := new(big.Int).Add(offset, size)
+	if !end.IsUint64() || end.Uint64() > uint64(memory.Len()) {
+		return nil
+	}
+	return memory.GetCopy(offset.Int64(), size.Int64())
+}
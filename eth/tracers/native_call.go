@@ -0,0 +1,286 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+func init() {
+	RegisterNative("callTracer", newCallTracer)
+}
+
+// callFrame is a single call of the call tracer output, with the same fields
+// and field order as produced by the JavaScript call tracer.
+type callFrame struct {
+	Type    string       `json:"type"`
+	From    string       `json:"from,omitempty"`
+	To      string       `json:"to,omitempty"`
+	Value   string       `json:"value,omitempty"`
+	Gas     string       `json:"gas,omitempty"`
+	GasUsed string       `json:"gasUsed,omitempty"`
+	Input   string       `json:"input,omitempty"`
+	Output  string       `json:"output,omitempty"`
+	Error   string       `json:"error,omitempty"`
+	Time    string       `json:"time,omitempty"`
+	Calls   []*callFrame `json:"calls,omitempty"`
+
+	// Bookkeeping of a call in progress, not part of the output
+	gasIn   uint64   // Gas available before the call opcode
+	gasCost uint64   // Cost of the call opcode
+	gas     *uint64  // Gas available within the call, nil if not yet known
+	outOff  *big.Int // Memory offset of the call output in the caller
+	outLen  *big.Int // Memory size of the call output in the caller
+}
+
+// callTracer is a native implementation of the JavaScript callTracer, which
+// reports all the internal calls made by a transaction.
+type callTracer struct {
+	callstack []*callFrame // Current recursive call stack of the execution
+	descended bool         // Whether an inner call has just been entered
+
+	// Transaction context, gathered at the start and end of the execution
+	typ     string
+	from    common.Address
+	to      common.Address
+	input   []byte
+	gas     uint64
+	value   *big.Int
+	output  []byte
+	gasUsed uint64
+	time    time.Duration
+	err     error
+
+	interrupt uint32 // Atomic flag to signal execution interruption
+	reason    error  // Textual reason for the interruption
+}
+
+// newCallTracer creates a new native call tracer.
+func newCallTracer() ResultTracer {
+	return &callTracer{callstack: []*callFrame{{}}}
+}
+
+// CaptureStart implements the Tracer interface to initialize the tracing operation.
+func (t *callTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	t.typ = "CALL"
+	if create {
+		t.typ = "CREATE"
+	}
+	t.from, t.to, t.input, t.gas, t.value = from, to, common.CopyBytes(input), gas, value
+	return nil
+}
+
+// CaptureState implements the Tracer interface to trace a single step of VM execution.
+func (t *callTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	// If tracing was interrupted, skip the remaining steps
+	if atomic.LoadUint32(&t.interrupt) > 0 {
+		return nil
+	}
+	// Capture any errors immediately
+	if err != nil {
+		t.fault(err)
+		return nil
+	}
+	// If a new contract is being created, add to the call stack
+	switch op {
+	case vm.CREATE, vm.CREATE2:
+		t.callstack = append(t.callstack, &callFrame{
+			Type:    op.String(),
+			From:    hexutil.Encode(contract.Address().Bytes()),
+			Input:   hexutil.Encode(memorySlice(memory, peek(stack, 1), peek(stack, 2))),
+			Value:   hexutil.EncodeBig(peek(stack, 0)),
+			gasIn:   gas,
+			gasCost: cost,
+		})
+		t.descended = true
+		return nil
+
+	case vm.SELFDESTRUCT:
+		// If a contract is being self destructed, gather that as a subcall too
+		top := t.callstack[len(t.callstack)-1]
+		top.Calls = append(top.Calls, &callFrame{Type: op.String()})
+		return nil
+
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		// Skip any pre-compile invocations, those are just fancy opcodes
+		to := common.BigToAddress(peek(stack, 1))
+		if _, ok := vm.PrecompiledContractsIstanbul[to]; ok {
+			return nil
+		}
+		off := 1
+		if op == vm.DELEGATECALL || op == vm.STATICCALL {
+			off = 0
+		}
+		call := &callFrame{
+			Type:    op.String(),
+			From:    hexutil.Encode(contract.Address().Bytes()),
+			To:      hexutil.Encode(to.Bytes()),
+			Input:   hexutil.Encode(memorySlice(memory, peek(stack, 2+off), peek(stack, 3+off))),
+			gasIn:   gas,
+			gasCost: cost,
+			outOff:  new(big.Int).Set(peek(stack, 4+off)),
+			outLen:  new(big.Int).Set(peek(stack, 5+off)),
+		}
+		if off == 1 {
+			call.Value = hexutil.EncodeBig(peek(stack, 2))
+		}
+		t.callstack = append(t.callstack, call)
+		t.descended = true
+		return nil
+	}
+	// If we've just descended into an inner call, retrieve it's true allowance. The
+	// gas of calls to plain accounts is unknown and left out.
+	if t.descended {
+		if depth >= len(t.callstack) {
+			t.callstack[len(t.callstack)-1].gas = &gas
+		}
+		t.descended = false
+	}
+	// If an existing call is returning, pop off the call stack
+	if op == vm.REVERT {
+		t.callstack[len(t.callstack)-1].Error = "execution reverted"
+		return nil
+	}
+	if depth != len(t.callstack)-1 {
+		return nil
+	}
+	call := t.callstack[len(t.callstack)-1]
+	t.callstack = t.callstack[:len(t.callstack)-1]
+
+	ret := peek(stack, 0)
+	if call.Type == "CREATE" || call.Type == "CREATE2" {
+		// If the call was a CREATE, retrieve the contract address and output code
+		call.GasUsed = hexInt(int64(call.gasIn) - int64(call.gasCost) - int64(gas))
+		if ret.Sign() != 0 {
+			addr := common.BigToAddress(ret)
+			call.To = hexutil.Encode(addr.Bytes())
+			call.Output = hexutil.Encode(env.StateDB.GetCode(addr))
+		} else if call.Error == "" {
+			call.Error = "internal failure"
+		}
+	} else if call.gas != nil {
+		// If the call was a contract call, retrieve the gas usage and output
+		call.GasUsed = hexInt(int64(call.gasIn) - int64(call.gasCost) + int64(*call.gas) - int64(gas))
+		if ret.Sign() != 0 {
+			call.Output = hexutil.Encode(memorySlice(memory, call.outOff, call.outLen))
+		} else if call.Error == "" {
+			call.Error = "internal failure"
+		}
+	}
+	if call.gas != nil {
+		call.Gas = hexInt(int64(*call.gas))
+	}
+	// Inject the call into the previous one
+	parent := t.callstack[len(t.callstack)-1]
+	parent.Calls = append(parent.Calls, call)
+	return nil
+}
+
+// CaptureFault implements the Tracer interface to trace an execution fault
+// while running an opcode.
+func (t *callTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if atomic.LoadUint32(&t.interrupt) > 0 {
+		return nil
+	}
+	t.fault(err)
+	return nil
+}
+
+// fault handles the failure of the topmost call.
+func (t *callTracer) fault(err error) {
+	// If the topmost call already reverted, don't handle the additional fault again
+	if t.callstack[len(t.callstack)-1].Error != "" {
+		return
+	}
+	// Pop off the just failed call, consuming all available gas
+	call := t.callstack[len(t.callstack)-1]
+	t.callstack = t.callstack[:len(t.callstack)-1]
+
+	call.Error = err.Error()
+	if call.gas != nil {
+		call.Gas = hexInt(int64(*call.gas))
+		call.GasUsed = call.Gas
+	}
+	// Flatten the failed call into its parent, or leave it in the stack if the
+	// last call failed too
+	if len(t.callstack) > 0 {
+		parent := t.callstack[len(t.callstack)-1]
+		parent.Calls = append(parent.Calls, call)
+		return
+	}
+	t.callstack = append(t.callstack, call)
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *callTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	t.output, t.gasUsed, t.time, t.err = common.CopyBytes(output), gasUsed, d, err
+	return nil
+}
+
+// GetResult returns the call tree of the traced transaction.
+func (t *callTracer) GetResult() (json.RawMessage, error) {
+	if atomic.LoadUint32(&t.interrupt) > 0 {
+		return nil, t.reason
+	}
+	result := &callFrame{
+		Type:    t.typ,
+		From:    hexutil.Encode(t.from.Bytes()),
+		To:      hexutil.Encode(t.to.Bytes()),
+		Value:   hexutil.EncodeBig(t.value),
+		Gas:     hexInt(int64(t.gas)),
+		GasUsed: hexInt(int64(t.gasUsed)),
+		Input:   hexutil.Encode(t.input),
+		Output:  hexutil.Encode(t.output),
+		Time:    t.time.String(),
+		Calls:   t.callstack[0].Calls,
+		Error:   t.callstack[0].Error,
+	}
+	if result.Error == "" && t.err != nil {
+		result.Error = t.err.Error()
+	}
+	if result.Error != "" {
+		result.Output = ""
+	}
+	return json.Marshal(result)
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *callTracer) Stop(err error) {
+	t.reason = err
+	atomic.StoreUint32(&t.interrupt, 1)
+}
+
+// peek returns the n-th item from the top of the stack, or zero if the stack
+// is not deep enough.
+func peek(stack *vm.Stack, n int) *big.Int {
+	if len(stack.Data()) <= n {
+		return new(big.Int)
+	}
+	return stack.Back(n)
+}
+
+// hexInt formats a signed integer the same way the JavaScript tracers do.
+func hexInt(n int64) string {
+	return "0x" + big.NewInt(n).Text(16)
+}
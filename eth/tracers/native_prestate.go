@@ -0,0 +1,176 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func init() {
+	RegisterNative("prestateTracer", newPrestateTracer)
+}
+
+// prestateAccount is a single account of the prestate tracer output.
+type prestateAccount struct {
+	Balance *hexutil.Big                `json:"balance"`
+	Nonce   uint64                      `json:"nonce"`
+	Code    hexutil.Bytes               `json:"code"`
+	Storage map[common.Hash]common.Hash `json:"storage"`
+}
+
+// prestateTracer is a native implementation of the JavaScript prestateTracer,
+// which outputs sufficient information to create a local execution of the
+// transaction from a custom assembled genesis block.
+type prestateTracer struct {
+	prestate map[common.Address]*prestateAccount // Genesis allocation being built
+	db       vm.StateDB                          // State database of the execution
+
+	// Transaction context, gathered at the start of the execution
+	create bool
+	from   common.Address
+	to     common.Address
+	value  *big.Int
+
+	interrupt uint32 // Atomic flag to signal execution interruption
+	reason    error  // Textual reason for the interruption
+}
+
+// newPrestateTracer creates a new native prestate tracer.
+func newPrestateTracer() ResultTracer {
+	return &prestateTracer{prestate: make(map[common.Address]*prestateAccount)}
+}
+
+// CaptureStart implements the Tracer interface to initialize the tracing operation.
+func (t *prestateTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	t.create, t.from, t.to, t.value = create, from, to, value
+	return nil
+}
+
+// CaptureState implements the Tracer interface to trace a single step of VM execution.
+func (t *prestateTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	// If tracing was interrupted, skip the remaining steps
+	if atomic.LoadUint32(&t.interrupt) > 0 {
+		return nil
+	}
+	// Add the current account if we just started tracing. Balance will potentially
+	// be wrong here, since this will include the value sent along with the message.
+	// We fix that in GetResult.
+	if t.db == nil {
+		t.db = env.StateDB
+		t.lookupAccount(contract.Address())
+	}
+	// Whenever new state is accessed, add it to the prestate
+	switch op {
+	case vm.EXTCODECOPY, vm.EXTCODESIZE, vm.BALANCE:
+		t.lookupAccount(common.BigToAddress(peek(stack, 0)))
+
+	case vm.CREATE:
+		from := contract.Address()
+		t.lookupAccount(crypto.CreateAddress(from, t.db.GetNonce(from)))
+
+	case vm.CREATE2:
+		// stack: endowment, offset, size, salt
+		code := memorySlice(memory, peek(stack, 1), peek(stack, 2))
+		salt := common.BigToHash(peek(stack, 3))
+		t.lookupAccount(crypto.CreateAddress2(contract.Address(), salt, crypto.Keccak256(code)))
+
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		t.lookupAccount(common.BigToAddress(peek(stack, 1)))
+
+	case vm.SSTORE, vm.SLOAD:
+		t.lookupStorage(contract.Address(), common.BigToHash(peek(stack, 0)))
+	}
+	return nil
+}
+
+// CaptureFault implements the Tracer interface to trace an execution fault
+// while running an opcode.
+func (t *prestateTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	return nil
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *prestateTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	return nil
+}
+
+// GetResult returns the assembled prestate of the traced transaction. If no
+// code was executed there is no state to report and the prestate is empty.
+func (t *prestateTracer) GetResult() (json.RawMessage, error) {
+	if atomic.LoadUint32(&t.interrupt) > 0 {
+		return nil, t.reason
+	}
+	if t.db != nil {
+		// At this point, we need to deduct the 'value' from the outer transaction,
+		// and move it back to the origin
+		t.lookupAccount(t.from)
+		t.lookupAccount(t.to)
+
+		from, to := t.prestate[t.from], t.prestate[t.to]
+		to.Balance = (*hexutil.Big)(new(big.Int).Sub(to.Balance.ToInt(), t.value))
+		from.Balance = (*hexutil.Big)(new(big.Int).Add(from.Balance.ToInt(), t.value))
+
+		// Decrement the caller's nonce, and remove empty create targets. We can
+		// blindly delete the contract prestate, as any existing state would have
+		// caused the transaction to be rejected as invalid in the first place.
+		if from.Nonce > 0 {
+			from.Nonce--
+		}
+		if t.create {
+			delete(t.prestate, t.to)
+		}
+	}
+	return json.Marshal(t.prestate)
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *prestateTracer) Stop(err error) {
+	t.reason = err
+	atomic.StoreUint32(&t.interrupt, 1)
+}
+
+// lookupAccount injects the specified account into the prestate.
+func (t *prestateTracer) lookupAccount(addr common.Address) {
+	if _, ok := t.prestate[addr]; ok {
+		return
+	}
+	t.prestate[addr] = &prestateAccount{
+		Balance: (*hexutil.Big)(new(big.Int).Set(t.db.GetBalance(addr))),
+		Nonce:   t.db.GetNonce(addr),
+		Code:    common.CopyBytes(t.db.GetCode(addr)),
+		Storage: make(map[common.Hash]common.Hash),
+	}
+}
+
+// lookupStorage injects the specified storage entry of the given account into
+// the prestate.
+func (t *prestateTracer) lookupStorage(addr common.Address, key common.Hash) {
+	t.lookupAccount(addr)
+
+	if _, ok := t.prestate[addr].Storage[key]; ok {
+		return
+	}
+	t.prestate[addr].Storage[key] = t.db.GetState(addr, key)
+}
@@ -0,0 +1,145 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/tests"
+)
+
+// runTracerTest executes the transaction of a tracer test case with the given
+// tracer attached and returns the decoded trace result, without timing info.
+func runTracerTest(t *testing.T, test *callTracerTest, tracer ResultTracer) interface{} {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(common.FromHex(test.Input), tx); err != nil {
+		t.Fatalf("failed to parse testcase input: %v", err)
+	}
+	signer := types.MakeSigner(test.Genesis.Config, new(big.Int).SetUint64(uint64(test.Context.Number)))
+	origin, _ := signer.Sender(tx)
+
+	context := vm.Context{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		Origin:      origin,
+		Coinbase:    test.Context.Miner,
+		BlockNumber: new(big.Int).SetUint64(uint64(test.Context.Number)),
+		Time:        new(big.Int).SetUint64(uint64(test.Context.Time)),
+		Difficulty:  (*big.Int)(test.Context.Difficulty),
+		GasLimit:    uint64(test.Context.GasLimit),
+		GasPrice:    tx.GasPrice(),
+	}
+	statedb := tests.MakePreState(rawdb.NewMemoryDatabase(), test.Genesis.Alloc)
+	evm := vm.NewEVM(context, statedb, test.Genesis.Config, vm.Config{Debug: true, Tracer: tracer})
+
+	msg, err := tx.AsMessage(signer)
+	if err != nil {
+		t.Fatalf("failed to prepare transaction for tracing: %v", err)
+	}
+	st := core.NewStateTransition(evm, msg, new(core.GasPool).AddGas(tx.Gas()))
+	if _, _, _, err = st.TransitionDb(); err != nil {
+		t.Fatalf("failed to execute transaction: %v", err)
+	}
+	res, err := tracer.GetResult()
+	if err != nil {
+		t.Fatalf("failed to retrieve trace result: %v", err)
+	}
+	var ret interface{}
+	if err := json.Unmarshal(res, &ret); err != nil {
+		t.Fatalf("failed to unmarshal trace result: %v", err)
+	}
+	if obj, ok := ret.(map[string]interface{}); ok {
+		delete(obj, "time")
+	}
+	return ret
+}
+
+// Tests that the native tracers produce the exact same output as their
+// JavaScript counterparts on all the datasets of the tracer test harness.
+func TestNativeTracerParity(t *testing.T) {
+	if vm.UsingOVM {
+		t.Skip("tracer test harness requires plain EVM execution")
+	}
+	files, err := ioutil.ReadDir("testdata")
+	if err != nil {
+		t.Fatalf("failed to retrieve tracer test suite: %v", err)
+	}
+	for _, name := range []string{"callTracer", "prestateTracer"} {
+		for _, file := range files {
+			if !strings.HasPrefix(file.Name(), "call_tracer_") {
+				continue
+			}
+			name, file := name, file // capture range variables
+			t.Run(name+"/"+camel(strings.TrimSuffix(strings.TrimPrefix(file.Name(), "call_tracer_"), ".json")), func(t *testing.T) {
+				t.Parallel()
+
+				blob, err := ioutil.ReadFile(filepath.Join("testdata", file.Name()))
+				if err != nil {
+					t.Fatalf("failed to read testcase: %v", err)
+				}
+				test := new(callTracerTest)
+				if err := json.Unmarshal(blob, test); err != nil {
+					t.Fatalf("failed to parse testcase: %v", err)
+				}
+				jsTracer, err := New(name)
+				if err != nil {
+					t.Fatalf("failed to create JavaScript tracer: %v", err)
+				}
+				nativeTracer, ok := NewNative(name)
+				if !ok {
+					t.Fatalf("native tracer %s not registered", name)
+				}
+				want := runTracerTest(t, test, jsTracer)
+				if have := runTracerTest(t, test, nativeTracer); !reflect.DeepEqual(have, want) {
+					have, _ := json.Marshal(have)
+					want, _ := json.Marshal(want)
+					t.Fatalf("trace mismatch:\nhave %s\nwant %s", have, want)
+				}
+			})
+		}
+	}
+}
+
+// Tests that interrupting a native tracer surfaces the interruption reason
+// instead of a partial result.
+func TestNativeTracerStop(t *testing.T) {
+	for _, name := range []string{"callTracer", "prestateTracer"} {
+		tracer, ok := NewNative(name)
+		if !ok {
+			t.Fatalf("native tracer %s not registered", name)
+		}
+		reason := errors.New("stopped")
+		tracer.Stop(reason)
+
+		if res, err := runTrace(tracer); err != reason {
+			t.Errorf("%s: result mismatch: have %s/%v, want error %v", name, res, err, reason)
+		}
+	}
+}
@@ -18,8 +18,12 @@
 package graphql
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"math/big"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
@@ -29,9 +33,9 @@ import (
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/diffdb"
 	"github.com/ethereum/go-ethereum/eth/filters"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
-	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
@@ -314,6 +318,73 @@ func (t *Transaction) Logs(ctx context.Context) (*[]*Log, error) {
 	return &ret, nil
 }
 
+// getMeta returns the rollup metadata associated with this transaction, if any.
+func (t *Transaction) getMeta(ctx context.Context) (*types.TransactionMeta, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil {
+		return nil, err
+	}
+	return tx.GetMeta(), nil
+}
+
+func (t *Transaction) QueueOrigin(ctx context.Context) (*string, error) {
+	meta, err := t.getMeta(ctx)
+	if err != nil || meta == nil || meta.QueueOrigin == nil {
+		return nil, err
+	}
+	var origin string
+	switch meta.QueueOrigin.Uint64() {
+	case uint64(types.QueueOriginSequencer):
+		origin = "sequencer"
+	case uint64(types.QueueOriginL1ToL2):
+		origin = "l1"
+	default:
+		return nil, nil
+	}
+	return &origin, nil
+}
+
+func (t *Transaction) L1TxOrigin(ctx context.Context) (*common.Address, error) {
+	meta, err := t.getMeta(ctx)
+	if err != nil || meta == nil {
+		return nil, err
+	}
+	return meta.L1MessageSender, nil
+}
+
+func (t *Transaction) L1BlockNumber(ctx context.Context) (*hexutil.Big, error) {
+	meta, err := t.getMeta(ctx)
+	if err != nil || meta == nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(meta.L1BlockNumber), nil
+}
+
+func (t *Transaction) L1Timestamp(ctx context.Context) (*hexutil.Uint64, error) {
+	meta, err := t.getMeta(ctx)
+	if err != nil || meta == nil {
+		return nil, err
+	}
+	ret := hexutil.Uint64(meta.L1Timestamp)
+	return &ret, nil
+}
+
+func (t *Transaction) CtcIndex(ctx context.Context) (*hexutil.Uint64, error) {
+	meta, err := t.getMeta(ctx)
+	if err != nil || meta == nil {
+		return nil, err
+	}
+	return (*hexutil.Uint64)(meta.Index), nil
+}
+
+func (t *Transaction) QueueIndex(ctx context.Context) (*hexutil.Uint64, error) {
+	meta, err := t.getMeta(ctx)
+	if err != nil || meta == nil {
+		return nil, err
+	}
+	return (*hexutil.Uint64)(meta.QueueIndex), nil
+}
+
 type BlockType int
 
 // Block represents an Ethereum block.
@@ -679,13 +750,37 @@ type BlockFilterCriteria struct {
 	Topics *[][]common.Hash
 }
 
+// limitError is a log query limit error, exposing the cursor to resume the
+// query from in the GraphQL error extensions.
+type limitError struct {
+	*filters.LimitError
+}
+
+func (e limitError) Extensions() map[string]interface{} {
+	return map[string]interface{}{
+		"code": e.ErrorCode(),
+		"cursor": map[string]interface{}{
+			"blockNumber": uint64(e.Cursor.BlockNumber),
+			"logIndex":    uint(e.Cursor.LogIndex),
+		},
+	}
+}
+
 // runFilter accepts a filter and executes it, returning all its results as
 // `Log` objects.
 func runFilter(ctx context.Context, be ethapi.Backend, filter *filters.Filter) ([]*Log, error) {
 	logs, err := filter.Logs(ctx)
+	if err, ok := err.(*filters.LimitError); ok {
+		return nil, limitError{err}
+	}
 	if err != nil || logs == nil {
 		return nil, err
 	}
+	return wrapLogs(be, logs), nil
+}
+
+// wrapLogs converts internal log entries into `Log` objects.
+func wrapLogs(be ethapi.Backend, logs []*types.Log) []*Log {
 	ret := make([]*Log, 0, len(logs))
 	for _, log := range logs {
 		ret = append(ret, &Log{
@@ -694,7 +789,40 @@ func runFilter(ctx context.Context, be ethapi.Backend, filter *filters.Filter) (
 			log:         log,
 		})
 	}
-	return ret, nil
+	return ret
+}
+
+// LogCursor is the position of a log entry to resume a log query from.
+type LogCursor struct {
+	cursor filters.Cursor
+}
+
+func (c *LogCursor) BlockNumber(ctx context.Context) hexutil.Uint64 {
+	return c.cursor.BlockNumber
+}
+
+func (c *LogCursor) LogIndex(ctx context.Context) int32 {
+	return int32(c.cursor.LogIndex)
+}
+
+// LogCursorInput is a LogCursor passed back by the client.
+type LogCursorInput struct {
+	BlockNumber hexutil.Uint64
+	LogIndex    int32
+}
+
+// LogPage is a page of log entries along with the cursor to the next page.
+type LogPage struct {
+	logs   []*Log
+	cursor *LogCursor
+}
+
+func (p *LogPage) Logs(ctx context.Context) []*Log {
+	return p.logs
+}
+
+func (p *LogPage) Cursor(ctx context.Context) *LogCursor {
+	return p.cursor
 }
 
 func (b *Block) Logs(ctx context.Context, args struct{ Filter BlockFilterCriteria }) ([]*Log, error) {
@@ -776,16 +904,19 @@ func (b *Block) Call(ctx context.Context, args struct {
 			return nil, err
 		}
 	}
-	result, gas, failed, err := ethapi.DoCall(ctx, b.backend, args.Data, *b.numberOrHash, nil, vm.Config{}, 5*time.Second, b.backend.RPCGasCap())
+	result, err := ethapi.DoCall(ctx, b.backend, args.Data, *b.numberOrHash, nil, vm.Config{}, 5*time.Second, b.backend.RPCGasCap())
+	if err != nil {
+		return nil, err
+	}
 	status := hexutil.Uint64(1)
-	if failed {
+	if result.Failed() {
 		status = 0
 	}
 	return &CallResult{
-		data:    hexutil.Bytes(result),
-		gasUsed: hexutil.Uint64(gas),
+		data:    result.ReturnData,
+		gasUsed: hexutil.Uint64(result.UsedGas),
 		status:  status,
-	}, err
+	}, nil
 }
 
 func (b *Block) EstimateGas(ctx context.Context, args struct {
@@ -842,16 +973,19 @@ func (p *Pending) Call(ctx context.Context, args struct {
 	Data ethapi.CallArgs
 }) (*CallResult, error) {
 	pendingBlockNr := rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
-	result, gas, failed, err := ethapi.DoCall(ctx, p.backend, args.Data, pendingBlockNr, nil, vm.Config{}, 5*time.Second, p.backend.RPCGasCap())
+	result, err := ethapi.DoCall(ctx, p.backend, args.Data, pendingBlockNr, nil, vm.Config{}, 5*time.Second, p.backend.RPCGasCap())
+	if err != nil {
+		return nil, err
+	}
 	status := hexutil.Uint64(1)
-	if failed {
+	if result.Failed() {
 		status = 0
 	}
 	return &CallResult{
-		data:    hexutil.Bytes(result),
-		gasUsed: hexutil.Uint64(gas),
+		data:    result.ReturnData,
+		gasUsed: hexutil.Uint64(result.UsedGas),
 		status:  status,
-	}, err
+	}, nil
 }
 
 func (p *Pending) EstimateGas(ctx context.Context, args struct {
@@ -950,7 +1084,7 @@ func (r *Resolver) Transaction(ctx context.Context, args struct{ Hash common.Has
 
 func (r *Resolver) SendRawTransaction(ctx context.Context, args struct{ Data hexutil.Bytes }) (common.Hash, error) {
 	tx := new(types.Transaction)
-	if err := rlp.DecodeBytes(args.Data, tx); err != nil {
+	if err := tx.UnmarshalBinary(args.Data); err != nil {
 		return common.Hash{}, err
 	}
 	hash, err := ethapi.SubmitTransaction(ctx, r.backend, tx)
@@ -978,26 +1112,52 @@ type FilterCriteria struct {
 }
 
 func (r *Resolver) Logs(ctx context.Context, args struct{ Filter FilterCriteria }) ([]*Log, error) {
+	return runFilter(ctx, r.backend, r.rangeFilter(args.Filter))
+}
+
+func (r *Resolver) LogsPage(ctx context.Context, args struct {
+	Filter FilterCriteria
+	Cursor *LogCursorInput
+}) (*LogPage, error) {
+	filter := r.rangeFilter(args.Filter)
+	if args.Cursor != nil {
+		if args.Cursor.LogIndex < 0 {
+			return nil, errors.New("negative cursor log index")
+		}
+		filter.Resume(filters.Cursor{BlockNumber: args.Cursor.BlockNumber, LogIndex: hexutil.Uint(args.Cursor.LogIndex)})
+	}
+	logs, cursor, err := filter.LogsPage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	page := &LogPage{logs: wrapLogs(r.backend, logs)}
+	if cursor != nil {
+		page.cursor = &LogCursor{cursor: *cursor}
+	}
+	return page, nil
+}
+
+// rangeFilter creates a range filter for the given criteria.
+func (r *Resolver) rangeFilter(crit FilterCriteria) *filters.Filter {
 	// Convert the RPC block numbers into internal representations
 	begin := rpc.LatestBlockNumber.Int64()
-	if args.Filter.FromBlock != nil {
-		begin = int64(*args.Filter.FromBlock)
+	if crit.FromBlock != nil {
+		begin = int64(*crit.FromBlock)
 	}
 	end := rpc.LatestBlockNumber.Int64()
-	if args.Filter.ToBlock != nil {
-		end = int64(*args.Filter.ToBlock)
+	if crit.ToBlock != nil {
+		end = int64(*crit.ToBlock)
 	}
 	var addresses []common.Address
-	if args.Filter.Addresses != nil {
-		addresses = *args.Filter.Addresses
+	if crit.Addresses != nil {
+		addresses = *crit.Addresses
 	}
 	var topics [][]common.Hash
-	if args.Filter.Topics != nil {
-		topics = *args.Filter.Topics
+	if crit.Topics != nil {
+		topics = *crit.Topics
 	}
 	// Construct the range filter
-	filter := filters.NewRangeFilter(filters.Backend(r.backend), begin, end, addresses, topics)
-	return runFilter(ctx, r.backend, filter)
+	return filters.NewRangeFilter(filters.Backend(r.backend), begin, end, addresses, topics)
 }
 
 func (r *Resolver) GasPrice(ctx context.Context) (hexutil.Big, error) {
@@ -1053,3 +1213,275 @@ func (r *Resolver) Syncing() (*SyncState, error) {
 	// Otherwise gather the block sync stats
 	return &SyncState{progress}, nil
 }
+
+func (r *Resolver) TransactionByIndex(ctx context.Context, args struct{ CtcIndex hexutil.Uint64 }) (*Transaction, error) {
+	// Every L2 block holds a single transaction, offset by the genesis block
+	number := rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(uint64(args.CtcIndex) + 1))
+	block := &Block{
+		backend:      r.backend,
+		numberOrHash: &number,
+	}
+	return block.TransactionAt(ctx, struct{ Index int32 }{0})
+}
+
+func (r *Resolver) Enqueue(ctx context.Context, args struct{ QueueIndex hexutil.Uint64 }) (*Transaction, error) {
+	entry := rawdb.ReadEnqueueLookupEntry(r.backend.ChainDb(), uint64(args.QueueIndex))
+	if entry == nil {
+		return nil, nil
+	}
+	tx, err := r.Transaction(ctx, struct{ Hash common.Hash }{entry.TxHash})
+	if err != nil || tx == nil {
+		return nil, err
+	}
+	// The lookup may point to a block that has since been rewound
+	if tx.block == nil {
+		return nil, nil
+	}
+	header, err := tx.block.resolveHeader(ctx)
+	if err != nil || header == nil || header.Number.Uint64() != entry.BlockNumber {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// EthContext represents the L1 context returned from the `rollupInfo` accessor.
+type EthContext struct {
+	context ethapi.EthContext
+}
+
+func (c *EthContext) BlockNumber() hexutil.Uint64 {
+	return hexutil.Uint64(c.context.BlockNumber)
+}
+
+func (c *EthContext) Timestamp() hexutil.Uint64 {
+	return hexutil.Uint64(c.context.Timestamp)
+}
+
+// RollupContext represents the rollup position returned from the `rollupInfo`
+// accessor.
+type RollupContext struct {
+	context ethapi.RollupContext
+}
+
+func (c *RollupContext) Index() hexutil.Uint64 {
+	return hexutil.Uint64(c.context.Index)
+}
+
+func (c *RollupContext) QueueIndex() hexutil.Uint64 {
+	return hexutil.Uint64(c.context.QueueIndex)
+}
+
+// RollupInfo represents the rollup state returned from the `rollupInfo` accessor.
+type RollupInfo struct {
+	backend ethapi.Backend
+}
+
+func (i *RollupInfo) Mode() string {
+	if i.backend.IsVerifier() {
+		return "verifier"
+	}
+	return "sequencer"
+}
+
+func (i *RollupInfo) Syncing() bool {
+	return i.backend.IsSyncing()
+}
+
+func (i *RollupInfo) Degraded() bool {
+	return i.backend.IsDegraded()
+}
+
+func (i *RollupInfo) EthContext() *EthContext {
+	bn, ts := i.backend.GetEthContext()
+	return &EthContext{ethapi.EthContext{BlockNumber: bn, Timestamp: ts}}
+}
+
+func (i *RollupInfo) RollupContext() *RollupContext {
+	index, queueIndex := i.backend.GetRollupContext()
+	return &RollupContext{ethapi.RollupContext{Index: index, QueueIndex: queueIndex}}
+}
+
+func (r *Resolver) RollupInfo(ctx context.Context) *RollupInfo {
+	return &RollupInfo{r.backend}
+}
+
+// StorageDiff represents a storage slot returned from the `stateDiff` accessor.
+type StorageDiff struct {
+	key diffdb.Key
+}
+
+func (d *StorageDiff) Key() common.Hash {
+	return d.key.Key
+}
+
+func (d *StorageDiff) Mutated() bool {
+	return d.key.Mutated
+}
+
+// AccountDiff represents an account returned from the `stateDiff` accessor.
+type AccountDiff struct {
+	address common.Address
+	keys    []diffdb.Key
+}
+
+func (d *AccountDiff) Address() common.Address {
+	return d.address
+}
+
+func (d *AccountDiff) Storage() []*StorageDiff {
+	ret := make([]*StorageDiff, 0, len(d.keys))
+	for _, key := range d.keys {
+		ret = append(ret, &StorageDiff{key})
+	}
+	return ret
+}
+
+func (r *Resolver) StateDiff(ctx context.Context, args BlockNumberArgs) ([]*AccountDiff, error) {
+	diff, err := ethapi.NewPublicBlockChainAPI(r.backend).GetStateDiff(ctx, args.NumberOrLatest())
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*AccountDiff, 0, len(diff))
+	for addr, keys := range diff {
+		ret = append(ret, &AccountDiff{address: addr, keys: keys})
+	}
+	// Sort the accounts to keep the output deterministic
+	sort.Slice(ret, func(i, j int) bool {
+		return bytes.Compare(ret[i].address[:], ret[j].address[:]) < 0
+	})
+	return ret, nil
+}
+
+// SubscriptionResolver is the top-level object of the GraphQL subscription
+// hierarchy. Subscriptions are backed by a filter event system, which is only
+// created once the first subscription is made.
+type SubscriptionResolver struct {
+	backend   ethapi.Backend
+	lightMode bool
+
+	eventsOnce sync.Once
+	events     *filters.EventSystem
+}
+
+// eventSystem returns the event system backing the subscriptions, creating it
+// if necessary.
+func (r *SubscriptionResolver) eventSystem() *filters.EventSystem {
+	r.eventsOnce.Do(func() {
+		r.events = filters.NewEventSystem(r.backend, r.lightMode)
+	})
+	return r.events
+}
+
+func (r *SubscriptionResolver) ProtocolVersion(ctx context.Context) (int32, error) {
+	return int32(r.backend.ProtocolVersion()), nil
+}
+
+func (r *SubscriptionResolver) NewBlocks(ctx context.Context) (<-chan *Block, error) {
+	headers := make(chan *types.Header)
+	sub := r.eventSystem().SubscribeNewHeads(headers)
+
+	blocks := make(chan *Block)
+	go func() {
+		defer close(blocks)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case header := <-headers:
+				hash := header.Hash()
+				numberOrHash := rpc.BlockNumberOrHashWithHash(hash, false)
+				block := &Block{
+					backend:      r.backend,
+					numberOrHash: &numberOrHash,
+					hash:         hash,
+					header:       header,
+				}
+				select {
+				case blocks <- block:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return blocks, nil
+}
+
+func (r *SubscriptionResolver) Logs(ctx context.Context, args struct{ Filter FilterCriteria }) (<-chan *Log, error) {
+	// Convert the filter criteria into an event system query, missing block
+	// numbers meaning the latest block just like for the `logs` query
+	var crit ethereum.FilterQuery
+	if args.Filter.FromBlock != nil {
+		crit.FromBlock = new(big.Int).SetUint64(uint64(*args.Filter.FromBlock))
+	}
+	if args.Filter.ToBlock != nil {
+		crit.ToBlock = new(big.Int).SetUint64(uint64(*args.Filter.ToBlock))
+	}
+	if args.Filter.Addresses != nil {
+		crit.Addresses = *args.Filter.Addresses
+	}
+	if args.Filter.Topics != nil {
+		crit.Topics = *args.Filter.Topics
+	}
+	matches := make(chan []*types.Log)
+	sub, err := r.eventSystem().SubscribeLogs(crit, matches)
+	if err != nil {
+		return nil, err
+	}
+	logs := make(chan *Log)
+	go func() {
+		defer close(logs)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case matched := <-matches:
+				for _, log := range matched {
+					entry := &Log{
+						backend:     r.backend,
+						transaction: &Transaction{backend: r.backend, hash: log.TxHash},
+						log:         log,
+					}
+					select {
+					case logs <- entry:
+					case <-ctx.Done():
+						return
+					}
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return logs, nil
+}
+
+func (r *SubscriptionResolver) PendingTransactions(ctx context.Context) (<-chan *Transaction, error) {
+	pending := make(chan []*types.Transaction)
+	sub := r.eventSystem().SubscribePendingTxs(pending)
+
+	txs := make(chan *Transaction)
+	go func() {
+		defer close(txs)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case batch := <-pending:
+				for _, pendingTx := range batch {
+					tx := &Transaction{backend: r.backend, hash: pendingTx.Hash(), tx: pendingTx}
+					select {
+					case txs <- tx:
+					case <-ctx.Done():
+						return
+					}
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return txs, nil
+}
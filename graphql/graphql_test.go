@@ -17,12 +17,471 @@
 package graphql
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/bloombits"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/diffdb"
+	"github.com/ethereum/go-ethereum/eth/filters"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
+// testBackend serves the chain written into its database. The methods it does
+// not implement panic through the embedded nil backend.
+type testBackend struct {
+	ethapi.Backend
+	db     ethdb.Database
+	limits filters.Limits
+	diffs  map[uint64]diffdb.Diff
+}
+
+func (b *testBackend) ChainDb() ethdb.Database { return b.db }
+
+func (b *testBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	hash := rawdb.ReadHeadBlockHash(b.db)
+	if number != rpc.LatestBlockNumber {
+		hash = rawdb.ReadCanonicalHash(b.db, uint64(number))
+	}
+	return b.HeaderByHash(ctx, hash)
+}
+
+func (b *testBackend) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	number := rawdb.ReadHeaderNumber(b.db, hash)
+	if number == nil {
+		return nil, nil
+	}
+	return rawdb.ReadHeader(b.db, hash, *number), nil
+}
+
+func (b *testBackend) HeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Header, error) {
+	if number, ok := blockNrOrHash.Number(); ok {
+		return b.HeaderByNumber(ctx, number)
+	}
+	hash, _ := blockNrOrHash.Hash()
+	return b.HeaderByHash(ctx, hash)
+}
+
+func (b *testBackend) BlockByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Block, error) {
+	header, _ := b.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	if header == nil {
+		return nil, nil
+	}
+	return rawdb.ReadBlock(b.db, header.Hash(), header.Number.Uint64()), nil
+}
+
+func (b *testBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, error) {
+	header, _ := b.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	if header == nil {
+		return nil, nil, errors.New("header not found")
+	}
+	return nil, header, nil
+}
+
+func (b *testBackend) GetPoolTransaction(hash common.Hash) *types.Transaction { return nil }
+
+func (b *testBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	number := rawdb.ReadHeaderNumber(b.db, hash)
+	if number == nil {
+		return nil, nil
+	}
+	return rawdb.ReadReceipts(b.db, hash, *number, params.TestChainConfig), nil
+}
+
+func (b *testBackend) GetLogs(ctx context.Context, hash common.Hash) ([][]*types.Log, error) {
+	receipts, _ := b.GetReceipts(ctx, hash)
+	logs := make([][]*types.Log, len(receipts))
+	for i, receipt := range receipts {
+		logs[i] = receipt.Logs
+	}
+	return logs, nil
+}
+
+func (b *testBackend) BloomStatus() (uint64, uint64) { return params.BloomBitsBlocks, 0 }
+
+func (b *testBackend) ServiceFilter(ctx context.Context, session *bloombits.MatcherSession) {}
+
+func (b *testBackend) LogQueryLimits() (uint64, int) {
+	return b.limits.MaxBlockRange, b.limits.MaxResults
+}
+
+func (b *testBackend) IsVerifier() bool                   { return true }
+func (b *testBackend) IsSyncing() bool                    { return false }
+func (b *testBackend) IsDegraded() bool                   { return true }
+func (b *testBackend) GetEthContext() (uint64, uint64)    { return 100, 1600000000 }
+func (b *testBackend) GetRollupContext() (uint64, uint64) { return 5, 3 }
+
+func (b *testBackend) GetDiff(number *big.Int) (diffdb.Diff, error) {
+	return b.diffs[number.Uint64()], nil
+}
+
+// newTestBackend writes a chain of n blocks into a fresh database, emitting the
+// given number of logs in each block listed in logs.
+func newTestBackend(n int, logs map[int]int) *testBackend {
+	db := rawdb.NewMemoryDatabase()
+	genesis := core.GenesisBlockForTesting(db, common.Address{}, big.NewInt(1000000))
+	chain, receipts := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, n, func(i int, gen *core.BlockGen) {
+		if count := logs[i+1]; count > 0 {
+			receipt := types.NewReceipt(nil, false, 0)
+			for j := 0; j < count; j++ {
+				receipt.Logs = append(receipt.Logs, &types.Log{Address: common.Address{0x01}})
+			}
+			gen.AddUncheckedReceipt(receipt)
+			gen.AddUncheckedTx(types.NewTransaction(uint64(i), common.Address{0x02}, big.NewInt(1), 1, big.NewInt(1), nil))
+		}
+	})
+	for i, block := range chain {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(db, block.Hash())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+	return &testBackend{db: db}
+}
+
+// newRollupTestBackend writes a chain holding a single transaction per block,
+// like the L2 chain, into a fresh database. The transaction of block n has
+// the canonical transaction chain index n-1. A sequencer transaction leads,
+// followed by one L1 to L2 transaction enqueued by each of the given origins.
+func newRollupTestBackend(origins []common.Address) *testBackend {
+	db := rawdb.NewMemoryDatabase()
+	genesis := core.GenesisBlockForTesting(db, common.Address{}, big.NewInt(1000000))
+	chain, receipts := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, len(origins)+1, func(i int, gen *core.BlockGen) {
+		var (
+			index = uint64(i)
+			tx    = types.NewTransaction(index, common.Address{0x02}, big.NewInt(1), 1, big.NewInt(1), nil)
+		)
+		if i == 0 {
+			tx.SetTransactionMeta(types.NewTransactionMeta(big.NewInt(10), 1000, nil, types.SighashEIP155, types.QueueOriginSequencer, &index, nil, nil))
+		} else {
+			queueIndex := index - 1
+			tx.SetTransactionMeta(types.NewTransactionMeta(big.NewInt(10), 1000, &origins[i-1], types.SighashEIP155, types.QueueOriginL1ToL2, &index, &queueIndex, nil))
+		}
+		gen.AddUncheckedReceipt(types.NewReceipt(nil, false, 0))
+		gen.AddUncheckedTx(tx)
+	})
+	for i, block := range chain {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(db, block.Hash())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+		rawdb.WriteTxLookupEntries(db, block)
+		rawdb.WriteTransactionMeta(db, block.NumberU64(), block.Transactions()[0].GetMeta())
+		rawdb.WriteEnqueueLookupEntries(db, block)
+	}
+	return &testBackend{db: db}
+}
+
+// query runs a GraphQL query against the backend, decoding the response data
+// into result and returning the error messages, if any.
+func query(t *testing.T, backend ethapi.Backend, q string, result interface{}) []string {
+	t.Helper()
+
+	handler, err := newHandler(backend)
+	if err != nil {
+		t.Fatalf("failed to create handler: %v", err)
+	}
+	body, _ := json.Marshal(map[string]string{"query": q})
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response %q: %v", rec.Body.String(), err)
+	}
+	var errs []string
+	for _, e := range resp.Errors {
+		errs = append(errs, e.Message)
+	}
+	if len(errs) == 0 {
+		if err := json.Unmarshal(resp.Data, result); err != nil {
+			t.Fatalf("failed to decode response data %s: %v", resp.Data, err)
+		}
+	}
+	return errs
+}
+
 func TestBuildSchema(t *testing.T) {
 	// Make sure the schema can be parsed and matched up to the object model.
 	if _, err := newHandler(nil); err != nil {
 		t.Errorf("Could not construct GraphQL handler: %v", err)
 	}
+	if _, err := newWebsocketHandler(nil, false, nil); err != nil {
+		t.Errorf("Could not construct GraphQL websocket handler: %v", err)
+	}
+}
+
+// Tests that the rollup fields of a transaction are read from its metadata.
+func TestTransactionRollupFields(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		sender     = common.HexToAddress("0x1234")
+		index      = uint64(7)
+		queueIndex = uint64(3)
+	)
+	tx := types.NewTransaction(0, common.Address{}, new(big.Int), 0, new(big.Int), nil)
+	tx.SetTransactionMeta(types.NewTransactionMeta(big.NewInt(100), 200, &sender, types.SighashEIP155, types.QueueOriginL1ToL2, &index, &queueIndex, nil))
+	gtx := &Transaction{hash: tx.Hash(), tx: tx}
+
+	if origin, _ := gtx.QueueOrigin(ctx); origin == nil || *origin != "l1" {
+		t.Errorf("queue origin mismatch: have %v, want l1", origin)
+	}
+	if origin, _ := gtx.L1TxOrigin(ctx); origin == nil || *origin != sender {
+		t.Errorf("l1 tx origin mismatch: have %v, want %x", origin, sender)
+	}
+	if number, _ := gtx.L1BlockNumber(ctx); number == nil || number.ToInt().Uint64() != 100 {
+		t.Errorf("l1 block number mismatch: have %v, want 100", number)
+	}
+	if ts, _ := gtx.L1Timestamp(ctx); ts == nil || *ts != 200 {
+		t.Errorf("l1 timestamp mismatch: have %v, want 200", ts)
+	}
+	if have, _ := gtx.CtcIndex(ctx); have == nil || uint64(*have) != index {
+		t.Errorf("ctc index mismatch: have %v, want %d", have, index)
+	}
+	if have, _ := gtx.QueueIndex(ctx); have == nil || uint64(*have) != queueIndex {
+		t.Errorf("queue index mismatch: have %v, want %d", have, queueIndex)
+	}
+}
+
+// Tests that log pages are returned along with the cursor to the next one.
+func TestLogsPage(t *testing.T) {
+	backend := newTestBackend(10, map[int]int{2: 3, 5: 1, 8: 1})
+	backend.limits.MaxResults = 2
+
+	type page struct {
+		LogsPage struct {
+			Logs []struct {
+				Index int32 `json:"index"`
+			} `json:"logs"`
+			Cursor *struct {
+				BlockNumber hexutil.Uint64 `json:"blockNumber"`
+				LogIndex    int32          `json:"logIndex"`
+			} `json:"cursor"`
+		} `json:"logsPage"`
+	}
+	var (
+		cursor = ""
+		counts []int
+	)
+	for i := 0; i < 5; i++ {
+		var res page
+		if errs := query(t, backend, `{ logsPage(filter: {fromBlock: 0}`+cursor+`) { logs { index } cursor { blockNumber logIndex } } }`, &res); len(errs) > 0 {
+			t.Fatalf("page %d: query failed: %v", i, errs)
+		}
+		counts = append(counts, len(res.LogsPage.Logs))
+		if res.LogsPage.Cursor == nil {
+			break
+		}
+		if i == 0 && (res.LogsPage.Cursor.BlockNumber != 2 || res.LogsPage.Cursor.LogIndex != 2) {
+			t.Errorf("page %d: cursor mismatch: have %+v, want block 2 log 2", i, *res.LogsPage.Cursor)
+		}
+		cursor = fmt.Sprintf(`, cursor: {blockNumber: %d, logIndex: %d}`, res.LogsPage.Cursor.BlockNumber, res.LogsPage.Cursor.LogIndex)
+	}
+	if want := []int{2, 2, 1}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("page sizes mismatch: have %v, want %v", counts, want)
+	}
+}
+
+// rollupTx is the subset of the transaction fields requested by the rollup
+// query tests.
+type rollupTx struct {
+	Hash        common.Hash     `json:"hash"`
+	QueueOrigin string          `json:"queueOrigin"`
+	L1TxOrigin  *common.Address `json:"l1TxOrigin"`
+	CtcIndex    *hexutil.Uint64 `json:"ctcIndex"`
+	QueueIndex  *hexutil.Uint64 `json:"queueIndex"`
+	Block       struct {
+		Number hexutil.Uint64 `json:"number"`
+	} `json:"block"`
+}
+
+const rollupTxFields = `{ hash queueOrigin l1TxOrigin ctcIndex queueIndex block { number } }`
+
+// Tests that transactions are looked up by their canonical transaction chain
+// index.
+func TestTransactionByIndex(t *testing.T) {
+	origin := common.HexToAddress("0x1234")
+	backend := newRollupTestBackend([]common.Address{origin})
+
+	var res struct {
+		First   *rollupTx `json:"first"`
+		Second  *rollupTx `json:"second"`
+		Missing *rollupTx `json:"missing"`
+	}
+	q := `{ first: transactionByIndex(ctcIndex: 0) ` + rollupTxFields + ` second: transactionByIndex(ctcIndex: 1) ` + rollupTxFields + ` missing: transactionByIndex(ctcIndex: 2) { hash } }`
+	if errs := query(t, backend, q, &res); len(errs) > 0 {
+		t.Fatalf("query failed: %v", errs)
+	}
+	for i, tx := range []*rollupTx{res.First, res.Second} {
+		if tx == nil {
+			t.Fatalf("transaction %d: not found", i)
+		}
+		block := rawdb.ReadBlock(backend.db, rawdb.ReadCanonicalHash(backend.db, uint64(i+1)), uint64(i+1))
+		if tx.Hash != block.Transactions()[0].Hash() || uint64(tx.Block.Number) != block.NumberU64() {
+			t.Errorf("transaction %d: position mismatch: have %x in block %d", i, tx.Hash, tx.Block.Number)
+		}
+		if tx.CtcIndex == nil || uint64(*tx.CtcIndex) != uint64(i) {
+			t.Errorf("transaction %d: ctc index mismatch: have %v", i, tx.CtcIndex)
+		}
+	}
+	if res.First.QueueOrigin != "sequencer" || res.First.L1TxOrigin != nil || res.First.QueueIndex != nil {
+		t.Errorf("sequencer transaction mismatch: %+v", res.First)
+	}
+	if res.Second.QueueOrigin != "l1" || res.Second.L1TxOrigin == nil || *res.Second.L1TxOrigin != origin {
+		t.Errorf("enqueued transaction mismatch: %+v", res.Second)
+	}
+	if res.Missing != nil {
+		t.Errorf("transaction beyond the head returned: %+v", res.Missing)
+	}
+}
+
+// Tests that L1 to L2 transactions are looked up by their queue index, and
+// that lookups pointing to rewound blocks are ignored.
+func TestEnqueue(t *testing.T) {
+	origins := []common.Address{common.HexToAddress("0x1234"), common.HexToAddress("0x5678")}
+	backend := newRollupTestBackend(origins)
+
+	// Point queue index 5 at the transaction of block 2 in a block that does
+	// not exist anymore
+	block := rawdb.ReadBlock(backend.db, rawdb.ReadCanonicalHash(backend.db, 2), 2)
+	stale := types.NewBlock(&types.Header{Number: big.NewInt(9)}, block.Transactions(), nil, nil)
+	queueIndex := uint64(5)
+	stale.Transactions()[0].GetMeta().QueueIndex = &queueIndex
+	rawdb.WriteEnqueueLookupEntries(backend.db, stale)
+
+	var res struct {
+		First   *rollupTx `json:"first"`
+		Second  *rollupTx `json:"second"`
+		Pending *rollupTx `json:"pending"`
+		Stale   *rollupTx `json:"stale"`
+	}
+	q := `{ first: enqueue(queueIndex: 0) ` + rollupTxFields + ` second: enqueue(queueIndex: 1) ` + rollupTxFields + ` pending: enqueue(queueIndex: 2) { hash } stale: enqueue(queueIndex: 5) { hash } }`
+	if errs := query(t, backend, q, &res); len(errs) > 0 {
+		t.Fatalf("query failed: %v", errs)
+	}
+	for i, tx := range []*rollupTx{res.First, res.Second} {
+		if tx == nil {
+			t.Fatalf("enqueue %d: not found", i)
+		}
+		if tx.QueueIndex == nil || uint64(*tx.QueueIndex) != uint64(i) {
+			t.Errorf("enqueue %d: queue index mismatch: have %v", i, tx.QueueIndex)
+		}
+		if tx.L1TxOrigin == nil || *tx.L1TxOrigin != origins[i] {
+			t.Errorf("enqueue %d: origin mismatch: have %v, want %x", i, tx.L1TxOrigin, origins[i])
+		}
+		if uint64(tx.Block.Number) != uint64(i+2) {
+			t.Errorf("enqueue %d: block mismatch: have %d, want %d", i, tx.Block.Number, i+2)
+		}
+	}
+	if res.Pending != nil {
+		t.Errorf("pending enqueue returned: %+v", res.Pending)
+	}
+	if res.Stale != nil {
+		t.Errorf("enqueue of a rewound block returned: %+v", res.Stale)
+	}
+}
+
+// Tests that the rollup state is read from the backend.
+func TestRollupInfo(t *testing.T) {
+	var res struct {
+		RollupInfo struct {
+			Mode       string `json:"mode"`
+			Syncing    bool   `json:"syncing"`
+			Degraded   bool   `json:"degraded"`
+			EthContext struct {
+				BlockNumber hexutil.Uint64 `json:"blockNumber"`
+				Timestamp   hexutil.Uint64 `json:"timestamp"`
+			} `json:"ethContext"`
+			RollupContext struct {
+				Index      hexutil.Uint64 `json:"index"`
+				QueueIndex hexutil.Uint64 `json:"queueIndex"`
+			} `json:"rollupContext"`
+		} `json:"rollupInfo"`
+	}
+	q := `{ rollupInfo { mode syncing degraded ethContext { blockNumber timestamp } rollupContext { index queueIndex } } }`
+	if errs := query(t, new(testBackend), q, &res); len(errs) > 0 {
+		t.Fatalf("query failed: %v", errs)
+	}
+	info := res.RollupInfo
+	if info.Mode != "verifier" || info.Syncing || !info.Degraded {
+		t.Errorf("rollup state mismatch: have mode %s, syncing %v, degraded %v", info.Mode, info.Syncing, info.Degraded)
+	}
+	if info.EthContext.BlockNumber != 100 || info.EthContext.Timestamp != 1600000000 {
+		t.Errorf("eth context mismatch: have %+v", info.EthContext)
+	}
+	if info.RollupContext.Index != 5 || info.RollupContext.QueueIndex != 3 {
+		t.Errorf("rollup context mismatch: have %+v", info.RollupContext)
+	}
+}
+
+// Tests that the state diff of a block is returned with its accounts sorted by
+// address.
+func TestStateDiff(t *testing.T) {
+	var (
+		backend = newTestBackend(3, nil)
+		addr1   = common.HexToAddress("0x01")
+		addr2   = common.HexToAddress("0x02")
+		slot    = common.HexToHash("0x0a")
+	)
+	// Like eth_getStateDiff, the diff of a block is looked up by its number
+	// plus one
+	backend.diffs = map[uint64]diffdb.Diff{
+		3: {
+			addr2: {{Key: slot, Mutated: true}},
+			addr1: {},
+		},
+	}
+	type accountDiff struct {
+		Address common.Address `json:"address"`
+		Storage []struct {
+			Key     common.Hash `json:"key"`
+			Mutated bool        `json:"mutated"`
+		} `json:"storage"`
+	}
+	var res struct {
+		Diff   []accountDiff `json:"diff"`
+		Latest []accountDiff `json:"latest"`
+	}
+	q := `{ diff: stateDiff(block: 2) { address storage { key mutated } } latest: stateDiff { address } }`
+	if errs := query(t, backend, q, &res); len(errs) > 0 {
+		t.Fatalf("query failed: %v", errs)
+	}
+	if len(res.Diff) != 2 || res.Diff[0].Address != addr1 || res.Diff[1].Address != addr2 {
+		t.Fatalf("account diff mismatch: have %+v", res.Diff)
+	}
+	if len(res.Diff[0].Storage) != 0 {
+		t.Errorf("unexpected storage diff: %+v", res.Diff[0].Storage)
+	}
+	if storage := res.Diff[1].Storage; len(storage) != 1 || storage[0].Key != slot || !storage[0].Mutated {
+		t.Errorf("storage diff mismatch: have %+v", storage)
+	}
+	if len(res.Latest) != 0 {
+		t.Errorf("unexpected diff of the latest block: %+v", res.Latest)
+	}
+	if errs := query(t, backend, `{ stateDiff(block: 9) { address } }`, new(interface{})); len(errs) == 0 {
+		t.Error("state diff of a missing block returned")
+	}
 }
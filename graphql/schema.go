@@ -16,7 +16,9 @@
 
 package graphql
 
-const schema string = `
+// schemaTypes contains the scalars and object types shared by the query and
+// subscription schemas.
+const schemaTypes string = `
     # Bytes32 is a 32 byte binary string, represented as 0x-prefixed hexadecimal.
     scalar Bytes32
     # Address is a 20 byte Ethereum address, represented as 0x-prefixed hexadecimal.
@@ -31,11 +33,6 @@ const schema string = `
     # Long is a 64 bit unsigned integer.
     scalar Long
 
-    schema {
-        query: Query
-        mutation: Mutation
-    }
-
     # Account is an Ethereum account at a particular block.
     type Account {
         # Address is the address owning the account.
@@ -115,6 +112,24 @@ const schema string = `
         # Logs is a list of log entries emitted by this transaction. If the
         # transaction has not yet been mined, this field will be null.
         logs: [Log!]
+
+        # QueueOrigin is the queue the transaction entered the rollup through,
+        # either "sequencer" or "l1".
+        queueOrigin: String
+        # L1TxOrigin is the L1 account that enqueued this transaction. This is
+        # null for transactions submitted to the sequencer.
+        l1TxOrigin: Address
+        # L1BlockNumber is the L1 block number this transaction was included
+        # with.
+        l1BlockNumber: BigInt
+        # L1Timestamp is the L1 timestamp this transaction was included with.
+        l1Timestamp: Long
+        # CtcIndex is the index of this transaction in the canonical transaction
+        # chain. This will be null if the transaction has not been indexed yet.
+        ctcIndex: Long
+        # QueueIndex is the index of this transaction in the L1 to L2 queue. This
+        # is null for transactions submitted to the sequencer.
+        queueIndex: Long
     }
 
     # BlockFilterCriteria encapsulates log filter criteria for a filter applied
@@ -260,6 +275,32 @@ const schema string = `
         topics: [[Bytes32!]!]
     }
 
+    # LogCursor is the position of a log entry within the chain, returned when
+    # a log query hits a limit of the node to resume the query from.
+    type LogCursor {
+        # BlockNumber is the number of the block holding the first log entry
+        # not yet returned.
+        blockNumber: Long!
+        # LogIndex is the index of the first log entry not yet returned within
+        # its block.
+        logIndex: Int!
+    }
+
+    # LogCursorInput is a LogCursor passed back to resume a log query.
+    input LogCursorInput {
+        blockNumber: Long!
+        logIndex: Int!
+    }
+
+    # LogPage is a page of log entries matching a filter.
+    type LogPage {
+        # Logs is the list of log entries in this page.
+        logs: [Log!]!
+        # Cursor is the position to retrieve the next page from, or null if all
+        # matching log entries have been returned.
+        cursor: LogCursor
+    }
+
     # SyncState contains the current synchronisation state of the client.
     type SyncState{
         # StartingBlock is the block number at which synchronisation started.
@@ -291,6 +332,61 @@ const schema string = `
       estimateGas(data: CallData!): Long!
     }
 
+    # EthContext is the latest L1 context known to the rollup.
+    type EthContext {
+        # BlockNumber is the number of the latest L1 block.
+        blockNumber: Long!
+        # Timestamp is the timestamp of the latest L1 block.
+        timestamp: Long!
+    }
+
+    # RollupContext is the position of the node in the rollup.
+    type RollupContext {
+        # Index is the latest canonical transaction chain index.
+        index: Long!
+        # QueueIndex is the latest L1 to L2 queue index.
+        queueIndex: Long!
+    }
+
+    # RollupInfo contains the current rollup state of the node.
+    type RollupInfo {
+        # Mode is the mode the node is running in, either "sequencer" or
+        # "verifier".
+        mode: String!
+        # Syncing is true if the node is syncing with L1.
+        syncing: Boolean!
+        # Degraded is true if the node fell behind its L1 data source.
+        degraded: Boolean!
+        # EthContext is the latest L1 context known to the node.
+        ethContext: EthContext!
+        # RollupContext is the position of the node in the rollup.
+        rollupContext: RollupContext!
+    }
+
+    # StorageDiff is a storage slot accessed while executing a block.
+    type StorageDiff {
+        # Key is the storage slot that was accessed.
+        key: Bytes32!
+        # Mutated is true if the slot was written to.
+        mutated: Boolean!
+    }
+
+    # AccountDiff is an account accessed while executing a block.
+    type AccountDiff {
+        # Address is the address of the account that was accessed.
+        address: Address!
+        # Storage is the list of storage slots accessed in the account.
+        storage: [StorageDiff!]!
+    }
+`
+
+// schema is the GraphQL schema served for queries and mutations over HTTP.
+const schema string = schemaTypes + `
+    schema {
+        query: Query
+        mutation: Mutation
+    }
+
     type Query {
         # Block fetches an Ethereum block by number or by hash. If neither is
         # supplied, the most recent known block is returned.
@@ -302,8 +398,14 @@ const schema string = `
         pending: Pending!
         # Transaction returns a transaction specified by its hash.
         transaction(hash: Bytes32!): Transaction
-        # Logs returns log entries matching the provided filter.
+        # Logs returns log entries matching the provided filter. The query fails
+        # if it hits a limit of the node, the error extensions holding the
+        # cursor to resume from with logsPage.
         logs(filter: FilterCriteria!): [Log!]!
+        # LogsPage returns log entries matching the provided filter, starting
+        # from the cursor if supplied. If a limit of the node is hit, the log
+        # entries found so far are returned along with the cursor to resume from.
+        logsPage(filter: FilterCriteria!, cursor: LogCursorInput): LogPage!
         # GasPrice returns the node's estimate of a gas price sufficient to
         # ensure a transaction is mined in a timely fashion.
         gasPrice: BigInt!
@@ -311,6 +413,18 @@ const schema string = `
         protocolVersion: Int!
         # Syncing returns information on the current synchronisation state.
         syncing: SyncState
+        # TransactionByIndex returns the transaction at the given index of the
+        # canonical transaction chain.
+        transactionByIndex(ctcIndex: Long!): Transaction
+        # Enqueue returns the L1 to L2 transaction at the given queue index, or
+        # null if it has not been included in the L2 chain yet.
+        enqueue(queueIndex: Long!): Transaction
+        # RollupInfo returns the current rollup state of the node.
+        rollupInfo: RollupInfo!
+        # StateDiff returns the accounts and storage slots accessed while
+        # executing a block. If block is not supplied, the most recent known
+        # block is used.
+        stateDiff(block: Long): [AccountDiff!]!
     }
 
     type Mutation {
@@ -318,3 +432,30 @@ const schema string = `
         sendRawTransaction(data: Bytes!): Bytes32!
     }
 `
+
+// subscriptionSchema is the GraphQL schema served for subscriptions over
+// websocket. It shares its types with the query schema, but is resolved by a
+// different root object as both define a logs field.
+const subscriptionSchema string = schemaTypes + `
+    schema {
+        query: Query
+        subscription: Subscription
+    }
+
+    # Query is required by GraphQL, but the full set of queries is only served
+    # over HTTP.
+    type Query {
+        # ProtocolVersion returns the current wire protocol version number.
+        protocolVersion: Int!
+    }
+
+    type Subscription {
+        # NewBlocks emits every new block added to the canonical chain.
+        newBlocks: Block!
+        # Logs emits new log entries matching the provided filter.
+        logs(filter: FilterCriteria!): Log!
+        # PendingTransactions emits every transaction added to the transaction
+        # pool.
+        pendingTransactions: Transaction!
+    }
+`
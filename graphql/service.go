@@ -20,34 +20,39 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gorilla/websocket"
 	"github.com/graph-gophers/graphql-go"
 	"github.com/graph-gophers/graphql-go/relay"
 )
 
 // Service encapsulates a GraphQL service.
 type Service struct {
-	endpoint string           // The host:port endpoint for this service.
-	cors     []string         // Allowed CORS domains
-	vhosts   []string         // Recognised vhosts
-	timeouts rpc.HTTPTimeouts // Timeout settings for HTTP requests.
-	backend  ethapi.Backend   // The backend that queries will operate onn.
-	handler  http.Handler     // The `http.Handler` used to answer queries.
-	listener net.Listener     // The listening socket.
+	endpoint  string           // The host:port endpoint for this service.
+	cors      []string         // Allowed CORS domains
+	vhosts    []string         // Recognised vhosts
+	timeouts  rpc.HTTPTimeouts // Timeout settings for HTTP requests.
+	backend   ethapi.Backend   // The backend that queries will operate onn.
+	lightMode bool             // Whether the backend is a light client.
+	handler   http.Handler     // The `http.Handler` used to answer queries.
+	wsHandler http.Handler     // The `http.Handler` used to serve subscriptions.
+	listener  net.Listener     // The listening socket.
 }
 
 // New constructs a new GraphQL service instance.
-func New(backend ethapi.Backend, endpoint string, cors, vhosts []string, timeouts rpc.HTTPTimeouts) (*Service, error) {
+func New(backend ethapi.Backend, lightMode bool, endpoint string, cors, vhosts []string, timeouts rpc.HTTPTimeouts) (*Service, error) {
 	return &Service{
-		endpoint: endpoint,
-		cors:     cors,
-		vhosts:   vhosts,
-		timeouts: timeouts,
-		backend:  backend,
+		endpoint:  endpoint,
+		cors:      cors,
+		vhosts:    vhosts,
+		timeouts:  timeouts,
+		backend:   backend,
+		lightMode: lightMode,
 	}, nil
 }
 
@@ -65,11 +70,27 @@ func (s *Service) Start(server *p2p.Server) error {
 	if err != nil {
 		return err
 	}
+	s.wsHandler, err = newWebsocketHandler(s.backend, s.lightMode, s.cors)
+	if err != nil {
+		return err
+	}
 	if s.listener, err = net.Listen("tcp", s.endpoint); err != nil {
 		return err
 	}
-	go rpc.NewHTTPServer(s.cors, s.vhosts, s.timeouts, s.handler).Serve(s.listener)
-	log.Info("GraphQL endpoint opened", "url", fmt.Sprintf("http://%s", s.endpoint))
+	// Websocket upgrades bypass the wrappers of the HTTP server, as response
+	// compression would prevent taking over the connection. The virtual hosts
+	// are still enforced to guard against DNS rebinding.
+	srv := rpc.NewHTTPServer(s.cors, s.vhosts, s.timeouts, s.handler)
+	handler, wsHandler := srv.Handler, rpc.NewVHostHandler(s.vhosts, s.wsHandler)
+	srv.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if websocket.IsWebSocketUpgrade(r) && strings.HasPrefix(r.URL.Path, "/graphql") {
+			wsHandler.ServeHTTP(w, r)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+	go srv.Serve(s.listener)
+	log.Info("GraphQL endpoint opened", "url", fmt.Sprintf("http://%s", s.endpoint), "ws", fmt.Sprintf("ws://%s/graphql", s.endpoint))
 	return nil
 }
 
@@ -0,0 +1,289 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/gorilla/websocket"
+	"github.com/graph-gophers/graphql-go"
+)
+
+const (
+	wsProtocol          = "graphql-ws"     // Websocket subprotocol spoken by the server
+	wsReadLimit         = 1024 * 1024      // Maximum size of a client message
+	wsWriteTimeout      = 10 * time.Second // Maximum time to deliver a server message
+	wsKeepAliveInterval = 30 * time.Second // Interval between keep-alive messages
+)
+
+// Message types of the graphql-ws protocol, as defined by
+// https://github.com/apollographql/subscriptions-transport-ws/blob/master/PROTOCOL.md
+const (
+	wsConnectionInit      = "connection_init"
+	wsConnectionAck       = "connection_ack"
+	wsConnectionError     = "connection_error"
+	wsConnectionKeepAlive = "ka"
+	wsConnectionTerminate = "connection_terminate"
+	wsStart               = "start"
+	wsStop                = "stop"
+	wsData                = "data"
+	wsError               = "error"
+	wsComplete            = "complete"
+)
+
+var (
+	errUnknownMessage     = errors.New("unknown message type")
+	errDuplicateOperation = errors.New("operation id already in use")
+)
+
+// wsMessage is a single message exchanged over a graphql-ws connection.
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// wsStartPayload is the payload of a start message, requesting the execution
+// of a subscription operation.
+type wsStartPayload struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// wsHandler is an `http.Handler` serving GraphQL subscriptions to websocket
+// connections using the graphql-ws protocol.
+type wsHandler struct {
+	schema   *graphql.Schema
+	upgrader websocket.Upgrader
+}
+
+// newWebsocketHandler returns a new `http.Handler` that will serve GraphQL
+// subscriptions over websocket, accepting connections from the given origins.
+func newWebsocketHandler(backend ethapi.Backend, lightMode bool, origins []string) (http.Handler, error) {
+	s, err := graphql.ParseSchema(subscriptionSchema, &SubscriptionResolver{backend: backend, lightMode: lightMode})
+	if err != nil {
+		return nil, err
+	}
+	return &wsHandler{
+		schema: s,
+		upgrader: websocket.Upgrader{
+			Subprotocols: []string{wsProtocol},
+			CheckOrigin:  wsOriginValidator(origins),
+		},
+	}, nil
+}
+
+// wsOriginValidator returns a function that verifies the origin during the
+// websocket upgrade. Connections without an origin are always accepted, others
+// only if listed in origins or if it contains '*'. If no origins are given,
+// only localhost is accepted.
+func wsOriginValidator(origins []string) func(*http.Request) bool {
+	allowed := make(map[string]struct{})
+	for _, origin := range origins {
+		if origin != "" {
+			allowed[strings.ToLower(origin)] = struct{}{}
+		}
+	}
+	if len(allowed) == 0 {
+		allowed["http://localhost"] = struct{}{}
+		if hostname, err := os.Hostname(); err == nil {
+			allowed["http://"+strings.ToLower(hostname)] = struct{}{}
+		}
+	}
+	return func(r *http.Request) bool {
+		// Non-browser clients can forge the origin anyway, only browsers need
+		// to be checked and they always send one
+		if _, ok := r.Header["Origin"]; !ok {
+			return true
+		}
+		origin := strings.ToLower(r.Header.Get("Origin"))
+		if _, ok := allowed["*"]; ok {
+			return true
+		}
+		if _, ok := allowed[origin]; ok {
+			return true
+		}
+		log.Warn("Rejected GraphQL websocket connection", "origin", origin)
+		return false
+	}
+}
+
+// ServeHTTP upgrades the request to a websocket connection and serves GraphQL
+// subscriptions over it until the connection is closed.
+func (h *wsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Debug("GraphQL websocket upgrade failed", "err", err)
+		return
+	}
+	conn.SetReadLimit(wsReadLimit)
+
+	c := &wsConn{
+		conn:   conn,
+		schema: h.schema,
+		ops:    make(map[string]context.CancelFunc),
+	}
+	c.serve()
+}
+
+// wsConn is a single graphql-ws connection along with its running operations.
+type wsConn struct {
+	conn   *websocket.Conn
+	schema *graphql.Schema
+
+	writeLock sync.Mutex                    // Serializes writes to the connection
+	opsLock   sync.Mutex                    // Protects the running operations
+	ops       map[string]context.CancelFunc // Running operations, by client id
+	wg        sync.WaitGroup                // Tracks the running operations
+}
+
+// serve reads and handles client messages until the connection fails or is
+// terminated by the client, stopping all running operations afterwards.
+func (c *wsConn) serve() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		c.wg.Wait()
+		c.conn.Close()
+	}()
+	var acked bool
+	for {
+		var msg wsMessage
+		if err := c.conn.ReadJSON(&msg); err != nil {
+			log.Debug("GraphQL websocket read failed", "err", err)
+			return
+		}
+		switch msg.Type {
+		case wsConnectionInit:
+			c.write(&wsMessage{Type: wsConnectionAck})
+			if !acked {
+				acked = true
+				c.write(&wsMessage{Type: wsConnectionKeepAlive})
+
+				c.wg.Add(1)
+				go c.keepAlive(ctx)
+			}
+
+		case wsStart:
+			var payload wsStartPayload
+			if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+				c.writeError(msg.ID, wsError, err)
+				continue
+			}
+			c.start(ctx, msg.ID, &payload)
+
+		case wsStop:
+			c.opsLock.Lock()
+			if stop, ok := c.ops[msg.ID]; ok {
+				stop()
+			}
+			c.opsLock.Unlock()
+
+		case wsConnectionTerminate:
+			return
+
+		default:
+			c.writeError(msg.ID, wsConnectionError, errUnknownMessage)
+		}
+	}
+}
+
+// start runs a subscription operation, forwarding its results to the client
+// until the operation is stopped or the subscription ends.
+func (c *wsConn) start(ctx context.Context, id string, payload *wsStartPayload) {
+	c.opsLock.Lock()
+	defer c.opsLock.Unlock()
+
+	if _, ok := c.ops[id]; ok {
+		c.writeError(id, wsError, errDuplicateOperation)
+		return
+	}
+	ctx, stop := context.WithCancel(ctx)
+	results, err := c.schema.Subscribe(ctx, payload.Query, payload.OperationName, payload.Variables)
+	if err != nil {
+		stop()
+		c.writeError(id, wsError, err)
+		return
+	}
+	c.ops[id] = stop
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+
+		for result := range results {
+			data, err := json.Marshal(result)
+			if err != nil {
+				log.Warn("Failed to encode GraphQL result", "err", err)
+				continue
+			}
+			c.write(&wsMessage{ID: id, Type: wsData, Payload: data})
+		}
+		c.opsLock.Lock()
+		delete(c.ops, id)
+		c.opsLock.Unlock()
+
+		stop()
+		c.write(&wsMessage{ID: id, Type: wsComplete})
+	}()
+}
+
+// keepAlive periodically sends keep-alive messages until the context is done.
+func (c *wsConn) keepAlive(ctx context.Context) {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(wsKeepAliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.write(&wsMessage{Type: wsConnectionKeepAlive})
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// writeError sends an error message of the given type to the client.
+func (c *wsConn) writeError(id string, typ string, err error) {
+	payload, _ := json.Marshal(map[string]string{"message": err.Error()})
+	c.write(&wsMessage{ID: id, Type: typ, Payload: payload})
+}
+
+// write sends a message to the client. On failure the connection is closed,
+// which in turn aborts the read loop and stops all running operations.
+func (c *wsConn) write(msg *wsMessage) {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	if err := c.conn.WriteJSON(msg); err != nil {
+		log.Debug("GraphQL websocket write failed", "err", err)
+		c.conn.Close()
+	}
+}
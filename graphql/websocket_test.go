@@ -0,0 +1,196 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gorilla/websocket"
+	"github.com/graph-gophers/graphql-go"
+)
+
+// tickResolver is a subscription resolver emitting an endless counter.
+type tickResolver struct{}
+
+func (*tickResolver) Version() int32 { return 1 }
+
+func (*tickResolver) Ticks(ctx context.Context) (<-chan int32, error) {
+	ticks := make(chan int32)
+	go func() {
+		defer close(ticks)
+		for i := int32(0); ; i++ {
+			select {
+			case ticks <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ticks, nil
+}
+
+// Tests that subscriptions are served over websocket using the graphql-ws
+// protocol, from the connection handshake until the operation is stopped.
+func TestWebsocketSubscription(t *testing.T) {
+	schema, err := graphql.ParseSchema(`
+		schema { query: Query, subscription: Subscription }
+		type Query { version: Int! }
+		type Subscription { ticks: Int! }
+	`, &tickResolver{})
+	if err != nil {
+		t.Fatalf("failed to parse schema: %v", err)
+	}
+	handler := &wsHandler{
+		schema: schema,
+		upgrader: websocket.Upgrader{
+			Subprotocols: []string{wsProtocol},
+			CheckOrigin:  wsOriginValidator(nil),
+		},
+	}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	dialer := websocket.Dialer{Subprotocols: []string{wsProtocol}}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("failed to dial server: %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	if conn.Subprotocol() != wsProtocol {
+		t.Fatalf("subprotocol mismatch: have %q, want %q", conn.Subprotocol(), wsProtocol)
+	}
+	read := func() *wsMessage {
+		var msg wsMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("failed to read message: %v", err)
+		}
+		return &msg
+	}
+	// Initialize the connection and expect an acknowledgement
+	if err := conn.WriteJSON(&wsMessage{Type: wsConnectionInit}); err != nil {
+		t.Fatalf("failed to write init: %v", err)
+	}
+	if msg := read(); msg.Type != wsConnectionAck {
+		t.Fatalf("message type mismatch: have %q, want %q", msg.Type, wsConnectionAck)
+	}
+	if msg := read(); msg.Type != wsConnectionKeepAlive {
+		t.Fatalf("message type mismatch: have %q, want %q", msg.Type, wsConnectionKeepAlive)
+	}
+	// Start a subscription and check the first few results
+	payload, _ := json.Marshal(&wsStartPayload{Query: "subscription { ticks }"})
+	if err := conn.WriteJSON(&wsMessage{ID: "1", Type: wsStart, Payload: payload}); err != nil {
+		t.Fatalf("failed to write start: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		msg := read()
+		if msg.ID != "1" || msg.Type != wsData {
+			t.Fatalf("result %d: message mismatch: have %s/%q, want 1/%q", i, msg.ID, msg.Type, wsData)
+		}
+		var result struct {
+			Data struct{ Ticks int }
+		}
+		if err := json.Unmarshal(msg.Payload, &result); err != nil {
+			t.Fatalf("result %d: failed to decode payload: %v", i, err)
+		}
+		if result.Data.Ticks != i {
+			t.Errorf("result %d: tick mismatch: have %d, want %d", i, result.Data.Ticks, i)
+		}
+	}
+	// Stop the subscription and expect it to complete
+	if err := conn.WriteJSON(&wsMessage{ID: "1", Type: wsStop}); err != nil {
+		t.Fatalf("failed to write stop: %v", err)
+	}
+	for {
+		msg := read()
+		if msg.Type == wsData {
+			continue
+		}
+		if msg.ID != "1" || msg.Type != wsComplete {
+			t.Fatalf("message mismatch: have %s/%q, want 1/%q", msg.ID, msg.Type, wsComplete)
+		}
+		break
+	}
+}
+
+// Tests that cross origin websocket connections are only accepted from the
+// configured origins.
+func TestWebsocketOrigins(t *testing.T) {
+	tests := []struct {
+		origins []string
+		origin  string
+		allowed bool
+	}{
+		{nil, "", true},
+		{nil, "http://localhost", true},
+		{nil, "http://localhost:8547", false},
+		{nil, "http://example.com", false},
+		{[]string{"http://example.com"}, "http://localhost", false},
+		{[]string{"http://example.com"}, "http://example.com", true},
+		{[]string{"http://example.com"}, "http://other.com", false},
+		{[]string{"*"}, "http://other.com", true},
+	}
+	for i, tt := range tests {
+		req, _ := http.NewRequest("GET", "http://localhost:8547/graphql", nil)
+		if tt.origin != "" {
+			req.Header.Set("Origin", tt.origin)
+		}
+		if allowed := wsOriginValidator(tt.origins)(req); allowed != tt.allowed {
+			t.Errorf("test %d: allowed mismatch: have %v, want %v", i, allowed, tt.allowed)
+		}
+	}
+}
+
+// Tests that websocket upgrades are subject to the virtual host checks of the
+// HTTP endpoint, preventing DNS rebinding attacks.
+func TestWebsocketVirtualHosts(t *testing.T) {
+	service, err := New(nil, false, "127.0.0.1:0", nil, []string{"localhost"}, rpc.DefaultHTTPTimeouts)
+	if err != nil {
+		t.Fatalf("failed to create service: %v", err)
+	}
+	if err := service.Start(nil); err != nil {
+		t.Fatalf("failed to start service: %v", err)
+	}
+	defer service.Stop()
+
+	url := "ws://" + service.listener.Addr().String() + "/graphql"
+	dialer := websocket.Dialer{Subprotocols: []string{wsProtocol}}
+	for host, allowed := range map[string]bool{"": true, "localhost": true, "attacker.com": false} {
+		header := make(http.Header)
+		if host != "" {
+			header.Set("Host", host)
+		}
+		conn, resp, err := dialer.Dial(url, header)
+		if conn != nil {
+			conn.Close()
+		}
+		switch {
+		case allowed && err != nil:
+			t.Errorf("host %q: connection rejected: %v", host, err)
+		case !allowed && (err == nil || resp == nil || resp.StatusCode != http.StatusForbidden):
+			t.Errorf("host %q: connection not rejected with 403: %v", host, err)
+		}
+	}
+}
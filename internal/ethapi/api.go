@@ -27,6 +27,7 @@ import (
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/accounts/scwallet"
 	"github.com/ethereum/go-ethereum/common"
@@ -119,7 +120,7 @@ func (s *PublicTxPoolAPI) Content() map[string]map[string]map[string]*RPCTransac
 	for account, txs := range pending {
 		dump := make(map[string]*RPCTransaction)
 		for _, tx := range txs {
-			dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPendingTransaction(tx)
+			dump[fmt.Sprintf("%d", tx.Nonce())] = NewRPCPendingTransaction(tx)
 		}
 		content["pending"][account.Hex()] = dump
 	}
@@ -127,7 +128,7 @@ func (s *PublicTxPoolAPI) Content() map[string]map[string]map[string]*RPCTransac
 	for account, txs := range queue {
 		dump := make(map[string]*RPCTransaction)
 		for _, tx := range txs {
-			dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPendingTransaction(tx)
+			dump[fmt.Sprintf("%d", tx.Nonce())] = NewRPCPendingTransaction(tx)
 		}
 		content["queued"][account.Hex()] = dump
 	}
@@ -397,7 +398,7 @@ func (s *PrivateAccountAPI) SignTransaction(ctx context.Context, args SendTxArgs
 		log.Warn("Failed transaction sign attempt", "from", args.From, "to", args.To, "value", args.Value.ToInt(), "err", err)
 		return nil, err
 	}
-	data, err := rlp.EncodeToBytes(signed)
+	data, err := signed.MarshalBinary()
 	if err != nil {
 		return nil, err
 	}
@@ -831,12 +832,80 @@ func (s *PublicBlockChainAPI) GetBlockRange(ctx context.Context, startNumber rpc
 
 // CallArgs represents the arguments for a call.
 type CallArgs struct {
-	From     *common.Address `json:"from"`
-	To       *common.Address `json:"to"`
-	Gas      *hexutil.Uint64 `json:"gas"`
-	GasPrice *hexutil.Big    `json:"gasPrice"`
-	Value    *hexutil.Big    `json:"value"`
-	Data     *hexutil.Bytes  `json:"data"`
+	From       *common.Address   `json:"from"`
+	To         *common.Address   `json:"to"`
+	Gas        *hexutil.Uint64   `json:"gas"`
+	GasPrice   *hexutil.Big      `json:"gasPrice"`
+	Value      *hexutil.Big      `json:"value"`
+	Data       *hexutil.Bytes    `json:"data"`
+	AccessList *types.AccessList `json:"accessList"`
+}
+
+// ToMessage converts the call arguments into a message executable on top of the
+// given header, capping the gas allowance at globalGasCap. When running the OVM,
+// the message is wrapped into a simulated execution manager call.
+func (args *CallArgs) ToMessage(b Backend, header *types.Header, globalGasCap *big.Int) (core.Message, error) {
+	// Set sender address or use a default if none specified
+	addr := args.from(b)
+
+	// Set default gas & gas price if none were set
+	gas := b.GasLimit()
+	if args.Gas != nil {
+		gas = uint64(*args.Gas)
+	}
+	if globalGasCap != nil && globalGasCap.Uint64() < gas {
+		log.Warn("Caller gas above allowance, capping", "requested", gas, "cap", globalGasCap)
+		gas = globalGasCap.Uint64()
+	}
+	gasPrice := new(big.Int).SetUint64(defaultGasPrice)
+	if args.GasPrice != nil {
+		gasPrice = args.GasPrice.ToInt()
+	}
+
+	value := new(big.Int)
+	if args.Value != nil {
+		value = args.Value.ToInt()
+	}
+
+	var data []byte
+	if args.Data != nil {
+		data = []byte(*args.Data)
+	}
+	var accessList types.AccessList
+	if args.AccessList != nil {
+		accessList = *args.AccessList
+	}
+
+	// Create new call message
+	var msg core.Message
+	msg = types.NewMessage(addr, args.To, 0, value, gas, gasPrice, data, accessList, false, &addr, nil, types.QueueOriginSequencer, 0)
+	if vm.UsingOVM {
+		cfg := b.ChainConfig()
+		executionManager := cfg.StateDump.Accounts["OVM_ExecutionManager"]
+		stateManager := cfg.StateDump.Accounts["OVM_StateManager"]
+		var err error
+		blockNumber := header.Number
+		timestamp := new(big.Int).SetUint64(header.Time)
+		msg, err = core.EncodeSimulatedMessage(msg, timestamp, blockNumber, executionManager, stateManager)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return msg, nil
+}
+
+// from retrieves the sender of the call, defaulting to the first account of the
+// first wallet if none was specified.
+func (args *CallArgs) from(b Backend) common.Address {
+	if args.From != nil {
+		return *args.From
+	}
+	if wallets := b.AccountManager().Wallets(); len(wallets) > 0 {
+		if accounts := wallets[0].Accounts(); len(accounts) > 0 {
+			return accounts[0].Address
+		}
+	}
+	return common.Address{}
 }
 
 // account indicates the overriding fields of account during the execution of
@@ -853,23 +922,12 @@ type account struct {
 	StateDiff *map[common.Hash]common.Hash `json:"stateDiff"`
 }
 
-func DoCall(ctx context.Context, b Backend, args CallArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides map[common.Address]account, vmCfg vm.Config, timeout time.Duration, globalGasCap *big.Int) ([]byte, uint64, bool, error) {
+func DoCall(ctx context.Context, b Backend, args CallArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides map[common.Address]account, vmCfg vm.Config, timeout time.Duration, globalGasCap *big.Int) (*core.ExecutionResult, error) {
 	defer func(start time.Time) { log.Debug("Executing EVM call finished", "runtime", time.Since(start)) }(time.Now())
 
 	state, header, err := b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
 	if state == nil || err != nil {
-		return nil, 0, false, err
-	}
-	// Set sender address or use a default if none specified
-	var addr common.Address
-	if args.From == nil {
-		if wallets := b.AccountManager().Wallets(); len(wallets) > 0 {
-			if accounts := wallets[0].Accounts(); len(accounts) > 0 {
-				addr = accounts[0].Address
-			}
-		}
-	} else {
-		addr = *args.From
+		return nil, err
 	}
 	// Override the fields of specified contracts before execution.
 	for addr, account := range overrides {
@@ -886,7 +944,7 @@ func DoCall(ctx context.Context, b Backend, args CallArgs, blockNrOrHash rpc.Blo
 			state.SetBalance(addr, (*big.Int)(*account.Balance))
 		}
 		if account.State != nil && account.StateDiff != nil {
-			return nil, 0, false, fmt.Errorf("account %s has both 'state' and 'stateDiff'", addr.Hex())
+			return nil, fmt.Errorf("account %s has both 'state' and 'stateDiff'", addr.Hex())
 		}
 		// Replace entire state if caller requires.
 		if account.State != nil {
@@ -899,44 +957,9 @@ func DoCall(ctx context.Context, b Backend, args CallArgs, blockNrOrHash rpc.Blo
 			}
 		}
 	}
-	// Set default gas & gas price if none were set
-	gas := b.GasLimit()
-	if args.Gas != nil {
-		gas = uint64(*args.Gas)
-	}
-	if globalGasCap != nil && globalGasCap.Uint64() < gas {
-		log.Warn("Caller gas above allowance, capping", "requested", gas, "cap", globalGasCap)
-		gas = globalGasCap.Uint64()
-	}
-	gasPrice := new(big.Int).SetUint64(defaultGasPrice)
-	if args.GasPrice != nil {
-		gasPrice = args.GasPrice.ToInt()
-	}
-
-	value := new(big.Int)
-	if args.Value != nil {
-		value = args.Value.ToInt()
-	}
-
-	var data []byte
-	if args.Data != nil {
-		data = []byte(*args.Data)
-	}
-
-	// Create new call message
-	var msg core.Message
-	msg = types.NewMessage(addr, args.To, 0, value, gas, gasPrice, data, false, &addr, nil, types.QueueOriginSequencer, 0)
-	if vm.UsingOVM {
-		cfg := b.ChainConfig()
-		executionManager := cfg.StateDump.Accounts["OVM_ExecutionManager"]
-		stateManager := cfg.StateDump.Accounts["OVM_StateManager"]
-		var err error
-		blockNumber := header.Number
-		timestamp := new(big.Int).SetUint64(header.Time)
-		msg, err = core.EncodeSimulatedMessage(msg, timestamp, blockNumber, executionManager, stateManager)
-		if err != nil {
-			return nil, 0, false, err
-		}
+	msg, err := args.ToMessage(b, header, globalGasCap)
+	if err != nil {
+		return nil, err
 	}
 
 	// Setup context so it may be cancelled the call has completed
@@ -952,9 +975,9 @@ func DoCall(ctx context.Context, b Backend, args CallArgs, blockNrOrHash rpc.Blo
 	defer cancel()
 
 	// Get a new instance of the EVM.
-	evm, vmError, err := b.GetEVM(ctx, msg, state, header)
+	evm, vmError, err := b.GetEVM(ctx, msg, state, header, nil)
 	if err != nil {
-		return nil, 0, false, err
+		return nil, err
 	}
 	// Wait for the context to be done and cancel the evm. Even if the
 	// EVM has finished, cancelling may be done (repeatedly)
@@ -967,17 +990,18 @@ func DoCall(ctx context.Context, b Backend, args CallArgs, blockNrOrHash rpc.Blo
 	// and apply the message.
 	gp := new(core.GasPool).AddGas(math.MaxUint64)
 	if vm.UsingOVM {
+		addr := args.from(b)
 		evm.Context.EthCallSender = &addr
 	}
-	res, gas, failed, err := core.ApplyMessage(evm, msg, gp)
+	result, err := core.ApplyMessageWithResult(evm, msg, gp)
 	if err := vmError(); err != nil {
-		return nil, 0, false, err
+		return nil, err
 	}
 	// If the timer caused an abort, return an appropriate error message
 	if evm.Cancelled() {
-		return nil, 0, false, fmt.Errorf("execution aborted (timeout = %v)", timeout)
+		return nil, fmt.Errorf("execution aborted (timeout = %v)", timeout)
 	}
-	return res, gas, failed, err
+	return result, err
 }
 
 // Call executes the given transaction on the state for the given block number.
@@ -991,8 +1015,48 @@ func (s *PublicBlockChainAPI) Call(ctx context.Context, args CallArgs, blockNrOr
 	if overrides != nil {
 		accounts = *overrides
 	}
-	result, _, _, err := DoCall(ctx, s.b, args, blockNrOrHash, accounts, vm.Config{}, 5*time.Second, s.b.RPCGasCap())
-	return (hexutil.Bytes)(result), err
+	result, err := DoCall(ctx, s.b, args, blockNrOrHash, accounts, vm.Config{}, 5*time.Second, s.b.RPCGasCap())
+	if err != nil {
+		return nil, err
+	}
+	// If the result contains a revert reason, try to unpack and return it.
+	if len(result.Revert()) > 0 {
+		return nil, newRevertError(result)
+	}
+	return result.Return(), result.Err
+}
+
+// revertError is an API error that encompasses an EVM revert with JSON error
+// code and a binary data blob. The data is the revert data exactly as produced
+// by the target contract, so that clients can decode custom errors as well.
+type revertError struct {
+	error
+	reason string // revert reason hex encoded
+}
+
+// ErrorCode returns the JSON error code for a revertal.
+// See: https://github.com/ethereum/wiki/wiki/JSON-RPC-Error-Codes-Improvement-Proposal
+func (e *revertError) ErrorCode() int {
+	return 3
+}
+
+// ErrorData returns the hex encoded revert reason.
+func (e *revertError) ErrorData() interface{} {
+	return e.reason
+}
+
+// newRevertError creates a revertError instance with the provided revert data.
+// Error(string) and Panic(uint256) payloads are decoded into the error message.
+func newRevertError(result *core.ExecutionResult) *revertError {
+	reason, errUnpack := abi.UnpackRevert(result.Revert())
+	err := errors.New("execution reverted")
+	if errUnpack == nil {
+		err = fmt.Errorf("execution reverted: %v", reason)
+	}
+	return &revertError{
+		error:  err,
+		reason: hexutil.Encode(result.Revert()),
+	}
 }
 
 // Optimism note: The gasPrice in Optimism is modified to always return 1 gwei. We
@@ -1065,19 +1129,19 @@ func legacyDoEstimateGas(ctx context.Context, b Backend, args CallArgs, blockNrO
 		args.From = &common.Address{}
 	}
 	// Create a helper to check if a gas allowance results in an executable transaction
-	executable := func(gas uint64) bool {
+	executable := func(gas uint64) (bool, *core.ExecutionResult) {
 		args.Gas = (*hexutil.Uint64)(&gas)
 
-		_, _, failed, err := DoCall(ctx, b, args, blockNrOrHash, nil, vm.Config{}, 0, gasCap)
-		if err != nil || failed {
-			return false
+		result, err := DoCall(ctx, b, args, blockNrOrHash, nil, vm.Config{}, 0, gasCap)
+		if err != nil || result.Failed() {
+			return false, result
 		}
-		return true
+		return true, result
 	}
 	// Execute the binary search and hone in on an executable gas limit
 	for lo+1 < hi {
 		mid := (hi + lo) / 2
-		if !executable(mid) {
+		if ok, _ := executable(mid); !ok {
 			lo = mid
 		} else {
 			hi = mid
@@ -1085,7 +1149,10 @@ func legacyDoEstimateGas(ctx context.Context, b Backend, args CallArgs, blockNrO
 	}
 	// Reject the transaction as invalid if it still fails at the highest allowance
 	if hi == cap {
-		if !executable(hi) {
+		if ok, result := executable(hi); !ok {
+			if result != nil && len(result.Revert()) > 0 {
+				return 0, newRevertError(result)
+			}
 			return 0, fmt.Errorf("gas required exceeds allowance (%d) or always failing transaction", cap)
 		}
 	}
@@ -1099,6 +1166,125 @@ func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (h
 	return DoEstimateGas(ctx, s.b, args, blockNrOrHash, s.b.RPCGasCap())
 }
 
+// accessListResult returns an optional accesslist
+// Its the result of the `eth_createAccessList` RPC call.
+// It contains an error if the transaction itself failed.
+type accessListResult struct {
+	Accesslist *types.AccessList `json:"accessList"`
+	Error      string            `json:"error,omitempty"`
+	GasUsed    hexutil.Uint64    `json:"gasUsed"`
+}
+
+// CreateAccessList creates an EIP-2930 type AccessList for the given transaction.
+// Reexec and BlockNrOrHash can be specified to create the accessList on top of a certain state.
+func (s *PublicBlockChainAPI) CreateAccessList(ctx context.Context, args SendTxArgs, blockNrOrHash *rpc.BlockNumberOrHash) (*accessListResult, error) {
+	bNrOrHash := rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
+	if blockNrOrHash != nil {
+		bNrOrHash = *blockNrOrHash
+	}
+	acl, gasUsed, vmerr, err := AccessList(ctx, s.b, bNrOrHash, args)
+	if err != nil {
+		return nil, err
+	}
+	result := &accessListResult{Accesslist: &acl, GasUsed: hexutil.Uint64(gasUsed)}
+	if vmerr != nil {
+		result.Error = vmerr.Error()
+	}
+	return result, nil
+}
+
+// AccessList creates an access list for the given transaction by repeatedly
+// executing it with the access list tracer until the touched accounts and slots
+// stop changing. If the accesslist creation fails an error is returned. If the
+// transaction itself fails, a vmErr is returned.
+//
+// When running the OVM, the storage slots the execution manager accesses through
+// the state manager are included too. The sequencer rejects typed transactions,
+// so such a list cannot be attached to a transaction. It only reports the state
+// a call touches.
+func AccessList(ctx context.Context, b Backend, blockNrOrHash rpc.BlockNumberOrHash, args SendTxArgs) (acl types.AccessList, gasUsed uint64, vmErr error, err error) {
+	// Retrieve the execution context
+	db, header, err := b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if db == nil || err != nil {
+		return nil, 0, nil, err
+	}
+	// If the gas amount is not set, extract this as it will depend on access
+	// lists and we'll need to reestimate every time
+	nogas := args.Gas == nil
+
+	// Ensure any missing fields are filled, extract the recipient and input data
+	if err := args.setDefaults(ctx, b); err != nil {
+		return nil, 0, nil, err
+	}
+	var to common.Address
+	if args.To != nil {
+		to = *args.To
+	} else {
+		to = crypto.CreateAddress(args.From, uint64(*args.Nonce))
+	}
+	input := args.Input
+	if input == nil {
+		input = args.Data
+	}
+	// Retrieve the precompiles since they don't need to be added to the access list
+	precompiles := vm.ActivePrecompiles(b.ChainConfig().Rules(header.Number))
+
+	// Create an initial tracer
+	prevTracer := vm.NewAccessListTracer(nil, args.From, to, precompiles)
+	if args.AccessList != nil {
+		prevTracer = vm.NewAccessListTracer(*args.AccessList, args.From, to, precompiles)
+	}
+	for {
+		// Retrieve the current access list to expand
+		accessList := prevTracer.AccessList()
+		log.Trace("Creating access list", "input", accessList)
+
+		// If no gas amount was specified, each unique access list needs it's own
+		// gas calculation. This is quite expensive, but we need to be accurate
+		// and it's covered by the sender only anyway.
+		if nogas {
+			args.Gas = nil
+			args.AccessList = &accessList
+			if err := args.setDefaults(ctx, b); err != nil {
+				return nil, 0, nil, err // shouldn't happen, just in case
+			}
+		}
+		// Copy the original db so we don't modify it
+		statedb := db.Copy()
+		callArgs := CallArgs{
+			From:       &args.From,
+			To:         args.To,
+			Gas:        args.Gas,
+			GasPrice:   args.GasPrice,
+			Value:      args.Value,
+			Data:       input,
+			AccessList: &accessList,
+		}
+		msg, err := callArgs.ToMessage(b, header, b.RPCGasCap())
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		// Apply the transaction with the access list tracer
+		tracer := vm.NewAccessListTracer(accessList, args.From, to, precompiles)
+		config := vm.Config{Tracer: tracer, Debug: true}
+		vmenv, _, err := b.GetEVM(ctx, msg, statedb, header, &config)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		if vm.UsingOVM {
+			vmenv.Context.EthCallSender = &args.From
+		}
+		res, err := core.ApplyMessageWithResult(vmenv, msg, new(core.GasPool).AddGas(msg.Gas()))
+		if err != nil {
+			return nil, 0, nil, fmt.Errorf("failed to apply transaction: %v err: %v", args.toTransaction().Hash(), err)
+		}
+		if tracer.Equal(prevTracer) {
+			return accessList, res.UsedGas, res.Err, nil
+		}
+		prevTracer = tracer
+	}
+}
+
 // ExecutionResult groups all structured logs emitted by the EVM
 // while replaying a transaction in debug mode as well as transaction
 // execution status, the amount of gas used and the return value
@@ -1242,28 +1428,31 @@ func (s *PublicBlockChainAPI) rpcMarshalBlock(b *types.Block, inclTx bool, fullT
 
 // RPCTransaction represents a transaction that will serialize to the RPC representation of a transaction
 type RPCTransaction struct {
-	BlockHash        *common.Hash    `json:"blockHash"`
-	BlockNumber      *hexutil.Big    `json:"blockNumber"`
-	From             common.Address  `json:"from"`
-	Gas              hexutil.Uint64  `json:"gas"`
-	GasPrice         *hexutil.Big    `json:"gasPrice"`
-	Hash             common.Hash     `json:"hash"`
-	Input            hexutil.Bytes   `json:"input"`
-	Nonce            hexutil.Uint64  `json:"nonce"`
-	To               *common.Address `json:"to"`
-	TransactionIndex *hexutil.Uint64 `json:"transactionIndex"`
-	Value            *hexutil.Big    `json:"value"`
-	V                *hexutil.Big    `json:"v"`
-	R                *hexutil.Big    `json:"r"`
-	S                *hexutil.Big    `json:"s"`
-	QueueOrigin      string          `json:"queueOrigin"`
-	TxType           string          `json:"txType"`
-	L1TxOrigin       *common.Address `json:"l1TxOrigin"`
-	L1BlockNumber    *hexutil.Big    `json:"l1BlockNumber"`
-	L1Timestamp      hexutil.Uint64  `json:"l1Timestamp"`
-	Index            *hexutil.Uint64 `json:"index"`
-	QueueIndex       *hexutil.Uint64 `json:"queueIndex"`
-	RawTransaction   hexutil.Bytes   `json:"rawTransaction"`
+	BlockHash        *common.Hash      `json:"blockHash"`
+	BlockNumber      *hexutil.Big      `json:"blockNumber"`
+	From             common.Address    `json:"from"`
+	Type             hexutil.Uint64    `json:"type"`
+	Gas              hexutil.Uint64    `json:"gas"`
+	GasPrice         *hexutil.Big      `json:"gasPrice"`
+	Hash             common.Hash       `json:"hash"`
+	Input            hexutil.Bytes     `json:"input"`
+	Nonce            hexutil.Uint64    `json:"nonce"`
+	To               *common.Address   `json:"to"`
+	TransactionIndex *hexutil.Uint64   `json:"transactionIndex"`
+	Value            *hexutil.Big      `json:"value"`
+	Accesses         *types.AccessList `json:"accessList,omitempty"`
+	ChainID          *hexutil.Big      `json:"chainId,omitempty"`
+	V                *hexutil.Big      `json:"v"`
+	R                *hexutil.Big      `json:"r"`
+	S                *hexutil.Big      `json:"s"`
+	QueueOrigin      string            `json:"queueOrigin"`
+	TxType           string            `json:"txType"`
+	L1TxOrigin       *common.Address   `json:"l1TxOrigin"`
+	L1BlockNumber    *hexutil.Big      `json:"l1BlockNumber"`
+	L1Timestamp      hexutil.Uint64    `json:"l1Timestamp"`
+	Index            *hexutil.Uint64   `json:"index"`
+	QueueIndex       *hexutil.Uint64   `json:"queueIndex"`
+	RawTransaction   hexutil.Bytes     `json:"rawTransaction"`
 }
 
 // newRPCTransaction returns a transaction that will serialize to the RPC
@@ -1275,6 +1464,7 @@ func newRPCTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber
 
 	result := &RPCTransaction{
 		From:     from,
+		Type:     hexutil.Uint64(tx.Type()),
 		Gas:      hexutil.Uint64(tx.Gas()),
 		GasPrice: (*hexutil.Big)(tx.GasPrice()),
 		Hash:     tx.Hash(),
@@ -1291,6 +1481,11 @@ func newRPCTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber
 		result.BlockNumber = (*hexutil.Big)(new(big.Int).SetUint64(blockNumber))
 		result.TransactionIndex = (*hexutil.Uint64)(&index)
 	}
+	if tx.Type() == types.AccessListTxType {
+		al := tx.AccessList()
+		result.Accesses = &al
+		result.ChainID = (*hexutil.Big)(tx.ChainId())
+	}
 
 	if meta := tx.GetMeta(); meta != nil {
 		result.RawTransaction = meta.RawTransaction
@@ -1329,8 +1524,8 @@ func newRPCTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber
 	return result
 }
 
-// newRPCPendingTransaction returns a pending transaction that will serialize to the RPC representation
-func newRPCPendingTransaction(tx *types.Transaction) *RPCTransaction {
+// NewRPCPendingTransaction returns a pending transaction that will serialize to the RPC representation
+func NewRPCPendingTransaction(tx *types.Transaction) *RPCTransaction {
 	return newRPCTransaction(tx, common.Hash{}, 0, 0)
 }
 
@@ -1349,7 +1544,7 @@ func newRPCRawTransactionFromBlockIndex(b *types.Block, index uint64) hexutil.By
 	if index >= uint64(len(txs)) {
 		return nil
 	}
-	blob, _ := rlp.EncodeToBytes(txs[index])
+	blob, _ := txs[index].MarshalBinary()
 	return blob
 }
 
@@ -1456,7 +1651,7 @@ func (s *PublicTransactionPoolAPI) GetTransactionByHash(ctx context.Context, has
 	}
 	// No finalized transaction, try to retrieve it from the pool
 	if tx := s.b.GetPoolTransaction(hash); tx != nil {
-		return newRPCPendingTransaction(tx), nil
+		return NewRPCPendingTransaction(tx), nil
 	}
 
 	// Transaction unknown, return as such
@@ -1476,8 +1671,8 @@ func (s *PublicTransactionPoolAPI) GetRawTransactionByHash(ctx context.Context,
 			return nil, nil
 		}
 	}
-	// Serialize to RLP and return
-	return rlp.EncodeToBytes(tx)
+	// Serialize to the canonical encoding and return
+	return tx.MarshalBinary()
 }
 
 // GetTransactionReceipt returns the transaction receipt for the given transaction hash.
@@ -1513,6 +1708,7 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, ha
 		"contractAddress":   nil,
 		"logs":              receipt.Logs,
 		"logsBloom":         receipt.Bloom,
+		"type":              hexutil.Uint(tx.Type()),
 	}
 
 	// Assign receipt status or post state.
@@ -1557,6 +1753,10 @@ type SendTxArgs struct {
 	Data  *hexutil.Bytes `json:"data"`
 	Input *hexutil.Bytes `json:"input"`
 
+	// For non-legacy transactions
+	AccessList *types.AccessList `json:"accessList,omitempty"`
+	ChainID    *hexutil.Big      `json:"chainId,omitempty"`
+
 	L1BlockNumber     *big.Int                `json:"l1BlockNumber"`
 	L1MessageSender   *common.Address         `json:"l1MessageSender"`
 	SignatureHashType types.SignatureHashType `json:"signatureHashType"`
@@ -1605,11 +1805,12 @@ func (args *SendTxArgs) setDefaults(ctx context.Context, b Backend) error {
 			input = args.Data
 		}
 		callArgs := CallArgs{
-			From:     &args.From, // From shouldn't be nil
-			To:       args.To,
-			GasPrice: args.GasPrice,
-			Value:    args.Value,
-			Data:     input,
+			From:       &args.From, // From shouldn't be nil
+			To:         args.To,
+			GasPrice:   args.GasPrice,
+			Value:      args.Value,
+			Data:       input,
+			AccessList: args.AccessList,
 		}
 		pendingBlockNr := rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
 		estimated, err := DoEstimateGas(ctx, b, callArgs, pendingBlockNr, b.RPCGasCap())
@@ -1619,6 +1820,16 @@ func (args *SendTxArgs) setDefaults(ctx context.Context, b Backend) error {
 		args.Gas = &estimated
 		log.Trace("Estimate gas usage automatically", "gas", args.Gas)
 	}
+	// If chain id is provided, ensure it matches the local chain id. Otherwise,
+	// set the local chain id as the default.
+	want := b.ChainConfig().ChainID
+	if args.ChainID != nil {
+		if have := (*big.Int)(args.ChainID); have.Cmp(want) != 0 {
+			return fmt.Errorf("chainId does not match node's (have=%v, want=%v)", have, want)
+		}
+	} else {
+		args.ChainID = (*hexutil.Big)(want)
+	}
 	return nil
 }
 
@@ -1629,6 +1840,21 @@ func (args *SendTxArgs) toTransaction() *types.Transaction {
 	} else if args.Data != nil {
 		input = *args.Data
 	}
+	if args.AccessList != nil {
+		tx := types.NewTx(&types.AccessListTx{
+			ChainID:    (*big.Int)(args.ChainID),
+			Nonce:      uint64(*args.Nonce),
+			GasPrice:   (*big.Int)(args.GasPrice),
+			Gas:        uint64(*args.Gas),
+			To:         args.To,
+			Value:      (*big.Int)(args.Value),
+			Data:       input,
+			AccessList: *args.AccessList,
+		})
+		txMeta := types.NewTransactionMeta(args.L1BlockNumber, 0, args.L1MessageSender, types.SighashEIP155, types.QueueOriginSequencer, nil, nil, nil)
+		tx.SetTransactionMeta(txMeta)
+		return tx
+	}
 	if args.To == nil {
 		tx := types.NewContractCreation(uint64(*args.Nonce), (*big.Int)(args.Value), uint64(*args.Gas), (*big.Int)(args.GasPrice), input)
 		txMeta := types.NewTransactionMeta(args.L1BlockNumber, 0, nil, types.SighashEIP155, types.QueueOriginSequencer, nil, nil, nil)
@@ -1708,7 +1934,7 @@ func (s *PublicTransactionPoolAPI) FillTransaction(ctx context.Context, args Sen
 	}
 	// Assemble the transaction and obtain rlp
 	tx := args.toTransaction()
-	data, err := rlp.EncodeToBytes(tx)
+	data, err := tx.MarshalBinary()
 	if err != nil {
 		return nil, err
 	}
@@ -1722,12 +1948,16 @@ func (s *PublicTransactionPoolAPI) SendRawTransaction(ctx context.Context, encod
 		return common.Hash{}, errors.New("Cannot send raw transaction in verifier mode")
 	}
 
+	if s.b.IsDegraded() {
+		return common.Hash{}, errors.New("Cannot send raw transaction while the sync service is degraded")
+	}
+
 	if s.b.IsSyncing() {
 		return common.Hash{}, errors.New("Cannot send raw transaction while syncing")
 	}
 
 	tx := new(types.Transaction)
-	if err := rlp.DecodeBytes(encodedTx, tx); err != nil {
+	if err := tx.UnmarshalBinary(encodedTx); err != nil {
 		return common.Hash{}, err
 	}
 
@@ -1749,12 +1979,16 @@ func (s *PublicTransactionPoolAPI) SendRawEthSignTransaction(ctx context.Context
 		return common.Hash{}, errors.New("Cannot send raw ethsign transaction in verifier mode")
 	}
 
+	if s.b.IsDegraded() {
+		return common.Hash{}, errors.New("Cannot send raw ethsign transaction while the sync service is degraded")
+	}
+
 	if s.b.IsSyncing() {
 		return common.Hash{}, errors.New("Cannot send raw transaction while syncing")
 	}
 
 	tx := new(types.Transaction)
-	if err := rlp.DecodeBytes(encodedTx, tx); err != nil {
+	if err := tx.UnmarshalBinary(encodedTx); err != nil {
 		return common.Hash{}, err
 	}
 
@@ -1818,7 +2052,7 @@ func (s *PublicTransactionPoolAPI) SignTransaction(ctx context.Context, args Sen
 	if err != nil {
 		return nil, err
 	}
-	data, err := rlp.EncodeToBytes(tx)
+	data, err := tx.MarshalBinary()
 	if err != nil {
 		return nil, err
 	}
@@ -1846,7 +2080,7 @@ func (s *PublicTransactionPoolAPI) PendingTransactions() ([]*RPCTransaction, err
 		}
 		from, _ := types.Sender(signer, tx)
 		if _, exists := accounts[from]; exists {
-			transactions = append(transactions, newRPCPendingTransaction(tx))
+			transactions = append(transactions, NewRPCPendingTransaction(tx))
 		}
 	}
 	return transactions, nil
@@ -1921,6 +2155,7 @@ type RollupContext struct {
 type rollupInfo struct {
 	Mode          string        `json:"mode"`
 	Syncing       bool          `json:"syncing"`
+	Degraded      bool          `json:"degraded"`
 	EthContext    EthContext    `json:"ethContext"`
 	RollupContext RollupContext `json:"rollupContext"`
 }
@@ -1931,12 +2166,14 @@ func (api *PublicRollupAPI) GetInfo(ctx context.Context) rollupInfo {
 		mode = "verifier"
 	}
 	syncing := api.b.IsSyncing()
+	degraded := api.b.IsDegraded()
 	bn, ts := api.b.GetEthContext()
 	index, queueIndex := api.b.GetRollupContext()
 
 	return rollupInfo{
-		Mode:    mode,
-		Syncing: syncing,
+		Mode:     mode,
+		Syncing:  syncing,
+		Degraded: degraded,
 		EthContext: EthContext{
 			BlockNumber: bn,
 			Timestamp:   ts,
@@ -1948,6 +2185,132 @@ func (api *PublicRollupAPI) GetInfo(ctx context.Context) rollupInfo {
 	}
 }
 
+// maxEnqueuesPerRequest is the maximum number of enqueued transactions
+// returned by a single rollup_getEnqueuesBySender call.
+const maxEnqueuesPerRequest = 1000
+
+// rpcEnqueue is an L1 to L2 transaction along with its position in the L2 chain.
+type rpcEnqueue struct {
+	QueueIndex      hexutil.Uint64  `json:"queueIndex"`
+	Index           *hexutil.Uint64 `json:"index"`
+	L1TxOrigin      *common.Address `json:"l1TxOrigin"`
+	L1BlockNumber   *hexutil.Big    `json:"l1BlockNumber"`
+	L1Timestamp     hexutil.Uint64  `json:"l1Timestamp"`
+	Target          *common.Address `json:"target"`
+	GasLimit        hexutil.Uint64  `json:"gasLimit"`
+	Data            hexutil.Bytes   `json:"data"`
+	TransactionHash common.Hash     `json:"transactionHash"`
+	BlockHash       common.Hash     `json:"blockHash"`
+	BlockNumber     hexutil.Uint64  `json:"blockNumber"`
+}
+
+// enqueueStatus describes whether an L1 to L2 transaction has been included
+// in the L2 chain and, if so, whether its execution succeeded.
+type enqueueStatus struct {
+	QueueIndex      hexutil.Uint64  `json:"queueIndex"`
+	Status          string          `json:"status"`
+	TransactionHash *common.Hash    `json:"transactionHash"`
+	BlockNumber     *hexutil.Uint64 `json:"blockNumber"`
+}
+
+// readEnqueue looks up the canonical L2 transaction for the given queue index.
+func (api *PublicRollupAPI) readEnqueue(index uint64) (*types.Transaction, common.Hash, uint64, uint64) {
+	entry := rawdb.ReadEnqueueLookupEntry(api.b.ChainDb(), index)
+	if entry == nil {
+		return nil, common.Hash{}, 0, 0
+	}
+	tx, blockHash, blockNumber, txIndex := rawdb.ReadTransaction(api.b.ChainDb(), entry.TxHash)
+	// The lookup may point to a block that has since been rewound
+	if tx == nil || blockNumber != entry.BlockNumber {
+		return nil, common.Hash{}, 0, 0
+	}
+	return tx, blockHash, blockNumber, txIndex
+}
+
+func newRPCEnqueue(index uint64, tx *types.Transaction, blockHash common.Hash, blockNumber uint64) *rpcEnqueue {
+	meta := tx.GetMeta()
+	enqueue := &rpcEnqueue{
+		QueueIndex:      hexutil.Uint64(index),
+		L1TxOrigin:      meta.L1MessageSender,
+		L1BlockNumber:   (*hexutil.Big)(meta.L1BlockNumber),
+		L1Timestamp:     hexutil.Uint64(meta.L1Timestamp),
+		Target:          tx.To(),
+		GasLimit:        hexutil.Uint64(tx.Gas()),
+		Data:            tx.Data(),
+		TransactionHash: tx.Hash(),
+		BlockHash:       blockHash,
+		BlockNumber:     hexutil.Uint64(blockNumber),
+	}
+	if meta.Index != nil {
+		enqueue.Index = (*hexutil.Uint64)(meta.Index)
+	}
+	return enqueue
+}
+
+// GetEnqueueByIndex returns the L1 to L2 transaction with the given queue
+// index, or nil if it has not been included in the L2 chain yet.
+func (api *PublicRollupAPI) GetEnqueueByIndex(ctx context.Context, index hexutil.Uint64) (*rpcEnqueue, error) {
+	tx, blockHash, blockNumber, _ := api.readEnqueue(uint64(index))
+	if tx == nil {
+		return nil, nil
+	}
+	return newRPCEnqueue(uint64(index), tx, blockHash, blockNumber), nil
+}
+
+// GetEnqueuesBySender returns the L1 to L2 transactions sent by the given L1
+// account that have been included in the L2 chain, ordered by queue index and
+// starting at queue index start. At most limit transactions are returned.
+func (api *PublicRollupAPI) GetEnqueuesBySender(ctx context.Context, sender common.Address, start hexutil.Uint64, limit *hexutil.Uint64) ([]*rpcEnqueue, error) {
+	max := maxEnqueuesPerRequest
+	if limit != nil && uint64(*limit) < uint64(max) {
+		max = int(*limit)
+	}
+	enqueues := make([]*rpcEnqueue, 0)
+	for _, index := range rawdb.ReadEnqueueIndicesBySender(api.b.ChainDb(), sender, uint64(start), max) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		tx, blockHash, blockNumber, _ := api.readEnqueue(index)
+		if tx == nil {
+			continue
+		}
+		enqueues = append(enqueues, newRPCEnqueue(index, tx, blockHash, blockNumber))
+	}
+	return enqueues, nil
+}
+
+// GetEnqueueStatus returns the status of the L1 to L2 transaction with the
+// given queue index. The status is "pending" until the transaction has been
+// included in the L2 chain and "success" or "failed" afterwards, depending on
+// the status of its L2 receipt.
+func (api *PublicRollupAPI) GetEnqueueStatus(ctx context.Context, index hexutil.Uint64) (*enqueueStatus, error) {
+	status := &enqueueStatus{
+		QueueIndex: index,
+		Status:     "pending",
+	}
+	tx, blockHash, blockNumber, txIndex := api.readEnqueue(uint64(index))
+	if tx == nil {
+		return status, nil
+	}
+	receipts, err := api.b.GetReceipts(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	if len(receipts) <= int(txIndex) {
+		return nil, fmt.Errorf("receipt of enqueue %d not found", index)
+	}
+	hash := tx.Hash()
+	number := hexutil.Uint64(blockNumber)
+	status.TransactionHash = &hash
+	status.BlockNumber = &number
+	if receipts[txIndex].Status == types.ReceiptStatusSuccessful {
+		status.Status = "success"
+	} else {
+		status.Status = "failed"
+	}
+	return status, nil
+}
+
 // PrivatelRollupAPI provides private RPC methods to control the sequencer.
 // These methods can be abused by external users and must be considered insecure for use by untrusted users.
 type PrivateRollupAPI struct {
@@ -18,6 +18,9 @@ package node
 
 import (
 	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -25,6 +28,7 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/external"
@@ -45,6 +49,7 @@ const (
 	datadirStaticNodes     = "static-nodes.json"  // Path within the datadir to the static node list
 	datadirTrustedNodes    = "trusted-nodes.json" // Path within the datadir to the trusted node list
 	datadirNodeDatabase    = "nodes"              // Path within the datadir to store the node infos
+	datadirJWTSecret       = "jwtsecret"          // Path within the datadir to the RPC authentication secret
 )
 
 // Config represents a small collection of configuration values to fine tune the
@@ -136,6 +141,14 @@ type Config struct {
 	// interface.
 	HTTPTimeouts rpc.HTTPTimeouts
 
+	// HTTPAccess holds the method access rules, rate limits and request size limits
+	// enforced on the clients of the HTTP RPC interface.
+	HTTPAccess rpc.AccessConfig `toml:",omitempty"`
+
+	// HTTPJWTAuth requires the clients of the HTTP RPC interface to authenticate
+	// with JSON Web Tokens signed with the JWT secret.
+	HTTPJWTAuth bool `toml:",omitempty"`
+
 	// WSHost is the host interface on which to start the websocket RPC server. If
 	// this field is empty, no websocket API endpoint will be started.
 	WSHost string `toml:",omitempty"`
@@ -162,6 +175,14 @@ type Config struct {
 	// private APIs to untrusted users is a major security risk.
 	WSExposeAll bool `toml:",omitempty"`
 
+	// WSAccess holds the method access rules, rate limits and request size limits
+	// enforced on the clients of the WebSocket RPC interface.
+	WSAccess rpc.AccessConfig `toml:",omitempty"`
+
+	// WSJWTAuth requires the clients of the websocket RPC interface to authenticate
+	// with JSON Web Tokens signed with the JWT secret.
+	WSJWTAuth bool `toml:",omitempty"`
+
 	// GraphQLHost is the host interface on which to start the GraphQL server. If this
 	// field is empty, no GraphQL API endpoint will be started.
 	GraphQLHost string `toml:",omitempty"`
@@ -185,6 +206,39 @@ type Config struct {
 	// Requests using ip address directly are not affected
 	GraphQLVirtualHosts []string `toml:",omitempty"`
 
+	// AuthHost is the host interface on which to start the authenticated RPC server,
+	// serving both HTTP and websocket clients which authenticate with JSON Web Tokens
+	// signed with the JWT secret. If this field is empty, no authenticated API
+	// endpoint will be started.
+	AuthHost string `toml:",omitempty"`
+
+	// AuthPort is the TCP port number on which to start the authenticated RPC server.
+	AuthPort int `toml:",omitempty"`
+
+	// AuthModules is a list of API modules to expose via the authenticated RPC
+	// interface. If the module list is empty, all RPC API endpoints designated
+	// public will be exposed.
+	AuthModules []string `toml:",omitempty"`
+
+	// AuthVirtualHosts is the list of virtual hostnames which are allowed on incoming
+	// requests to the authenticated RPC interface.
+	AuthVirtualHosts []string `toml:",omitempty"`
+
+	// JWTSecret is the path to the file holding the hex encoded 32 byte secret the
+	// JSON Web Tokens of authenticated RPC clients are signed with. If the path is a
+	// simple file name, it is placed inside the data directory. If the file does not
+	// exist, a random secret is generated into it.
+	JWTSecret string `toml:",omitempty"`
+
+	// RPCRecord configures the recording of the calls served by the HTTP, websocket
+	// and authenticated RPC interfaces into a rotating file, for later replay.
+	// Relative record file paths are placed inside the data directory.
+	RPCRecord rpc.RecorderConfig `toml:",omitempty"`
+
+	// JWTClockSkew is the maximum difference tolerated between the issuance time
+	// of JSON Web Tokens and the local time, rpc.DefaultJWTClockSkew if zero.
+	JWTClockSkew time.Duration `toml:",omitempty"`
+
 	// Logger is a custom logger to use with the p2p.Server.
 	Logger log.Logger `toml:",omitempty"`
 
@@ -247,6 +301,15 @@ func (c *Config) HTTPEndpoint() string {
 	return fmt.Sprintf("%s:%d", c.HTTPHost, c.HTTPPort)
 }
 
+// AuthEndpoint resolves an authenticated RPC endpoint based on the configured host
+// interface and port parameters.
+func (c *Config) AuthEndpoint() string {
+	if c.AuthHost == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", c.AuthHost, c.AuthPort)
+}
+
 // GraphQLEndpoint resolves a GraphQL endpoint based on the configured host interface
 // and port parameters.
 func (c *Config) GraphQLEndpoint() string {
@@ -391,6 +454,41 @@ func (c *Config) NodeKey() *ecdsa.PrivateKey {
 	return key
 }
 
+// JWTSecretKey retrieves the secret the JSON Web Tokens of authenticated RPC
+// clients are signed with, from the configured file or the default one in the
+// data folder. If the file does not exist, a new secret is generated into it.
+func (c *Config) JWTSecretKey() ([]byte, error) {
+	path := c.JWTSecret
+	if path == "" {
+		path = datadirJWTSecret
+	}
+	if path = c.ResolvePath(path); path == "" {
+		return nil, errors.New("no JWT secret file configured")
+	}
+	if data, err := ioutil.ReadFile(path); err == nil {
+		secret, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(string(data)), "0x"))
+		if err != nil || len(secret) != 32 {
+			return nil, fmt.Errorf("invalid JWT secret in %s, want 32 hex encoded bytes", path)
+		}
+		return secret, nil
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	// No secret found, generate and store a new one
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(path, []byte(hex.EncodeToString(secret)), 0600); err != nil {
+		return nil, err
+	}
+	log.Info("Generated JWT secret", "path", path)
+	return secret, nil
+}
+
 // StaticNodes returns a list of node enode URLs configured as static nodes.
 func (c *Config) StaticNodes() []*enode.Node {
 	return c.parsePersistentNodes(&c.staticNodesWarning, c.ResolvePath(datadirStaticNodes))
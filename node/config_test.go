@@ -161,3 +161,45 @@ func TestNodeKeyPersistency(t *testing.T) {
 		t.Fatalf("ephemeral node key persisted to disk")
 	}
 }
+
+// Tests that the JWT secret is generated into the data directory if missing,
+// reused afterwards, and that invalid secret files are rejected.
+func TestJWTSecretPersistency(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary data directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := &Config{Name: "unit-test", DataDir: dir}
+	secret, err := config.JWTSecretKey()
+	if err != nil {
+		t.Fatalf("failed to generate JWT secret: %v", err)
+	}
+	if len(secret) != 32 {
+		t.Fatalf("JWT secret length mismatch: have %d, want 32", len(secret))
+	}
+	if _, err := os.Stat(filepath.Join(dir, "unit-test", datadirJWTSecret)); err != nil {
+		t.Fatalf("JWT secret not persisted: %v", err)
+	}
+	reloaded, err := config.JWTSecretKey()
+	if err != nil {
+		t.Fatalf("failed to load JWT secret: %v", err)
+	}
+	if !bytes.Equal(secret, reloaded) {
+		t.Errorf("reloaded JWT secret mismatch: have %x, want %x", reloaded, secret)
+	}
+	// Configured secret files are used as is and validated
+	path := filepath.Join(dir, "custom")
+	if err := ioutil.WriteFile(path, []byte("0x1234\n"), 0600); err != nil {
+		t.Fatalf("failed to write JWT secret: %v", err)
+	}
+	config.JWTSecret = path
+	if _, err := config.JWTSecretKey(); err == nil {
+		t.Errorf("short JWT secret accepted")
+	}
+	// Ephemeral nodes need an explicit secret file
+	if _, err := (&Config{}).JWTSecretKey(); err == nil {
+		t.Errorf("JWT secret generated without data directory")
+	}
+}
@@ -34,6 +34,8 @@ const (
 	DefaultWSPort      = 8546        // Default TCP port for the websocket RPC server
 	DefaultGraphQLHost = "localhost" // Default host interface for the GraphQL server
 	DefaultGraphQLPort = 8547        // Default TCP port for the GraphQL server
+	DefaultAuthHost    = "localhost" // Default host interface for the authenticated RPC server
+	DefaultAuthPort    = 8551        // Default TCP port for the authenticated RPC server
 )
 
 // DefaultConfig contains reasonable default settings.
@@ -47,6 +49,8 @@ var DefaultConfig = Config{
 	WSModules:           []string{"net", "web3"},
 	GraphQLPort:         DefaultGraphQLPort,
 	GraphQLVirtualHosts: []string{"localhost"},
+	AuthPort:            DefaultAuthPort,
+	AuthVirtualHosts:    []string{"localhost"},
 	P2P: p2p.Config{
 		ListenAddr: ":30303",
 		MaxPeers:   50,
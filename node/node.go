@@ -68,6 +68,12 @@ type Node struct {
 	wsListener net.Listener // Websocket RPC listener socket to server API requests
 	wsHandler  *rpc.Server  // Websocket RPC request handler to process the API requests
 
+	authEndpoint string       // Authenticated RPC endpoint (interface + port) to listen at (empty = disabled)
+	authListener net.Listener // Authenticated RPC listener socket to serve API requests
+	authHandler  *rpc.Server  // Authenticated RPC request handler to process the API requests
+
+	recorder *rpc.Recorder // Recorder of the calls served by the external RPC endpoints (nil = disabled)
+
 	stop chan struct{} // Channel to wait for termination notifications
 	lock sync.RWMutex
 
@@ -117,6 +123,7 @@ func New(conf *Config) (*Node, error) {
 		ipcEndpoint:       conf.IPCEndpoint(),
 		httpEndpoint:      conf.HTTPEndpoint(),
 		wsEndpoint:        conf.WSEndpoint(),
+		authEndpoint:      conf.AuthEndpoint(),
 		eventmux:          new(event.TypeMux),
 		log:               conf.Logger,
 	}, nil
@@ -284,22 +291,37 @@ func (n *Node) startRPC(services map[reflect.Type]Service) error {
 		apis = append(apis, service.APIs()...)
 	}
 	// Start the various API endpoints, terminating all in case of errors
+	if err := n.startRecorder(); err != nil {
+		return err
+	}
 	if err := n.startInProc(apis); err != nil {
+		n.stopRecorder()
 		return err
 	}
 	if err := n.startIPC(apis); err != nil {
 		n.stopInProc()
+		n.stopRecorder()
 		return err
 	}
-	if err := n.startHTTP(n.httpEndpoint, apis, n.config.HTTPModules, n.config.HTTPCors, n.config.HTTPVirtualHosts, n.config.HTTPTimeouts); err != nil {
+	if err := n.startHTTP(n.httpEndpoint, apis, n.config.HTTPModules, n.config.HTTPCors, n.config.HTTPVirtualHosts, n.config.HTTPTimeouts, &n.config.HTTPAccess); err != nil {
 		n.stopIPC()
 		n.stopInProc()
+		n.stopRecorder()
 		return err
 	}
-	if err := n.startWS(n.wsEndpoint, apis, n.config.WSModules, n.config.WSOrigins, n.config.WSExposeAll); err != nil {
+	if err := n.startWS(n.wsEndpoint, apis, n.config.WSModules, n.config.WSOrigins, n.config.WSExposeAll, &n.config.WSAccess); err != nil {
 		n.stopHTTP()
 		n.stopIPC()
 		n.stopInProc()
+		n.stopRecorder()
+		return err
+	}
+	if err := n.startAuth(n.authEndpoint, apis, n.config.AuthModules, n.config.AuthVirtualHosts, n.config.HTTPTimeouts); err != nil {
+		n.stopWS()
+		n.stopHTTP()
+		n.stopIPC()
+		n.stopInProc()
+		n.stopRecorder()
 		return err
 	}
 	// All API endpoints started successfully
@@ -307,6 +329,33 @@ func (n *Node) startRPC(services map[reflect.Type]Service) error {
 	return nil
 }
 
+// startRecorder opens the record file of the calls served by the external RPC
+// endpoints, if recording is enabled.
+func (n *Node) startRecorder() error {
+	config := n.config.RPCRecord
+	if config.Path == "" {
+		return nil // Recording disabled.
+	}
+	if path := n.config.ResolvePath(config.Path); path != "" {
+		config.Path = path
+	}
+	recorder, err := rpc.NewRecorder(config)
+	if err != nil {
+		return err
+	}
+	n.log.Info("Recording RPC calls", "path", config.Path, "methods", strings.Join(config.Methods, ","), "sample", config.SampleRate)
+	n.recorder = recorder
+	return nil
+}
+
+// stopRecorder closes the record file of the served RPC calls.
+func (n *Node) stopRecorder() {
+	if n.recorder != nil {
+		n.recorder.Close()
+		n.recorder = nil
+	}
+}
+
 // startInProc initializes an in-process RPC endpoint.
 func (n *Node) startInProc(apis []rpc.API) error {
 	// Register all the APIs exposed by the services
@@ -359,16 +408,23 @@ func (n *Node) stopIPC() {
 }
 
 // startHTTP initializes and starts the HTTP RPC endpoint.
-func (n *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors []string, vhosts []string, timeouts rpc.HTTPTimeouts) error {
+func (n *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors []string, vhosts []string, timeouts rpc.HTTPTimeouts, access *rpc.AccessConfig) error {
 	// Short circuit if the HTTP endpoint isn't being exposed
 	if endpoint == "" {
 		return nil
 	}
-	listener, handler, err := rpc.StartHTTPEndpoint(endpoint, apis, modules, cors, vhosts, timeouts)
+	jwt, err := n.jwtConfig(n.config.HTTPJWTAuth)
+	if err != nil {
+		return err
+	}
+	listener, handler, err := rpc.StartHTTPEndpoint(endpoint, apis, modules, cors, vhosts, timeouts, access, jwt)
 	if err != nil {
 		return err
 	}
-	n.log.Info("HTTP endpoint opened", "url", fmt.Sprintf("http://%s", endpoint), "cors", strings.Join(cors, ","), "vhosts", strings.Join(vhosts, ","))
+	if n.recorder != nil {
+		handler.SetRecorder(n.recorder)
+	}
+	n.log.Info("HTTP endpoint opened", "url", fmt.Sprintf("http://%s", endpoint), "cors", strings.Join(cors, ","), "vhosts", strings.Join(vhosts, ","), "auth", jwt != nil)
 	// All listeners booted successfully
 	n.httpEndpoint = endpoint
 	n.httpListener = listener
@@ -392,16 +448,23 @@ func (n *Node) stopHTTP() {
 }
 
 // startWS initializes and starts the websocket RPC endpoint.
-func (n *Node) startWS(endpoint string, apis []rpc.API, modules []string, wsOrigins []string, exposeAll bool) error {
+func (n *Node) startWS(endpoint string, apis []rpc.API, modules []string, wsOrigins []string, exposeAll bool, access *rpc.AccessConfig) error {
 	// Short circuit if the WS endpoint isn't being exposed
 	if endpoint == "" {
 		return nil
 	}
-	listener, handler, err := rpc.StartWSEndpoint(endpoint, apis, modules, wsOrigins, exposeAll)
+	jwt, err := n.jwtConfig(n.config.WSJWTAuth)
+	if err != nil {
+		return err
+	}
+	listener, handler, err := rpc.StartWSEndpoint(endpoint, apis, modules, wsOrigins, exposeAll, access, jwt)
 	if err != nil {
 		return err
 	}
-	n.log.Info("WebSocket endpoint opened", "url", fmt.Sprintf("ws://%s", listener.Addr()))
+	if n.recorder != nil {
+		handler.SetRecorder(n.recorder)
+	}
+	n.log.Info("WebSocket endpoint opened", "url", fmt.Sprintf("ws://%s", listener.Addr()), "auth", jwt != nil)
 	// All listeners booted successfully
 	n.wsEndpoint = endpoint
 	n.wsListener = listener
@@ -424,6 +487,60 @@ func (n *Node) stopWS() {
 	}
 }
 
+// startAuth initializes and starts the authenticated RPC endpoint, serving both
+// HTTP and websocket clients.
+func (n *Node) startAuth(endpoint string, apis []rpc.API, modules []string, vhosts []string, timeouts rpc.HTTPTimeouts) error {
+	// Short circuit if the authenticated endpoint isn't being exposed
+	if endpoint == "" {
+		return nil
+	}
+	jwt, err := n.jwtConfig(true)
+	if err != nil {
+		return err
+	}
+	listener, handler, err := rpc.StartAuthEndpoint(endpoint, apis, modules, vhosts, timeouts, jwt)
+	if err != nil {
+		return err
+	}
+	if n.recorder != nil {
+		handler.SetRecorder(n.recorder)
+	}
+	n.log.Info("Authenticated RPC endpoint opened", "url", fmt.Sprintf("http://%s", listener.Addr()), "vhosts", strings.Join(vhosts, ","))
+	// All listeners booted successfully
+	n.authEndpoint = endpoint
+	n.authListener = listener
+	n.authHandler = handler
+
+	return nil
+}
+
+// stopAuth terminates the authenticated RPC endpoint.
+func (n *Node) stopAuth() {
+	if n.authListener != nil {
+		n.authListener.Close()
+		n.authListener = nil
+
+		n.log.Info("Authenticated RPC endpoint closed", "url", fmt.Sprintf("http://%s", n.authEndpoint))
+	}
+	if n.authHandler != nil {
+		n.authHandler.Stop()
+		n.authHandler = nil
+	}
+}
+
+// jwtConfig assembles the JSON Web Token authentication settings of an RPC
+// endpoint, or returns nil if the endpoint does not require authentication.
+func (n *Node) jwtConfig(enabled bool) (*rpc.JWTConfig, error) {
+	if !enabled {
+		return nil, nil
+	}
+	secret, err := n.config.JWTSecretKey()
+	if err != nil {
+		return nil, err
+	}
+	return &rpc.JWTConfig{Secret: secret, ClockSkew: n.config.JWTClockSkew}, nil
+}
+
 // Stop terminates a running node along with all it's services. In the node was
 // not started, an error is returned.
 func (n *Node) Stop() error {
@@ -436,9 +553,11 @@ func (n *Node) Stop() error {
 	}
 
 	// Terminate the API, services and the p2p server.
+	n.stopAuth()
 	n.stopWS()
 	n.stopHTTP()
 	n.stopIPC()
+	n.stopRecorder()
 	n.rpcAPIs = nil
 	failure := &StopError{
 		Services: make(map[reflect.Type]error),
@@ -597,6 +716,18 @@ func (n *Node) WSEndpoint() string {
 	return n.wsEndpoint
 }
 
+// AuthEndpoint retrieves the current authenticated RPC endpoint used by the
+// protocol stack.
+func (n *Node) AuthEndpoint() string {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.authListener != nil {
+		return n.authListener.Addr().String()
+	}
+	return n.authEndpoint
+}
+
 // EventMux retrieves the event multiplexer used by all the network services in
 // the current protocol stack.
 func (n *Node) EventMux() *event.TypeMux {
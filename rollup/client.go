@@ -222,6 +222,13 @@ func (c *Client) GetLatestEnqueue() (*types.Transaction, error) {
 	return tx, nil
 }
 
+// ParseTransactionResponse converts a transaction in the format served by the
+// data transport layer into a transaction with its rollup metadata attached.
+func ParseTransactionResponse(res *TransactionResponse, chainID *big.Int) (*types.Transaction, error) {
+	signer := types.NewOVMSigner(chainID)
+	return transactionResponseToTransaction(res, &signer)
+}
+
 func transactionResponseToTransaction(res *TransactionResponse, signer *types.OVMSigner) (*types.Transaction, error) {
 	// `nil` transactions are not found
 	if res.Transaction == nil {
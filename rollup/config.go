@@ -29,6 +29,8 @@ type Config struct {
 	CanonicalTransactionChainDeployHeight *big.Int
 	// Path to the state dump
 	StateDumpPath string
+	// Expected content hash of the state dump, zero to accept any dump
+	StateDumpHash common.Hash
 	// Polling interval for rollup client
 	PollInterval time.Duration
 	// Interval for updating the timestamp
@@ -0,0 +1,102 @@
+package dump
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// StorageDiff is a single storage slot that differs between two dumps. A
+// zero value denotes a slot that is absent from the respective dump.
+type StorageDiff struct {
+	Key common.Hash `json:"key"`
+	Old common.Hash `json:"old"`
+	New common.Hash `json:"new"`
+}
+
+// AccountDiff describes how a named contract differs between two dumps.
+type AccountDiff struct {
+	Name        string         `json:"name"`
+	Added       bool           `json:"added,omitempty"`
+	Removed     bool           `json:"removed,omitempty"`
+	OldAddress  common.Address `json:"oldAddress"`
+	NewAddress  common.Address `json:"newAddress"`
+	OldCodeHash common.Hash    `json:"oldCodeHash"`
+	NewCodeHash common.Hash    `json:"newCodeHash"`
+	OldNonce    uint64         `json:"oldNonce"`
+	NewNonce    uint64         `json:"newNonce"`
+	Storage     []StorageDiff  `json:"storage,omitempty"`
+}
+
+// AddressChanged reports whether the contract moved to a different address.
+func (d *AccountDiff) AddressChanged() bool { return d.OldAddress != d.NewAddress }
+
+// CodeChanged reports whether the contract code differs.
+func (d *AccountDiff) CodeChanged() bool { return d.OldCodeHash != d.NewCodeHash }
+
+// Diff compares two dumps contract by contract and returns the differences,
+// ordered by contract name. Contracts that are identical in both dumps are
+// omitted.
+func Diff(a, b *OvmDump) []AccountDiff {
+	names := make(map[string]struct{})
+	for name := range a.Accounts {
+		names[name] = struct{}{}
+	}
+	for name := range b.Accounts {
+		names[name] = struct{}{}
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	var diffs []AccountDiff
+	for _, name := range sorted {
+		old, inA := a.Accounts[name]
+		new, inB := b.Accounts[name]
+
+		diff := AccountDiff{Name: name, Added: !inA, Removed: !inB}
+		if inA {
+			diff.OldAddress, diff.OldNonce = old.Address, old.Nonce
+			diff.OldCodeHash = crypto.Keccak256Hash(common.FromHex(old.Code))
+		}
+		if inB {
+			diff.NewAddress, diff.NewNonce = new.Address, new.Nonce
+			diff.NewCodeHash = crypto.Keccak256Hash(common.FromHex(new.Code))
+		}
+		diff.Storage = diffStorage(old.Storage, new.Storage)
+
+		if inA && inB && !diff.AddressChanged() && !diff.CodeChanged() && diff.OldNonce == diff.NewNonce && len(diff.Storage) == 0 {
+			continue
+		}
+		diffs = append(diffs, diff)
+	}
+	return diffs
+}
+
+func diffStorage(a, b map[common.Hash]string) []StorageDiff {
+	slots := make(map[common.Hash]*StorageDiff)
+	for key, val := range a {
+		slots[key] = &StorageDiff{Key: key, Old: common.HexToHash(val)}
+	}
+	for key, val := range b {
+		if slot, ok := slots[key]; ok {
+			slot.New = common.HexToHash(val)
+		} else {
+			slots[key] = &StorageDiff{Key: key, New: common.HexToHash(val)}
+		}
+	}
+	var diffs []StorageDiff
+	for _, slot := range slots {
+		if slot.Old != slot.New {
+			diffs = append(diffs, *slot)
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return bytes.Compare(diffs[i].Key[:], diffs[j].Key[:]) < 0
+	})
+	return diffs
+}
@@ -0,0 +1,189 @@
+package dump
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func testDump() *OvmDump {
+	d := &OvmDump{Accounts: make(map[string]OvmDumpAccount)}
+	for i, name := range RequiredAccounts {
+		code := []byte{0x60, byte(i), 0x60, 0x00, 0x55}
+		d.Accounts[name] = OvmDumpAccount{
+			Address:  common.BytesToAddress([]byte{byte(i + 1)}),
+			Code:     common.Bytes2Hex(code),
+			CodeHash: crypto.Keccak256Hash(code).Hex(),
+			Storage: map[common.Hash]string{
+				common.BigToHash(common.Big1): "0x01",
+				common.BigToHash(common.Big2): "0x0000000000000000000000000000000000000000000000000000000000000002",
+			},
+			Nonce: uint64(i),
+		}
+	}
+	return d
+}
+
+// encodeDump serializes a dump the way the contract deployment tooling does,
+// with the ABI as a JSON array.
+func encodeDump(t *testing.T, d *OvmDump) []byte {
+	type jsonAccount struct {
+		Address  common.Address         `json:"address"`
+		Code     string                 `json:"code"`
+		CodeHash string                 `json:"codeHash"`
+		Storage  map[common.Hash]string `json:"storage"`
+		ABI      []interface{}          `json:"abi"`
+		Nonce    uint64                 `json:"nonce"`
+	}
+	accounts := make(map[string]jsonAccount)
+	for name, acc := range d.Accounts {
+		accounts[name] = jsonAccount{acc.Address, acc.Code, acc.CodeHash, acc.Storage, []interface{}{}, acc.Nonce}
+	}
+	blob, err := json.Marshal(map[string]interface{}{
+		"version":  d.Version,
+		"hash":     d.Hash,
+		"accounts": accounts,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return blob
+}
+
+func TestComputeHashDeterministic(t *testing.T) {
+	a, b := testDump(), testDump()
+	if a.ComputeHash() != b.ComputeHash() {
+		t.Fatal("identical dumps hash differently")
+	}
+	// Reformatting a storage value must not change the hash
+	acc := b.Accounts["OVM_StateManager"]
+	acc.Storage[common.BigToHash(common.Big1)] = "0x0000000000000000000000000000000000000000000000000000000000000001"
+	if a.ComputeHash() != b.ComputeHash() {
+		t.Fatal("hash depends on value formatting")
+	}
+	acc.Storage[common.BigToHash(common.Big3)] = "0x03"
+	if a.ComputeHash() == b.ComputeHash() {
+		t.Fatal("storage change not reflected in hash")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	d := testDump()
+	if err := d.Validate(); err != nil {
+		t.Fatalf("legacy dump rejected: %v", err)
+	}
+	d.Seal()
+	if err := d.Validate(); err != nil {
+		t.Fatalf("sealed dump rejected: %v", err)
+	}
+	d.Hash = common.Hash{0x01}
+	if err := d.Validate(); !errors.Is(err, ErrHashMismatch) {
+		t.Fatalf("wrong error for bad hash: %v", err)
+	}
+	d.Seal()
+	d.Version = CurrentVersion + 1
+	if err := d.Validate(); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Fatalf("wrong error for future version: %v", err)
+	}
+	d = testDump()
+	acc := d.Accounts["OVM_ExecutionManager"]
+	acc.Code = "0x00"
+	d.Accounts["OVM_ExecutionManager"] = acc
+	if err := d.Validate(); err == nil {
+		t.Fatal("code hash mismatch not detected")
+	}
+	d = testDump()
+	delete(d.Accounts, "Lib_AddressManager")
+	if err := d.Validate(); err == nil {
+		t.Fatal("missing required account not detected")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	d := testDump()
+	d.Seal()
+	blob := encodeDump(t, d)
+	dir, err := ioutil.TempDir("", "ovm-dump")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "dump.json")
+	if err := ioutil.WriteFile(path, blob, 0644); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(blob)
+	}))
+	defer srv.Close()
+
+	for _, location := range []string{path, "file://" + path, srv.URL} {
+		loaded, err := Load(location)
+		if err != nil {
+			t.Fatalf("failed to load %s: %v", location, err)
+		}
+		if loaded.Hash != d.Hash {
+			t.Fatalf("hash mismatch for %s: have %x, want %x", location, loaded.Hash, d.Hash)
+		}
+	}
+	if _, err := Load(filepath.Join(dir, "missing.json")); err == nil {
+		t.Fatal("missing file loaded")
+	}
+}
+
+func TestSealJSON(t *testing.T) {
+	d := testDump()
+	sealed, err := SealJSON(encodeDump(t, d))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded OvmDump
+	if err := json.Unmarshal(sealed, &loaded); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Version != CurrentVersion {
+		t.Fatalf("wrong version: have %d, want %d", loaded.Version, CurrentVersion)
+	}
+	if err := loaded.Validate(); err != nil {
+		t.Fatalf("sealed dump rejected: %v", err)
+	}
+	if loaded.Hash != d.ComputeHash() {
+		t.Fatalf("wrong hash: have %x, want %x", loaded.Hash, d.ComputeHash())
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a, b := testDump(), testDump()
+	if diffs := Diff(a, b); len(diffs) != 0 {
+		t.Fatalf("identical dumps differ: %v", diffs)
+	}
+	delete(b.Accounts, "Lib_AddressManager")
+	b.Accounts["OVM_ETH"] = OvmDumpAccount{Code: "0x00"}
+
+	em := b.Accounts["OVM_ExecutionManager"]
+	em.Code = "0x6001"
+	em.Storage = map[common.Hash]string{common.BigToHash(common.Big1): "0x05"}
+	b.Accounts["OVM_ExecutionManager"] = em
+
+	diffs := Diff(a, b)
+	if len(diffs) != 3 {
+		t.Fatalf("wrong number of diffs: have %d, want 3", len(diffs))
+	}
+	if diffs[0].Name != "Lib_AddressManager" || !diffs[0].Removed {
+		t.Errorf("removed account not reported: %+v", diffs[0])
+	}
+	if diffs[1].Name != "OVM_ETH" || !diffs[1].Added {
+		t.Errorf("added account not reported: %+v", diffs[1])
+	}
+	if diffs[2].Name != "OVM_ExecutionManager" || !diffs[2].CodeChanged() || len(diffs[2].Storage) != 2 {
+		t.Errorf("changed account not reported: %+v", diffs[2])
+	}
+}
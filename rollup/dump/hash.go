@@ -0,0 +1,139 @@
+package dump
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// CurrentVersion is the most recent state dump format understood by this
+// node. Version 0 denotes a legacy dump without an embedded content hash.
+const CurrentVersion = 1
+
+// RequiredAccounts are the contracts that must be present in every state dump
+// used to build an OVM genesis.
+var RequiredAccounts = []string{
+	"Lib_AddressManager",
+	"OVM_StateManager",
+	"OVM_ExecutionManager",
+	"OVM_SequencerEntrypoint",
+}
+
+var (
+	// ErrUnsupportedVersion is returned when a dump was written in a format
+	// newer than CurrentVersion.
+	ErrUnsupportedVersion = errors.New("unsupported state dump version")
+
+	// ErrHashMismatch is returned when the contents of a dump do not hash to
+	// the expected value.
+	ErrHashMismatch = errors.New("state dump hash mismatch")
+)
+
+// hashSlot and hashAccount are the canonical representation of a dump that
+// is fed into the content hash. The ABI is informational only and does not
+// influence the resulting state, so it is left out.
+type hashSlot struct {
+	Key   common.Hash
+	Value common.Hash
+}
+
+type hashAccount struct {
+	Name    string
+	Address common.Address
+	Nonce   uint64
+	Code    []byte
+	Storage []hashSlot
+}
+
+// ComputeHash returns the content hash of the dump. Accounts are ordered by
+// name and storage slots by key, so the result does not depend on map
+// iteration order or on the formatting of the hex values in the JSON.
+func (d *OvmDump) ComputeHash() common.Hash {
+	names := d.AccountNames()
+	accounts := make([]hashAccount, 0, len(names))
+	for _, name := range names {
+		account := d.Accounts[name]
+		accounts = append(accounts, hashAccount{
+			Name:    name,
+			Address: account.Address,
+			Nonce:   account.Nonce,
+			Code:    common.FromHex(account.Code),
+			Storage: sortedStorage(account.Storage),
+		})
+	}
+	blob, err := rlp.EncodeToBytes(accounts)
+	if err != nil {
+		panic(fmt.Sprintf("failed to encode state dump: %v", err))
+	}
+	return crypto.Keccak256Hash(blob)
+}
+
+// AccountNames returns the names of all accounts in the dump in sorted order.
+func (d *OvmDump) AccountNames() []string {
+	names := make([]string, 0, len(d.Accounts))
+	for name := range d.Accounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate checks the internal consistency of the dump: the format version
+// must be supported, the embedded hash (if any) must match the contents, the
+// code hashes must match the code and all required accounts must be present.
+func (d *OvmDump) Validate() error {
+	if d.Version > CurrentVersion {
+		return fmt.Errorf("%w: have %d, max %d", ErrUnsupportedVersion, d.Version, CurrentVersion)
+	}
+	if d.Version > 0 {
+		if err := d.Verify(d.Hash); err != nil {
+			return err
+		}
+	}
+	for _, name := range d.AccountNames() {
+		account := d.Accounts[name]
+		if account.CodeHash == "" {
+			continue
+		}
+		want := common.HexToHash(account.CodeHash)
+		if have := crypto.Keccak256Hash(common.FromHex(account.Code)); have != want {
+			return fmt.Errorf("code hash mismatch for %s: have %x, want %x", name, have, want)
+		}
+	}
+	for _, name := range RequiredAccounts {
+		if _, ok := d.Accounts[name]; !ok {
+			return fmt.Errorf("%s not in state dump", name)
+		}
+	}
+	return nil
+}
+
+// Verify checks that the contents of the dump hash to expected.
+func (d *OvmDump) Verify(expected common.Hash) error {
+	if have := d.ComputeHash(); have != expected {
+		return fmt.Errorf("%w: have %x, want %x", ErrHashMismatch, have, expected)
+	}
+	return nil
+}
+
+// Seal upgrades the dump to the current version and embeds its content hash.
+func (d *OvmDump) Seal() {
+	d.Version = CurrentVersion
+	d.Hash = d.ComputeHash()
+}
+
+func sortedStorage(storage map[common.Hash]string) []hashSlot {
+	slots := make([]hashSlot, 0, len(storage))
+	for key, val := range storage {
+		slots = append(slots, hashSlot{Key: key, Value: common.HexToHash(val)})
+	}
+	sort.Slice(slots, func(i, j int) bool {
+		return bytes.Compare(slots[i].Key[:], slots[j].Key[:]) < 0
+	})
+	return slots
+}
@@ -0,0 +1,66 @@
+package dump
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// Load reads a state dump from a local file or an http(s) URL and validates
+// it. Unlike a plain JSON decode, Load refuses dumps in an unknown format and
+// dumps whose embedded content hash does not match their accounts.
+func Load(path string) (*OvmDump, error) {
+	blob, err := fetch(path)
+	if err != nil {
+		return nil, err
+	}
+	var d OvmDump
+	if err := json.Unmarshal(blob, &d); err != nil {
+		return nil, fmt.Errorf("invalid state dump %s: %w", path, err)
+	}
+	if err := d.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid state dump %s: %w", path, err)
+	}
+	return &d, nil
+}
+
+// fetch returns the raw contents of the dump located at path.
+func fetch(path string) ([]byte, error) {
+	switch {
+	case strings.HasPrefix(path, "http://"), strings.HasPrefix(path, "https://"):
+		resp, err := http.Get(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to GET state dump: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= 400 {
+			return nil, fmt.Errorf("unable to GET state dump: %s", resp.Status)
+		}
+		return ioutil.ReadAll(resp.Body)
+	case strings.HasPrefix(path, "file://"):
+		return ioutil.ReadFile(strings.TrimPrefix(path, "file://"))
+	default:
+		return ioutil.ReadFile(path)
+	}
+}
+
+// SealJSON upgrades a JSON encoded dump to the current version and embeds its
+// content hash. All other fields, including the contract ABIs, are preserved
+// as they appear in the input.
+func SealJSON(blob []byte) ([]byte, error) {
+	var d OvmDump
+	if err := json.Unmarshal(blob, &d); err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(blob, &fields); err != nil {
+		return nil, err
+	}
+	d.Seal()
+	version, _ := json.Marshal(d.Version)
+	hash, _ := json.Marshal(d.Hash)
+	fields["version"], fields["hash"] = version, hash
+	return json.MarshalIndent(fields, "", "  ")
+}
@@ -14,6 +14,11 @@ type OvmDumpAccount struct {
 	Nonce    uint64                 `json:"nonce"`
 }
 
+// OvmDump is the set of predeployed contracts used to build the OVM genesis
+// state. Dumps with a version of at least 1 carry the hash of their contents,
+// which is checked whenever the dump is loaded.
 type OvmDump struct {
+	Version  uint64                    `json:"version,omitempty"`
+	Hash     common.Hash               `json:"hash"`
 	Accounts map[string]OvmDumpAccount `json:"accounts"`
 }
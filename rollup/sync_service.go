@@ -23,6 +23,27 @@ import (
 	"github.com/ethereum/go-ethereum/eth/gasprice"
 )
 
+var (
+	// errRemoteSyncing is returned when the remote server is still syncing
+	// the layer one contracts.
+	errRemoteSyncing = errors.New("Data transport layer is still syncing")
+	// errDegraded is returned when submitting transactions while the remote
+	// server cannot be reached.
+	errDegraded = errors.New("Cannot accept transactions while the sync service is degraded")
+	// errMetaUnavailable is returned when the remote server cannot provide the
+	// transaction to validate the metadata received from a peer against.
+	errMetaUnavailable = errors.New("canonical transaction unavailable")
+)
+
+const (
+	minReconnectBackoff = time.Second
+	maxReconnectBackoff = time.Minute
+
+	// txMetaValidationWorkers is the maximum number of concurrent queries to the
+	// remote server when validating the metadata of a batch of transactions.
+	txMetaValidationWorkers = 8
+)
+
 // OVMContext represents the blocknumber and timestamp
 // that exist during L2 execution
 type OVMContext struct {
@@ -47,10 +68,12 @@ type SyncService struct {
 	L1gpo                     *gasprice.L1Oracle
 	client                    RollupClient
 	syncing                   atomic.Value
+	degraded                  atomic.Value
 	OVMContext                OVMContext
 	confirmationDepth         uint64
 	pollInterval              time.Duration
 	timestampRefreshThreshold time.Duration
+	ctcDeployHeight           *big.Int
 }
 
 // NewSyncService returns an initialized sync service
@@ -101,6 +124,7 @@ func NewSyncService(ctx context.Context, cfg Config, txpool *core.TxPool, bc *co
 		db:                        db,
 		pollInterval:              pollInterval,
 		timestampRefreshThreshold: timestampRefreshThreshold,
+		ctcDeployHeight:           cfg.CanonicalTransactionChainDeployHeight,
 	}
 
 	// Initial sync service setup if it is enabled. This code depends on
@@ -108,59 +132,22 @@ func NewSyncService(ctx context.Context, cfg Config, txpool *core.TxPool, bc *co
 	// code behind this if statement so that this can run without the
 	// requirement of the remote server being up.
 	if service.enable {
-		// Ensure that the rollup client can connect to a remote server
-		// before starting.
-		err := service.ensureClient()
-		if err != nil {
-			return nil, fmt.Errorf("Rollup client unable to connect: %w", err)
-		}
-
-		// Ensure that the remote is still not syncing
-		for {
-			status, err := service.client.SyncStatus()
-			if err != nil {
-				log.Error("Cannot get sync status")
-				continue
-			}
-			if !status.Syncing {
-				break
-			}
-			log.Info("Still syncing", "index", status.CurrentTransactionIndex, "tip", status.HighestKnownTransactionIndex)
-			time.Sleep(10 * time.Second)
+		if service.GetLatestIndex() == nil && service.ctcDeployHeight == nil {
+			return nil, errors.New("Must configure with canonical transaction chain deploy height")
 		}
-
-		// Initialize the latest L1 data here to make sure that
-		// it happens before the RPC endpoints open up
-		// Only do it if the sync service is enabled so that this
-		// can be ran without needing to have a configured client.
-		err = service.initializeLatestL1(cfg.CanonicalTransactionChainDeployHeight)
-		if err != nil {
-			return nil, fmt.Errorf("Cannot initialize latest L1 data: %w", err)
-		}
-
-		bn := service.GetLatestL1BlockNumber()
-		ts := service.GetLatestL1Timestamp()
-		log.Info("Initialized Latest L1 Info", "blocknumber", bn, "timestamp", ts)
-
-		var i, q string
-		index := service.GetLatestIndex()
-		queueIndex := service.GetLatestEnqueueIndex()
-		if index == nil {
-			i = "<nil>"
-		} else {
-			i = strconv.FormatUint(*index, 10)
+		// Connect to the remote server before the RPC endpoints open up.
+		// If it cannot be reached, start in degraded mode: RPC is served
+		// from the local state, transactions are refused and the
+		// connection is retried in the background once started.
+		if err := service.connect(); err != nil {
+			log.Warn("Rollup client unable to connect, starting in degraded mode", "err", err)
+			service.setDegraded(true)
 		}
-		if queueIndex == nil {
-			q = "<nil>"
-		} else {
-			q = strconv.FormatUint(*queueIndex, 10)
-		}
-		log.Info("Initialized Eth Context", "index", i, "queue-index", q)
 
 		// The sequencer needs to sync to the tip at start up
 		// By setting the sync status to true, it will prevent RPC calls.
 		// Be sure this is set to false later.
-		if !service.verifier {
+		if !service.verifier || service.IsDegraded() {
 			service.setSyncStatus(true)
 		}
 	}
@@ -176,6 +163,72 @@ func (s *SyncService) ensureClient() error {
 	return nil
 }
 
+// connect ensures that the remote server can be reached and has finished
+// syncing, then initializes the latest L1 data from it.
+func (s *SyncService) connect() error {
+	if err := s.ensureClient(); err != nil {
+		return err
+	}
+	status, err := s.client.SyncStatus()
+	if err != nil {
+		return fmt.Errorf("Cannot get sync status: %w", err)
+	}
+	if status.Syncing {
+		log.Info("Still syncing", "index", status.CurrentTransactionIndex, "tip", status.HighestKnownTransactionIndex)
+		return errRemoteSyncing
+	}
+	if err := s.initializeLatestL1(s.ctcDeployHeight); err != nil {
+		return fmt.Errorf("Cannot initialize latest L1 data: %w", err)
+	}
+
+	bn := s.GetLatestL1BlockNumber()
+	ts := s.GetLatestL1Timestamp()
+	log.Info("Initialized Latest L1 Info", "blocknumber", bn, "timestamp", ts)
+
+	var i, q string
+	index := s.GetLatestIndex()
+	queueIndex := s.GetLatestEnqueueIndex()
+	if index == nil {
+		i = "<nil>"
+	} else {
+		i = strconv.FormatUint(*index, 10)
+	}
+	if queueIndex == nil {
+		q = "<nil>"
+	} else {
+		q = strconv.FormatUint(*queueIndex, 10)
+	}
+	log.Info("Initialized Eth Context", "index", i, "queue-index", q)
+	return nil
+}
+
+// reconnectLoop retries connecting to the remote server with exponential
+// backoff while in degraded mode. Once connected, the sync service is
+// started and leaves degraded mode.
+func (s *SyncService) reconnectLoop() {
+	backoff := minReconnectBackoff
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		err := s.connect()
+		if err == nil {
+			err = s.start()
+		}
+		if err == nil {
+			log.Info("Sync service recovered from degraded mode")
+			return
+		}
+		log.Warn("Sync service still degraded", "err", err, "retry", backoff)
+		backoff *= 2
+		if backoff > maxReconnectBackoff {
+			backoff = maxReconnectBackoff
+		}
+	}
+}
+
 // Start initializes the service, connecting to Ethereum1 and starting the
 // subservices required for the operation of the SyncService.
 // txs through syncservice go to mempool.locals
@@ -186,6 +239,18 @@ func (s *SyncService) Start() error {
 	}
 	log.Info("Initializing Sync Service", "eth1-chainid", s.eth1ChainId)
 
+	// The remote server could not be reached when the service was created,
+	// keep serving the local state and reconnect in the background.
+	if s.IsDegraded() {
+		go s.reconnectLoop()
+		return nil
+	}
+	return s.start()
+}
+
+// start syncs the sequencer to the tip and starts the main loop. It must only
+// be called once the remote server is reachable.
+func (s *SyncService) start() error {
 	// When a sequencer, be sure to sync to the tip of the ctc before allowing
 	// user transactions.
 	if !s.verifier {
@@ -195,8 +260,9 @@ func (s *SyncService) Start() error {
 		}
 		// TODO: This should also sync the enqueue'd transactions that have not
 		// been synced yet
-		s.setSyncStatus(false)
 	}
+	s.setDegraded(false)
+	s.setSyncStatus(false)
 
 	if s.verifier {
 		go s.VerifierLoop()
@@ -282,6 +348,25 @@ func (s *SyncService) IsSyncing() bool {
 	return val
 }
 
+// setDegraded sets whether the sync service is running without a connection
+// to the remote server. A degraded sync service refuses transactions.
+func (s *SyncService) setDegraded(degraded bool) {
+	log.Info("Setting degraded status", "status", degraded)
+	s.degraded.Store(degraded)
+}
+
+// IsDegraded returns true if the sync service could not connect to the
+// remote server and is serving the local state only.
+// Returns false if not yet set.
+func (s *SyncService) IsDegraded() bool {
+	value := s.degraded.Load()
+	val, ok := value.(bool)
+	if !ok {
+		return false
+	}
+	return val
+}
+
 // Stop will close the open channels and cancel the goroutines
 // started by this service.
 func (s *SyncService) Stop() error {
@@ -645,6 +730,112 @@ func (s *SyncService) maybeApplyTransaction(tx *types.Transaction) error {
 	return nil
 }
 
+// ValidateTransactionMetas checks the metadata of transactions received from a
+// peer against the transactions indexed by the remote server, querying it
+// concurrently. Transactions are accepted unvalidated if the remote server is
+// unavailable, does not know about them yet or does not answer before the
+// context is done: the L1 data remains authoritative and is synced regardless,
+// whereas refusing peer data would stall block propagation for as long as the
+// remote server is down.
+func (s *SyncService) ValidateTransactionMetas(ctx context.Context, txs []*types.Transaction) error {
+	if !s.enable || s.IsDegraded() || len(txs) == 0 {
+		return nil
+	}
+	for _, tx := range txs {
+		if tx.GetMeta().Index == nil {
+			return errors.New("transaction without index")
+		}
+	}
+	workers := txMetaValidationWorkers
+	if len(txs) < workers {
+		workers = len(txs)
+	}
+	var (
+		tasks = make(chan *types.Transaction)
+		errc  = make(chan error, len(txs))
+	)
+	for i := 0; i < workers; i++ {
+		go func() {
+			for tx := range tasks {
+				errc <- s.validateTransactionMeta(tx)
+			}
+		}()
+	}
+	// Feed the transactions to the workers and collect the results, aborting on
+	// the first mismatch
+	var sent, done, unavailable int
+	defer close(tasks)
+
+	for done < len(txs) {
+		var (
+			feed chan *types.Transaction
+			next *types.Transaction
+		)
+		if sent < len(txs) {
+			feed, next = tasks, txs[sent]
+		}
+		select {
+		case feed <- next:
+			sent++
+		case err := <-errc:
+			done++
+			switch err {
+			case nil:
+			case errMetaUnavailable:
+				unavailable++
+			default:
+				return err
+			}
+		case <-ctx.Done():
+			log.Warn("Timed out validating transaction metadata, accepting unvalidated", "txs", len(txs), "validated", done)
+			return nil
+		}
+	}
+	if unavailable > 0 {
+		log.Debug("Accepted transaction metadata unvalidated", "txs", len(txs), "unavailable", unavailable)
+	}
+	return nil
+}
+
+// validateTransactionMeta checks the metadata of a single transaction against
+// the transaction indexed by the remote server, returning errMetaUnavailable
+// if the remote server cannot provide it.
+func (s *SyncService) validateTransactionMeta(tx *types.Transaction) error {
+	meta := tx.GetMeta()
+	expected, err := s.client.GetTransaction(*meta.Index)
+	if err != nil {
+		log.Debug("Cannot fetch transaction to validate metadata", "index", *meta.Index, "err", err)
+		return errMetaUnavailable
+	}
+	if expected == nil {
+		return errMetaUnavailable
+	}
+	if !isCtcTxEqual(tx, expected) {
+		return fmt.Errorf("transaction %d does not match the canonical transaction chain", *meta.Index)
+	}
+	want := expected.GetMeta()
+	if meta.L1Timestamp != want.L1Timestamp {
+		return fmt.Errorf("transaction %d: L1 timestamp mismatch: have %d, want %d", *meta.Index, meta.L1Timestamp, want.L1Timestamp)
+	}
+	if !bigEqual(meta.L1BlockNumber, want.L1BlockNumber) {
+		return fmt.Errorf("transaction %d: L1 block number mismatch: have %v, want %v", *meta.Index, meta.L1BlockNumber, want.L1BlockNumber)
+	}
+	if !bigEqual(meta.QueueOrigin, want.QueueOrigin) {
+		return fmt.Errorf("transaction %d: queue origin mismatch: have %v, want %v", *meta.Index, meta.QueueOrigin, want.QueueOrigin)
+	}
+	if (meta.QueueIndex == nil) != (want.QueueIndex == nil) || (meta.QueueIndex != nil && *meta.QueueIndex != *want.QueueIndex) {
+		return fmt.Errorf("transaction %d: queue index mismatch", *meta.Index)
+	}
+	return nil
+}
+
+func bigEqual(a, b *big.Int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Cmp(b) == 0
+}
+
 // Lower level API used to apply a transaction, must only be used with
 // transactions that came from L1.
 func (s *SyncService) applyTransaction(tx *types.Transaction) error {
@@ -668,6 +859,9 @@ func (s *SyncService) ApplyTransaction(tx *types.Transaction) error {
 	if s.verifier {
 		return errors.New("Verifier does not accept transactions out of band")
 	}
+	if s.IsDegraded() {
+		return errDegraded
+	}
 	qo := tx.QueueOrigin()
 	if qo == nil {
 		return errors.New("invalid transaction with no queue origin")
@@ -712,6 +906,11 @@ func getRawTransaction(tx *types.Transaction) ([]byte, error) {
 	if tx == nil {
 		return nil, errors.New("Cannot process nil transaction")
 	}
+	// The sequencer entrypoint encoding has no room for typed transaction
+	// fields such as access lists
+	if tx.Type() != types.LegacyTxType {
+		return nil, types.ErrTxTypeNotSupported
+	}
 	v, r, s := tx.RawSignatureValues()
 
 	// V parameter here will include the chain ID, so we need to recover the original V. If the V
@@ -5,8 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"net"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
@@ -14,6 +16,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/eth/gasprice"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/params"
@@ -299,6 +302,178 @@ func TestInitializeL1ContextPostGenesis(t *testing.T) {
 	}
 }
 
+// Test that the sync service starts in degraded mode when the data transport
+// layer cannot be reached and refuses transactions.
+func TestSyncServiceDegraded(t *testing.T) {
+	service, err := newDegradedTestSyncService(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !service.IsDegraded() {
+		t.Fatal("sync service not degraded")
+	}
+	if !service.IsSyncing() {
+		t.Fatal("degraded sync service not syncing")
+	}
+	tx := types.NewTransaction(0, common.Address{}, big.NewInt(0), 0, big.NewInt(0), nil)
+	meta := types.NewTransactionMeta(nil, 0, nil, types.SighashEIP155, types.QueueOriginSequencer, nil, nil, nil)
+	tx.SetTransactionMeta(meta)
+	if err := service.ApplyTransaction(tx); err != errDegraded {
+		t.Fatalf("unexpected error applying transaction: have %v, want %v", err, errDegraded)
+	}
+}
+
+// Test that a degraded sync service recovers once the data transport layer
+// can be reached.
+func TestSyncServiceReconnect(t *testing.T) {
+	service, err := newDegradedTestSyncService(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer service.Stop()
+
+	if !service.IsDegraded() {
+		t.Fatal("sync service not degraded")
+	}
+	setupMockClient(service, map[string]interface{}{
+		"GetEthContext": []*EthContext{
+			{
+				BlockNumber: uint64(10),
+				Timestamp:   uint64(20),
+			},
+		},
+	})
+	service.reconnectLoop()
+
+	if service.IsDegraded() {
+		t.Fatal("sync service still degraded")
+	}
+	if service.IsSyncing() {
+		t.Fatal("sync service still syncing")
+	}
+	if bn := service.GetLatestL1BlockNumber(); bn != 10 {
+		t.Fatalf("L1 block number mismatch: have %d, want %d", bn, 10)
+	}
+	if ts := service.GetLatestL1Timestamp(); ts != 20 {
+		t.Fatalf("L1 timestamp mismatch: have %d, want %d", ts, 20)
+	}
+}
+
+// Test that typed transactions are refused by the sequencer, as they cannot be
+// encoded for the sequencer entrypoint.
+func TestSyncServiceTypedTransaction(t *testing.T) {
+	service, _, _, err := newTestSyncService(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setupMockClient(service, map[string]interface{}{})
+
+	defer func(usingOVM bool) { vm.UsingOVM = usingOVM }(vm.UsingOVM)
+	vm.UsingOVM = true
+
+	key, _ := crypto.GenerateKey()
+	to := common.HexToAddress("0x1")
+	tx, err := types.SignTx(types.NewTx(&types.AccessListTx{
+		ChainID:    big.NewInt(420),
+		To:         &to,
+		Gas:        21000,
+		GasPrice:   big.NewInt(0),
+		AccessList: types.AccessList{{Address: to}},
+	}), types.NewEIP2930Signer(big.NewInt(420)), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta := types.NewTransactionMeta(nil, 0, nil, types.SighashEIP155, types.QueueOriginSequencer, nil, nil, nil)
+	tx.SetTransactionMeta(meta)
+	if err := service.ApplyTransaction(tx); !errors.Is(err, core.ErrTypedTxNotSupportedOVM) {
+		t.Fatalf("unexpected error applying transaction: have %v, want %v", err, core.ErrTypedTxNotSupportedOVM)
+	}
+}
+
+// Test that the metadata of transactions received from peers is validated
+// against the remote server, and accepted as is if it's unavailable.
+func TestSyncServiceValidateTransactionMetas(t *testing.T) {
+	service, _, _, err := newTestSyncService(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.enable = true
+
+	makeTx := func(timestamp uint64) *types.Transaction {
+		index := uint64(0)
+		tx := types.NewTransaction(0, common.HexToAddress("0x1"), big.NewInt(0), 21000, big.NewInt(0), []byte{0x01})
+		meta := types.NewTransactionMeta(big.NewInt(1), timestamp, nil, types.SighashEIP155, types.QueueOriginSequencer, &index, nil, nil)
+		tx.SetTransactionMeta(meta)
+		return tx
+	}
+	tests := []struct {
+		remote []*types.Transaction
+		fail   bool
+	}{
+		{[]*types.Transaction{makeTx(1)}, false},
+		{[]*types.Transaction{makeTx(2)}, true},
+		{nil, false},
+	}
+	for i, tt := range tests {
+		setupMockClient(service, map[string]interface{}{"GetTransaction": tt.remote})
+		err := service.ValidateTransactionMetas(context.Background(), []*types.Transaction{makeTx(1)})
+		if (err != nil) != tt.fail {
+			t.Errorf("test %d: validation error mismatch: have %v, want failure %v", i, err, tt.fail)
+		}
+	}
+	// A remote server not answering in time must not fail the validation
+	release := make(chan struct{})
+	defer close(release)
+	service.client = &stallingClient{mockClient: newMockClient(nil), release: release}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := service.ValidateTransactionMetas(ctx, []*types.Transaction{makeTx(1), makeTx(2)}); err != nil {
+		t.Fatalf("stalled validation failed: %v", err)
+	}
+}
+
+// stallingClient is a rollup client whose transaction queries only return once
+// released.
+type stallingClient struct {
+	*mockClient
+	release chan struct{}
+}
+
+func (c *stallingClient) GetTransaction(index uint64) (*types.Transaction, error) {
+	<-c.release
+	return nil, errors.New("released")
+}
+
+// newDegradedTestSyncService creates an enabled sync service whose rollup
+// client points at a closed port.
+func newDegradedTestSyncService(isVerifier bool) (*SyncService, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	url := "http://" + listener.Addr().String()
+	listener.Close()
+
+	chainCfg := params.AllEthashProtocolChanges
+	chainCfg.ChainID = big.NewInt(420)
+
+	db := rawdb.NewMemoryDatabase()
+	_ = new(core.Genesis).MustCommit(db)
+	chain, err := core.NewBlockChain(db, nil, chainCfg, ethash.NewFaker(), vm.Config{}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot initialize blockchain: %w", err)
+	}
+	txPool := core.NewTxPool(core.TxPoolConfig{PriceLimit: 0}, &params.ChainConfig{ChainID: chainCfg.ChainID}, chain)
+	cfg := Config{
+		CanonicalTransactionChainDeployHeight: big.NewInt(0),
+		IsVerifier:                            isVerifier,
+		Eth1SyncServiceEnable:                 true,
+		RollupClientHttp:                      url,
+	}
+	return NewSyncService(context.Background(), cfg, txPool, chain, db)
+}
+
 func newTestSyncService(isVerifier bool) (*SyncService, chan core.NewTxsEvent, event.Subscription, error) {
 	chainCfg := params.AllEthashProtocolChanges
 	chainID := big.NewInt(420)
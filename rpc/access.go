@@ -0,0 +1,262 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/metrics"
+	lru "github.com/hashicorp/golang-lru"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// apiKeyHeader is the HTTP header clients present their API key in.
+	apiKeyHeader = "X-API-Key"
+
+	// maxLimitedClients is the number of clients whose rate limit buckets are
+	// tracked, the least recently active ones being forgotten beyond it.
+	maxLimitedClients = 16384
+)
+
+var (
+	deniedMeter          = metrics.NewRegisteredMeter("rpc/access/denied", nil)
+	rateLimitedMeter     = metrics.NewRegisteredMeter("rpc/access/ratelimited", nil)
+	batchLimitedMeter    = metrics.NewRegisteredMeter("rpc/access/batchlimited", nil)
+	responseLimitedMeter = metrics.NewRegisteredMeter("rpc/access/responselimited", nil)
+)
+
+// AccessConfig holds the method access rules and resource limits an RPC server
+// applies to the requests of its clients. Methods are selected by patterns,
+// which are either exact method names, prefixes ending in '*' like "debug_*",
+// or "*" for all methods.
+type AccessConfig struct {
+	// Allow lists the methods served to clients, all methods being served if
+	// empty. Deny lists the methods never served, overriding Allow.
+	Allow []string `toml:",omitempty"`
+	Deny  []string `toml:",omitempty"`
+
+	// RateLimits maps method patterns to the limit applied to each client calling
+	// the matching methods. The calls of a client to all methods matching the same
+	// pattern share one limit, methods matching several patterns being limited by
+	// the most specific one.
+	RateLimits map[string]RateLimit `toml:",omitempty"`
+
+	// APIKeys are the keys clients may present in the X-API-Key header to be
+	// rate limited by key instead of by IP address.
+	APIKeys []string `toml:",omitempty"`
+
+	MaxBatchSize    int `toml:",omitempty"` // Maximum number of requests in a batch, 0 for no limit
+	MaxResponseSize int `toml:",omitempty"` // Maximum size of the responses to a request in bytes, 0 for no limit
+}
+
+// RateLimit is a token bucket limit, allowing a client to make Burst requests at
+// once and Rate requests per second on average.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// rateLimit is a configured rate limit along with its rejection meter.
+type rateLimit struct {
+	pattern string
+	limit   RateLimit
+	meter   metrics.Meter
+}
+
+// bucketKey identifies the token bucket of a client for a rate limit.
+type bucketKey struct {
+	client  string
+	pattern string
+}
+
+// accessControl enforces an access configuration.
+type accessControl struct {
+	allow   []string
+	deny    []string
+	limits  []*rateLimit
+	keys    map[string]struct{}
+	buckets *lru.Cache // Token buckets of the recently active clients, by bucketKey
+
+	maxBatchSize    int
+	maxResponseSize int
+}
+
+// newAccessControl validates an access configuration and creates the access
+// control enforcing it.
+func newAccessControl(config *AccessConfig) (*accessControl, error) {
+	buckets, _ := lru.New(maxLimitedClients)
+	ac := &accessControl{
+		allow:           config.Allow,
+		deny:            config.Deny,
+		keys:            make(map[string]struct{}),
+		buckets:         buckets,
+		maxBatchSize:    config.MaxBatchSize,
+		maxResponseSize: config.MaxResponseSize,
+	}
+	for pattern, limit := range config.RateLimits {
+		if limit.Rate <= 0 || limit.Burst <= 0 {
+			return nil, fmt.Errorf("invalid rate limit for %q: rate and burst must be positive", pattern)
+		}
+		ac.limits = append(ac.limits, &rateLimit{
+			pattern: pattern,
+			limit:   limit,
+			meter:   metrics.NewRegisteredMeter("rpc/access/ratelimited/"+pattern, nil),
+		})
+	}
+	for _, key := range config.APIKeys {
+		ac.keys[key] = struct{}{}
+	}
+	return ac, nil
+}
+
+// matchMethod returns whether a method is selected by a method pattern.
+func matchMethod(pattern, method string) bool {
+	if strings.HasSuffix(pattern, "*") {
+		return strings.HasPrefix(method, strings.TrimSuffix(pattern, "*"))
+	}
+	return pattern == method
+}
+
+// matchAny returns whether a method is selected by any of the given patterns.
+func matchAny(patterns []string, method string) bool {
+	for _, pattern := range patterns {
+		if matchMethod(pattern, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// check verifies that the given client may call a method, consuming a token of
+// the rate limit applying to the method, if any.
+func (ac *accessControl) check(client, method string) error {
+	if matchAny(ac.deny, method) || (len(ac.allow) > 0 && !matchAny(ac.allow, method)) {
+		deniedMeter.Mark(1)
+		return &methodNotFoundError{method: method}
+	}
+	limit := ac.rateLimit(method)
+	if limit == nil {
+		return nil
+	}
+	key := bucketKey{client: client, pattern: limit.pattern}
+
+	bucket, ok := ac.buckets.Get(key)
+	if !ok {
+		bucket = rate.NewLimiter(rate.Limit(limit.limit.Rate), limit.limit.Burst)
+		if prev, ok, _ := ac.buckets.PeekOrAdd(key, bucket); ok {
+			bucket = prev
+		}
+	}
+	if !bucket.(*rate.Limiter).Allow() {
+		rateLimitedMeter.Mark(1)
+		limit.meter.Mark(1)
+		return &limitExceededError{fmt.Sprintf("rate limit exceeded for %s", method)}
+	}
+	return nil
+}
+
+// rateLimit returns the most specific rate limit applying to a method, an exact
+// method name taking precedence over the longest matching prefix.
+func (ac *accessControl) rateLimit(method string) *rateLimit {
+	var best *rateLimit
+	for _, limit := range ac.limits {
+		if !matchMethod(limit.pattern, method) {
+			continue
+		}
+		if limit.pattern == method {
+			return limit
+		}
+		if best == nil || len(limit.pattern) > len(best.pattern) {
+			best = limit
+		}
+	}
+	return best
+}
+
+// checkBatch verifies that a batch of the given size may be served.
+func (ac *accessControl) checkBatch(size int) error {
+	if ac.maxBatchSize > 0 && size > ac.maxBatchSize {
+		batchLimitedMeter.Mark(1)
+		return &limitExceededError{fmt.Sprintf("batch too large (%d>%d)", size, ac.maxBatchSize)}
+	}
+	return nil
+}
+
+// checkResponse verifies that responses of the given total size may be sent.
+func (ac *accessControl) checkResponse(size int) error {
+	if ac.maxResponseSize > 0 && size > ac.maxResponseSize {
+		responseLimitedMeter.Mark(1)
+		return &limitExceededError{fmt.Sprintf("response too large (%d>%d)", size, ac.maxResponseSize)}
+	}
+	return nil
+}
+
+// clientID identifies the client making an HTTP request for rate limiting, by
+// its API key if a known one is presented or by its IP address otherwise.
+func (ac *accessControl) clientID(r *http.Request) string {
+	if key := r.Header.Get(apiKeyHeader); key != "" {
+		if _, ok := ac.keys[key]; ok {
+			return "key:" + key
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return host
+}
+
+// accessCodec is a server codec of a single client, whose requests are subject
+// to an access control and recorded, if configured.
+type accessCodec struct {
+	ServerCodec
+	access   *accessControl
+	client   string
+	recorder *Recorder
+}
+
+// SetAccessConfig configures the method access rules and resource limits
+// applied to the clients of connections served over HTTP and websocket after
+// the call. It is meant to be called before serving any connection.
+func (s *Server) SetAccessConfig(config *AccessConfig) error {
+	ac, err := newAccessControl(config)
+	if err != nil {
+		return err
+	}
+	s.access.Store(ac)
+	return nil
+}
+
+// accessCodec wraps the codec serving an HTTP request or websocket connection
+// into one enforcing the access configuration of the server and recording the
+// served calls, if any.
+func (s *Server) accessCodec(codec ServerCodec, r *http.Request) ServerCodec {
+	ac, _ := s.access.Load().(*accessControl)
+	rec, _ := s.recorder.Load().(*Recorder)
+	if ac == nil && rec == nil {
+		return codec
+	}
+	wrapped := &accessCodec{ServerCodec: codec, recorder: rec}
+	if ac != nil {
+		wrapped.access, wrapped.client = ac, ac.clientID(r)
+	}
+	return wrapped
+}
@@ -0,0 +1,187 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newAccessTestServer starts an HTTP server for the test service, enforcing the
+// given access configuration.
+func newAccessTestServer(t *testing.T, config *AccessConfig) (*Server, *httptest.Server) {
+	server := newTestServer()
+	if err := server.SetAccessConfig(config); err != nil {
+		t.Fatalf("failed to set access config: %v", err)
+	}
+	return server, httptest.NewServer(server)
+}
+
+// errorCode returns the JSON-RPC error code of a call error, or 0 if none.
+func errorCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	if rpcErr, ok := err.(Error); ok {
+		return rpcErr.ErrorCode()
+	}
+	return defaultErrorCode
+}
+
+func TestAccessAllowDeny(t *testing.T) {
+	server, httpsrv := newAccessTestServer(t, &AccessConfig{
+		Allow: []string{"test_*", "rpc_modules"},
+		Deny:  []string{"test_sleep"},
+	})
+	defer server.Stop()
+	defer httpsrv.Close()
+
+	client, err := DialHTTP(httpsrv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	tests := []struct {
+		method string
+		code   int
+	}{
+		{"test_rets", 0},
+		{"rpc_modules", 0},
+		{"test_sleep", -32601},
+		{"nftest_echo", -32601},
+	}
+	for _, tt := range tests {
+		var result interface{}
+		if code := errorCode(client.Call(&result, tt.method)); code != tt.code {
+			t.Errorf("%s: error code mismatch: have %d, want %d", tt.method, code, tt.code)
+		}
+	}
+}
+
+func TestAccessRateLimit(t *testing.T) {
+	server, httpsrv := newAccessTestServer(t, &AccessConfig{
+		RateLimits: map[string]RateLimit{
+			"test_*":    {Rate: 0.001, Burst: 1},
+			"test_rets": {Rate: 0.001, Burst: 2},
+		},
+	})
+	defer server.Stop()
+	defer httpsrv.Close()
+
+	client, err := DialHTTP(httpsrv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	// The exact method limit applies instead of the wildcard one
+	var result interface{}
+	for i := 0; i < 2; i++ {
+		if err := client.Call(&result, "test_rets"); err != nil {
+			t.Fatalf("call %d rejected: %v", i, err)
+		}
+	}
+	if code := errorCode(client.Call(&result, "test_rets")); code != -32005 {
+		t.Errorf("exhausted limit error code mismatch: have %d, want %d", code, -32005)
+	}
+	// Other methods share the wildcard limit
+	if err := client.Call(&result, "test_noArgsRets"); err != nil {
+		t.Fatalf("first wildcard call rejected: %v", err)
+	}
+	if code := errorCode(client.Call(&result, "test_returnError")); code != -32005 {
+		t.Errorf("exhausted wildcard limit error code mismatch: have %d, want %d", code, -32005)
+	}
+	// Unlimited methods are unaffected
+	if err := client.Call(&result, "rpc_modules"); err != nil {
+		t.Errorf("unlimited call rejected: %v", err)
+	}
+}
+
+func TestAccessBatchAndResponseLimits(t *testing.T) {
+	server, httpsrv := newAccessTestServer(t, &AccessConfig{
+		MaxBatchSize:    2,
+		MaxResponseSize: 64,
+	})
+	defer server.Stop()
+	defer httpsrv.Close()
+
+	post := func(body string) string {
+		resp, err := http.Post(httpsrv.URL, contentType, strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		defer resp.Body.Close()
+
+		var out json.RawMessage
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return string(out)
+	}
+	call := `{"jsonrpc":"2.0","id":1,"method":"test_echo","params":["x",1]}`
+	if resp := post("[" + call + "," + call + "," + call + "]"); !strings.Contains(resp, "batch too large") {
+		t.Errorf("oversized batch served: %s", resp)
+	}
+	// Two echoes fit the batch limit, but not the response limit together
+	resp := post("[" + call + "," + call + "]")
+	if strings.Count(resp, `"result"`) != 1 || !strings.Contains(resp, "response too large") {
+		t.Errorf("oversized batch response served: %s", resp)
+	}
+	large := `{"jsonrpc":"2.0","id":1,"method":"test_echo","params":["` + strings.Repeat("x", 64) + `",1]}`
+	if resp := post(large); !strings.Contains(resp, "response too large") {
+		t.Errorf("oversized response served: %s", resp)
+	}
+}
+
+func TestAccessClientID(t *testing.T) {
+	ac, err := newAccessControl(&AccessConfig{APIKeys: []string{"secret"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		remote, key string
+		id          string
+	}{
+		{"10.0.0.1:1234", "", "10.0.0.1"},
+		{"10.0.0.1:5678", "unknown", "10.0.0.1"},
+		{"10.0.0.1:1234", "secret", "key:secret"},
+		{"[::1]:1234", "", "::1"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, "http://url.com", nil)
+		r.RemoteAddr = tt.remote
+		if tt.key != "" {
+			r.Header.Set(apiKeyHeader, tt.key)
+		}
+		if id := ac.clientID(r); id != tt.id {
+			t.Errorf("%s/%q: client id mismatch: have %q, want %q", tt.remote, tt.key, id, tt.id)
+		}
+	}
+}
+
+func TestAccessInvalidConfig(t *testing.T) {
+	server := NewServer()
+	defer server.Stop()
+
+	if err := server.SetAccessConfig(&AccessConfig{RateLimits: map[string]RateLimit{"eth_getLogs": {Rate: 1}}}); err == nil {
+		t.Errorf("zero burst rate limit accepted")
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultJWTClockSkew is the default maximum difference tolerated between the
+// issuance time of a token and the local time.
+const DefaultJWTClockSkew = 60 * time.Second
+
+var (
+	errMissingToken   = errors.New("missing token")
+	errMalformedToken = errors.New("malformed token")
+	errInvalidAlg     = errors.New("unsupported signing algorithm")
+	errInvalidSig     = errors.New("invalid token signature")
+	errMissingIat     = errors.New("missing issuance time")
+	errStaleToken     = errors.New("stale token")
+	errFutureToken    = errors.New("future token")
+	errEmptySecret    = errors.New("empty JWT secret")
+
+	// jwtHeader is the encoded header of all tokens issued.
+	jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+)
+
+// JWTConfig holds the shared secret and clock skew tolerance of an endpoint
+// requiring its clients to authenticate with HS256 JSON Web Tokens.
+type JWTConfig struct {
+	Secret    []byte
+	ClockSkew time.Duration // Zero for DefaultJWTClockSkew
+}
+
+// jwtClaims are the claims of a token which are verified.
+type jwtClaims struct {
+	IssuedAt *int64 `json:"iat"`
+}
+
+// NewJWTToken creates an HS256 JSON Web Token issued at the given time, signed
+// with the given secret.
+func NewJWTToken(secret []byte, issuedAt time.Time) string {
+	claims, _ := json.Marshal(map[string]int64{"iat": issuedAt.Unix()})
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(claims)
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(jwtSignature(secret, unsigned))
+}
+
+// jwtSignature computes the HS256 signature of an unsigned token.
+func jwtSignature(secret []byte, unsigned string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+	return mac.Sum(nil)
+}
+
+// verifyJWTToken checks that a token is signed with the given secret using
+// HS256, and that it was issued within the tolerated clock skew of now.
+func verifyJWTToken(secret []byte, token string, now time.Time, skew time.Duration) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return errMalformedToken
+	}
+	header, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return errMalformedToken
+	}
+	var head struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(header, &head); err != nil {
+		return errMalformedToken
+	}
+	if head.Alg != "HS256" {
+		return errInvalidAlg
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return errMalformedToken
+	}
+	if !hmac.Equal(sig, jwtSignature(secret, parts[0]+"."+parts[1])) {
+		return errInvalidSig
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return errMalformedToken
+	}
+	var claims jwtClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return errMalformedToken
+	}
+	if claims.IssuedAt == nil {
+		return errMissingIat
+	}
+	issued := time.Unix(*claims.IssuedAt, 0)
+	if issued.Before(now.Add(-skew)) {
+		return errStaleToken
+	}
+	if issued.After(now.Add(skew)) {
+		return errFutureToken
+	}
+	return nil
+}
+
+// jwtHandler is an HTTP handler rejecting the requests which do not carry a
+// valid bearer token, before passing them to the wrapped handler.
+type jwtHandler struct {
+	secret []byte
+	skew   time.Duration
+	next   http.Handler
+}
+
+// NewJWTHandler wraps an HTTP handler into one only serving the requests which
+// are authenticated by an HS256 JSON Web Token in the Authorization header. As
+// websocket handshakes are HTTP requests, it also protects websocket handlers.
+func NewJWTHandler(config *JWTConfig, next http.Handler) http.Handler {
+	skew := config.ClockSkew
+	if skew == 0 {
+		skew = DefaultJWTClockSkew
+	}
+	return &jwtHandler{secret: config.Secret, skew: skew, next: next}
+}
+
+// ServeHTTP implements http.Handler, rejecting unauthenticated requests.
+func (h *jwtHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" || token == r.Header.Get("Authorization") {
+		http.Error(w, errMissingToken.Error(), http.StatusUnauthorized)
+		return
+	}
+	if err := verifyJWTToken(h.secret, token, time.Now(), h.skew); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	h.next.ServeHTTP(w, r)
+}
+
+// HTTPAuth is a function adding authentication to the headers of the requests
+// a client sends over HTTP, or of its websocket handshakes.
+type HTTPAuth func(h http.Header) error
+
+// NewJWTAuth creates an HTTPAuth attaching a freshly issued HS256 JSON Web Token
+// signed with the given secret to every request.
+func NewJWTAuth(secret []byte) HTTPAuth {
+	if len(secret) == 0 {
+		return func(http.Header) error { return errEmptySecret }
+	}
+	return func(h http.Header) error {
+		h.Set("Authorization", "Bearer "+NewJWTToken(secret, time.Now()))
+		return nil
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJWTVerification(t *testing.T) {
+	var (
+		secret = []byte("0123456789abcdef0123456789abcdef")
+		now    = time.Unix(1600000000, 0)
+		skew   = 5 * time.Second
+	)
+	none := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(`{"iat":1600000000}`)) + "."
+
+	tests := []struct {
+		token string
+		err   error
+	}{
+		{NewJWTToken(secret, now), nil},
+		{NewJWTToken(secret, now.Add(-skew)), nil},
+		{NewJWTToken(secret, now.Add(skew)), nil},
+		{NewJWTToken(secret, now.Add(-skew-time.Second)), errStaleToken},
+		{NewJWTToken(secret, now.Add(skew+time.Second)), errFutureToken},
+		{NewJWTToken([]byte("other secret"), now), errInvalidSig},
+		{none, errInvalidAlg},
+		{"garbage", errMalformedToken},
+	}
+	for i, tt := range tests {
+		if err := verifyJWTToken(secret, tt.token, now, skew); err != tt.err {
+			t.Errorf("test %d: verification error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
+
+func TestJWTHandler(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+
+	server := newTestServer()
+	defer server.Stop()
+	httpsrv := httptest.NewServer(NewJWTHandler(&JWTConfig{Secret: secret}, server))
+	defer httpsrv.Close()
+
+	// Unauthenticated requests are rejected before reaching the server
+	body := `{"jsonrpc":"2.0","id":1,"method":"rpc_modules"}`
+	for _, auth := range []string{"", "Basic Zm9vOmJhcg==", "Bearer " + NewJWTToken(secret, time.Now().Add(-time.Hour))} {
+		req, _ := http.NewRequest(http.MethodPost, httpsrv.URL, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("authorization %q: status mismatch: have %d, want %d", auth, resp.StatusCode, http.StatusUnauthorized)
+		}
+	}
+}
+
+func TestAuthEndpoint(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	apis := []API{{Namespace: "test", Service: new(testService), Public: true}}
+
+	listener, server, err := StartAuthEndpoint("127.0.0.1:0", apis, nil, nil, DefaultHTTPTimeouts, &JWTConfig{Secret: secret})
+	if err != nil {
+		t.Fatalf("failed to start endpoint: %v", err)
+	}
+	defer server.Stop()
+	defer listener.Close()
+
+	// Clients configured with the secret authenticate automatically over both
+	// HTTP and websocket, others are rejected
+	ctx := context.Background()
+	for _, url := range []string{"http://" + listener.Addr().String(), "ws://" + listener.Addr().String()} {
+		var result string
+		if client, err := DialOptions(ctx, url); err == nil {
+			if err := client.Call(&result, "test_rets"); err == nil {
+				t.Errorf("%s: unauthenticated call accepted", url)
+			}
+			client.Close()
+		}
+		client, err := DialOptions(ctx, url, WithHTTPAuth(NewJWTAuth(secret)))
+		if err != nil {
+			t.Fatalf("%s: failed to dial: %v", url, err)
+		}
+		if err := client.Call(&result, "test_rets"); err != nil {
+			t.Errorf("%s: authenticated call failed: %v", url, err)
+		}
+		client.Close()
+	}
+	if _, _, err := StartAuthEndpoint("127.0.0.1:0", apis, nil, nil, DefaultHTTPTimeouts, &JWTConfig{}); err != errEmptySecret {
+		t.Errorf("empty secret error mismatch: have %v, want %v", err, errEmptySecret)
+	}
+}
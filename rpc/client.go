@@ -167,15 +167,26 @@ func Dial(rawurl string) (*Client, error) {
 // The context is used to cancel or time out the initial connection establishment. It does
 // not affect subsequent interactions with the client.
 func DialContext(ctx context.Context, rawurl string) (*Client, error) {
+	return DialOptions(ctx, rawurl)
+}
+
+// DialOptions creates a new RPC client for the given URL, just like DialContext,
+// configured with the given options. HTTP related options only apply to clients
+// connecting over HTTP or websocket.
+func DialOptions(ctx context.Context, rawurl string, options ...ClientOption) (*Client, error) {
 	u, err := url.Parse(rawurl)
 	if err != nil {
 		return nil, err
 	}
+	cfg := new(clientConfig)
+	for _, opt := range options {
+		opt.applyOption(cfg)
+	}
 	switch u.Scheme {
 	case "http", "https":
-		return DialHTTP(rawurl)
+		return newClientHTTP(rawurl, cfg)
 	case "ws", "wss":
-		return DialWebsocket(ctx, rawurl, "")
+		return dialWebsocket(ctx, rawurl, "", defaultWebsocketDialer(), cfg)
 	case "stdio":
 		return DialStdIO(ctx)
 	case "":
@@ -0,0 +1,67 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"net/http"
+)
+
+// ClientOption is a configuration option for the RPC client.
+type ClientOption interface {
+	applyOption(*clientConfig)
+}
+
+// clientConfig holds the transport settings of a client created by DialOptions.
+type clientConfig struct {
+	httpClient  *http.Client
+	httpHeaders http.Header
+	httpAuth    HTTPAuth
+}
+
+// optionFunc is a ClientOption backed by a function.
+type optionFunc func(*clientConfig)
+
+func (fn optionFunc) applyOption(cfg *clientConfig) {
+	fn(cfg)
+}
+
+// WithHTTPClient configures the HTTP client used by clients connecting over HTTP.
+func WithHTTPClient(c *http.Client) ClientOption {
+	return optionFunc(func(cfg *clientConfig) {
+		cfg.httpClient = c
+	})
+}
+
+// WithHeader configures an HTTP header sent along with all the requests of
+// clients connecting over HTTP, and with the handshake of websocket clients.
+func WithHeader(key, value string) ClientOption {
+	return optionFunc(func(cfg *clientConfig) {
+		if cfg.httpHeaders == nil {
+			cfg.httpHeaders = make(http.Header)
+		}
+		cfg.httpHeaders.Set(key, value)
+	})
+}
+
+// WithHTTPAuth configures the authentication added to all the requests of clients
+// connecting over HTTP, and to the handshake of websocket clients. Use NewJWTAuth
+// to authenticate to endpoints requiring JSON Web Tokens.
+func WithHTTPAuth(a HTTPAuth) ClientOption {
+	return optionFunc(func(cfg *clientConfig) {
+		cfg.httpAuth = a
+	})
+}
@@ -49,6 +49,27 @@ func TestClientRequest(t *testing.T) {
 	}
 }
 
+func TestClientResponseType(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	client := DialInProc(server)
+	defer client.Close()
+
+	err := client.Call(nil, "test_returnError")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if code := err.(Error).ErrorCode(); code != 444 {
+		t.Errorf("wrong error code %d, want 444", code)
+	}
+	if data := err.(DataError).ErrorData(); data != "testError data" {
+		t.Errorf("wrong error data %#v, want %q", data, "testError data")
+	}
+	if msg := err.Error(); msg != "testError" {
+		t.Errorf("wrong error message %q, want %q", msg, "testError")
+	}
+}
+
 func TestClientBatchRequest(t *testing.T) {
 	server := newTestServer()
 	defer server.Stop()
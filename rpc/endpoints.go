@@ -18,12 +18,18 @@ package rpc
 
 import (
 	"net"
+	"net/http"
 
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/gorilla/websocket"
 )
 
-// StartHTTPEndpoint starts the HTTP RPC endpoint, configured with cors/vhosts/modules
-func StartHTTPEndpoint(endpoint string, apis []API, modules []string, cors []string, vhosts []string, timeouts HTTPTimeouts) (net.Listener, *Server, error) {
+// StartHTTPEndpoint starts the HTTP RPC endpoint, configured with cors/vhosts/modules,
+// optional access rules and limits and optional JWT authentication.
+func StartHTTPEndpoint(endpoint string, apis []API, modules []string, cors []string, vhosts []string, timeouts HTTPTimeouts, access *AccessConfig, jwt *JWTConfig) (net.Listener, *Server, error) {
+	if jwt != nil && len(jwt.Secret) == 0 {
+		return nil, nil, errEmptySecret
+	}
 	// Generate the whitelist based on the allowed modules
 	whitelist := make(map[string]bool)
 	for _, module := range modules {
@@ -39,6 +45,11 @@ func StartHTTPEndpoint(endpoint string, apis []API, modules []string, cors []str
 			log.Debug("HTTP registered", "namespace", api.Namespace)
 		}
 	}
+	if access != nil {
+		if err := handler.SetAccessConfig(access); err != nil {
+			return nil, nil, err
+		}
+	}
 	// All APIs registered, start the HTTP listener
 	var (
 		listener net.Listener
@@ -47,13 +58,20 @@ func StartHTTPEndpoint(endpoint string, apis []API, modules []string, cors []str
 	if listener, err = net.Listen("tcp", endpoint); err != nil {
 		return nil, nil, err
 	}
-	go NewHTTPServer(cors, vhosts, timeouts, handler).Serve(listener)
+	var srv http.Handler = handler
+	if jwt != nil {
+		srv = NewJWTHandler(jwt, handler)
+	}
+	go NewHTTPServer(cors, vhosts, timeouts, srv).Serve(listener)
 	return listener, handler, err
 }
 
-// StartWSEndpoint starts a websocket endpoint
-func StartWSEndpoint(endpoint string, apis []API, modules []string, wsOrigins []string, exposeAll bool) (net.Listener, *Server, error) {
-
+// StartWSEndpoint starts a websocket endpoint, with optional access rules and limits
+// and optional JWT authentication.
+func StartWSEndpoint(endpoint string, apis []API, modules []string, wsOrigins []string, exposeAll bool, access *AccessConfig, jwt *JWTConfig) (net.Listener, *Server, error) {
+	if jwt != nil && len(jwt.Secret) == 0 {
+		return nil, nil, errEmptySecret
+	}
 	// Generate the whitelist based on the allowed modules
 	whitelist := make(map[string]bool)
 	for _, module := range modules {
@@ -69,6 +87,11 @@ func StartWSEndpoint(endpoint string, apis []API, modules []string, wsOrigins []
 			log.Debug("WebSocket registered", "service", api.Service, "namespace", api.Namespace)
 		}
 	}
+	if access != nil {
+		if err := handler.SetAccessConfig(access); err != nil {
+			return nil, nil, err
+		}
+	}
 	// All APIs registered, start the HTTP listener
 	var (
 		listener net.Listener
@@ -77,11 +100,64 @@ func StartWSEndpoint(endpoint string, apis []API, modules []string, wsOrigins []
 	if listener, err = net.Listen("tcp", endpoint); err != nil {
 		return nil, nil, err
 	}
-	go NewWSServer(wsOrigins, handler).Serve(listener)
+	srv := NewWSServer(wsOrigins, handler)
+	if jwt != nil {
+		srv.Handler = NewJWTHandler(jwt, srv.Handler)
+	}
+	go srv.Serve(listener)
 	return listener, handler, err
 
 }
 
+// StartAuthEndpoint starts an endpoint serving both HTTP and websocket clients,
+// configured with vhosts/modules, which requires all of them to authenticate
+// with JSON Web Tokens.
+func StartAuthEndpoint(endpoint string, apis []API, modules []string, vhosts []string, timeouts HTTPTimeouts, jwt *JWTConfig) (net.Listener, *Server, error) {
+	if len(jwt.Secret) == 0 {
+		return nil, nil, errEmptySecret
+	}
+	// Generate the whitelist based on the allowed modules
+	whitelist := make(map[string]bool)
+	for _, module := range modules {
+		whitelist[module] = true
+	}
+	// Register all the APIs exposed by the services
+	handler := NewServer()
+	for _, api := range apis {
+		if whitelist[api.Namespace] || (len(whitelist) == 0 && api.Public) {
+			if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
+				return nil, nil, err
+			}
+			log.Debug("Authenticated RPC registered", "namespace", api.Namespace)
+		}
+	}
+	// All APIs registered, start the HTTP listener
+	var (
+		listener net.Listener
+		err      error
+	)
+	if listener, err = net.Listen("tcp", endpoint); err != nil {
+		return nil, nil, err
+	}
+	// Serve websocket handshakes without the HTTP response compression, as their
+	// connections are hijacked. Authenticated clients are trusted, so websocket
+	// origins are not checked.
+	server := NewHTTPServer(nil, vhosts, timeouts, handler)
+	var (
+		httpHandler = server.Handler
+		wsHandler   = NewVHostHandler(vhosts, handler.WebsocketHandler([]string{"*"}))
+	)
+	server.Handler = NewJWTHandler(jwt, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if websocket.IsWebSocketUpgrade(r) {
+			wsHandler.ServeHTTP(w, r)
+			return
+		}
+		httpHandler.ServeHTTP(w, r)
+	}))
+	go server.Serve(listener)
+	return listener, handler, err
+}
+
 // StartIPCEndpoint starts an IPC endpoint.
 func StartIPCEndpoint(ipcEndpoint string, apis []API) (net.Listener, *Server, error) {
 	// Register all the APIs exposed by the services.
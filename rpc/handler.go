@@ -61,6 +61,9 @@ type handler struct {
 	conn           jsonWriter                     // where responses will be sent
 	log            log.Logger
 	allowSubscribe bool
+	access         *accessControl // access rules and limits of the client, if any
+	client         string         // identity of the client for rate limiting
+	recorder       *Recorder      // recorder of the served calls, if any
 
 	subLock    sync.Mutex
 	serverSubs map[ID]*Subscription
@@ -88,6 +91,9 @@ func newHandler(connCtx context.Context, conn jsonWriter, idgen func() ID, reg *
 	if conn.remoteAddr() != "" {
 		h.log = h.log.New("conn", conn.remoteAddr())
 	}
+	if codec, ok := conn.(*accessCodec); ok {
+		h.access, h.client, h.recorder = codec.access, codec.client, codec.recorder
+	}
 	h.unsubscribeCb = newCallback(reflect.Value{}, reflect.ValueOf(h.unsubscribe))
 	return h
 }
@@ -101,6 +107,14 @@ func (h *handler) handleBatch(msgs []*jsonrpcMessage) {
 		})
 		return
 	}
+	if h.access != nil {
+		if err := h.access.checkBatch(len(msgs)); err != nil {
+			h.startCallProc(func(cp *callProc) {
+				h.conn.writeJSON(cp.ctx, errorMessage(err))
+			})
+			return
+		}
+	}
 
 	// Handle non-call messages first:
 	calls := make([]*jsonrpcMessage, 0, len(msgs))
@@ -115,10 +129,20 @@ func (h *handler) handleBatch(msgs []*jsonrpcMessage) {
 	// Process calls on a goroutine because they may block indefinitely:
 	h.startCallProc(func(cp *callProc) {
 		answers := make([]*jsonrpcMessage, 0, len(msgs))
+		size := 0
 		for _, msg := range calls {
-			if answer := h.handleCallMsg(cp, msg); answer != nil {
-				answers = append(answers, answer)
+			answer := h.handleCallMsg(cp, msg)
+			if answer == nil {
+				continue
 			}
+			// Replace answers beyond the response size limit with errors
+			if h.access != nil {
+				size += len(answer.Result)
+				if err := h.access.checkResponse(size); err != nil {
+					answer = msg.errorResponse(err)
+				}
+			}
+			answers = append(answers, answer)
 		}
 		h.addSubscriptions(cp.notifiers)
 		if len(answers) > 0 {
@@ -296,6 +320,9 @@ func (h *handler) handleCallMsg(ctx *callProc, msg *jsonrpcMessage) *jsonrpcMess
 		return nil
 	case msg.isCall():
 		resp := h.handleCall(ctx, msg)
+		if h.recorder != nil {
+			h.recorder.record(msg, resp, start, time.Since(start))
+		}
 		if resp.Error != nil {
 			h.log.Warn("Served "+msg.Method, "reqid", idForLog{msg.ID}, "t", time.Since(start), "err", resp.Error.Message)
 		} else {
@@ -311,6 +338,11 @@ func (h *handler) handleCallMsg(ctx *callProc, msg *jsonrpcMessage) *jsonrpcMess
 
 // handleCall processes method calls.
 func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage {
+	if h.access != nil && !msg.isUnsubscribe() {
+		if err := h.access.check(h.client, msg.Method); err != nil {
+			return msg.errorResponse(err)
+		}
+	}
 	if msg.isSubscribe() {
 		return h.handleSubscribe(cp, msg)
 	}
@@ -370,7 +402,13 @@ func (h *handler) runMethod(ctx context.Context, msg *jsonrpcMessage, callb *cal
 	if err != nil {
 		return msg.errorResponse(err)
 	}
-	return msg.response(result)
+	resp := msg.response(result)
+	if h.access != nil && resp.Error == nil {
+		if err := h.access.checkResponse(len(resp.Result)); err != nil {
+			return msg.errorResponse(err)
+		}
+	}
+	return resp
 }
 
 // unsubscribe is the callback function for all *_unsubscribe calls.
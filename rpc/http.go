@@ -46,6 +46,7 @@ var acceptedContentTypes = []string{contentType, "application/json-rpc", "applic
 type httpConn struct {
 	client    *http.Client
 	req       *http.Request
+	auth      HTTPAuth
 	closeOnce sync.Once
 	closeCh   chan interface{}
 }
@@ -107,24 +108,37 @@ var DefaultHTTPTimeouts = HTTPTimeouts{
 // DialHTTPWithClient creates a new RPC client that connects to an RPC server over HTTP
 // using the provided HTTP Client.
 func DialHTTPWithClient(endpoint string, client *http.Client) (*Client, error) {
+	return newClientHTTP(endpoint, &clientConfig{httpClient: client})
+}
+
+// DialHTTP creates a new RPC client that connects to an RPC server over HTTP.
+func DialHTTP(endpoint string) (*Client, error) {
+	return DialHTTPWithClient(endpoint, new(http.Client))
+}
+
+// newClientHTTP creates a new RPC client that connects to an RPC server over HTTP
+// with the given transport settings.
+func newClientHTTP(endpoint string, cfg *clientConfig) (*Client, error) {
 	req, err := http.NewRequest(http.MethodPost, endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
+	for key, values := range cfg.httpHeaders {
+		req.Header[key] = values
+	}
 	req.Header.Set("Content-Type", contentType)
 	req.Header.Set("Accept", contentType)
 
+	client := cfg.httpClient
+	if client == nil {
+		client = new(http.Client)
+	}
 	initctx := context.Background()
 	return newClient(initctx, func(context.Context) (ServerCodec, error) {
-		return &httpConn{client: client, req: req, closeCh: make(chan interface{})}, nil
+		return &httpConn{client: client, req: req, auth: cfg.httpAuth, closeCh: make(chan interface{})}, nil
 	})
 }
 
-// DialHTTP creates a new RPC client that connects to an RPC server over HTTP.
-func DialHTTP(endpoint string) (*Client, error) {
-	return DialHTTPWithClient(endpoint, new(http.Client))
-}
-
 func (c *Client) sendHTTP(ctx context.Context, op *requestOp, msg interface{}) error {
 	hc := c.writeConn.(*httpConn)
 	respBody, err := hc.doRequest(ctx, msg)
@@ -175,6 +189,14 @@ func (hc *httpConn) doRequest(ctx context.Context, msg interface{}) (io.ReadClos
 	req.Body = ioutil.NopCloser(bytes.NewReader(body))
 	req.ContentLength = int64(len(body))
 
+	// Authenticate every request separately, as credentials like tokens may expire
+	if hc.auth != nil {
+		req.Header = hc.req.Header.Clone()
+		if err := hc.auth(req.Header); err != nil {
+			return nil, err
+		}
+	}
+
 	resp, err := hc.client.Do(req)
 	if err != nil {
 		return nil, err
@@ -215,7 +237,7 @@ func (t *httpServerConn) SetWriteDeadline(time.Time) error { return nil }
 func NewHTTPServer(cors []string, vhosts []string, timeouts HTTPTimeouts, srv http.Handler) *http.Server {
 	// Wrap the CORS-handler within a host-handler
 	handler := newCorsHandler(srv, cors)
-	handler = newVHostHandler(vhosts, handler)
+	handler = NewVHostHandler(vhosts, handler)
 	handler = newGzipHandler(handler)
 
 	// Make sure timeout values are meaningful
@@ -265,7 +287,7 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("content-type", contentType)
-	codec := newHTTPServerConn(r, w)
+	codec := s.accessCodec(newHTTPServerConn(r, w), r)
 	defer codec.close()
 	s.serveSingleRequest(ctx, codec)
 }
@@ -350,7 +372,9 @@ func (h *virtualHostHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	http.Error(w, "invalid host specified", http.StatusForbidden)
 }
 
-func newVHostHandler(vhosts []string, next http.Handler) http.Handler {
+// NewVHostHandler wraps next in a handler rejecting the requests whose Host
+// header is neither an IP address nor one of the given virtual hostnames.
+func NewVHostHandler(vhosts []string, next http.Handler) http.Handler {
 	vhostMap := make(map[string]struct{})
 	for _, allowedHost := range vhosts {
 		vhostMap[strings.ToLower(allowedHost)] = struct{}{}
@@ -0,0 +1,216 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// defaultRecordFiles is the number of rotated record files kept if unspecified.
+const defaultRecordFiles = 5
+
+// sensitiveMethods are the method patterns of the calls which carry passphrases,
+// keys or payloads to sign, or administer the node. They are not recorded unless
+// explicitly selected.
+var sensitiveMethods = []string{"personal_*", "admin_*", "account_*", "eth_sign*"}
+
+// RecorderConfig holds the settings of an RPC call recorder.
+type RecorderConfig struct {
+	// Path is the file the calls are recorded into, one JSON record per line.
+	// Recording is disabled if empty.
+	Path string `toml:",omitempty"`
+
+	// Methods lists the method patterns of the calls recorded, all calls but the
+	// personal, admin, signer and eth_sign* ones being recorded if empty. Patterns
+	// are exact method names, prefixes ending in '*' like "eth_*", or "*".
+	Methods []string `toml:",omitempty"`
+
+	// SampleRate is the fraction of the matching calls which are recorded, all of
+	// them if zero.
+	SampleRate float64 `toml:",omitempty"`
+
+	// MaxFileSize is the size in bytes beyond which the record file is rotated,
+	// no rotation taking place if zero. MaxFiles is the number of rotated files
+	// kept, the oldest ones being deleted.
+	MaxFileSize int64 `toml:",omitempty"`
+	MaxFiles    int   `toml:",omitempty"`
+}
+
+// Record is a single recorded RPC call along with its response.
+type Record struct {
+	Time    time.Time       `json:"time"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RecordError    `json:"error,omitempty"`
+	Latency time.Duration   `json:"latency"` // Time taken to serve the call, in nanoseconds
+}
+
+// RecordError is the error response of a recorded call.
+type RecordError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// Recorder writes the calls served by RPC servers into a rotating record file.
+// It is safe for concurrent use and may be shared by multiple servers.
+type Recorder struct {
+	config RecorderConfig
+
+	lock   sync.Mutex
+	file   *os.File // Record file currently written, nil if closed or failed to rotate
+	size   int64    // Number of bytes written into the current file
+	closed bool     // Whether the recorder was closed
+	rand   *rand.Rand
+}
+
+// NewRecorder creates a recorder writing into the configured record file,
+// appending to it if it already exists.
+func NewRecorder(config RecorderConfig) (*Recorder, error) {
+	if config.Path == "" {
+		return nil, errors.New("no record file configured")
+	}
+	if config.SampleRate < 0 || config.SampleRate > 1 {
+		return nil, fmt.Errorf("invalid sample rate %v, want between 0 and 1", config.SampleRate)
+	}
+	if config.MaxFiles == 0 {
+		config.MaxFiles = defaultRecordFiles
+	}
+	r := &Recorder{
+		config: config,
+		rand:   rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// open opens the record file for appending.
+func (r *Recorder) open() error {
+	file, err := os.OpenFile(r.config.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	r.file, r.size = file, stat.Size()
+	return nil
+}
+
+// rotate shifts the current record file and the previously rotated ones one
+// position back, deleting the oldest one, and opens a fresh record file.
+func (r *Recorder) rotate() error {
+	r.file.Close()
+	r.file = nil
+
+	os.Remove(fmt.Sprintf("%s.%d", r.config.Path, r.config.MaxFiles))
+	for i := r.config.MaxFiles - 1; i > 0; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", r.config.Path, i), fmt.Sprintf("%s.%d", r.config.Path, i+1))
+	}
+	if err := os.Rename(r.config.Path, r.config.Path+".1"); err != nil {
+		return err
+	}
+	return r.open()
+}
+
+// record writes a served call and its response into the record file, if the
+// call is selected by the recorder's method filter and sampling.
+func (r *Recorder) record(msg, resp *jsonrpcMessage, start time.Time, latency time.Duration) {
+	if len(r.config.Methods) > 0 {
+		if !matchAny(r.config.Methods, msg.Method) {
+			return
+		}
+	} else if matchAny(sensitiveMethods, msg.Method) {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.closed {
+		return
+	}
+	if r.config.SampleRate > 0 && r.rand.Float64() >= r.config.SampleRate {
+		return
+	}
+	rec := &Record{
+		Time:    start,
+		Method:  msg.Method,
+		Params:  msg.Params,
+		Result:  resp.Result,
+		Latency: latency,
+	}
+	if resp.Error != nil {
+		rec.Error = &RecordError{Code: resp.Error.Code, Message: resp.Error.Message, Data: resp.Error.Data}
+	}
+	blob, err := json.Marshal(rec)
+	if err != nil {
+		log.Warn("Failed to encode RPC record", "method", msg.Method, "err", err)
+		return
+	}
+	// Reopen the record file if the last rotation failed, retrying it if needed
+	if r.file == nil {
+		if err := r.open(); err != nil {
+			log.Error("Failed to reopen RPC record file", "path", r.config.Path, "err", err)
+			return
+		}
+	}
+	if r.config.MaxFileSize > 0 && r.size > 0 && r.size+int64(len(blob))+1 > r.config.MaxFileSize {
+		if err := r.rotate(); err != nil {
+			log.Error("Failed to rotate RPC record file", "path", r.config.Path, "err", err)
+			return
+		}
+	}
+	n, err := r.file.Write(append(blob, '\n'))
+	r.size += int64(n)
+	if err != nil {
+		log.Warn("Failed to write RPC record", "path", r.config.Path, "err", err)
+	}
+}
+
+// Close flushes and closes the record file. Calls served afterwards are not
+// recorded anymore.
+func (r *Recorder) Close() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.closed || r.file == nil {
+		r.closed = true
+		return nil
+	}
+	err := r.file.Close()
+	r.file, r.closed = nil, true
+	return err
+}
+
+// SetRecorder configures the recorder of the calls served on the connections
+// established after the call, disabling recording if nil.
+func (s *Server) SetRecorder(r *Recorder) {
+	s.recorder.Store(r)
+}
@@ -0,0 +1,247 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// readRecords decodes all the records of a record file.
+func readRecords(t *testing.T, path string) []*Record {
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open record file: %v", err)
+	}
+	defer file.Close()
+
+	var records []*Record
+	for scanner := bufio.NewScanner(file); scanner.Scan(); {
+		rec := new(Record)
+		if err := json.Unmarshal(scanner.Bytes(), rec); err != nil {
+			t.Fatalf("failed to decode record: %v", err)
+		}
+		records = append(records, rec)
+	}
+	return records
+}
+
+func TestRecorder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rpc-recorder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "calls.jsonl")
+	recorder, err := NewRecorder(RecorderConfig{Path: path, Methods: []string{"test_*"}})
+	if err != nil {
+		t.Fatalf("failed to create recorder: %v", err)
+	}
+	server := newTestServer()
+	server.SetRecorder(recorder)
+	defer server.Stop()
+
+	// Calls over all transports are recorded, unless filtered out
+	httpsrv := httptest.NewServer(server)
+	defer httpsrv.Close()
+
+	for _, client := range []*Client{DialInProc(server), mustDialHTTP(t, httpsrv.URL)} {
+		var result echoResult
+		if err := client.Call(&result, "test_echo", "x", 1, nil); err != nil {
+			t.Fatalf("call failed: %v", err)
+		}
+		if err := client.Call(nil, "test_returnError"); err == nil {
+			t.Fatalf("erroring call succeeded")
+		}
+		if err := client.Call(nil, "rpc_modules"); err != nil {
+			t.Fatalf("call failed: %v", err)
+		}
+		client.Close()
+	}
+	recorder.Close()
+
+	records := readRecords(t, path)
+	if len(records) != 4 {
+		t.Fatalf("record count mismatch: have %d, want 4", len(records))
+	}
+	for i := 0; i < len(records); i += 2 {
+		echo, fail := records[i], records[i+1]
+		if echo.Method != "test_echo" || string(echo.Params) != `["x",1,null]` || echo.Error != nil {
+			t.Errorf("record %d: echo mismatch: %+v", i, echo)
+		}
+		var result echoResult
+		if err := json.Unmarshal(echo.Result, &result); err != nil || result.String != "x" || result.Int != 1 {
+			t.Errorf("record %d: echo result mismatch: %s", i, echo.Result)
+		}
+		if fail.Method != "test_returnError" || fail.Error == nil || fail.Error.Code != (testError{}).ErrorCode() {
+			t.Errorf("record %d: error mismatch: %+v", i+1, fail)
+		}
+	}
+}
+
+func TestRecorderRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rpc-recorder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "calls.jsonl")
+	recorder, err := NewRecorder(RecorderConfig{Path: path, MaxFileSize: 1, MaxFiles: 2})
+	if err != nil {
+		t.Fatalf("failed to create recorder: %v", err)
+	}
+	server := newTestServer()
+	server.SetRecorder(recorder)
+	defer server.Stop()
+
+	client := DialInProc(server)
+	defer client.Close()
+
+	// Every record exceeds the file size, so each gets a file of its own
+	for i := 0; i < 4; i++ {
+		var result echoResult
+		if err := client.Call(&result, "test_echo", "x", i, nil); err != nil {
+			t.Fatalf("call failed: %v", err)
+		}
+	}
+	recorder.Close()
+
+	for i, name := range []string{path, path + ".1", path + ".2"} {
+		records := readRecords(t, name)
+		if len(records) != 1 {
+			t.Fatalf("%s: record count mismatch: have %d, want 1", name, len(records))
+		}
+		if want := `["x",` + string(rune('3'-i)) + `,null]`; string(records[0].Params) != want {
+			t.Errorf("%s: params mismatch: have %s, want %s", name, records[0].Params, want)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("rotated file beyond limit kept")
+	}
+}
+
+func TestRecorderSensitiveMethods(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rpc-recorder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	server := newTestServer()
+	defer server.Stop()
+	if err := server.RegisterName("personal", new(testService)); err != nil {
+		t.Fatal(err)
+	}
+	// Sensitive calls are only recorded if explicitly selected
+	for _, methods := range [][]string{nil, {"personal_*"}} {
+		path := filepath.Join(dir, fmt.Sprintf("calls-%d.jsonl", len(methods)))
+		recorder, err := NewRecorder(RecorderConfig{Path: path, Methods: methods})
+		if err != nil {
+			t.Fatalf("failed to create recorder: %v", err)
+		}
+		server.SetRecorder(recorder)
+
+		client := DialInProc(server)
+		for _, method := range []string{"test_echo", "personal_echo"} {
+			var result echoResult
+			if err := client.Call(&result, method, "x", 1, nil); err != nil {
+				t.Fatalf("call failed: %v", err)
+			}
+		}
+		client.Close()
+		recorder.Close()
+
+		records := readRecords(t, path)
+		if len(records) != 1 {
+			t.Fatalf("methods %v: record count mismatch: have %d, want 1", methods, len(records))
+		}
+		want := "test_echo"
+		if len(methods) > 0 {
+			want = "personal_echo"
+		}
+		if records[0].Method != want {
+			t.Errorf("methods %v: recorded method mismatch: have %s, want %s", methods, records[0].Method, want)
+		}
+	}
+}
+
+func TestRecorderRotationFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rpc-recorder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "calls.jsonl")
+	recorder, err := NewRecorder(RecorderConfig{Path: path, MaxFileSize: 1, MaxFiles: 1})
+	if err != nil {
+		t.Fatalf("failed to create recorder: %v", err)
+	}
+	server := newTestServer()
+	server.SetRecorder(recorder)
+	defer server.Stop()
+
+	client := DialInProc(server)
+	defer client.Close()
+
+	call := func(i int) {
+		var result echoResult
+		if err := client.Call(&result, "test_echo", "x", i, nil); err != nil {
+			t.Fatalf("call failed: %v", err)
+		}
+	}
+	// Block the rotation with a non-empty directory in place of the rotated file
+	blocker := filepath.Join(path+".1", "blocker")
+	if err := os.MkdirAll(blocker, 0700); err != nil {
+		t.Fatal(err)
+	}
+	call(0)
+	call(1) // dropped, rotation fails
+
+	// Recording resumes once the rotation succeeds again
+	if err := os.RemoveAll(path + ".1"); err != nil {
+		t.Fatal(err)
+	}
+	call(2)
+	recorder.Close()
+
+	for i, name := range []string{path, path + ".1"} {
+		records := readRecords(t, name)
+		if len(records) != 1 {
+			t.Fatalf("%s: record count mismatch: have %d, want 1", name, len(records))
+		}
+		if want := fmt.Sprintf(`["x",%d,null]`, 2-2*i); string(records[0].Params) != want {
+			t.Errorf("%s: params mismatch: have %s, want %s", name, records[0].Params, want)
+		}
+	}
+}
+
+func mustDialHTTP(t *testing.T, url string) *Client {
+	client, err := DialHTTP(url)
+	if err != nil {
+		t.Fatalf("failed to dial %s: %v", url, err)
+	}
+	return client
+}
@@ -46,6 +46,8 @@ type Server struct {
 	idgen    func() ID
 	run      int32
 	codecs   mapset.Set
+	access   atomic.Value // *accessControl applied to HTTP and websocket clients
+	recorder atomic.Value // *Recorder of the served calls
 }
 
 // NewServer creates a new server instance with no registered handlers.
@@ -72,6 +74,11 @@ func (s *Server) RegisterName(name string, receiver interface{}) error {
 //
 // Note that codec options are no longer supported.
 func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
+	if _, ok := codec.(*accessCodec); !ok {
+		if rec, _ := s.recorder.Load().(*Recorder); rec != nil {
+			codec = &accessCodec{ServerCodec: codec, recorder: rec}
+		}
+	}
 	defer codec.close()
 
 	// Don't serve if server is stopped.
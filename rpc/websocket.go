@@ -62,7 +62,7 @@ func (s *Server) WebsocketHandler(allowedOrigins []string) http.Handler {
 			log.Debug("WebSocket upgrade failed", "err", err)
 			return
 		}
-		codec := newWebsocketCodec(conn)
+		codec := s.accessCodec(newWebsocketCodec(conn), r)
 		s.ServeCodec(codec, 0)
 	})
 }
@@ -127,12 +127,48 @@ func (e wsHandshakeError) Error() string {
 // DialWebsocketWithDialer creates a new RPC client that communicates with a JSON-RPC server
 // that is listening on the given endpoint using the provided dialer.
 func DialWebsocketWithDialer(ctx context.Context, endpoint, origin string, dialer websocket.Dialer) (*Client, error) {
+	return dialWebsocket(ctx, endpoint, origin, dialer, new(clientConfig))
+}
+
+// DialWebsocket creates a new RPC client that communicates with a JSON-RPC server
+// that is listening on the given endpoint.
+//
+// The context is used for the initial connection establishment. It does not
+// affect subsequent interactions with the client.
+func DialWebsocket(ctx context.Context, endpoint, origin string) (*Client, error) {
+	return DialWebsocketWithDialer(ctx, endpoint, origin, defaultWebsocketDialer())
+}
+
+// defaultWebsocketDialer returns the dialer used by websocket clients unless a
+// custom one is provided.
+func defaultWebsocketDialer() websocket.Dialer {
+	return websocket.Dialer{
+		ReadBufferSize:  wsReadBuffer,
+		WriteBufferSize: wsWriteBuffer,
+		WriteBufferPool: wsBufferPool,
+	}
+}
+
+// dialWebsocket creates a new RPC client that communicates with a JSON-RPC server
+// over websocket, with the given handshake settings.
+func dialWebsocket(ctx context.Context, endpoint, origin string, dialer websocket.Dialer, cfg *clientConfig) (*Client, error) {
 	endpoint, header, err := wsClientHeaders(endpoint, origin)
 	if err != nil {
 		return nil, err
 	}
+	for key, values := range cfg.httpHeaders {
+		header[key] = values
+	}
 	return newClient(ctx, func(ctx context.Context) (ServerCodec, error) {
-		conn, resp, err := dialer.DialContext(ctx, endpoint, header)
+		// Authenticate every handshake separately, as reconnects may need new credentials
+		hdr := header
+		if cfg.httpAuth != nil {
+			hdr = header.Clone()
+			if err := cfg.httpAuth(hdr); err != nil {
+				return nil, err
+			}
+		}
+		conn, resp, err := dialer.DialContext(ctx, endpoint, hdr)
 		if err != nil {
 			hErr := wsHandshakeError{err: err}
 			if resp != nil {
@@ -144,20 +180,6 @@ func DialWebsocketWithDialer(ctx context.Context, endpoint, origin string, diale
 	})
 }
 
-// DialWebsocket creates a new RPC client that communicates with a JSON-RPC server
-// that is listening on the given endpoint.
-//
-// The context is used for the initial connection establishment. It does not
-// affect subsequent interactions with the client.
-func DialWebsocket(ctx context.Context, endpoint, origin string) (*Client, error) {
-	dialer := websocket.Dialer{
-		ReadBufferSize:  wsReadBuffer,
-		WriteBufferSize: wsWriteBuffer,
-		WriteBufferPool: wsBufferPool,
-	}
-	return DialWebsocketWithDialer(ctx, endpoint, origin, dialer)
-}
-
 func wsClientHeaders(endpoint, origin string) (string, http.Header, error) {
 	endpointURL, err := url.Parse(endpoint)
 	if err != nil {
@@ -0,0 +1,203 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rangeproof
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+type kv struct {
+	k, v []byte
+}
+type entrySlice []*kv
+
+func (p entrySlice) Len() int           { return len(p) }
+func (p entrySlice) Less(i, j int) bool { return bytes.Compare(p[i].k, p[j].k) < 0 }
+func (p entrySlice) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+type fuzzer struct {
+	input     io.Reader
+	exhausted bool
+}
+
+func (f *fuzzer) randBytes(n int) []byte {
+	r := make([]byte, n)
+	if _, err := f.input.Read(r); err != nil {
+		f.exhausted = true
+	}
+	return r
+}
+
+func (f *fuzzer) readInt() uint64 {
+	var x uint64
+	if err := binary.Read(f.input, binary.LittleEndian, &x); err != nil {
+		f.exhausted = true
+	}
+	return x
+}
+
+// randomTrie builds a trie with fuzzer supplied keys and values, returning it
+// along with its entries sorted by key.
+func (f *fuzzer) randomTrie(n int) (*trie.Trie, entrySlice) {
+	tr, _ := trie.New(common.Hash{}, trie.NewDatabase(memorydb.New()))
+
+	vals := make(map[string]*kv)
+	size := f.readInt()
+	// Fill it with some fluff
+	for i := byte(0); i < byte(size); i++ {
+		value := &kv{common.LeftPadBytes([]byte{i}, 32), []byte{i}}
+		value2 := &kv{common.LeftPadBytes([]byte{i + 10}, 32), []byte{i}}
+		tr.Update(value.k, value.v)
+		tr.Update(value2.k, value2.v)
+		vals[string(value.k)] = value
+		vals[string(value2.k)] = value2
+	}
+	if f.exhausted {
+		return nil, nil
+	}
+	// And now fill with some random
+	for i := 0; i < n; i++ {
+		k := f.randBytes(32)
+		v := f.randBytes(20)
+		if f.exhausted {
+			return nil, nil
+		}
+		value := &kv{k, v}
+		tr.Update(k, v)
+		vals[string(k)] = value
+	}
+	var entries entrySlice
+	for _, kv := range vals {
+		entries = append(entries, kv)
+	}
+	sort.Sort(entries)
+	return tr, entries
+}
+
+func (f *fuzzer) fuzz() int {
+	maxSize := 200
+	tr, entries := f.randomTrie(1 + int(f.readInt())%maxSize)
+	if f.exhausted || len(entries) <= 1 {
+		return 0
+	}
+	var (
+		root = tr.Hash()
+		ok   = 0
+	)
+	for i := 0; i < 10; i++ {
+		start := int(f.readInt() % uint64(len(entries)))
+		end := 1 + start + int(f.readInt()%uint64(len(entries)-start))
+		testcase := f.readInt() % 6
+		index := int(f.readInt() % uint64(end-start))
+		tamper := f.randBytes(20)
+		if f.exhausted {
+			break
+		}
+		proof := memorydb.New()
+		if err := tr.ProveRange(entries[start].k, entries[end-1].k, proof); err != nil {
+			panic(fmt.Sprintf("failed to prove range: %v", err))
+		}
+		var keys, vals [][]byte
+		for _, entry := range entries[start:end] {
+			keys = append(keys, entry.k)
+			vals = append(vals, entry.v)
+		}
+		first := keys[0]
+
+		// A correct range must always verify
+		if err := trie.VerifyRangeProof(root, first, keys, vals, proof); err != nil {
+			panic(fmt.Sprintf("valid range proof rejected: %v", err))
+		}
+		// Tamper with the range in some way, which must be detected
+		switch testcase {
+		case 0:
+			// Modified value
+			if bytes.Equal(vals[index], tamper) {
+				continue
+			}
+			vals[index] = tamper
+		case 1:
+			// Gapped entry slice, skipping the edges which the proofs cover
+			if index == 0 || index == len(keys)-1 {
+				continue
+			}
+			keys = append(keys[:index], keys[index+1:]...)
+			vals = append(vals[:index], vals[index+1:]...)
+		case 2:
+			// Deleted value
+			vals[index] = nil
+		case 3:
+			// Out of order
+			if index == 0 {
+				continue
+			}
+			keys[index-1], keys[index] = keys[index], keys[index-1]
+			vals[index-1], vals[index] = vals[index], vals[index-1]
+		case 4:
+			// Garbage proof node replacing an existing one
+			it := proof.NewIterator()
+			for j := 0; j <= index && it.Next(); j++ {
+			}
+			key := common.CopyBytes(it.Key())
+			it.Release()
+			if key == nil {
+				continue
+			}
+			proof.Put(key, tamper)
+		case 5:
+			// Injected element in between existing ones
+			if index == 0 {
+				continue
+			}
+			key := common.CopyBytes(keys[index])
+			key[len(key)-1]--
+			if bytes.Compare(key, keys[index-1]) <= 0 {
+				continue
+			}
+			keys = append(keys[:index], append([][]byte{key}, keys[index:]...)...)
+			vals = append(vals[:index], append([][]byte{tamper}, vals[index:]...)...)
+		}
+		if err := trie.VerifyRangeProof(root, first, keys, vals, proof); err == nil {
+			panic(fmt.Sprintf("tampered range proof accepted (case %d, index %d, range %d->%d)", testcase, index, start, end-1))
+		}
+		ok = 1
+	}
+	return ok
+}
+
+// Fuzz builds a trie from the input and verifies both valid and tampered range
+// proofs over it. It returns 1 if at least one range was checked, prioritising
+// the input in the corpus, or 0 otherwise.
+func Fuzz(input []byte) int {
+	if len(input) < 100 {
+		return 0
+	}
+	r := bytes.NewReader(input)
+	f := fuzzer{
+		input:     r,
+		exhausted: false,
+	}
+	return f.fuzz()
+}
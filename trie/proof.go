@@ -18,10 +18,12 @@ package trie
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rlp"
 )
@@ -100,6 +102,17 @@ func (t *SecureTrie) Prove(key []byte, fromLevel uint, proofDb ethdb.KeyValueWri
 	return t.trie.Prove(key, fromLevel, proofDb)
 }
 
+// ProveRange constructs the edge proofs of the key range [firstKey, lastKey]
+// into proofDb, to be verified along with the leaves of the range by
+// VerifyRangeProof. The first key doesn't need to exist in the trie, in which
+// case its proof proves the absence of any key between it and the first leaf.
+func (t *Trie) ProveRange(firstKey []byte, lastKey []byte, proofDb ethdb.KeyValueWriter) error {
+	if err := t.Prove(firstKey, 0, proofDb); err != nil {
+		return err
+	}
+	return t.Prove(lastKey, 0, proofDb)
+}
+
 // VerifyProof checks merkle proofs. The given proof must contain the value for
 // key in a trie with the given root hash. VerifyProof returns an error if the
 // proof contains invalid trie nodes or the wrong value.
@@ -115,7 +128,7 @@ func VerifyProof(rootHash common.Hash, key []byte, proofDb ethdb.KeyValueReader)
 		if err != nil {
 			return nil, i, fmt.Errorf("bad proof node %d: %v", i, err)
 		}
-		keyrest, cld := get(n, key)
+		keyrest, cld := get(n, key, true)
 		switch cld := cld.(type) {
 		case nil:
 			// The trie doesn't contain the key.
@@ -129,7 +142,410 @@ func VerifyProof(rootHash common.Hash, key []byte, proofDb ethdb.KeyValueReader)
 	}
 }
 
-func get(tn node, key []byte) ([]byte, node) {
+// proofToPath converts a merkle proof to a trie node path. All nodes along the
+// path of the key are resolved from the proof, while the remaining siblings are
+// left as hash nodes. If root is not nil, the path is merged into it.
+//
+// If allowNonExistent is set, the proof may prove the absence of the key, in
+// which case the resolved path ends at the node proving it.
+func proofToPath(rootHash common.Hash, root node, key []byte, proofDb ethdb.KeyValueReader, allowNonExistent bool) (node, []byte, error) {
+	// resolveNode retrieves and resolves trie node from merkle proof stream
+	resolveNode := func(hash common.Hash) (node, error) {
+		buf, _ := proofDb.Get(hash[:])
+		if buf == nil {
+			return nil, fmt.Errorf("proof node (hash %064x) missing", hash)
+		}
+		n, err := decodeNode(hash[:], buf)
+		if err != nil {
+			return nil, fmt.Errorf("bad proof node %v", err)
+		}
+		return n, nil
+	}
+	// If the root node is empty, resolve it first. The root node must always be
+	// included in the proof.
+	if root == nil {
+		n, err := resolveNode(rootHash)
+		if err != nil {
+			return nil, nil, err
+		}
+		root = n
+	}
+	var (
+		err           error
+		child, parent node
+		keyrest       []byte
+		valnode       []byte
+	)
+	key, parent = keybytesToHex(key), root
+	for {
+		keyrest, child = get(parent, key, false)
+		switch cld := child.(type) {
+		case nil:
+			// The trie doesn't contain the key. All the resolved nodes are still
+			// proven correct, which is enough to prove the edge of a range.
+			if allowNonExistent {
+				return root, nil, nil
+			}
+			return nil, nil, errors.New("the node is not contained in trie")
+		case *shortNode:
+			key, parent = keyrest, child // Already resolved
+			continue
+		case *fullNode:
+			key, parent = keyrest, child // Already resolved
+			continue
+		case hashNode:
+			child, err = resolveNode(common.BytesToHash(cld))
+			if err != nil {
+				return nil, nil, err
+			}
+		case valueNode:
+			valnode = cld
+		}
+		// Link the parent and child.
+		switch pnode := parent.(type) {
+		case *shortNode:
+			pnode.Val = child
+		case *fullNode:
+			pnode.Children[key[0]] = child
+		default:
+			panic(fmt.Sprintf("%T: invalid node: %v", pnode, pnode))
+		}
+		if len(valnode) > 0 {
+			return root, valnode, nil // The whole path is resolved
+		}
+		key, parent = keyrest, child
+	}
+}
+
+// unsetInternal removes all internal node references (hash nodes and embedded
+// nodes) between the two edge paths of a trie constructed from edge proofs. The
+// removed parts are expected to be refilled by the leaves of the range.
+//
+// All visited nodes are marked dirty since their content might be modified. It
+// can happen that some full nodes are left with a single child, which is not a
+// valid trie shape, but if the proof is valid the missing children are refilled
+// and otherwise the resulting root hash mismatches anyway.
+//
+// The left key must be smaller than the right one. The returned flag reports
+// whether the entire trie falls inside the range and should be discarded.
+func unsetInternal(n node, left []byte, right []byte) (bool, error) {
+	left, right = keybytesToHex(left), keybytesToHex(right)
+
+	// Step down to the fork point. There are two scenarios:
+	// - the fork point is a short node: the key of the left or right proof
+	//   doesn't match the key of the short node.
+	// - the fork point is a full node: both edge proofs may point to a
+	//   non-existent key.
+	var (
+		pos    = 0
+		parent node
+
+		// fork indicator, 0 means no fork, -1 means the path is less, 1 means the path is greater
+		shortForkLeft, shortForkRight int
+	)
+findFork:
+	for {
+		switch rn := (n).(type) {
+		case *shortNode:
+			rn.flags = nodeFlag{dirty: true}
+
+			// If either the left or the right path doesn't match the short node,
+			// stop here, the fork point is the short node.
+			if len(left)-pos < len(rn.Key) {
+				shortForkLeft = bytes.Compare(left[pos:], rn.Key)
+			} else {
+				shortForkLeft = bytes.Compare(left[pos:pos+len(rn.Key)], rn.Key)
+			}
+			if len(right)-pos < len(rn.Key) {
+				shortForkRight = bytes.Compare(right[pos:], rn.Key)
+			} else {
+				shortForkRight = bytes.Compare(right[pos:pos+len(rn.Key)], rn.Key)
+			}
+			if shortForkLeft != 0 || shortForkRight != 0 {
+				break findFork
+			}
+			parent = n
+			n, pos = rn.Val, pos+len(rn.Key)
+		case *fullNode:
+			rn.flags = nodeFlag{dirty: true}
+
+			// If either the left or the right path leads to a different child,
+			// stop here, the fork point is the full node.
+			leftnode, rightnode := rn.Children[left[pos]], rn.Children[right[pos]]
+			if leftnode == nil || rightnode == nil || leftnode != rightnode {
+				break findFork
+			}
+			parent = n
+			n, pos = rn.Children[left[pos]], pos+1
+		default:
+			return false, fmt.Errorf("%T: invalid edge path node", n)
+		}
+	}
+	switch rn := n.(type) {
+	case *shortNode:
+		// There are five scenarios:
+		// - both paths are less than the short node => no valid range
+		// - both paths are greater than the short node => no valid range
+		// - left path is less and right path is greater => the short node is
+		//   entirely inside the range, unset it
+		// - left path points into the short node, but right path is greater
+		// - right path points into the short node, but left path is less
+		if shortForkLeft == -1 && shortForkRight == -1 {
+			return false, errors.New("empty range")
+		}
+		if shortForkLeft == 1 && shortForkRight == 1 {
+			return false, errors.New("empty range")
+		}
+		if shortForkLeft != 0 && shortForkRight != 0 {
+			// The fork point is the root node, unset the entire trie
+			if parent == nil {
+				return true, nil
+			}
+			parent.(*fullNode).Children[left[pos-1]] = nil
+			return false, nil
+		}
+		// Only one path points to a non-existent key
+		if shortForkRight != 0 {
+			if _, ok := rn.Val.(valueNode); ok {
+				// The fork point is the root node, unset the entire trie
+				if parent == nil {
+					return true, nil
+				}
+				parent.(*fullNode).Children[left[pos-1]] = nil
+				return false, nil
+			}
+			return false, unset(rn, rn.Val, left[pos:], len(rn.Key), false)
+		}
+		if shortForkLeft != 0 {
+			if _, ok := rn.Val.(valueNode); ok {
+				// The fork point is the root node, unset the entire trie
+				if parent == nil {
+					return true, nil
+				}
+				parent.(*fullNode).Children[right[pos-1]] = nil
+				return false, nil
+			}
+			return false, unset(rn, rn.Val, right[pos:], len(rn.Key), true)
+		}
+		return false, nil
+	case *fullNode:
+		// Unset all the internal nodes between the two paths in the fork point
+		for i := left[pos] + 1; i < right[pos]; i++ {
+			rn.Children[i] = nil
+		}
+		if err := unset(rn, rn.Children[left[pos]], left[pos:], 1, false); err != nil {
+			return false, err
+		}
+		if err := unset(rn, rn.Children[right[pos]], right[pos:], 1, true); err != nil {
+			return false, err
+		}
+		return false, nil
+	default:
+		return false, fmt.Errorf("%T: invalid fork point node", n)
+	}
+}
+
+// unset removes all internal node references on one side of the given path,
+// the left side if removeLeft is set and the right side otherwise. The path
+// may or may not exist in the trie:
+//
+//   - if the path exists, the nodes along it are unset in the given direction
+//   - if the path ends in a nil child of a full node, there's nothing to unset
+//   - if the path forks off a short node which is inside the range, the entire
+//     branch is unset
+//   - if the path forks off a short node which is outside the range, the branch
+//     is kept along with its cached hash
+func unset(parent node, child node, key []byte, pos int, removeLeft bool) error {
+	switch cld := child.(type) {
+	case *fullNode:
+		if removeLeft {
+			for i := 0; i < int(key[pos]); i++ {
+				cld.Children[i] = nil
+			}
+		} else {
+			for i := key[pos] + 1; i < 16; i++ {
+				cld.Children[i] = nil
+			}
+		}
+		cld.flags = nodeFlag{dirty: true}
+		return unset(cld, cld.Children[key[pos]], key, pos+1, removeLeft)
+	case *shortNode:
+		if len(key[pos:]) < len(cld.Key) || !bytes.Equal(cld.Key, key[pos:pos+len(cld.Key)]) {
+			// Found the fork point, it's a non-existent branch
+			if removeLeft {
+				if bytes.Compare(cld.Key, key[pos:]) < 0 {
+					// The short node is less than the path, so it's inside the
+					// range. Unset the entire branch, the parent must be a full node.
+					parent.(*fullNode).Children[key[pos-1]] = nil
+				}
+			} else {
+				if bytes.Compare(cld.Key, key[pos:]) > 0 {
+					// The short node is greater than the path, so it's inside the
+					// range. Unset the entire branch, the parent must be a full node.
+					parent.(*fullNode).Children[key[pos-1]] = nil
+				}
+			}
+			return nil
+		}
+		if _, ok := cld.Val.(valueNode); ok {
+			parent.(*fullNode).Children[key[pos-1]] = nil
+			return nil
+		}
+		cld.flags = nodeFlag{dirty: true}
+		return unset(cld, cld.Val, key, pos+len(cld.Key), removeLeft)
+	case nil:
+		// The path ends in a non-existent child of the fork point full node
+		return nil
+	default:
+		return fmt.Errorf("%T: invalid edge path node", child)
+	}
+}
+
+// hasRightElement reports whether there are more elements on the right side of
+// the given path. The path may point to an existent or a non-existent key, but
+// it must be entirely resolved.
+func hasRightElement(node node, key []byte) bool {
+	pos, key := 0, keybytesToHex(key)
+	for node != nil {
+		switch rn := node.(type) {
+		case *fullNode:
+			for i := key[pos] + 1; i < 16; i++ {
+				if rn.Children[i] != nil {
+					return true
+				}
+			}
+			node, pos = rn.Children[key[pos]], pos+1
+		case *shortNode:
+			if len(key)-pos < len(rn.Key) || !bytes.Equal(rn.Key, key[pos:pos+len(rn.Key)]) {
+				return bytes.Compare(rn.Key, key[pos:]) > 0
+			}
+			node, pos = rn.Val, pos+len(rn.Key)
+		case valueNode:
+			return false // We have resolved the whole path
+		default:
+			return true // Unresolved sibling, can't be ruled out
+		}
+	}
+	return false
+}
+
+// VerifyRangeProof checks whether the given leaves and edge proofs prove that
+// the leaves are exactly the contiguous range of the trie with the given root,
+// starting at firstKey and ending at the last of the given keys. The keys must
+// be sorted in increasing order and no value may be empty.
+//
+// The proof must contain the edge proofs of firstKey and the last key (see
+// ProveRange). The first key doesn't need to exist, in which case its proof
+// proves there are no leaves between it and the first given key.
+//
+// There are a few special cases:
+//
+//   - If the proof is nil, the leaves must be the entire trie.
+//   - If there are no leaves, the proof of firstKey must prove there are no
+//     leaves at or after it.
+//   - If there's a single leaf which is firstKey, its proof is a plain proof of
+//     existence.
+func VerifyRangeProof(rootHash common.Hash, firstKey []byte, keys [][]byte, values [][]byte, proof ethdb.KeyValueReader) error {
+	if len(keys) != len(values) {
+		return fmt.Errorf("inconsistent proof data, keys: %d, values: %d", len(keys), len(values))
+	}
+	// Ensure the received batch is monotonic increasing and contains no deletions
+	for i := 0; i < len(keys)-1; i++ {
+		if bytes.Compare(keys[i], keys[i+1]) >= 0 {
+			return errors.New("range is not monotonically increasing")
+		}
+	}
+	for _, value := range values {
+		if len(value) == 0 {
+			return errors.New("range contains deletion")
+		}
+	}
+	// Special case, there is no edge proof at all. The given range is expected
+	// to be the whole leaf-set in the trie.
+	if proof == nil {
+		tr, _ := New(common.Hash{}, NewDatabase(memorydb.New()))
+		for index, key := range keys {
+			tr.Update(key, values[index])
+		}
+		if have, want := tr.Hash(), rootHash; have != want {
+			return fmt.Errorf("invalid proof, want hash %x, got %x", want, have)
+		}
+		return nil
+	}
+	// Special case, there is an edge proof but zero key/value pairs, ensure
+	// there are no more elements in the trie.
+	if len(keys) == 0 {
+		root, val, err := proofToPath(rootHash, nil, firstKey, proof, true)
+		if err != nil {
+			return err
+		}
+		if val != nil || hasRightElement(root, firstKey) {
+			return errors.New("more entries available")
+		}
+		return nil
+	}
+	if bytes.Compare(firstKey, keys[0]) > 0 {
+		return errors.New("first key is greater than the range")
+	}
+	lastKey := keys[len(keys)-1]
+
+	// Special case, there is only one element and the two edge keys are the same.
+	// In this case we can't construct two edge paths, so handle it here.
+	if len(keys) == 1 && bytes.Equal(firstKey, lastKey) {
+		_, val, err := proofToPath(rootHash, nil, firstKey, proof, false)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(val, values[0]) {
+			return errors.New("correct proof but invalid data")
+		}
+		return nil
+	}
+	// In all other cases two edge paths are required
+	if len(firstKey) != len(lastKey) {
+		return errors.New("inconsistent edge keys")
+	}
+	// Convert the edge proofs to edge trie paths, reconstructing the edges of
+	// the original trie. The first edge proof may be a proof of absence.
+	root, _, err := proofToPath(rootHash, nil, firstKey, proof, true)
+	if err != nil {
+		return err
+	}
+	// Merge the path of the last key into the first one. The last key is in
+	// the range, so its proof must be a proof of existence.
+	root, _, err = proofToPath(rootHash, root, lastKey, proof, false)
+	if err != nil {
+		return err
+	}
+	// Remove all internal references. All the removed parts should be refilled
+	// (or reconstructed) by the given leaves.
+	empty, err := unsetInternal(root, firstKey, lastKey)
+	if err != nil {
+		return err
+	}
+	// Rebuild the trie with the leaves, the shape of the trie should be the same
+	// as the original one.
+	tr := &Trie{root: root, db: NewDatabase(memorydb.New())}
+	if empty {
+		tr.root = nil
+	}
+	for index, key := range keys {
+		if err := tr.TryUpdate(key, values[index]); err != nil {
+			return fmt.Errorf("invalid proof: %v", err)
+		}
+	}
+	if have, want := tr.Hash(), rootHash; have != want {
+		return fmt.Errorf("invalid proof, want hash %x, got %x", want, have)
+	}
+	return nil
+}
+
+// get returns the child of the given node. Return nil if the node with specified
+// key doesn't exist at all.
+//
+// There is an additional flag `skipResolved`. If it's set then all resolved
+// nodes won't be returned.
+func get(tn node, key []byte, skipResolved bool) ([]byte, node) {
 	for {
 		switch n := tn.(type) {
 		case *shortNode:
@@ -138,9 +554,15 @@ func get(tn node, key []byte) ([]byte, node) {
 			}
 			tn = n.Val
 			key = key[len(n.Key):]
+			if !skipResolved {
+				return key, tn
+			}
 		case *fullNode:
 			tn = n.Children[key[0]]
 			key = key[1:]
+			if !skipResolved {
+				return key, tn
+			}
 		case hashNode:
 			return key, n
 		case nil:
@@ -20,6 +20,7 @@ import (
 	"bytes"
 	crand "crypto/rand"
 	mrand "math/rand"
+	"sort"
 	"testing"
 	"time"
 
@@ -221,3 +222,301 @@ func randBytes(n int) []byte {
 	crand.Read(r)
 	return r
 }
+
+type entrySlice []*kv
+
+func (p entrySlice) Len() int           { return len(p) }
+func (p entrySlice) Less(i, j int) bool { return bytes.Compare(p[i].k, p[j].k) < 0 }
+func (p entrySlice) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+// randomSortedTrie creates a random trie and returns its entries sorted by key.
+func randomSortedTrie(n int) (*Trie, entrySlice) {
+	trie, vals := randomTrie(n)
+
+	var entries entrySlice
+	for _, kv := range vals {
+		entries = append(entries, kv)
+	}
+	sort.Sort(entries)
+	return trie, entries
+}
+
+// rangeOf splits the entries into the keys and values of a range proof.
+func rangeOf(entries entrySlice) ([][]byte, [][]byte) {
+	var keys, vals [][]byte
+	for _, entry := range entries {
+		keys = append(keys, entry.k)
+		vals = append(vals, entry.v)
+	}
+	return keys, vals
+}
+
+// decreaseKey returns the key right before the given one.
+func decreaseKey(key []byte) []byte {
+	key = common.CopyBytes(key)
+	for i := len(key) - 1; i >= 0; i-- {
+		if key[i] > 0 {
+			key[i]--
+			break
+		}
+		key[i] = 0xff
+	}
+	return key
+}
+
+// Tests that random ranges of the trie can be proven and verified.
+func TestRangeProof(t *testing.T) {
+	trie, entries := randomSortedTrie(4096)
+	for i := 0; i < 500; i++ {
+		start := mrand.Intn(len(entries))
+		end := mrand.Intn(len(entries)-start) + start + 1
+
+		proof := memorydb.New()
+		if err := trie.ProveRange(entries[start].k, entries[end-1].k, proof); err != nil {
+			t.Fatalf("Failed to prove range [%d, %d): %v", start, end, err)
+		}
+		keys, vals := rangeOf(entries[start:end])
+		if err := VerifyRangeProof(trie.Hash(), keys[0], keys, vals, proof); err != nil {
+			t.Fatalf("Case %d(%d->%d) expect no error, got %v", i, start, end-1, err)
+		}
+	}
+}
+
+// Tests that ranges starting at a non-existent key can be proven, as long as
+// no existing key is skipped between the first key and the range.
+func TestRangeProofWithNonExistentProof(t *testing.T) {
+	trie, entries := randomSortedTrie(4096)
+	for i := 0; i < 500; i++ {
+		start := mrand.Intn(len(entries)-1) + 1
+		end := mrand.Intn(len(entries)-start) + start + 1
+
+		first := decreaseKey(entries[start].k)
+		if bytes.Equal(first, entries[start-1].k) {
+			continue
+		}
+		proof := memorydb.New()
+		if err := trie.ProveRange(first, entries[end-1].k, proof); err != nil {
+			t.Fatalf("Failed to prove range: %v", err)
+		}
+		keys, vals := rangeOf(entries[start:end])
+		if err := VerifyRangeProof(trie.Hash(), first, keys, vals, proof); err != nil {
+			t.Fatalf("Case %d(%d->%d) expect no error, got %v", i, start, end-1, err)
+		}
+	}
+}
+
+// Tests that a non-existent first key skipping over existing leaves is rejected.
+func TestRangeProofWithInvalidNonExistentProof(t *testing.T) {
+	trie, entries := randomSortedTrie(4096)
+
+	// The first key is before entries[start-1], which is left out of the range
+	start, end := 100, 200
+	first := decreaseKey(entries[start-1].k)
+
+	proof := memorydb.New()
+	if err := trie.ProveRange(first, entries[end-1].k, proof); err != nil {
+		t.Fatalf("Failed to prove range: %v", err)
+	}
+	keys, vals := rangeOf(entries[start:end])
+	if err := VerifyRangeProof(trie.Hash(), first, keys, vals, proof); err == nil {
+		t.Fatalf("Expected to detect the left out leaf")
+	}
+}
+
+// Tests ranges consisting of a single element, with both an existent and a
+// non-existent first key.
+func TestOneElementRangeProof(t *testing.T) {
+	trie, entries := randomSortedTrie(4096)
+	root := trie.Hash()
+
+	// One element with an existent edge proof
+	start := 1000
+	proof := memorydb.New()
+	if err := trie.ProveRange(entries[start].k, entries[start].k, proof); err != nil {
+		t.Fatalf("Failed to prove range: %v", err)
+	}
+	keys, vals := rangeOf(entries[start : start+1])
+	if err := VerifyRangeProof(root, keys[0], keys, vals, proof); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	// One element with a wrong value
+	if err := VerifyRangeProof(root, keys[0], keys, [][]byte{[]byte("invalid")}, proof); err == nil {
+		t.Fatalf("Expected error for invalid value")
+	}
+	// One element with a non-existent first key
+	first := decreaseKey(entries[start].k)
+	proof = memorydb.New()
+	if err := trie.ProveRange(first, entries[start].k, proof); err != nil {
+		t.Fatalf("Failed to prove range: %v", err)
+	}
+	if err := VerifyRangeProof(root, first, keys, vals, proof); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	// Trie with a single element
+	tinyTrie := new(Trie)
+	entry := &kv{randBytes(32), randBytes(20), false}
+	tinyTrie.Update(entry.k, entry.v)
+
+	first = common.HexToHash("0x0000000000000000000000000000000000000000000000000000000000000000").Bytes()
+	proof = memorydb.New()
+	if err := tinyTrie.ProveRange(first, entry.k, proof); err != nil {
+		t.Fatalf("Failed to prove range: %v", err)
+	}
+	if err := VerifyRangeProof(tinyTrie.Hash(), first, [][]byte{entry.k}, [][]byte{entry.v}, proof); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+}
+
+// Tests that the entire leaf set can be proven with or without edge proofs.
+func TestAllElementsProof(t *testing.T) {
+	trie, entries := randomSortedTrie(4096)
+	keys, vals := rangeOf(entries)
+
+	if err := VerifyRangeProof(trie.Hash(), nil, keys, vals, nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	proof := memorydb.New()
+	if err := trie.ProveRange(keys[0], keys[len(keys)-1], proof); err != nil {
+		t.Fatalf("Failed to prove range: %v", err)
+	}
+	if err := VerifyRangeProof(trie.Hash(), keys[0], keys, vals, proof); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	// Leaving out an element without edge proofs must be detected
+	if err := VerifyRangeProof(trie.Hash(), nil, keys[1:], vals[1:], nil); err == nil {
+		t.Fatalf("Expected error for incomplete leaf set")
+	}
+}
+
+// Tests empty ranges, which are only valid if no leaves follow the first key.
+func TestEmptyRangeProof(t *testing.T) {
+	trie, entries := randomSortedTrie(4096)
+
+	var cases = []struct {
+		pos int
+		err bool
+	}{
+		{len(entries) - 1, false},
+		{500, true},
+	}
+	for _, c := range cases {
+		first := common.CopyBytes(entries[c.pos].k)
+		first = append(first[:len(first)-1], first[len(first)-1]+1)
+		if c.pos == len(entries)-1 && bytes.Compare(first, entries[c.pos].k) <= 0 {
+			continue // overflowed, can't express a key after the last one
+		}
+		proof := memorydb.New()
+		if err := trie.Prove(first, 0, proof); err != nil {
+			t.Fatalf("Failed to prove the first node %v", err)
+		}
+		err := VerifyRangeProof(trie.Hash(), first, nil, nil, proof)
+		if c.err && err == nil {
+			t.Fatalf("Expected error, got nil")
+		}
+		if !c.err && err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+}
+
+// Tests that tampered ranges are rejected: modified keys or values, gaps,
+// injected elements, deletions and out of order entries.
+func TestBadRangeProof(t *testing.T) {
+	trie, entries := randomSortedTrie(4096)
+	root := trie.Hash()
+
+	for i := 0; i < 500; i++ {
+		start := mrand.Intn(len(entries))
+		end := mrand.Intn(len(entries)-start) + start + 1
+		if end-start < 3 {
+			continue // need an inner element to tamper with
+		}
+		proof := memorydb.New()
+		if err := trie.ProveRange(entries[start].k, entries[end-1].k, proof); err != nil {
+			t.Fatalf("Failed to prove range: %v", err)
+		}
+		keys, vals := rangeOf(entries[start:end])
+		first := keys[0]
+
+		testcase := mrand.Intn(6)
+		index := mrand.Intn(end-start-2) + 1 // inner element, edges are covered by the proofs
+		switch testcase {
+		case 0:
+			// Modified key
+			keys[index] = randBytes(32) // In theory it can't be same
+		case 1:
+			// Modified value
+			vals[index] = randBytes(20) // In theory it can't be same
+		case 2:
+			// Gapped entry slice
+			keys = append(keys[:index], keys[index+1:]...)
+			vals = append(vals[:index], vals[index+1:]...)
+		case 3:
+			// Injected element between two existing ones
+			key := decreaseKey(keys[index])
+			if bytes.Equal(key, keys[index-1]) {
+				continue
+			}
+			keys = append(keys[:index], append([][]byte{key}, keys[index:]...)...)
+			vals = append(vals[:index], append([][]byte{randBytes(20)}, vals[index:]...)...)
+		case 4:
+			// Set random value to nil, deletion
+			vals[index] = nil
+		case 5:
+			// Out of order
+			keys[index-1], keys[index] = keys[index], keys[index-1]
+			vals[index-1], vals[index] = vals[index], vals[index-1]
+		}
+		if err := VerifyRangeProof(root, first, keys, vals, proof); err == nil {
+			t.Fatalf("%d Case %d index %d range: (%d->%d) expect error, got nil", i, testcase, index, start, end-1)
+		}
+	}
+}
+
+// Tests that extra nodes in the proof are ignored, while tampered proof nodes
+// are rejected.
+func TestRangeProofMaliciousNodes(t *testing.T) {
+	trie, entries := randomSortedTrie(4096)
+	root := trie.Hash()
+
+	start, end := 1000, 1100
+	keys, vals := rangeOf(entries[start:end])
+
+	proof := memorydb.New()
+	if err := trie.ProveRange(keys[0], keys[len(keys)-1], proof); err != nil {
+		t.Fatalf("Failed to prove range: %v", err)
+	}
+	// Inject the proof of an unrelated key and some garbage
+	if err := trie.Prove(entries[3000].k, 0, proof); err != nil {
+		t.Fatalf("Failed to prove key: %v", err)
+	}
+	garbage := randBytes(100)
+	proof.Put(crypto.Keccak256(garbage), garbage)
+
+	if err := VerifyRangeProof(root, keys[0], keys, vals, proof); err != nil {
+		t.Fatalf("Expected no error with extra proof nodes, got %v", err)
+	}
+	// Replace the content of every edge proof node in turn with an unrelated
+	// node, every single one must be detected
+	edges := memorydb.New()
+	if err := trie.ProveRange(keys[0], keys[len(keys)-1], edges); err != nil {
+		t.Fatalf("Failed to prove range: %v", err)
+	}
+	fake := randBytes(100)
+
+	it := edges.NewIterator()
+	defer it.Release()
+	for it.Next() {
+		tampered := memorydb.New()
+		src := proof.NewIterator()
+		for src.Next() {
+			tampered.Put(src.Key(), src.Value())
+		}
+		src.Release()
+
+		tampered.Put(it.Key(), fake)
+		if err := VerifyRangeProof(root, keys[0], keys, vals, tampered); err == nil {
+			t.Fatalf("Expected error for tampered proof node %x", it.Key())
+		}
+	}
+}